@@ -16,6 +16,11 @@
 
 package driver
 
+import (
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/0xsoniclabs/hyperion/load/app"
+)
+
 //go:generate mockgen -source application.go -destination application_mock.go -package driver
 
 // Application is an abstraction of an application running on a Hyperion net.
@@ -39,3 +44,78 @@ type Application interface {
 	// on the network.
 	GetReceivedTransactions() (uint64, error)
 }
+
+// OperationMix is an optional extension of Applications producing a mix of
+// different operations. It provides transaction counts per operation.
+type OperationMix interface {
+	// GetOperations lists the operations of the application. The list is
+	// empty if the application does not mix operations.
+	GetOperations() []string
+
+	// GetSentTransactionsOf returns the number of transactions of the given
+	// operation sent by a given user.
+	GetSentTransactionsOf(operation string, user int) (uint64, error)
+
+	// GetReceivedTransactionsOf returns the number of transactions of the
+	// given operation received by the application on the network.
+	GetReceivedTransactionsOf(operation string) (uint64, error)
+}
+
+// RequestLoad is an optional extension of Applications issuing read-only RPC
+// requests instead of sending transactions. It provides request statistics
+// per RPC method.
+type RequestLoad interface {
+	// GetRequestMethods lists the RPC methods used by the application. The
+	// list is empty if the application does not issue requests.
+	GetRequestMethods() []string
+
+	// GetRequestStats returns the statistics of the requests of the given
+	// method issued so far.
+	GetRequestStats(method string) (app.RequestStats, error)
+}
+
+// SubscriptionLoad is an optional extension of Applications subscribing to
+// events instead of sending transactions. It provides notification statistics
+// per kind of subscription.
+type SubscriptionLoad interface {
+	// GetSubscriptionKinds lists the kinds of subscriptions of the
+	// application. The list is empty if the application does not subscribe.
+	GetSubscriptionKinds() []string
+
+	// GetSubscriptionStats returns the statistics of the notifications of
+	// the given kind received so far.
+	GetSubscriptionStats(kind string) (app.SubscriptionStats, error)
+}
+
+// NonceRecovery is an optional extension of Applications recovering nonce
+// gaps of the accounts of their users. It provides recovery statistics per user.
+type NonceRecovery interface {
+	// GetNonceStats returns the statistics of the nonce recovery of the
+	// accounts of the given user.
+	GetNonceStats(user int) (app.NonceStats, error)
+}
+
+// SendErrorTracker is an optional extension of Applications counting the
+// errors reported when sending the transactions of their users.
+type SendErrorTracker interface {
+	// GetSendErrors returns the errors reported so far when sending the
+	// transactions of the application.
+	GetSendErrors() rpc.SendErrors
+}
+
+// GasPriceTracker is an optional extension of Applications sampling the
+// effective gas price paid by their transactions.
+type GasPriceTracker interface {
+	// GetEffectiveGasPrice returns the average effective gas price in wei
+	// paid by a sample of the transactions processed since the last call.
+	GetEffectiveGasPrice() (uint64, error)
+}
+
+// RateController is an optional extension of Applications whose load is
+// controlled by a feedback loop holding a target inclusion latency or
+// transaction pool size.
+type RateController interface {
+	// GetSustainableRate returns the rate in Tx/s the feedback loop converged
+	// to, and false if the load of the application is not feedback-controlled.
+	GetSustainableRate() (float64, bool)
+}
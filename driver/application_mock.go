@@ -28,6 +28,8 @@ package driver
 import (
 	reflect "reflect"
 
+	rpc "github.com/0xsoniclabs/hyperion/driver/rpc"
+	app "github.com/0xsoniclabs/hyperion/load/app"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -139,3 +141,325 @@ func (mr *MockApplicationMockRecorder) Stop() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockApplication)(nil).Stop))
 }
+
+// MockOperationMix is a mock of OperationMix interface.
+type MockOperationMix struct {
+	ctrl     *gomock.Controller
+	recorder *MockOperationMixMockRecorder
+}
+
+// MockOperationMixMockRecorder is the mock recorder for MockOperationMix.
+type MockOperationMixMockRecorder struct {
+	mock *MockOperationMix
+}
+
+// NewMockOperationMix creates a new mock instance.
+func NewMockOperationMix(ctrl *gomock.Controller) *MockOperationMix {
+	mock := &MockOperationMix{ctrl: ctrl}
+	mock.recorder = &MockOperationMixMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOperationMix) EXPECT() *MockOperationMixMockRecorder {
+	return m.recorder
+}
+
+// GetOperations mocks base method.
+func (m *MockOperationMix) GetOperations() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperations")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetOperations indicates an expected call of GetOperations.
+func (mr *MockOperationMixMockRecorder) GetOperations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperations", reflect.TypeOf((*MockOperationMix)(nil).GetOperations))
+}
+
+// GetReceivedTransactionsOf mocks base method.
+func (m *MockOperationMix) GetReceivedTransactionsOf(operation string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReceivedTransactionsOf", operation)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReceivedTransactionsOf indicates an expected call of GetReceivedTransactionsOf.
+func (mr *MockOperationMixMockRecorder) GetReceivedTransactionsOf(operation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReceivedTransactionsOf", reflect.TypeOf((*MockOperationMix)(nil).GetReceivedTransactionsOf), operation)
+}
+
+// GetSentTransactionsOf mocks base method.
+func (m *MockOperationMix) GetSentTransactionsOf(operation string, user int) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSentTransactionsOf", operation, user)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSentTransactionsOf indicates an expected call of GetSentTransactionsOf.
+func (mr *MockOperationMixMockRecorder) GetSentTransactionsOf(operation, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentTransactionsOf", reflect.TypeOf((*MockOperationMix)(nil).GetSentTransactionsOf), operation, user)
+}
+
+// MockRequestLoad is a mock of RequestLoad interface.
+type MockRequestLoad struct {
+	ctrl     *gomock.Controller
+	recorder *MockRequestLoadMockRecorder
+}
+
+// MockRequestLoadMockRecorder is the mock recorder for MockRequestLoad.
+type MockRequestLoadMockRecorder struct {
+	mock *MockRequestLoad
+}
+
+// NewMockRequestLoad creates a new mock instance.
+func NewMockRequestLoad(ctrl *gomock.Controller) *MockRequestLoad {
+	mock := &MockRequestLoad{ctrl: ctrl}
+	mock.recorder = &MockRequestLoadMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRequestLoad) EXPECT() *MockRequestLoadMockRecorder {
+	return m.recorder
+}
+
+// GetRequestMethods mocks base method.
+func (m *MockRequestLoad) GetRequestMethods() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequestMethods")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetRequestMethods indicates an expected call of GetRequestMethods.
+func (mr *MockRequestLoadMockRecorder) GetRequestMethods() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestMethods", reflect.TypeOf((*MockRequestLoad)(nil).GetRequestMethods))
+}
+
+// GetRequestStats mocks base method.
+func (m *MockRequestLoad) GetRequestStats(method string) (app.RequestStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequestStats", method)
+	ret0, _ := ret[0].(app.RequestStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRequestStats indicates an expected call of GetRequestStats.
+func (mr *MockRequestLoadMockRecorder) GetRequestStats(method any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestStats", reflect.TypeOf((*MockRequestLoad)(nil).GetRequestStats), method)
+}
+
+// MockSubscriptionLoad is a mock of SubscriptionLoad interface.
+type MockSubscriptionLoad struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionLoadMockRecorder
+}
+
+// MockSubscriptionLoadMockRecorder is the mock recorder for MockSubscriptionLoad.
+type MockSubscriptionLoadMockRecorder struct {
+	mock *MockSubscriptionLoad
+}
+
+// NewMockSubscriptionLoad creates a new mock instance.
+func NewMockSubscriptionLoad(ctrl *gomock.Controller) *MockSubscriptionLoad {
+	mock := &MockSubscriptionLoad{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionLoadMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionLoad) EXPECT() *MockSubscriptionLoadMockRecorder {
+	return m.recorder
+}
+
+// GetSubscriptionKinds mocks base method.
+func (m *MockSubscriptionLoad) GetSubscriptionKinds() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionKinds")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetSubscriptionKinds indicates an expected call of GetSubscriptionKinds.
+func (mr *MockSubscriptionLoadMockRecorder) GetSubscriptionKinds() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionKinds", reflect.TypeOf((*MockSubscriptionLoad)(nil).GetSubscriptionKinds))
+}
+
+// GetSubscriptionStats mocks base method.
+func (m *MockSubscriptionLoad) GetSubscriptionStats(kind string) (app.SubscriptionStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionStats", kind)
+	ret0, _ := ret[0].(app.SubscriptionStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionStats indicates an expected call of GetSubscriptionStats.
+func (mr *MockSubscriptionLoadMockRecorder) GetSubscriptionStats(kind any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionStats", reflect.TypeOf((*MockSubscriptionLoad)(nil).GetSubscriptionStats), kind)
+}
+
+// MockNonceRecovery is a mock of NonceRecovery interface.
+type MockNonceRecovery struct {
+	ctrl     *gomock.Controller
+	recorder *MockNonceRecoveryMockRecorder
+}
+
+// MockNonceRecoveryMockRecorder is the mock recorder for MockNonceRecovery.
+type MockNonceRecoveryMockRecorder struct {
+	mock *MockNonceRecovery
+}
+
+// NewMockNonceRecovery creates a new mock instance.
+func NewMockNonceRecovery(ctrl *gomock.Controller) *MockNonceRecovery {
+	mock := &MockNonceRecovery{ctrl: ctrl}
+	mock.recorder = &MockNonceRecoveryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNonceRecovery) EXPECT() *MockNonceRecoveryMockRecorder {
+	return m.recorder
+}
+
+// GetNonceStats mocks base method.
+func (m *MockNonceRecovery) GetNonceStats(user int) (app.NonceStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonceStats", user)
+	ret0, _ := ret[0].(app.NonceStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonceStats indicates an expected call of GetNonceStats.
+func (mr *MockNonceRecoveryMockRecorder) GetNonceStats(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonceStats", reflect.TypeOf((*MockNonceRecovery)(nil).GetNonceStats), user)
+}
+
+// MockSendErrorTracker is a mock of SendErrorTracker interface.
+type MockSendErrorTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockSendErrorTrackerMockRecorder
+}
+
+// MockSendErrorTrackerMockRecorder is the mock recorder for MockSendErrorTracker.
+type MockSendErrorTrackerMockRecorder struct {
+	mock *MockSendErrorTracker
+}
+
+// NewMockSendErrorTracker creates a new mock instance.
+func NewMockSendErrorTracker(ctrl *gomock.Controller) *MockSendErrorTracker {
+	mock := &MockSendErrorTracker{ctrl: ctrl}
+	mock.recorder = &MockSendErrorTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSendErrorTracker) EXPECT() *MockSendErrorTrackerMockRecorder {
+	return m.recorder
+}
+
+// GetSendErrors mocks base method.
+func (m *MockSendErrorTracker) GetSendErrors() rpc.SendErrors {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSendErrors")
+	ret0, _ := ret[0].(rpc.SendErrors)
+	return ret0
+}
+
+// GetSendErrors indicates an expected call of GetSendErrors.
+func (mr *MockSendErrorTrackerMockRecorder) GetSendErrors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSendErrors", reflect.TypeOf((*MockSendErrorTracker)(nil).GetSendErrors))
+}
+
+// MockGasPriceTracker is a mock of GasPriceTracker interface.
+type MockGasPriceTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockGasPriceTrackerMockRecorder
+}
+
+// MockGasPriceTrackerMockRecorder is the mock recorder for MockGasPriceTracker.
+type MockGasPriceTrackerMockRecorder struct {
+	mock *MockGasPriceTracker
+}
+
+// NewMockGasPriceTracker creates a new mock instance.
+func NewMockGasPriceTracker(ctrl *gomock.Controller) *MockGasPriceTracker {
+	mock := &MockGasPriceTracker{ctrl: ctrl}
+	mock.recorder = &MockGasPriceTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGasPriceTracker) EXPECT() *MockGasPriceTrackerMockRecorder {
+	return m.recorder
+}
+
+// GetEffectiveGasPrice mocks base method.
+func (m *MockGasPriceTracker) GetEffectiveGasPrice() (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveGasPrice")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectiveGasPrice indicates an expected call of GetEffectiveGasPrice.
+func (mr *MockGasPriceTrackerMockRecorder) GetEffectiveGasPrice() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveGasPrice", reflect.TypeOf((*MockGasPriceTracker)(nil).GetEffectiveGasPrice))
+}
+
+// MockRateController is a mock of RateController interface.
+type MockRateController struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateControllerMockRecorder
+}
+
+// MockRateControllerMockRecorder is the mock recorder for MockRateController.
+type MockRateControllerMockRecorder struct {
+	mock *MockRateController
+}
+
+// NewMockRateController creates a new mock instance.
+func NewMockRateController(ctrl *gomock.Controller) *MockRateController {
+	mock := &MockRateController{ctrl: ctrl}
+	mock.recorder = &MockRateControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateController) EXPECT() *MockRateControllerMockRecorder {
+	return m.recorder
+}
+
+// GetSustainableRate mocks base method.
+func (m *MockRateController) GetSustainableRate() (float64, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSustainableRate")
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetSustainableRate indicates an expected call of GetSustainableRate.
+func (mr *MockRateControllerMockRecorder) GetSustainableRate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSustainableRate", reflect.TypeOf((*MockRateController)(nil).GetSustainableRate))
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+// Package command provides the command line interface of Hyperion. Besides
+// the hyperion binary, it can be used to build custom binaries linking in
+// additional application types, registered in the init functions of their
+// packages using app.Register:
+//
+//	import (
+//		"os"
+//
+//		"github.com/0xsoniclabs/hyperion/driver/command"
+//		_ "example.com/my/workload"
+//	)
+//
+//	func main() {
+//		if err := command.NewApp().Run(os.Args); err != nil {
+//			...
+//		}
+//	}
+package command
+
+import (
+	"github.com/0xsoniclabs/hyperion/driver/globalflags"
+	"github.com/urfave/cli/v2"
+)
+
+// NewApp creates the command line application with all Hyperion commands.
+func NewApp() *cli.App {
+	return &cli.App{
+		Name:      "Hyperion Network Runner",
+		HelpName:  "hyperion",
+		Usage:     "A set of tools for running network scenarios",
+		Copyright: "(c) 2023 Fantom Foundation",
+		Flags:     globalflags.AllGlobalFlags,
+		Commands: []*cli.Command{
+			&checkCommand,
+			&runCommand,
+			&purgeCommand,
+			&renderCommand,
+			&diffCommand,
+			&recordCommand,
+		},
+		Before: globalflags.ProcessGlobalFlags,
+	}
+}
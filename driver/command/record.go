@@ -0,0 +1,96 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/0xsoniclabs/hyperion/load/app"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+// Run with `go run ./driver/hyperion record --rpc-url <url> --from <block> --to <block> <trace.jsonl|trace.rlp>`
+
+var recordCommand = cli.Command{
+	Action: record,
+	Name:   "record",
+	Usage:  "records the transactions of a range of blocks of a chain into a replay file",
+	Flags: []cli.Flag{
+		&recordRpcUrl,
+		&recordFromBlock,
+		&recordToBlock,
+	},
+}
+
+var (
+	recordRpcUrl = cli.StringFlag{
+		Name:  "rpc-url",
+		Usage: "the RPC endpoint of the chain to record transactions from.",
+		Value: "http://localhost:18545",
+	}
+	recordFromBlock = cli.Uint64Flag{
+		Name:     "from",
+		Usage:    "the first block of the recorded range.",
+		Required: true,
+	}
+	recordToBlock = cli.Uint64Flag{
+		Name:     "to",
+		Usage:    "the last block of the recorded range.",
+		Required: true,
+	}
+)
+
+func record(ctx *cli.Context) error {
+	args := ctx.Args()
+	if args.Len() != 1 {
+		return fmt.Errorf("requires the path of the replay file as single argument")
+	}
+	path := args.First()
+
+	client, err := gethrpc.DialContext(ctx.Context, ctx.String(recordRpcUrl.Name))
+	if err != nil {
+		return fmt.Errorf("failed to connect to %v; %w", ctx.String(recordRpcUrl.Name), err)
+	}
+	rpcClient := rpc.WrapRpcClient(client)
+	defer rpcClient.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+
+	from, to := ctx.Uint64(recordFromBlock.Name), ctx.Uint64(recordToBlock.Name)
+	count, err := app.RecordReplay(rpcClient, from, to, app.NewReplayWriter(path, out))
+	if err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d transactions of blocks [%d,%d] to %s\n", count, from, to, path)
+	return nil
+}
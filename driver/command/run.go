@@ -14,7 +14,7 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
 
-package main
+package command
 
 import (
 	"fmt"
@@ -55,6 +55,7 @@ var runCommand = cli.Command{
 		&outputDirectory,
 		&externalRpcEndpoint,
 		&externalChainId,
+		&snapshotDirectory,
 	},
 }
 
@@ -97,6 +98,11 @@ var (
 		Usage: "chain ID for external network (used with --external-rpc)",
 		Value: 4002,
 	}
+	snapshotDirectory = cli.StringFlag{
+		Name:  "snapshot-directory",
+		Usage: "define a directory in which the snapshots restored and saved by scenarios are located.",
+		Value: "snapshots",
+	}
 )
 
 func run(ctx *cli.Context) (err error) {
@@ -111,6 +117,7 @@ func run(ctx *cli.Context) (err error) {
 	skipReportRendering := ctx.Bool(skipReportRendering.Name)
 	externalRpc := ctx.String(externalRpcEndpoint.Name)
 	chainId := ctx.Int64(externalChainId.Name)
+	snapshotDir := ctx.String(snapshotDirectory.Name)
 
 	path := args.First()
 
@@ -129,7 +136,7 @@ func run(ctx *cli.Context) (err error) {
 			if !d.IsDir() && (filepath.Ext(d.Name()) == ".yaml" || filepath.Ext(d.Name()) == ".yml") {
 				// Call runScenario for each YAML file
 				label := fmt.Sprintf("eval_%d", time.Now().Unix())
-				if err := runScenario(p, outputDir, label, keepPrometheusRunning, skipChecks, skipReportRendering, externalRpc, chainId); err != nil {
+				if err := runScenario(p, outputDir, label, keepPrometheusRunning, skipChecks, skipReportRendering, externalRpc, chainId, snapshotDir); err != nil {
 					return fmt.Errorf("failed to run: %s: %w", p, err)
 				}
 			}
@@ -142,11 +149,11 @@ func run(ctx *cli.Context) (err error) {
 			label = fmt.Sprintf("eval_%d", time.Now().Unix())
 		}
 
-		return runScenario(path, outputDir, label, keepPrometheusRunning, skipChecks, skipReportRendering, externalRpc, chainId)
+		return runScenario(path, outputDir, label, keepPrometheusRunning, skipChecks, skipReportRendering, externalRpc, chainId, snapshotDir)
 	}
 }
 
-func runScenario(path, outputDir, label string, keepPrometheusRunning, skipChecks, skipReportRendering bool, externalRpc string, chainId int64) error {
+func runScenario(path, outputDir, label string, keepPrometheusRunning, skipChecks, skipReportRendering bool, externalRpc string, chainId int64, snapshotDir string) error {
 
 	// if not configured, default to /tmp/hyperion_data_<label>_<timestamp> else /configured/path/hyperion_data_<l>_<t>
 	outputDir, err := os.MkdirTemp(outputDir, fmt.Sprintf("hyperion_data_%s_", label))
@@ -163,6 +170,9 @@ func runScenario(path, outputDir, label string, keepPrometheusRunning, skipCheck
 	if err := scenario.Check(); err != nil {
 		return err
 	}
+	if scenario.Snapshot != nil && externalRpc != "" {
+		return fmt.Errorf("snapshots are not supported for external networks")
+	}
 
 	fmt.Printf("Starting evaluation %s\n", label)
 
@@ -213,20 +223,44 @@ func runScenario(path, outputDir, label string, keepPrometheusRunning, skipCheck
 		}
 	} else {
 		// Use local Docker network
-		net, err = local.NewLocalNetwork(&driver.NetworkConfig{
+		config := &driver.NetworkConfig{
 			Validators:    driver.NewValidators(scenario.Validators),
 			RoundTripTime: scenario.GetRoundTripTime(),
 			NetworkRules:  driver.NetworkRules(maps.Clone(scenario.NetworkRules.Genesis)),
 			OutputDir:     outputDir,
-		})
+			Topology:      scenario.Topology,
+		}
+		if scenario.Snapshot != nil && scenario.Snapshot.Restore != "" {
+			config.RestoreSnapshot = filepath.Join(snapshotDir, scenario.Snapshot.Restore)
+			fmt.Printf("Restoring nodes from snapshot %s\n", config.RestoreSnapshot)
+		}
+		net, err = local.NewLocalNetwork(config)
 		if err != nil {
 			return err
 		}
 	}
 
+	// The snapshot is only saved if the scenario completed successfully, to
+	// not continue later scenarios from a broken state.
+	succeeded := false
 	defer func() {
-		fmt.Printf("Shutting down network ...\n")
-		if err := net.Shutdown(); err != nil {
+		dir := ""
+		if scenario.Snapshot != nil && scenario.Snapshot.Save != "" {
+			if succeeded {
+				dir = filepath.Join(snapshotDir, scenario.Snapshot.Save)
+			} else {
+				fmt.Printf("Snapshot %s is not saved, since the scenario failed\n", scenario.Snapshot.Save)
+			}
+		}
+		var err error
+		if saver, ok := net.(driver.SnapshotSaver); ok && dir != "" {
+			fmt.Printf("Shutting down network and saving snapshot to %s ...\n", dir)
+			err = saver.ShutdownAndSaveSnapshot(dir)
+		} else {
+			fmt.Printf("Shutting down network ...\n")
+			err = net.Shutdown()
+		}
+		if err != nil {
 			fmt.Printf("error during network shutdown:\n%v", err)
 		}
 	}()
@@ -310,10 +344,12 @@ func runScenario(path, outputDir, label string, keepPrometheusRunning, skipCheck
 		defer logger.shutdown()
 	}
 	err = executor.Run(clock, net, &scenario, checks)
+	printSendErrorSummary(os.Stdout, net)
 	if err != nil {
 		return err
 	}
 	fmt.Printf("Execution completed successfully!\n")
+	succeeded = true
 
 	return nil
 }
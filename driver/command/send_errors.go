@@ -0,0 +1,64 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package command
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+)
+
+// printSendErrorSummary prints the errors reported when sending transactions
+// during a run, per node and per application, as far as they are tracked by
+// the network and its applications.
+func printSendErrorSummary(out io.Writer, net driver.Network) {
+	var lines []string
+	if tracker, ok := net.(driver.NodeSendErrorTracker); ok {
+		errors := tracker.GetSendErrors()
+		nodes := make([]string, 0, len(errors))
+		for node := range errors {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			lines = append(lines, fmt.Sprintf("  node %s: %s", node, formatSendErrors(errors[node])))
+		}
+	}
+	apps := slices.Clone(net.GetActiveApplications())
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Config().Name < apps[j].Config().Name })
+	for _, app := range apps {
+		if tracker, ok := app.(driver.SendErrorTracker); ok {
+			lines = append(lines, fmt.Sprintf("  app %s: %s", app.Config().Name, formatSendErrors(tracker.GetSendErrors())))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Transaction send errors:\n%s\n", strings.Join(lines, "\n"))
+}
+
+func formatSendErrors(errors rpc.SendErrors) string {
+	classes := make([]string, 0, len(rpc.SendErrorClasses))
+	for _, class := range rpc.SendErrorClasses {
+		classes = append(classes, fmt.Sprintf("%s=%d", class, errors[class]))
+	}
+	return fmt.Sprintf("%d (%s)", errors.Total(), strings.Join(classes, ", "))
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPrintSendErrorSummary_ListsErrorsOfNodesAndApps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracker := driver.NewMockNodeSendErrorTracker(ctrl)
+	net := struct {
+		*driver.MockNetwork
+		*driver.MockNodeSendErrorTracker
+	}{driver.NewMockNetwork(ctrl), tracker}
+
+	nodeErrors := rpc.SendErrors{}
+	nodeErrors[rpc.NonceError] = 2
+	tracker.EXPECT().GetSendErrors().Return(map[string]rpc.SendErrors{"B": nodeErrors, "A": {}})
+
+	appErrors := rpc.SendErrors{}
+	appErrors[rpc.PoolFullError] = 1
+	app := struct {
+		*driver.MockApplication
+		*driver.MockSendErrorTracker
+	}{driver.NewMockApplication(ctrl), driver.NewMockSendErrorTracker(ctrl)}
+	app.MockApplication.EXPECT().Config().Return(&driver.ApplicationConfig{Name: "X"}).AnyTimes()
+	app.MockSendErrorTracker.EXPECT().GetSendErrors().Return(appErrors)
+	net.MockNetwork.EXPECT().GetActiveApplications().Return([]driver.Application{app})
+
+	out := &strings.Builder{}
+	printSendErrorSummary(out, net)
+	want := "Transaction send errors:\n" +
+		"  node A: 0 (nonce=0, underpriced=0, pool_full=0, connection=0, other=0)\n" +
+		"  node B: 2 (nonce=2, underpriced=0, pool_full=0, connection=0, other=0)\n" +
+		"  app X: 1 (nonce=0, underpriced=0, pool_full=1, connection=0, other=0)\n"
+	if got := out.String(); got != want {
+		t.Errorf("unexpected summary, wanted\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestPrintSendErrorSummary_NothingIsPrintedWithoutTracking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	net.EXPECT().GetActiveApplications().Return(nil)
+
+	out := &strings.Builder{}
+	printSendErrorSummary(out, net)
+	if out.Len() != 0 {
+		t.Errorf("unexpected summary %q", out.String())
+	}
+}
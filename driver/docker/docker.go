@@ -18,15 +18,21 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver/network"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/blkiodev"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
@@ -64,11 +70,24 @@ type Network struct {
 // Fantom network Node, thus an instance of the go-opera client.
 // *Container implements the driver.Host interface.
 type Container struct {
-	id      string
 	client  *Client
-	config  *ContainerConfig
 	stopped bool
 	cleaned bool
+
+	// id, config and paused are replaced by upgrades and pauses, which may
+	// run concurrently with other operations on the container.
+	stateMutex sync.Mutex
+	id         string
+	config     *ContainerConfig
+	paused     bool
+
+	// restarts tracks restarts of the container, such that log streams can
+	// continue with the log of the restarted container.
+	restartsMutex sync.Mutex
+	restarted     *sync.Cond
+	restarting    bool   // < true while a restart is in progress
+	restarts      int    // < number of completed restarts
+	startedAt     string // < start time of the latest restart
 }
 
 // ContainerConfig defines parameters for running Docker Containers.
@@ -80,6 +99,10 @@ type ContainerConfig struct {
 	Entrypoint      []string // Entrypoint to run when starting the container. Optional.
 	Network         *Network // Docker network to join, nil to join bridge network
 	DataDirBinding  *string  // mount client datadir to this path on host
+	Cpus            float64  // number of CPUs available to the container, 0 for unlimited
+	Memory          int64    // memory limit in bytes, 0 for unlimited
+	DiskIoBps       int64    // read and write limit of the disk in bytes per second, 0 for unlimited
+	InitialFiles    string   // tar archive, optionally compressed, extracted to the root of the container before it is started. Optional.
 }
 
 // NewClient creates a new client facilitating the creation of Docker
@@ -142,6 +165,21 @@ func (c *Client) Close() error {
 // services reachable from outside the Docker container (e.g. by the
 // application running this code).
 func (c *Client) Start(config *ContainerConfig) (*Container, error) {
+	id, err := c.create(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.start(id); err != nil {
+		return nil, err
+	}
+	res := &Container{id: id, client: c, config: config}
+	res.restarted = sync.NewCond(&res.restartsMutex)
+	return res, nil
+}
+
+// create creates a container as defined by the given configuration without
+// starting it, and returns its ID.
+func (c *Client) create(config *ContainerConfig) (string, error) {
 	envVars := []string{}
 	for key, value := range config.Environment {
 		envVars = append(envVars, fmt.Sprintf("%s=%s", key, value))
@@ -160,6 +198,11 @@ func (c *Client) Start(config *ContainerConfig) (*Container, error) {
 		binds = append(binds, *config.DataDirBinding)
 	}
 
+	resources, err := c.getResources(config)
+	if err != nil {
+		return "", err
+	}
+
 	init := true
 	stopTimeout := int(config.ShutdownTimeout.Seconds())
 	resp, err := c.cli.ContainerCreate(context.Background(), &container.Config{
@@ -176,9 +219,10 @@ func (c *Client) Start(config *ContainerConfig) (*Container, error) {
 		Init:         &init,
 		CapAdd:       []string{"NET_ADMIN"},
 		Binds:        binds,
+		Resources:    resources,
 	}, nil, nil, "")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	// connect to custom network if specified
@@ -188,17 +232,117 @@ func (c *Client) Start(config *ContainerConfig) (*Container, error) {
 	if config.Network != nil {
 		err = c.cli.NetworkConnect(context.Background(), config.Network.id, resp.ID, nil)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 	}
 
-	if err := network.Retry(network.DefaultRetryAttempts, 1*time.Second, func() error {
-		return c.cli.ContainerStart(context.Background(), resp.ID, container.StartOptions{})
-	}); err != nil {
-		return nil, err
+	if config.InitialFiles != "" {
+		if err := c.copyToContainer(resp.ID, config.InitialFiles); err != nil {
+			return "", err
+		}
+	}
+	return resp.ID, nil
+}
+
+// start runs the created container with the given ID.
+func (c *Client) start(id string) error {
+	return network.Retry(network.DefaultRetryAttempts, 1*time.Second, func() error {
+		return c.cli.ContainerStart(context.Background(), id, container.StartOptions{})
+	})
+}
+
+// copyToContainer extracts the given tar archive into the root directory of
+// the container with the given ID.
+func (c *Client) copyToContainer(id string, archive string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open archive %s; %w", archive, err)
+	}
+	defer file.Close()
+	if err := c.cli.CopyToContainer(context.Background(), id, "/", file, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy archive %s to container; %w", archive, err)
 	}
+	return nil
+}
 
-	return &Container{resp.ID, c, config, false, false}, nil
+// getResources maps the resource limits of the given configuration to the
+// resources of Docker containers. Disk throughput is throttled on the block
+// device hosting the data of the container, which is the mounted data
+// directory if there is one, and the data of the Docker daemon otherwise.
+func (c *Client) getResources(config *ContainerConfig) (container.Resources, error) {
+	res := container.Resources{
+		NanoCPUs: int64(config.Cpus * 1e9),
+		Memory:   config.Memory,
+	}
+	if config.DiskIoBps > 0 {
+		path, err := c.getDataPath(config)
+		if err != nil {
+			return res, err
+		}
+		device, err := getBlockDevice(path)
+		if err != nil {
+			return res, fmt.Errorf("failed to resolve device to limit disk throughput; %w", err)
+		}
+		limit := []*blkiodev.ThrottleDevice{{Path: device, Rate: uint64(config.DiskIoBps)}}
+		res.BlkioDeviceReadBps = limit
+		res.BlkioDeviceWriteBps = limit
+	}
+	return res, nil
+}
+
+// getDataPath returns the path on the host storing the data written by the
+// container of the given configuration.
+func (c *Client) getDataPath(config *ContainerConfig) (string, error) {
+	if config.DataDirBinding != nil {
+		return getHostPath(*config.DataDirBinding), nil
+	}
+	info, err := c.cli.Info(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to get docker info; %w", err)
+	}
+	return info.DockerRootDir, nil
+}
+
+// getHostPath returns the path on the host of the given binding of the form
+// "<host path>:<container path>".
+func getHostPath(binding string) string {
+	host, _, _ := strings.Cut(binding, ":")
+	return host
+}
+
+// getBlockDevice returns the path of the disk containing the given path. If
+// the path is located on a partition, the disk of the partition is returned,
+// since Docker can only throttle whole disks.
+func getBlockDevice(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", fmt.Errorf("unsupported platform")
+	}
+	dev := uint64(stat.Dev)
+	major := ((dev >> 8) & 0xfff) | ((dev >> 32) & ^uint64(0xfff))
+	minor := (dev & 0xff) | ((dev >> 12) & ^uint64(0xff))
+	device := fmt.Sprintf("%d:%d", major, minor)
+	if major == 0 {
+		return "", fmt.Errorf("%s is not located on a block device", path)
+	}
+
+	// partitions are listed as sub-directories of their disk in sysfs
+	sys, err := filepath.EvalSymlinks(filepath.Join("/sys/dev/block", device))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve block device %s; %w", device, err)
+	}
+	if _, err := os.Stat(filepath.Join(sys, "partition")); err == nil {
+		parent, err := os.ReadFile(filepath.Join(filepath.Dir(sys), "dev"))
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve disk of partition %s; %w", device, err)
+		}
+		device = strings.TrimSpace(string(parent))
+	}
+	return filepath.Join("/dev/block", device), nil
 }
 
 // CreateBridgeNetwork creates a new Docker bridge network.
@@ -223,11 +367,26 @@ func (c *Client) CreateBridgeNetwork() (*Network, error) {
 	}, nil
 }
 
+// getId returns the ID of the Docker container currently backing this container.
+func (c *Container) getId() string {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	return c.id
+}
+
+// getConfig returns the configuration of the Docker container currently
+// backing this container.
+func (c *Container) getConfig() *ContainerConfig {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	return c.config
+}
+
 // Hostname returns the hostname of the Container. In this case it is the ID of the
 // Docker Container.
 func (c *Container) Hostname() string {
 	// return the truncated container ID
-	return c.id[:12]
+	return c.getId()[:12]
 }
 
 // IsRunning returns true if the Container has not been stopped yet and is
@@ -244,11 +403,279 @@ func (c *Container) Stop() error {
 		return nil
 	}
 	c.stopped = true
-	timeout := int(c.config.ShutdownTimeout.Seconds())
-	return c.client.cli.ContainerStop(context.Background(), c.id, container.StopOptions{
+	timeout := int(c.getConfig().ShutdownTimeout.Seconds())
+	return c.client.cli.ContainerStop(context.Background(), c.getId(), container.StopOptions{
 		Signal: string(SigInt), Timeout: &timeout})
 }
 
+// Restart stops and starts this container again. If graceful is set, services
+// are signaled about the termination and killed after the configured shutdown
+// timeout, like in Stop; otherwise they are killed immediately. The file
+// system, the port-forwarding, and the network connections of the container
+// are retained.
+func (c *Container) Restart(graceful bool) error {
+	if c.stopped {
+		return fmt.Errorf("cannot restart stopped container")
+	}
+	if err := c.Resume(); err != nil {
+		return err
+	}
+
+	c.restartsMutex.Lock()
+	c.restarting = true
+	c.restartsMutex.Unlock()
+	startedAt := ""
+	defer func() {
+		c.restartsMutex.Lock()
+		defer c.restartsMutex.Unlock()
+		c.restarting = false
+		if startedAt != "" {
+			c.restarts++
+			c.startedAt = startedAt
+		}
+		c.restarted.Broadcast()
+	}()
+
+	signal, timeout := SigInt, int(c.getConfig().ShutdownTimeout.Seconds())
+	if !graceful {
+		signal, timeout = SigKill, 0
+	}
+	id := c.getId()
+	err := c.client.cli.ContainerRestart(context.Background(), id, container.StopOptions{
+		Signal: string(signal), Timeout: &timeout})
+	if err != nil {
+		return fmt.Errorf("failed to restart container; %w", err)
+	}
+	info, err := c.client.cli.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("failed to inspect restarted container; %w", err)
+	}
+	startedAt = info.State.StartedAt
+	return nil
+}
+
+// Upgrade replaces this container by a new container running the given image
+// with the configuration of this container, including its port-forwarding and
+// network connections. The file or directory at the given path, e.g. the data
+// directory of a service, is carried over to the new container unless it is
+// mounted from the host. Services are signaled about the termination like in
+// Stop. If the new container cannot be started, the old one is started again.
+// Log streams continue with the log of the new container, like after restarts.
+func (c *Container) Upgrade(image string, path string) error {
+	if c.stopped {
+		return fmt.Errorf("cannot upgrade stopped container")
+	}
+	if err := c.Resume(); err != nil {
+		return err
+	}
+
+	c.restartsMutex.Lock()
+	c.restarting = true
+	c.restartsMutex.Unlock()
+	old, startedAt := c.getId(), ""
+	defer func() {
+		c.restartsMutex.Lock()
+		defer c.restartsMutex.Unlock()
+		c.restarting = false
+		if startedAt != "" {
+			c.restarts++
+			c.startedAt = startedAt
+		}
+		c.restarted.Broadcast()
+	}()
+
+	timeout := int(c.getConfig().ShutdownTimeout.Seconds())
+	if err := c.client.cli.ContainerStop(context.Background(), old, container.StopOptions{
+		Signal: string(SigInt), Timeout: &timeout}); err != nil {
+		return fmt.Errorf("failed to stop container; %w", err)
+	}
+
+	config := *c.getConfig()
+	config.ImageName = image
+	config.InitialFiles = ""
+	next, err := c.client.create(&config)
+	if err == nil && !config.isMounted(path) {
+		err = c.client.copyBetweenContainers(old, next, path)
+	}
+	if err == nil {
+		err = c.client.start(next)
+	}
+	if err == nil {
+		startedAt, err = c.client.getStartTime(next)
+	}
+	if err != nil {
+		// restore the old container to keep the service available
+		err = fmt.Errorf("failed to upgrade container to image %s; %w", image, err)
+		if next != "" {
+			err = errors.Join(err, c.client.cli.ContainerRemove(context.Background(), next, container.RemoveOptions{Force: true}))
+		}
+		if restartErr := c.client.start(old); restartErr != nil {
+			return errors.Join(err, restartErr)
+		}
+		var restartErr error
+		startedAt, restartErr = c.client.getStartTime(old)
+		return errors.Join(err, restartErr)
+	}
+
+	c.stateMutex.Lock()
+	c.id, c.config = next, &config
+	c.stateMutex.Unlock()
+	return c.client.cli.ContainerRemove(context.Background(), old, container.RemoveOptions{})
+}
+
+// isMounted checks whether the given path in the container is mounted from
+// the host.
+func (c *ContainerConfig) isMounted(path string) bool {
+	return c.DataDirBinding != nil && strings.HasSuffix(*c.DataDirBinding, ":"+path)
+}
+
+// copyBetweenContainers copies the file or directory at the given path from
+// one container to the same location in another container.
+func (c *Client) copyBetweenContainers(from, to string, path string) error {
+	reader, _, err := c.cli.CopyFromContainer(context.Background(), from, path)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s from container; %w", path, err)
+	}
+	defer reader.Close()
+	if err := c.cli.CopyToContainer(context.Background(), to, filepath.Dir(path), reader, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy %s to container; %w", path, err)
+	}
+	return nil
+}
+
+// getStartTime returns the time the container with the given ID was started.
+func (c *Client) getStartTime(id string) (string, error) {
+	info, err := c.cli.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container; %w", err)
+	}
+	return info.State.StartedAt, nil
+}
+
+// Pause suspends all processes of this container. Paused containers retain
+// their state but do not respond to requests until they are resumed.
+func (c *Container) Pause() error {
+	if c.stopped {
+		return fmt.Errorf("cannot pause stopped container")
+	}
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	if c.paused {
+		return nil
+	}
+	if err := c.client.cli.ContainerPause(context.Background(), c.id); err != nil {
+		return fmt.Errorf("failed to pause container; %w", err)
+	}
+	c.paused = true
+	return nil
+}
+
+// Resume continues all processes of a container suspended by Pause. Resuming
+// a container which is not paused has no effect.
+func (c *Container) Resume() error {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	if !c.paused {
+		return nil
+	}
+	if err := c.client.cli.ContainerUnpause(context.Background(), c.id); err != nil {
+		return fmt.Errorf("failed to resume container; %w", err)
+	}
+	c.paused = false
+	return nil
+}
+
+// ContainerStats summarizes the resources consumed by a container. Except for
+// the memory, all values are cumulative since the start of the container.
+type ContainerStats struct {
+	Time           time.Time // < the time the stats were read
+	CpuUsage       uint64    // < CPU time consumed by the container, in nanoseconds
+	SystemCpuUsage uint64    // < CPU time consumed by the host, in nanoseconds
+	OnlineCpus     uint32    // < number of CPUs of the host
+	MemoryRss      uint64    // < resident set size of the container, in bytes
+	BlockRead      uint64    // < bytes read from block devices
+	BlockWrite     uint64    // < bytes written to block devices
+	NetworkRx      uint64    // < bytes received on all network interfaces
+	NetworkTx      uint64    // < bytes sent on all network interfaces
+}
+
+// GetStats reads the current resource usage of this container from Docker.
+func (c *Container) GetStats() (ContainerStats, error) {
+	resp, err := c.client.cli.ContainerStatsOneShot(context.Background(), c.getId())
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to get stats of container; %w", err)
+	}
+	defer resp.Body.Close()
+	var stats container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to decode stats of container; %w", err)
+	}
+	return newContainerStats(&stats), nil
+}
+
+// newContainerStats extracts the summarized stats from a Docker stats response.
+func newContainerStats(stats *container.StatsResponse) ContainerStats {
+	res := ContainerStats{
+		Time:           stats.Read,
+		CpuUsage:       stats.CPUStats.CPUUsage.TotalUsage,
+		SystemCpuUsage: stats.CPUStats.SystemUsage,
+		OnlineCpus:     stats.CPUStats.OnlineCPUs,
+	}
+	if res.OnlineCpus == 0 {
+		res.OnlineCpus = uint32(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	// cgroup v1 reports the resident set as rss, cgroup v2 as anon
+	if rss, found := stats.MemoryStats.Stats["rss"]; found {
+		res.MemoryRss = rss
+	} else {
+		res.MemoryRss = stats.MemoryStats.Stats["anon"]
+	}
+
+	// cgroup v1 capitalizes operations, cgroup v2 does not
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			res.BlockRead += entry.Value
+		case "write":
+			res.BlockWrite += entry.Value
+		}
+	}
+
+	for _, network := range stats.Networks {
+		res.NetworkRx += network.RxBytes
+		res.NetworkTx += network.TxBytes
+	}
+	return res
+}
+
+// GetCpuPercentage computes the CPU utilization of the container since the
+// given previous stats, in percent of a single CPU. Thus, values range from 0
+// to 100 times the number of CPUs of the host.
+func (s ContainerStats) GetCpuPercentage(previous ContainerStats) float64 {
+	if s.CpuUsage < previous.CpuUsage || s.SystemCpuUsage <= previous.SystemCpuUsage {
+		return 0 // < no progress or the container has been restarted
+	}
+	cpuDelta := float64(s.CpuUsage - previous.CpuUsage)
+	systemDelta := float64(s.SystemCpuUsage - previous.SystemCpuUsage)
+	return cpuDelta / systemDelta * float64(s.OnlineCpus) * 100
+}
+
+// CopyFrom writes a tar archive of the file or directory at the given path in
+// the container to the given writer. The container may be stopped, but it
+// must not have been cleaned up.
+func (c *Container) CopyFrom(path string, out io.Writer) error {
+	reader, _, err := c.client.cli.CopyFromContainer(context.Background(), c.getId(), path)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s from container; %w", path, err)
+	}
+	defer reader.Close()
+	if _, err := io.Copy(out, reader); err != nil {
+		return fmt.Errorf("failed to copy %s from container; %w", path, err)
+	}
+	return nil
+}
+
 // Cleanup stops the container (unless it is already stopped) and frees any
 // resources associated to it. After the operation, the Container is to be
 // considered invalid.
@@ -260,7 +687,7 @@ func (c *Container) Cleanup() error {
 		return err
 	}
 	c.cleaned = true
-	return c.client.cli.ContainerRemove(context.Background(), c.id, container.RemoveOptions{})
+	return c.client.cli.ContainerRemove(context.Background(), c.getId(), container.RemoveOptions{})
 }
 
 // GetAddressForService retrieves the Address of a service running in this
@@ -270,7 +697,7 @@ func (c *Container) Cleanup() error {
 func (c *Container) GetAddressForService(service *network.ServiceDescription) *network.AddressPort {
 	// All services inside the container are reached through port-forwarding
 	// on the localhost. Non-forwarded services are not supported.
-	port, ok := c.config.PortForwarding[service.Port]
+	port, ok := c.getConfig().PortForwarding[service.Port]
 	if !ok {
 		return nil
 	}
@@ -287,12 +714,15 @@ func (c *Container) SaveLogTo(directory string) error {
 
 	// TODO if this proves insufficient, an alternative would be to mount certain directories from
 	// the container to temp on the host and here just copy local directories
-	reader, err := c.client.cli.ContainerLogs(context.Background(), c.id, opt)
+	c.stateMutex.Lock()
+	id, image := c.id, c.config.ImageName
+	c.stateMutex.Unlock()
+	reader, err := c.client.cli.ContainerLogs(context.Background(), id, opt)
 	if err != nil {
 		return err
 	}
 
-	file, err := os.Create(fmt.Sprintf("%s/%s_%s.log", directory, c.config.ImageName, c.id))
+	file, err := os.Create(fmt.Sprintf("%s/%s_%s.log", directory, image, id))
 	if err != nil {
 		return err
 	}
@@ -305,24 +735,100 @@ func (c *Container) SaveLogTo(directory string) error {
 	return nil
 }
 
+// StreamLog provides a reader continuously providing the log of the container.
+// If the container is restarted, the stream continues with the log of the
+// restarted container.
 func (c *Container) StreamLog() (io.ReadCloser, error) {
+	c.restartsMutex.Lock()
+	restarts, since := c.restarts, c.startedAt
+	c.restartsMutex.Unlock()
+
+	reader, err := c.streamLogSince(since)
+	if err != nil {
+		return nil, err
+	}
+	return &containerLogStream{container: c, current: reader, restarts: restarts}, nil
+}
+
+// streamLogSince opens a stream of the log produced by the container since
+// the given time, or of its entire log if the time is empty.
+func (c *Container) streamLogSince(since string) (io.ReadCloser, error) {
 	opt := container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Follow:     true,
+		Since:      since,
 	}
+	return c.client.cli.ContainerLogs(context.Background(), c.getId(), opt)
+}
 
-	reader, err := c.client.cli.ContainerLogs(context.Background(), c.id, opt)
-	if err != nil {
-		return nil, err
+// awaitRestart blocks until a potentially ongoing restart of the container is
+// completed. It returns the start time of the container if it was restarted
+// more often than the given number of restarts, and false otherwise.
+func (c *Container) awaitRestart(restarts int) (int, string, bool) {
+	c.restartsMutex.Lock()
+	defer c.restartsMutex.Unlock()
+	for c.restarting {
+		c.restarted.Wait()
+	}
+	return c.restarts, c.startedAt, c.restarts > restarts
+}
+
+// containerLogStream is a log stream of a container surviving restarts. The
+// log stream of Docker ends whenever the container stops. If the end is caused
+// by a restart, the stream is continued by the log of the restarted container.
+type containerLogStream struct {
+	container *Container
+	mutex     sync.Mutex
+	current   io.ReadCloser
+	restarts  int // < number of restarts of the container covered by the stream
+	closed    bool
+}
+
+func (s *containerLogStream) Read(p []byte) (int, error) {
+	for {
+		s.mutex.Lock()
+		current := s.current
+		s.mutex.Unlock()
+
+		n, err := current.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		restarts, since, restarted := s.container.awaitRestart(s.restarts)
+		if !restarted {
+			return 0, io.EOF
+		}
+		next, err := s.container.streamLogSince(since)
+		if err != nil {
+			return 0, fmt.Errorf("failed to stream log of restarted container; %w", err)
+		}
+
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			_ = next.Close()
+			return 0, io.EOF
+		}
+		s.current, s.restarts = next, restarts
+		s.mutex.Unlock()
+		if err := current.Close(); err != nil {
+			return 0, err
+		}
 	}
+}
 
-	return reader, nil
+func (s *containerLogStream) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	return s.current.Close()
 }
 
 // SendSignal sends a signal to the container.
 func (c *Container) SendSignal(signal Signal) error {
-	return c.client.cli.ContainerKill(context.Background(), c.id, string(signal))
+	return c.client.cli.ContainerKill(context.Background(), c.getId(), string(signal))
 }
 
 // Exec executes a command in the container.
@@ -337,7 +843,7 @@ func (c *Container) Exec(cmd []string) (string, error) {
 		AttachStdout: true,
 		AttachStderr: true,
 	}
-	execResp, err := c.client.cli.ContainerExecCreate(context.Background(), c.id, execConfig)
+	execResp, err := c.client.cli.ContainerExecCreate(context.Background(), c.getId(), execConfig)
 	if err != nil {
 		return "", fmt.Errorf("failed to create exec instance: %s", err)
 	}
@@ -19,14 +19,18 @@ package docker
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver/network"
+	"github.com/docker/docker/api/types/container"
 )
 
 func TestImplements(t *testing.T) {
@@ -241,6 +245,335 @@ func TestContainer_SendSignal(t *testing.T) {
 	}
 }
 
+func TestContainer_RestartRetainsFileSystem(t *testing.T) {
+	for _, graceful := range []bool{true, false} {
+		t.Run(fmt.Sprintf("graceful=%t", graceful), func(t *testing.T) {
+			cli, cont := startRunningContainer(t, nil)
+			if _, err := cont.Exec([]string{"sh", "-c", "echo marker > /tmp/marker"}); err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if err := cont.Restart(graceful); err != nil {
+				t.Fatalf("error restarting container: %v", err)
+			}
+			if !cont.IsRunning() {
+				t.Errorf("restarted container is not running")
+			}
+			info, err := cli.cli.ContainerInspect(context.Background(), cont.id)
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if !info.State.Running {
+				t.Errorf("expected container to be running")
+			}
+			out, err := cont.Exec([]string{"cat", "/tmp/marker"})
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if !strings.Contains(out, "marker") {
+				t.Errorf("file system was not retained, got %s", out)
+			}
+		})
+	}
+}
+
+func TestContainer_CopiedFilesCanBeRestoredInOtherContainer(t *testing.T) {
+	cli, cont := startRunningContainer(t, nil)
+	if _, err := cont.Exec([]string{"sh", "-c", "mkdir /data && echo marker > /data/marker"}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := cont.Stop(); err != nil {
+		t.Fatalf("error stopping container: %v", err)
+	}
+	archive := filepath.Join(t.TempDir(), "data.tar")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := cont.CopyFrom("/data", file); err != nil {
+		t.Fatalf("error copying files from stopped container: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	timeout := time.Second
+	restored, err := cli.Start(&ContainerConfig{
+		ImageName:       "alpine",
+		Entrypoint:      []string{"tail", "-f", "/dev/null"},
+		ShutdownTimeout: &timeout,
+		InitialFiles:    archive,
+	})
+	if err != nil {
+		t.Fatalf("error starting container: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = restored.Cleanup()
+	})
+	out, err := restored.Exec([]string{"cat", "/data/marker"})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !strings.Contains(out, "marker") {
+		t.Errorf("files were not restored, got %s", out)
+	}
+}
+
+func TestContainer_UpgradeRetainsFilesOfPath(t *testing.T) {
+	cli, cont := startRunningContainer(t, nil)
+	if _, err := cont.Exec([]string{"sh", "-c", "mkdir /data && echo marker > /data/marker"}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	old := cont.id
+	if err := cont.Upgrade("alpine", "/data"); err != nil {
+		t.Fatalf("error upgrading container: %v", err)
+	}
+	if cont.id == old {
+		t.Errorf("container was not replaced")
+	}
+	if _, err := cli.cli.ContainerInspect(context.Background(), old); err == nil {
+		t.Errorf("old container was not removed")
+	}
+	out, err := cont.Exec([]string{"cat", "/data/marker"})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !strings.Contains(out, "marker") {
+		t.Errorf("files were not retained, got %s", out)
+	}
+}
+
+func TestContainer_UpgradeCanRunConcurrentlyWithOtherOperations(t *testing.T) {
+	_, cont := startRunningContainer(t, nil)
+	reader, err := cont.StreamLog()
+	if err != nil {
+		t.Fatalf("cannot read logs: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = reader.Close()
+	})
+	go func() {
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// errors are expected while the container is replaced
+			_, _ = cont.GetStats()
+			_ = cont.Hostname()
+		}
+	}()
+
+	if err := cont.Upgrade("alpine", "/tmp"); err != nil {
+		t.Errorf("error upgrading container: %v", err)
+	}
+	close(done)
+	wg.Wait()
+
+	if _, err := cont.GetStats(); err != nil {
+		t.Errorf("error getting stats of upgraded container: %v", err)
+	}
+}
+
+func TestContainer_FailedUpgradeRestoresOldContainer(t *testing.T) {
+	_, cont := startRunningContainer(t, nil)
+	old := cont.id
+	if err := cont.Upgrade("hyperion-missing-image", "/tmp"); err == nil {
+		t.Fatalf("upgrade to missing image should fail")
+	}
+	if cont.id != old {
+		t.Errorf("container was replaced despite failed upgrade")
+	}
+	if _, err := cont.Exec([]string{"true"}); err != nil {
+		t.Errorf("old container is not running: %v", err)
+	}
+}
+
+func TestContainer_RestartOfStoppedContainerFails(t *testing.T) {
+	_, cont := startRunningContainer(t, nil)
+	if err := cont.Stop(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := cont.Restart(true); err == nil {
+		t.Errorf("expected restart of stopped container to fail")
+	}
+}
+
+func TestContainer_PauseAndResume(t *testing.T) {
+	cli, cont := startRunningContainer(t, nil)
+
+	isPaused := func() bool {
+		info, err := cli.cli.ContainerInspect(context.Background(), cont.id)
+		if err != nil {
+			t.Fatalf("error: %v", err)
+		}
+		return info.State.Paused
+	}
+
+	if err := cont.Pause(); err != nil {
+		t.Fatalf("error pausing container: %v", err)
+	}
+	if !isPaused() {
+		t.Errorf("expected container to be paused")
+	}
+	if err := cont.Pause(); err != nil {
+		t.Errorf("error pausing paused container: %v", err)
+	}
+	if err := cont.Resume(); err != nil {
+		t.Fatalf("error resuming container: %v", err)
+	}
+	if isPaused() {
+		t.Errorf("expected container to be resumed")
+	}
+	if err := cont.Resume(); err != nil {
+		t.Errorf("error resuming running container: %v", err)
+	}
+}
+
+func TestContainer_GetStats(t *testing.T) {
+	_, cont := startRunningContainer(t, nil)
+
+	stats, err := cont.GetStats()
+	if err != nil {
+		t.Fatalf("error getting stats: %v", err)
+	}
+	if stats.Time.IsZero() {
+		t.Errorf("expected time of stats to be set")
+	}
+	if stats.CpuUsage == 0 || stats.OnlineCpus == 0 {
+		t.Errorf("expected CPU usage to be reported, got %+v", stats)
+	}
+}
+
+func TestContainerStats_AreExtractedFromResponse(t *testing.T) {
+	response := &container.StatsResponse{
+		Stats: container.Stats{
+			CPUStats: container.CPUStats{
+				CPUUsage:    container.CPUUsage{TotalUsage: 100},
+				SystemUsage: 1000,
+				OnlineCPUs:  4,
+			},
+			MemoryStats: container.MemoryStats{Stats: map[string]uint64{"anon": 42}},
+			BlkioStats: container.BlkioStats{IoServiceBytesRecursive: []container.BlkioStatEntry{
+				{Op: "Read", Value: 1}, {Op: "write", Value: 2}, {Op: "read", Value: 3}, {Op: "sync", Value: 4},
+			}},
+		},
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: 5, TxBytes: 6},
+			"eth1": {RxBytes: 7, TxBytes: 8},
+		},
+	}
+	want := ContainerStats{
+		CpuUsage:       100,
+		SystemCpuUsage: 1000,
+		OnlineCpus:     4,
+		MemoryRss:      42,
+		BlockRead:      4,
+		BlockWrite:     2,
+		NetworkRx:      12,
+		NetworkTx:      14,
+	}
+	if got := newContainerStats(response); got != want {
+		t.Errorf("unexpected stats, wanted %+v, got %+v", want, got)
+	}
+}
+
+func TestContainerStats_GetCpuPercentage(t *testing.T) {
+	previous := ContainerStats{CpuUsage: 100, SystemCpuUsage: 1000, OnlineCpus: 4}
+	tests := map[string]struct {
+		stats ContainerStats
+		want  float64
+	}{
+		"idle":      {ContainerStats{CpuUsage: 100, SystemCpuUsage: 2000, OnlineCpus: 4}, 0},
+		"one cpu":   {ContainerStats{CpuUsage: 350, SystemCpuUsage: 2000, OnlineCpus: 4}, 100},
+		"all cpus":  {ContainerStats{CpuUsage: 1100, SystemCpuUsage: 2000, OnlineCpus: 4}, 400},
+		"no time":   {ContainerStats{CpuUsage: 200, SystemCpuUsage: 1000, OnlineCpus: 4}, 0},
+		"restarted": {ContainerStats{CpuUsage: 10, SystemCpuUsage: 2000, OnlineCpus: 4}, 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.stats.GetCpuPercentage(previous); got != test.want {
+				t.Errorf("unexpected CPU percentage, wanted %f, got %f", test.want, got)
+			}
+		})
+	}
+}
+
+func TestContainer_StreamLogContinuesAfterRestart(t *testing.T) {
+	cli, err := NewClient()
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	timeout := time.Second
+	cont, err := cli.Start(&ContainerConfig{
+		ImageName:       "alpine",
+		Entrypoint:      []string{"sh", "-c", "echo started; exec tail -f /dev/null"},
+		ShutdownTimeout: &timeout,
+	})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cont.Cleanup()
+		_ = cli.Close()
+	})
+
+	reader, err := cont.StreamLog()
+	if err != nil {
+		t.Fatalf("cannot read logs: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = reader.Close()
+	})
+
+	lines := make(chan string, 10)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	awaitStart := func() {
+		t.Helper()
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, "started") {
+				t.Fatalf("unexpected log line: %s", line)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected log not found")
+		}
+	}
+
+	awaitStart()
+	if err := cont.Restart(false); err != nil {
+		t.Fatalf("error restarting container: %v", err)
+	}
+	awaitStart()
+
+	if err := cont.Stop(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	select {
+	case line, open := <-lines:
+		if open {
+			t.Errorf("unexpected log line after stop: %s", line)
+		}
+	case <-time.After(10 * time.Second):
+		t.Errorf("log stream did not end after stop")
+	}
+}
+
 func TestNetwork_Cleanup(t *testing.T) {
 	cli, net := createNetwork(t)
 
@@ -393,3 +726,33 @@ func createNetwork(t *testing.T) (*Client, *Network) {
 
 	return cli, net
 }
+
+func TestGetBlockDevice_ResolvesDiskOfPath(t *testing.T) {
+	device, err := getBlockDevice(t.TempDir())
+	if err != nil {
+		t.Skipf("temporary directory is not located on a block device: %v", err)
+	}
+	if !strings.HasPrefix(device, "/dev/block/") {
+		t.Errorf("unexpected device path: %s", device)
+	}
+	if _, err := os.Stat(filepath.Join("/sys/dev/block", filepath.Base(device), "partition")); err == nil {
+		t.Errorf("device %s is a partition, not a disk", device)
+	}
+}
+
+func TestGetBlockDevice_FailsForMissingPath(t *testing.T) {
+	if _, err := getBlockDevice(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected an error for a missing path")
+	}
+}
+
+func TestClient_GetDataPath_UsesHostPathOfMountedDataDir(t *testing.T) {
+	binding := "/mnt/fast/datadir:/datadir"
+	path, err := (&Client{}).getDataPath(&ContainerConfig{DataDirBinding: &binding})
+	if err != nil {
+		t.Fatalf("failed to get data path: %v", err)
+	}
+	if want := "/mnt/fast/datadir"; path != want {
+		t.Errorf("unexpected data path, wanted %s, got %s", want, path)
+	}
+}
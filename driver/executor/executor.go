@@ -17,15 +17,20 @@
 package executor
 
 import (
+	"cmp"
 	"fmt"
 	"github.com/0xsoniclabs/hyperion/driver/checking"
 	"log"
+	"math/big"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver"
 	"github.com/0xsoniclabs/hyperion/driver/parser"
+	"github.com/0xsoniclabs/hyperion/load/app"
 	pq "github.com/jupp0r/go-priority-queue"
 )
 
@@ -55,9 +60,12 @@ func Run(clock Clock, network driver.Network, scenario *parser.Scenario, checks
 		fmt.Printf("Network checks skipped\n")
 	}
 
-	// Schedule all operations listed in the scenario.
+	// Schedule all operations listed in the scenario. If a snapshot is saved,
+	// nodes running until the end are left to the shutdown of the network,
+	// which includes their data in the snapshot.
+	keepAtEnd := scenario.Snapshot != nil && scenario.Snapshot.Save != ""
 	for _, node := range scenario.Nodes {
-		scheduleNodeEvents(&node, queue, network, endTime)
+		scheduleNodeEvents(&node, queue, network, endTime, keepAtEnd)
 	}
 	for _, app := range scenario.Applications {
 		if err := scheduleApplicationEvents(&app, queue, network, endTime); err != nil {
@@ -70,6 +78,12 @@ func Run(clock Clock, network driver.Network, scenario *parser.Scenario, checks
 	for _, rule := range scenario.NetworkRules.Updates {
 		scheduleNetworkRulesEvents(rule, queue, network)
 	}
+	for _, upgrade := range scenario.Upgrades {
+		scheduleUpgradeEvents(&upgrade, queue, network)
+	}
+	for _, change := range scenario.StakeChanges {
+		scheduleStakeChangeEvents(&change, queue, network)
+	}
 
 	// Register a handler for Ctrl+C events.
 	abort := make(chan os.Signal, 1)
@@ -191,7 +205,9 @@ func toSingleEvent(time Time, name string, action func() error) event {
 // nodes during the scenario execution. The nature of the scheduled nodes is taken from the
 // given node description, and actions are applied to the given network.
 // Node Lifecycle: create -> timer sim events {start, end, kill, restart} -> remove
-func scheduleNodeEvents(node *parser.Node, queue *eventQueue, net driver.Network, end Time) {
+// If keepAtEnd is set, nodes running until the end of the scenario are not
+// removed, leaving them to the shutdown of the network.
+func scheduleNodeEvents(node *parser.Node, queue *eventQueue, net driver.Network, end Time, keepAtEnd bool) {
 	instances := 1
 	if node.Instances != nil {
 		instances = *node.Instances
@@ -231,6 +247,10 @@ func scheduleNodeEvents(node *parser.Node, queue *eventQueue, net driver.Network
 					Validator:  nodeIsValidator,
 					Cheater:    nodeIsCheater,
 					DataVolume: node.Client.DataVolume,
+					Type:       driver.NodeType(node.Client.Type),
+					Env:        node.Client.Env,
+					Args:       node.Client.Args,
+					Resources:  driver.NewNodeResources(node.Resources),
 				})
 
 				*instance = newNode
@@ -238,6 +258,9 @@ func scheduleNodeEvents(node *parser.Node, queue *eventQueue, net driver.Network
 			},
 		))
 
+		if keepAtEnd && endTime >= end {
+			continue
+		}
 		queue.add(toSingleEvent(
 			endTime,
 			fmt.Sprintf("[%s] Stop Node", name),
@@ -282,13 +305,23 @@ func scheduleApplicationEvents(source *parser.Application, queue *eventQueue, ne
 		endTime = Seconds(*source.End)
 	}
 
+	options, err := getApplicationOptions(source)
+	if err != nil {
+		return err
+	}
 	for i := 0; i < instances; i++ {
 		name := fmt.Sprintf("%s-%d", source.Name, i)
 		newApp, err := net.CreateApplication(&driver.ApplicationConfig{
-			Name:  name,
-			Type:  source.Type,
-			Rate:  &source.Rate,
-			Users: users,
+			Name:          name,
+			Type:          source.Type,
+			Rate:          &source.Rate,
+			Users:         users,
+			Options:       options,
+			ClosedLoop:    getClosedLoopConfig(source.ClosedLoop),
+			Fees:          getFeeOptions(source.Fees),
+			TxTypes:       getTxTypeWeights(source.TxTypes),
+			NonceRecovery: getNonceRecoveryConfig(source.NonceRecovery),
+			Accounts:      getAccountOptions(source.Accounts),
 		})
 		if err != nil {
 			return err
@@ -303,6 +336,133 @@ func scheduleApplicationEvents(source *parser.Application, queue *eventQueue, ne
 	return nil
 }
 
+// getApplicationOptions collects the type-specific options of the given
+// application description, decoded from its config section.
+func getApplicationOptions(source *parser.Application) (app.Options, error) {
+	config, err := source.GetConfig()
+	if err != nil {
+		return app.Options{}, err
+	}
+	return app.Options{Config: config}, nil
+}
+
+// getClosedLoopConfig converts the closed-loop settings of a scenario into the
+// configuration of the application, filling in defaults. The result is nil if
+// the application runs in open-loop mode.
+func getClosedLoopConfig(source *parser.ClosedLoop) *driver.ClosedLoopConfig {
+	if source == nil {
+		return nil
+	}
+	res := &driver.ClosedLoopConfig{
+		InFlight: 1,
+		Timeout:  10 * time.Second,
+	}
+	if source.InFlight != nil {
+		res.InFlight = *source.InFlight
+	}
+	if source.Timeout != nil {
+		res.Timeout = time.Duration(float64(*source.Timeout) * float64(time.Second))
+	}
+	if source.ThinkTime != nil {
+		res.ThinkTime = time.Duration(float64(*source.ThinkTime) * float64(time.Second))
+	}
+	return res
+}
+
+// getFeeOptions converts the fee settings of a scenario from gwei into the
+// fee options of the application. Unset values are left to the defaults of
+// the fee strategy. The result is nil if no fees are configured.
+func getFeeOptions(source *parser.Fees) *app.FeeOptions {
+	if source == nil {
+		return nil
+	}
+	toWei := func(gwei *float32) *big.Int {
+		if gwei == nil {
+			return nil
+		}
+		res, _ := new(big.Float).Mul(big.NewFloat(float64(*gwei)), big.NewFloat(1e9)).Int(nil)
+		return res
+	}
+	res := &app.FeeOptions{
+		Strategy: source.Strategy,
+		FeeCap:   toWei(source.FeeCap),
+		Tip:      toWei(source.Tip),
+		MinTip:   toWei(source.MinTip),
+		MaxTip:   toWei(source.MaxTip),
+	}
+	if source.Multiplier != nil {
+		res.Multiplier = float64(*source.Multiplier)
+	}
+	if source.Replacements != nil {
+		res.Replacements = *source.Replacements
+	}
+	if source.Bump != nil {
+		res.Bump = uint64(*source.Bump)
+	}
+	if source.ReplaceAfter != nil {
+		res.ReplaceAfter = time.Duration(float64(*source.ReplaceAfter) * float64(time.Second))
+	}
+	return res
+}
+
+// getTxTypeWeights converts the transaction types of a scenario into the
+// weights used by the application. The result is nil if no types are listed.
+func getTxTypeWeights(source []parser.TxType) []app.TxTypeWeight {
+	var res []app.TxTypeWeight
+	for _, txType := range source {
+		res = append(res, app.TxTypeWeight{
+			Type:   txType.Type,
+			Weight: float64(txType.Weight),
+		})
+	}
+	return res
+}
+
+// getNonceRecoveryConfig converts the nonce recovery settings of a scenario
+// into the configuration of the application, filling in defaults. The result
+// is nil if the recovery is not configured or disabled.
+func getNonceRecoveryConfig(source *parser.NonceRecovery) *driver.NonceRecoveryConfig {
+	if source == nil {
+		return nil
+	}
+	res := &driver.NonceRecoveryConfig{
+		Period: 10 * time.Second,
+	}
+	if source.Period != nil {
+		if *source.Period == 0 {
+			return nil
+		}
+		res.Period = time.Duration(float64(*source.Period) * float64(time.Second))
+	}
+	if source.Resend != nil {
+		res.Resend = *source.Resend
+	}
+	return res
+}
+
+// getAccountOptions converts the account pool settings of a scenario into the
+// account options of the application, converting the top-up threshold from
+// tokens into wei. The result is nil if accounts are not pooled.
+func getAccountOptions(source *parser.Accounts) *app.AccountOptions {
+	if source == nil {
+		return nil
+	}
+	res := &app.AccountOptions{
+		Mnemonic: source.Mnemonic,
+		Sweep:    source.Sweep,
+	}
+	if source.TopUp != nil {
+		res.TopUpThreshold = toWei(*source.TopUp)
+	}
+	return res
+}
+
+// toWei converts the given amount of tokens into wei.
+func toWei(tokens float32) *big.Int {
+	res, _ := new(big.Float).Mul(big.NewFloat(float64(tokens)), big.NewFloat(1e18)).Int(nil)
+	return res
+}
+
 // scheduleCheatEvents schedules a number of events covering the life-cycle of a class of
 // cheats during the scenario execution. Currently, a cheat is defined a simultaneous start
 // of multiple validator nodes with the same key.
@@ -323,3 +483,97 @@ func scheduleNetworkRulesEvents(rule parser.NetworkRulesUpdate, queue *eventQueu
 		return network.ApplyNetworkRules(driver.NetworkRules(rule.Rules))
 	}))
 }
+
+// scheduleUpgradeEvents schedules the upgrade of the named node, or of the
+// nodes of the named group one after another, like in rolling upgrades. The
+// nodes of a group are resolved when the first node is upgraded. If upgrades
+// take longer than the configured interval, the next node is upgraded right
+// after the previous one.
+func scheduleUpgradeEvents(upgrade *parser.Upgrade, queue *eventQueue, net driver.Network) {
+	start := Seconds(upgrade.Time)
+	interval := Time(0)
+	if upgrade.Interval != nil {
+		interval = Seconds(*upgrade.Interval)
+	}
+	queue.add(toEvent(start, fmt.Sprintf("[%s] Upgrading to %s", upgrade.Node, upgrade.Image), func() ([]event, error) {
+		upgrader, ok := net.(driver.NodeUpgrader)
+		if !ok {
+			return nil, fmt.Errorf("network does not support upgrading nodes")
+		}
+		nodes := getActiveNodesOf(net, upgrade.Node)
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("no active node %s to upgrade", upgrade.Node)
+		}
+		events := make([]event, 0, len(nodes))
+		for i, node := range nodes {
+			events = append(events, toSingleEvent(
+				start+Time(i)*interval,
+				fmt.Sprintf("[%s] Upgrading node to %s", node.GetLabel(), upgrade.Image),
+				func() error {
+					return upgrader.UpgradeNode(node, upgrade.Image)
+				},
+			))
+		}
+		return events, nil
+	}))
+}
+
+// scheduleStakeChangeEvents schedules the change of the stake of the named
+// validator, or of all validators of the named group, at the configured time.
+func scheduleStakeChangeEvents(change *parser.StakeChange, queue *eventQueue, net driver.Network) {
+	name := fmt.Sprintf("[%s] Changing stake: %s", change.Node, change.Action)
+	if change.Amount != "" {
+		name = fmt.Sprintf("%s %s tokens", name, change.Amount)
+	}
+	queue.add(toSingleEvent(Seconds(change.Time), name, func() error {
+		controller, ok := net.(driver.StakeController)
+		if !ok {
+			return fmt.Errorf("network does not support changing the stake of validators")
+		}
+		nodes := getActiveNodesOf(net, change.Node)
+		if len(nodes) == 0 {
+			return fmt.Errorf("no active node %s to change the stake of", change.Node)
+		}
+		for _, node := range nodes {
+			var err error
+			switch change.Action {
+			case "deactivate":
+				err = controller.DeactivateValidator(node)
+			case "delegate", "undelegate":
+				var amount *big.Int
+				amount, err = change.GetAmount()
+				if err != nil {
+					break
+				}
+				if change.Action == "delegate" {
+					err = controller.DelegateStake(node, amount)
+				} else {
+					err = controller.UndelegateStake(node, amount)
+				}
+			default:
+				err = fmt.Errorf("unknown stake change action %s", change.Action)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// getActiveNodesOf lists the active nodes of the given name, which is either
+// the label of a node or the name of a group of nodes, ordered by label.
+func getActiveNodesOf(net driver.Network, name string) []driver.Node {
+	nodes := slices.DeleteFunc(slices.Clone(net.GetActiveNodes()), func(node driver.Node) bool {
+		return !driver.IsNodeOfGroup(node.GetLabel(), name)
+	})
+	// instances of a group share the prefix of their labels, such that sorting
+	// by length first orders them by instance number
+	slices.SortFunc(nodes, func(a, b driver.Node) int {
+		return cmp.Or(
+			cmp.Compare(len(a.GetLabel()), len(b.GetLabel())),
+			strings.Compare(a.GetLabel(), b.GetLabel()),
+		)
+	})
+	return nodes
+}
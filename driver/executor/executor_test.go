@@ -17,15 +17,26 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
 	"github.com/0xsoniclabs/hyperion/driver/checking"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/network/local"
 	"github.com/0xsoniclabs/hyperion/driver/parser"
+	"github.com/0xsoniclabs/hyperion/load/app"
 	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
 )
 
 func TestExecutor_RunEmptyScenario(t *testing.T) {
@@ -82,6 +93,123 @@ func TestExecutor_RunSingleNodeScenario(t *testing.T) {
 	}
 }
 
+func TestExecutor_NodeTypeIsPassedToNetwork(t *testing.T) {
+	for _, nodeType := range []driver.NodeType{driver.ObserverNode, driver.RpcNode, driver.ArchiveNode, driver.ValidatorNode} {
+		t.Run(string(nodeType), func(t *testing.T) {
+			clock := NewSimClock()
+			scenario := parser.Scenario{
+				Name:       "Test",
+				Duration:   10,
+				Validators: []parser.Validator{{Name: "validator"}},
+				Nodes: []parser.Node{{
+					Name:   "A",
+					Start:  New[float32](3),
+					End:    New[float32](7),
+					Client: parser.ClientType{Type: string(nodeType)},
+				}},
+			}
+
+			ctrl := gomock.NewController(t)
+			net := driver.NewMockNetwork(ctrl)
+			node := driver.NewMockNode(ctrl)
+
+			gomock.InOrder(
+				net.EXPECT().CreateNode(gomock.Any()).Do(func(config *driver.NodeConfig) {
+					if config.Type != nodeType {
+						t.Errorf("unexpected node type, wanted %v, got %v", nodeType, config.Type)
+					}
+					if got, want := config.Validator, nodeType == driver.ValidatorNode; got != want {
+						t.Errorf("unexpected validator flag, wanted %t, got %t", want, got)
+					}
+				}).Return(node, nil),
+				net.EXPECT().RemoveNode(node),
+				node.EXPECT().Stop(),
+				node.EXPECT().Cleanup(),
+			)
+
+			if err := Run(clock, net, &scenario, nil); err != nil {
+				t.Errorf("failed to run scenario: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecutor_ClientSettingsArePassedToNetwork(t *testing.T) {
+	clock := NewSimClock()
+	env := map[string]string{"TXPOOL_GLOBAL_SLOTS": "1024"}
+	args := map[string]string{"cache": "8192"}
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   10,
+		Validators: []parser.Validator{{Name: "validator"}},
+		Nodes: []parser.Node{{
+			Name:   "A",
+			Start:  New[float32](3),
+			End:    New[float32](7),
+			Client: parser.ClientType{Env: env, Args: args},
+		}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	node := driver.NewMockNode(ctrl)
+
+	gomock.InOrder(
+		net.EXPECT().CreateNode(gomock.Any()).Do(func(config *driver.NodeConfig) {
+			if !maps.Equal(config.Env, env) {
+				t.Errorf("unexpected environment, wanted %v, got %v", env, config.Env)
+			}
+			if !maps.Equal(config.Args, args) {
+				t.Errorf("unexpected arguments, wanted %v, got %v", args, config.Args)
+			}
+		}).Return(node, nil),
+		net.EXPECT().RemoveNode(node),
+		node.EXPECT().Stop(),
+		node.EXPECT().Cleanup(),
+	)
+
+	if err := Run(clock, net, &scenario, nil); err != nil {
+		t.Errorf("failed to run scenario: %v", err)
+	}
+}
+
+func TestExecutor_ResourcesArePassedToNetwork(t *testing.T) {
+	clock := NewSimClock()
+	cpus := float32(1.5)
+	memory := "512MB"
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   10,
+		Validators: []parser.Validator{{Name: "validator"}},
+		Nodes: []parser.Node{{
+			Name:      "A",
+			Start:     New[float32](3),
+			End:       New[float32](7),
+			Resources: &parser.Resources{Cpus: &cpus, Memory: &memory},
+		}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	node := driver.NewMockNode(ctrl)
+
+	want := driver.NodeResources{Cpus: 1.5, Memory: 512 << 20}
+	gomock.InOrder(
+		net.EXPECT().CreateNode(gomock.Any()).Do(func(config *driver.NodeConfig) {
+			if config.Resources != want {
+				t.Errorf("unexpected resources, wanted %v, got %v", want, config.Resources)
+			}
+		}).Return(node, nil),
+		net.EXPECT().RemoveNode(node),
+		node.EXPECT().Stop(),
+		node.EXPECT().Cleanup(),
+	)
+
+	if err := Run(clock, net, &scenario, nil); err != nil {
+		t.Errorf("failed to run scenario: %v", err)
+	}
+}
+
 func TestExecutor_RunMultipleNodeScenario(t *testing.T) {
 
 	clock := NewSimClock()
@@ -125,6 +253,195 @@ func TestExecutor_RunMultipleNodeScenario(t *testing.T) {
 	}
 }
 
+func TestExecutor_NodesRunningUntilTheEndAreKeptForSnapshot(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   10,
+		Validators: []parser.Validator{{Name: "validator"}},
+		Nodes: []parser.Node{
+			{Name: "A", Start: New[float32](3)},
+			{Name: "B", Start: New[float32](3), End: New[float32](7)},
+		},
+		Snapshot: &parser.Snapshot{Save: "aged"},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	nodeA := driver.NewMockNode(ctrl)
+	nodeB := driver.NewMockNode(ctrl)
+
+	// Node A is left to the network to be included in the snapshot, while
+	// node B, ending before the end of the scenario, is removed.
+	net.EXPECT().CreateNode(gomock.Any()).DoAndReturn(func(config *driver.NodeConfig) (driver.Node, error) {
+		if config.Name == "A-0" {
+			return nodeA, nil
+		}
+		return nodeB, nil
+	}).Times(2)
+	gomock.InOrder(
+		net.EXPECT().RemoveNode(newIs(nodeB)),
+		nodeB.EXPECT().Stop(),
+		nodeB.EXPECT().Cleanup(),
+	)
+
+	if err := Run(clock, net, &scenario, nil); err != nil {
+		t.Errorf("failed to run scenario: %v", err)
+	}
+}
+
+func TestExecutor_NodesOfGroupsAreIncludedInSnapshot(t *testing.T) {
+	net, err := local.NewLocalNetwork(&driver.NetworkConfig{
+		Validators: driver.NewDefaultValidators(1),
+	})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   5,
+		Validators: []parser.Validator{{Name: "validator"}},
+		Nodes: []parser.Node{{
+			Name:   "rpc",
+			Client: parser.ClientType{Type: string(driver.RpcNode)},
+		}},
+		Snapshot: &parser.Snapshot{Save: "aged"},
+	}
+	if err := Run(NewWallTimeClock(), net, &scenario, nil); err != nil {
+		t.Errorf("failed to run scenario: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "aged")
+	if err := net.ShutdownAndSaveSnapshot(dir); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.yml"))
+	if err != nil {
+		t.Fatalf("failed to read snapshot manifest: %v", err)
+	}
+	var manifest struct {
+		Nodes []struct {
+			Label   string
+			Archive string
+		}
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("failed to decode snapshot manifest: %v", err)
+	}
+	archives := map[string]string{}
+	for _, node := range manifest.Nodes {
+		archives[node.Label] = node.Archive
+	}
+	archive, found := archives["rpc-0"]
+	if !found {
+		t.Fatalf("node of group is missing in snapshot, got nodes %v", slices.Collect(maps.Keys(archives)))
+	}
+	if _, err := os.Stat(filepath.Join(dir, archive)); err != nil {
+		t.Errorf("missing data directory of node of group in snapshot: %v", err)
+	}
+}
+
+func TestExecutor_ClosedLoopIsPassedToApplication(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   10,
+		Validators: []parser.Validator{{Name: "validator"}},
+		Applications: []parser.Application{{
+			Name:       "A",
+			Type:       "counter",
+			Rate:       parser.Rate{Constant: New[float32](10)},
+			ClosedLoop: &parser.ClosedLoop{InFlight: New(4), ThinkTime: New[float32](0.5)},
+		}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	app := driver.NewMockApplication(ctrl)
+
+	want := driver.ClosedLoopConfig{
+		InFlight:  4,
+		Timeout:   10 * time.Second,
+		ThinkTime: 500 * time.Millisecond,
+	}
+	net.EXPECT().CreateApplication(gomock.Any()).DoAndReturn(func(config *driver.ApplicationConfig) (driver.Application, error) {
+		if config.ClosedLoop == nil || *config.ClosedLoop != want {
+			t.Errorf("unexpected closed-loop configuration, wanted %v, got %v", want, config.ClosedLoop)
+		}
+		return app, nil
+	})
+	app.EXPECT().Start()
+	app.EXPECT().Stop()
+
+	if err := Run(clock, net, &scenario, nil); err != nil {
+		t.Errorf("failed to run scenario: %v", err)
+	}
+}
+
+func TestExecutor_NonceRecoveryIsOptIn(t *testing.T) {
+	if got := getNonceRecoveryConfig(nil); got != nil {
+		t.Errorf("nonce recovery should be disabled if not configured, got %v", got)
+	}
+	if got, want := getNonceRecoveryConfig(&parser.NonceRecovery{}), (driver.NonceRecoveryConfig{Period: 10 * time.Second}); got == nil || *got != want {
+		t.Errorf("unexpected default nonce recovery, wanted %v, got %v", want, got)
+	}
+	custom := &parser.NonceRecovery{Period: New[float32](2.5), Resend: New(true)}
+	if got, want := getNonceRecoveryConfig(custom), (driver.NonceRecoveryConfig{Period: 2500 * time.Millisecond, Resend: true}); got == nil || *got != want {
+		t.Errorf("unexpected nonce recovery, wanted %v, got %v", want, got)
+	}
+	if got := getNonceRecoveryConfig(&parser.NonceRecovery{Period: New[float32](0)}); got != nil {
+		t.Errorf("nonce recovery should be disabled, got %v", got)
+	}
+}
+
+func TestExecutor_FeesAreConvertedToWei(t *testing.T) {
+	if got := getFeeOptions(nil); got != nil {
+		t.Errorf("unconfigured fees should result in no options, got %v", got)
+	}
+	got := getFeeOptions(&parser.Fees{
+		Strategy:     "escalating",
+		FeeCap:       New[float32](1.5),
+		Tip:          New[float32](2),
+		Replacements: New(5),
+		Bump:         New(20),
+		ReplaceAfter: New[float32](0.5),
+	})
+	if got.Strategy != "escalating" || got.FeeCap.Int64() != 1_500_000_000 || got.Tip.Int64() != 2_000_000_000 {
+		t.Errorf("unexpected prices, got strategy %v, fee cap %v, tip %v", got.Strategy, got.FeeCap, got.Tip)
+	}
+	if got.MinTip != nil || got.MaxTip != nil {
+		t.Errorf("unset tip range should be left to defaults, got [%v,%v]", got.MinTip, got.MaxTip)
+	}
+	if got.Replacements != 5 || got.Bump != 20 || got.ReplaceAfter != 500*time.Millisecond {
+		t.Errorf("unexpected replacement schedule, got %d, %d, %v", got.Replacements, got.Bump, got.ReplaceAfter)
+	}
+}
+
+func TestExecutor_AccountPoolThresholdIsConvertedToWei(t *testing.T) {
+	if got := getAccountOptions(nil); got != nil {
+		t.Errorf("unconfigured account pool should result in no options, got %v", got)
+	}
+	got := getAccountOptions(&parser.Accounts{Mnemonic: "test", TopUp: New[float32](2.5), Sweep: true})
+	if got.Mnemonic != "test" || !got.Sweep || got.TopUpThreshold.Cmp(big.NewInt(2_500_000_000_000_000_000)) != 0 {
+		t.Errorf("unexpected account options, got %v", got)
+	}
+	if got := getAccountOptions(&parser.Accounts{}); got.TopUpThreshold != nil {
+		t.Errorf("unset threshold should be left to default, got %v", got.TopUpThreshold)
+	}
+}
+
+func TestExecutor_TxTypesArePassedToApplication(t *testing.T) {
+	if got := getTxTypeWeights(nil); got != nil {
+		t.Errorf("unconfigured transaction types should result in no weights, got %v", got)
+	}
+	got := getTxTypeWeights([]parser.TxType{{Type: "legacy", Weight: 1}, {Type: "set_code", Weight: 0.5}})
+	want := []app.TxTypeWeight{{Type: "legacy", Weight: 1}, {Type: "set_code", Weight: 0.5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected transaction types, wanted %v, got %v", want, got)
+	}
+}
+
 func TestExecutor_RunSingleApplicationScenario(t *testing.T) {
 
 	clock := NewSimClock()
@@ -279,3 +596,189 @@ func (e *is[T]) String() string {
 func newIs[T any](node T) *is[T] {
 	return &is[T]{node}
 }
+
+// upgradingNetwork is a network supporting the upgrade of nodes.
+type upgradingNetwork struct {
+	*driver.MockNetwork
+	*driver.MockNodeUpgrader
+}
+
+func TestExecutor_UpgradesNodesOfGroupOneAfterAnother(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   40,
+		Validators: []parser.Validator{{Name: "validator", Instances: New(11)}},
+		Nodes:      []parser.Node{{Name: "rpc"}},
+		Upgrades: []parser.Upgrade{
+			{Node: "validator", Image: "sonic:new", Time: 2, Interval: New[float32](3)},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := upgradingNetwork{driver.NewMockNetwork(ctrl), driver.NewMockNodeUpgrader(ctrl)}
+	nodes := map[string]*driver.MockNode{}
+	active := []driver.Node{}
+	for _, label := range []string{"validator-10", "rpc-0", "validator-2"} {
+		node := driver.NewMockNode(ctrl)
+		node.EXPECT().GetLabel().Return(label).AnyTimes()
+		nodes[label] = node
+		active = append(active, node)
+	}
+
+	node := driver.NewMockNode(ctrl)
+	net.MockNetwork.EXPECT().CreateNode(gomock.Any()).Return(node, nil)
+	net.MockNetwork.EXPECT().RemoveNode(node)
+	node.EXPECT().Stop()
+	node.EXPECT().Cleanup()
+	net.MockNetwork.EXPECT().GetActiveNodes().Return(active)
+
+	upgrades := map[string]Time{}
+	for label, node := range nodes {
+		if label == "rpc-0" {
+			continue
+		}
+		net.MockNodeUpgrader.EXPECT().UpgradeNode(newIs(node), "sonic:new").DoAndReturn(func(driver.Node, string) error {
+			upgrades[label] = clock.Now()
+			return nil
+		})
+	}
+
+	if err := Run(clock, net, &scenario, nil); err != nil {
+		t.Fatalf("failed to run scenario: %v", err)
+	}
+	if got, want := upgrades["validator-2"], Seconds(2); got != want {
+		t.Errorf("unexpected time of first upgrade, wanted %v, got %v", want, got)
+	}
+	if got, want := upgrades["validator-10"], Seconds(5); got != want {
+		t.Errorf("unexpected time of second upgrade, wanted %v, got %v", want, got)
+	}
+}
+
+func TestExecutor_UpgradeOfUnsupportingNetworkFails(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:     "Test",
+		Duration: 10,
+		Upgrades: []parser.Upgrade{{Node: "validator-0", Image: "sonic:new", Time: 2}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+
+	if err := Run(clock, net, &scenario, nil); err == nil || !strings.Contains(err.Error(), "does not support upgrading nodes") {
+		t.Errorf("expected unsupported upgrade to be reported, got %v", err)
+	}
+}
+
+func TestExecutor_UpgradeOfMissingNodeFails(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:     "Test",
+		Duration: 10,
+		Nodes:    []parser.Node{{Name: "rpc", Start: New[float32](5)}},
+		Upgrades: []parser.Upgrade{{Node: "rpc", Image: "sonic:new", Time: 2}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := upgradingNetwork{driver.NewMockNetwork(ctrl), driver.NewMockNodeUpgrader(ctrl)}
+	net.MockNetwork.EXPECT().GetActiveNodes().Return(nil)
+
+	if err := Run(clock, net, &scenario, nil); err == nil || !strings.Contains(err.Error(), "no active node rpc to upgrade") {
+		t.Errorf("expected missing node to be reported, got %v", err)
+	}
+}
+
+// stakingNetwork is a network supporting changes of the stake of validators.
+type stakingNetwork struct {
+	*driver.MockNetwork
+	*driver.MockStakeController
+}
+
+func TestExecutor_ChangesStakeOfValidators(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:       "Test",
+		Duration:   10,
+		Validators: []parser.Validator{{Name: "validator", Instances: New(2)}},
+		StakeChanges: []parser.StakeChange{
+			{Node: "validator-1", Action: "delegate", Amount: "1.5", Time: 2},
+			{Node: "validator-1", Action: "undelegate", Amount: "1", Time: 3},
+			{Node: "validator-1", Action: "deactivate", Time: 4},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := stakingNetwork{driver.NewMockNetwork(ctrl), driver.NewMockStakeController(ctrl)}
+	validator0 := driver.NewMockNode(ctrl)
+	validator0.EXPECT().GetLabel().Return("validator-0").AnyTimes()
+	validator1 := driver.NewMockNode(ctrl)
+	validator1.EXPECT().GetLabel().Return("validator-1").AnyTimes()
+	net.MockNetwork.EXPECT().GetActiveNodes().Return([]driver.Node{validator0, validator1}).Times(3)
+
+	changes := map[string]Time{}
+	delegated := new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17))
+	undelegated := big.NewInt(1e18)
+	gomock.InOrder(
+		net.MockStakeController.EXPECT().DelegateStake(newIs(validator1), delegated).DoAndReturn(func(driver.Node, *big.Int) error {
+			changes["delegate"] = clock.Now()
+			return nil
+		}),
+		net.MockStakeController.EXPECT().UndelegateStake(newIs(validator1), undelegated).DoAndReturn(func(driver.Node, *big.Int) error {
+			changes["undelegate"] = clock.Now()
+			return nil
+		}),
+		net.MockStakeController.EXPECT().DeactivateValidator(newIs(validator1)).DoAndReturn(func(driver.Node) error {
+			changes["deactivate"] = clock.Now()
+			return nil
+		}),
+	)
+
+	if err := Run(clock, net, &scenario, nil); err != nil {
+		t.Fatalf("failed to run scenario: %v", err)
+	}
+	for action, want := range map[string]Time{"delegate": Seconds(2), "undelegate": Seconds(3), "deactivate": Seconds(4)} {
+		if got := changes[action]; got != want {
+			t.Errorf("unexpected time of %s, wanted %v, got %v", action, want, got)
+		}
+	}
+}
+
+func TestExecutor_StakeChangeOfUnsupportingNetworkFails(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:         "Test",
+		Duration:     10,
+		Validators:   []parser.Validator{{Name: "validator", Instances: New(2)}},
+		StakeChanges: []parser.StakeChange{{Node: "validator-0", Action: "deactivate", Time: 2}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+
+	if err := Run(clock, net, &scenario, nil); err == nil || !strings.Contains(err.Error(), "does not support changing the stake") {
+		t.Errorf("expected unsupported stake change to be reported, got %v", err)
+	}
+}
+
+func TestExecutor_FailingStakeChangeIsReported(t *testing.T) {
+	clock := NewSimClock()
+	scenario := parser.Scenario{
+		Name:         "Test",
+		Duration:     10,
+		Validators:   []parser.Validator{{Name: "validator", Instances: New(2)}},
+		StakeChanges: []parser.StakeChange{{Node: "validator-1", Action: "deactivate", Time: 2}},
+	}
+
+	ctrl := gomock.NewController(t)
+	net := stakingNetwork{driver.NewMockNetwork(ctrl), driver.NewMockStakeController(ctrl)}
+	node := driver.NewMockNode(ctrl)
+	node.EXPECT().GetLabel().Return("validator-1").AnyTimes()
+	net.MockNetwork.EXPECT().GetActiveNodes().Return([]driver.Node{node})
+	injected := fmt.Errorf("injected error")
+	net.MockStakeController.EXPECT().DeactivateValidator(newIs(node)).Return(injected)
+
+	if err := Run(clock, net, &scenario, nil); !errors.Is(err, injected) {
+		t.Errorf("expected injected error, got %v", err)
+	}
+}
@@ -20,30 +20,13 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/0xsoniclabs/hyperion/driver/globalflags"
-	"github.com/urfave/cli/v2"
+	"github.com/0xsoniclabs/hyperion/driver/command"
 )
 
 // Run with `go run ./driver/hyperion`
 
 func main() {
-	app := &cli.App{
-		Name:      "Hyperion Network Runner",
-		HelpName:  "hyperion",
-		Usage:     "A set of tools for running network scenarios",
-		Copyright: "(c) 2023 Fantom Foundation",
-		Flags:     globalflags.AllGlobalFlags,
-		Commands: []*cli.Command{
-			&checkCommand,
-			&runCommand,
-			&purgeCommand,
-			&renderCommand,
-			&diffCommand,
-		},
-		Before: globalflags.ProcessGlobalFlags,
-	}
-
-	if err := app.Run(os.Args); err != nil {
+	if err := command.NewApp().Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -27,10 +27,26 @@ import (
 )
 
 // SensorFactory is a factory for sensors targeting selected applications.
+// Factories may return a nil sensor if the metric is not applicable to an
+// application, in which case the application is not tracked.
 type SensorFactory[T any] interface {
 	CreateSensor(driver.Application) (utils.Sensor[T], error)
 }
 
+// OperationSensorFactory is an optional extension of SensorFactory creating
+// sensors for individual operations of applications mixing operations. Data of
+// an operation is reported under the subject "<app>/<operation>".
+type OperationSensorFactory[T any] interface {
+	CreateOperationSensor(driver.OperationMix, string) (utils.Sensor[T], error)
+}
+
+// PartSensorFactory is an optional extension of SensorFactory creating sensors
+// for individual parts of the data of an application, e.g. categories of a
+// counter. Data of a part is reported under the subject "<app>/<part>".
+type PartSensorFactory[T any] interface {
+	CreatePartSensors(driver.Application) (map[string]utils.Sensor[T], error)
+}
+
 // periodicAppDataSource is a generic data source periodically querying
 // node-associated sensors for data.
 type periodicAppDataSource[T any] struct {
@@ -84,5 +100,36 @@ func (s *periodicAppDataSource[T]) AfterApplicationCreation(app driver.Applicati
 		log.Printf("failed to create sensor for metric %v / app %s: %v", s.GetMetric().Name, label, err)
 		return
 	}
-	s.AddSubject(mon.App(label), sensor)
+	if sensor != nil {
+		s.AddSubject(mon.App(label), sensor)
+	}
+
+	if factory, ok := s.factory.(PartSensorFactory[T]); ok {
+		sensors, err := factory.CreatePartSensors(app)
+		if err != nil {
+			log.Printf("failed to create part sensors for metric %v / app %s: %v", s.GetMetric().Name, label, err)
+		}
+		for part, sensor := range sensors {
+			s.AddSubject(mon.App(label+"/"+part), sensor)
+		}
+	}
+
+	factory, ok := s.factory.(OperationSensorFactory[T])
+	if !ok {
+		return
+	}
+	mix, ok := app.(driver.OperationMix)
+	if !ok {
+		return
+	}
+	for _, operation := range mix.GetOperations() {
+		sensor, err := factory.CreateOperationSensor(mix, operation)
+		if err != nil {
+			log.Printf("failed to create sensor for metric %v / app %s / operation %s: %v", s.GetMetric().Name, label, operation, err)
+			continue
+		}
+		if sensor != nil {
+			s.AddSubject(mon.App(label+"/"+operation), sensor)
+		}
+	}
 }
@@ -54,6 +54,48 @@ func (f *testSensorFactory) CreateSensor(driver.Application) (utils.Sensor[int],
 	return &testSensor{}, nil
 }
 
+func (f *testSensorFactory) CreateOperationSensor(driver.OperationMix, string) (utils.Sensor[int], error) {
+	return &testSensor{}, nil
+}
+
+// mixApplication is an application providing per-operation data.
+type mixApplication struct {
+	*driver.MockApplication
+	*driver.MockOperationMix
+}
+
+func TestAppSourceTracksOperationsOfMixApplications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+
+	config := driver.ApplicationConfig{Name: "A"}
+	app := mixApplication{
+		MockApplication:  driver.NewMockApplication(ctrl),
+		MockOperationMix: driver.NewMockOperationMix(ctrl),
+	}
+	app.MockApplication.EXPECT().Config().AnyTimes().Return(&config)
+	app.MockOperationMix.EXPECT().GetOperations().Return([]string{"x", "y"})
+
+	net.EXPECT().RegisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().UnregisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().GetActiveNodes().Return([]driver.Node{}).AnyTimes() // Because of the monitors default log consumer
+	net.EXPECT().GetActiveApplications().Return([]driver.Application{app}).AnyTimes()
+
+	monitor, err := mon.NewMonitor(net, mon.MonitorConfig{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to start monitor instance: %v", err)
+	}
+	source := newPeriodicAppDataSource[int](testAppMetric, monitor, 50*time.Millisecond, &testSensorFactory{})
+	defer source.Shutdown()
+
+	subjects := source.GetSubjects()
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i] < subjects[j] })
+	want := []mon.App{mon.App("A"), mon.App("A/x"), mon.App("A/y")}
+	if !slices.Equal(subjects, want) {
+		t.Errorf("invalid list of subjects, wanted %v, got %v", want, subjects)
+	}
+}
+
 func TestAppSourceRetrievesSensorData(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	net := driver.NewMockNetwork(ctrl)
@@ -0,0 +1,71 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package appmon
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+)
+
+// EffectiveGasPrice is a metric capturing the average effective gas price in wei paid by the
+// transactions of an application, as reported by their receipts. Each value is the average of
+// a sample of the transactions processed since the previous sample.
+var EffectiveGasPrice = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, int]]{
+	Name:        "EffectiveGasPrice",
+	Description: "The average effective gas price paid by transactions of an application over time",
+}
+
+func init() {
+	if err := monitoring.RegisterSource(EffectiveGasPrice, newEffectiveGasPriceSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newEffectiveGasPriceSource is an internal factory for the EffectiveGasPrice metric.
+func newEffectiveGasPriceSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, int]] {
+	return NewPeriodicAppDataSource[int](EffectiveGasPrice, monitor, &gasPriceSensorFactory{})
+}
+
+type gasPriceSensorFactory struct{}
+
+func (f *gasPriceSensorFactory) CreateSensor(application driver.Application) (utils.Sensor[int], error) {
+	tracker, ok := application.(driver.GasPriceTracker)
+	if !ok {
+		return nil, nil // not applicable to applications not tracking gas prices
+	}
+	if load, ok := application.(driver.RequestLoad); ok && len(load.GetRequestMethods()) > 0 {
+		return nil, nil // not applicable to applications not sending transactions
+	}
+	if load, ok := application.(driver.SubscriptionLoad); ok && len(load.GetSubscriptionKinds()) > 0 {
+		return nil, nil // not applicable to applications not sending transactions
+	}
+	return &gasPriceSensor{tracker: tracker}, nil
+}
+
+type gasPriceSensor struct {
+	tracker driver.GasPriceTracker
+}
+
+func (s *gasPriceSensor) ReadValue() (int, error) {
+	price, err := s.tracker.GetEffectiveGasPrice()
+	if err != nil {
+		return 0, err
+	}
+	return int(price), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package appmon
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEffectiveGasPriceSensor_ReportsPriceOfApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracker := driver.NewMockGasPriceTracker(ctrl)
+	application := struct {
+		*driver.MockApplication
+		*driver.MockGasPriceTracker
+	}{driver.NewMockApplication(ctrl), tracker}
+	tracker.EXPECT().GetEffectiveGasPrice().Return(uint64(2_000_000_000), nil)
+
+	sensor, err := (&gasPriceSensorFactory{}).CreateSensor(application)
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	if got, err := sensor.ReadValue(); err != nil || got != 2_000_000_000 {
+		t.Errorf("unexpected gas price, wanted 2 gwei, got %d, err %v", got, err)
+	}
+}
+
+func TestEffectiveGasPriceSensor_IsNotCreatedForRequestLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	load := driver.NewMockRequestLoad(ctrl)
+	application := struct {
+		*driver.MockApplication
+		*driver.MockGasPriceTracker
+		*driver.MockRequestLoad
+	}{driver.NewMockApplication(ctrl), driver.NewMockGasPriceTracker(ctrl), load}
+	load.EXPECT().GetRequestMethods().Return([]string{"eth_call"})
+
+	if sensor, err := (&gasPriceSensorFactory{}).CreateSensor(application); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor %v, err %v", sensor, err)
+	}
+}
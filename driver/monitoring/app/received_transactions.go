@@ -54,6 +54,13 @@ func (f *receivedTransactionsSensorFactory) CreateSensor(app driver.Application)
 	}, nil
 }
 
+func (f *receivedTransactionsSensorFactory) CreateOperationSensor(mix driver.OperationMix, operation string) (utils.Sensor[int], error) {
+	return &operationReceivedTransactionsSensor{
+		mix:       mix,
+		operation: operation,
+	}, nil
+}
+
 type receivedTransactionsSensor struct {
 	app driver.Application
 }
@@ -65,3 +72,16 @@ func (s *receivedTransactionsSensor) ReadValue() (int, error) {
 	}
 	return int(count), nil
 }
+
+type operationReceivedTransactionsSensor struct {
+	mix       driver.OperationMix
+	operation string
+}
+
+func (s *operationReceivedTransactionsSensor) ReadValue() (int, error) {
+	count, err := s.mix.GetReceivedTransactionsOf(s.operation)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
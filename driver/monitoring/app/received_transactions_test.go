@@ -41,3 +41,18 @@ func TestReceivedTransactionSensorReportsProperValue(t *testing.T) {
 	}
 
 }
+
+func TestReceivedTransactionSensorReportsProperValuePerOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := &receivedTransactionsSensorFactory{}
+	mix := driver.NewMockOperationMix(ctrl)
+	mix.EXPECT().GetReceivedTransactionsOf("transfer").Return(uint64(12), nil)
+
+	sensor, err := factory.CreateOperationSensor(mix, "transfer")
+	if err != nil {
+		t.Fatalf("creation of sensor failed: %v", err)
+	}
+	if res, err := sensor.ReadValue(); err != nil || res != 12 {
+		t.Errorf("sensor fetched wrong value, wanted %d, got %d, err %v", 12, res, err)
+	}
+}
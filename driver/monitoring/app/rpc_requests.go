@@ -0,0 +1,137 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package appmon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+	"github.com/0xsoniclabs/hyperion/load/app"
+)
+
+var (
+	// RpcRequestLatency is a metric capturing the average latency of RPC requests issued by
+	// applications producing read-only load. Each value is the average over the requests
+	// completed since the previous sample. Per-method data is reported for "<app>/<method>".
+	RpcRequestLatency = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, time.Duration]]{
+		Name:        "RpcRequestLatency",
+		Description: "The average latency of RPC requests issued by an application over time",
+	}
+
+	// RpcRequestErrorRate is a metric capturing the fraction of failed RPC requests issued by
+	// applications producing read-only load. Each value covers the requests completed since
+	// the previous sample. Per-method data is reported for "<app>/<method>".
+	RpcRequestErrorRate = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, float64]]{
+		Name:        "RpcRequestErrorRate",
+		Description: "The fraction of failed RPC requests issued by an application over time",
+	}
+)
+
+func init() {
+	if err := monitoring.RegisterSource(RpcRequestLatency, newRpcRequestLatencySource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+	if err := monitoring.RegisterSource(RpcRequestErrorRate, newRpcRequestErrorRateSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newRpcRequestLatencySource is an internal factory for the RpcRequestLatency metric.
+func newRpcRequestLatencySource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, time.Duration]] {
+	return NewPeriodicAppDataSource[time.Duration](RpcRequestLatency, monitor, &rpcRequestSensorFactory[time.Duration]{
+		summarize: func(delta app.RequestStats) time.Duration {
+			if delta.Requests == 0 {
+				return 0
+			}
+			return delta.Latency / time.Duration(delta.Requests)
+		},
+	})
+}
+
+// newRpcRequestErrorRateSource is an internal factory for the RpcRequestErrorRate metric.
+func newRpcRequestErrorRateSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, float64]] {
+	return NewPeriodicAppDataSource[float64](RpcRequestErrorRate, monitor, &rpcRequestSensorFactory[float64]{
+		summarize: func(delta app.RequestStats) float64 {
+			if delta.Requests == 0 {
+				return 0
+			}
+			return float64(delta.Errors) / float64(delta.Requests)
+		},
+	})
+}
+
+// rpcRequestSensorFactory creates sensors summarizing the RPC requests
+// completed between two consecutive samples.
+type rpcRequestSensorFactory[T any] struct {
+	summarize func(delta app.RequestStats) T
+}
+
+func (f *rpcRequestSensorFactory[T]) CreateSensor(application driver.Application) (utils.Sensor[T], error) {
+	load, ok := application.(driver.RequestLoad)
+	if !ok || len(load.GetRequestMethods()) == 0 {
+		return nil, nil // not applicable to applications not issuing requests
+	}
+	return &rpcRequestSensor[T]{
+		load:      load,
+		methods:   load.GetRequestMethods(),
+		summarize: f.summarize,
+	}, nil
+}
+
+func (f *rpcRequestSensorFactory[T]) CreateOperationSensor(mix driver.OperationMix, operation string) (utils.Sensor[T], error) {
+	load, ok := mix.(driver.RequestLoad)
+	if !ok || len(load.GetRequestMethods()) == 0 {
+		return nil, nil // not applicable to applications not issuing requests
+	}
+	return &rpcRequestSensor[T]{
+		load:      load,
+		methods:   []string{operation},
+		summarize: f.summarize,
+	}, nil
+}
+
+// rpcRequestSensor summarizes the requests of a set of methods.
+type rpcRequestSensor[T any] struct {
+	load      driver.RequestLoad
+	methods   []string
+	summarize func(delta app.RequestStats) T
+	last      app.RequestStats
+}
+
+func (s *rpcRequestSensor[T]) ReadValue() (T, error) {
+	current := app.RequestStats{}
+	for _, method := range s.methods {
+		stats, err := s.load.GetRequestStats(method)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		current.Requests += stats.Requests
+		current.Errors += stats.Errors
+		current.Latency += stats.Latency
+	}
+	delta := app.RequestStats{
+		Requests: current.Requests - s.last.Requests,
+		Errors:   current.Errors - s.last.Errors,
+		Latency:  current.Latency - s.last.Latency,
+	}
+	s.last = current
+	return s.summarize(delta), nil
+}
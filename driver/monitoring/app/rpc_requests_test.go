@@ -0,0 +1,108 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package appmon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/load/app"
+	"go.uber.org/mock/gomock"
+)
+
+// requestApplication is an application issuing RPC requests.
+type requestApplication struct {
+	*driver.MockApplication
+	*driver.MockRequestLoad
+}
+
+func TestRpcRequestSensors_ReportStatsOfRecentRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	load := driver.NewMockRequestLoad(ctrl)
+	application := requestApplication{driver.NewMockApplication(ctrl), load}
+
+	load.EXPECT().GetRequestMethods().Return([]string{"eth_call", "eth_getLogs"}).AnyTimes()
+	gomock.InOrder(
+		load.EXPECT().GetRequestStats("eth_call").Return(app.RequestStats{Requests: 2, Errors: 0, Latency: 2 * time.Millisecond}, nil),
+		load.EXPECT().GetRequestStats("eth_getLogs").Return(app.RequestStats{Requests: 2, Errors: 1, Latency: 6 * time.Millisecond}, nil),
+		load.EXPECT().GetRequestStats("eth_call").Return(app.RequestStats{Requests: 2, Errors: 0, Latency: 2 * time.Millisecond}, nil),
+		load.EXPECT().GetRequestStats("eth_getLogs").Return(app.RequestStats{Requests: 2, Errors: 1, Latency: 6 * time.Millisecond}, nil),
+	)
+
+	factory := &rpcRequestSensorFactory[time.Duration]{
+		summarize: func(delta app.RequestStats) time.Duration {
+			if delta.Requests == 0 {
+				return 0
+			}
+			return delta.Latency / time.Duration(delta.Requests)
+		},
+	}
+	sensor, err := factory.CreateSensor(application)
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	if got, err := sensor.ReadValue(); err != nil || got != 2*time.Millisecond {
+		t.Errorf("unexpected average latency, wanted %v, got %v, err %v", 2*time.Millisecond, got, err)
+	}
+	// Without new requests, no latency is reported.
+	if got, err := sensor.ReadValue(); err != nil || got != 0 {
+		t.Errorf("unexpected average latency, wanted 0, got %v, err %v", got, err)
+	}
+}
+
+func TestRpcRequestErrorRate_IsComputedPerOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	load := driver.NewMockRequestLoad(ctrl)
+	mix := struct {
+		*driver.MockOperationMix
+		*driver.MockRequestLoad
+	}{driver.NewMockOperationMix(ctrl), load}
+
+	load.EXPECT().GetRequestMethods().Return([]string{"eth_call"}).AnyTimes()
+	load.EXPECT().GetRequestStats("eth_call").Return(app.RequestStats{Requests: 4, Errors: 1}, nil)
+
+	factory := &rpcRequestSensorFactory[float64]{
+		summarize: func(delta app.RequestStats) float64 {
+			return float64(delta.Errors) / float64(delta.Requests)
+		},
+	}
+	sensor, err := factory.CreateOperationSensor(mix, "eth_call")
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	if got, err := sensor.ReadValue(); err != nil || got != 0.25 {
+		t.Errorf("unexpected error rate, wanted 0.25, got %v, err %v", got, err)
+	}
+}
+
+func TestRpcRequestSensors_AreNotCreatedForTransactionApplications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := &rpcRequestSensorFactory[float64]{}
+
+	sensor, err := factory.CreateSensor(driver.NewMockApplication(ctrl))
+	if err != nil || sensor != nil {
+		t.Errorf("no sensor should be created for applications not issuing requests, got %v, err %v", sensor, err)
+	}
+
+	load := driver.NewMockRequestLoad(ctrl)
+	load.EXPECT().GetRequestMethods().Return(nil)
+	sensor, err = factory.CreateSensor(requestApplication{driver.NewMockApplication(ctrl), load})
+	if err != nil || sensor != nil {
+		t.Errorf("no sensor should be created for applications without methods, got %v, err %v", sensor, err)
+	}
+}
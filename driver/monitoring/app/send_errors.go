@@ -0,0 +1,82 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package appmon
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+)
+
+// TransactionSendErrors is a metric capturing the number of transactions of an application
+// rejected when being sent to the network. The value is cumulative. Counts of individual
+// classes of errors (nonce, underpriced, pool_full, connection, other) are reported for
+// "<app>/<class>".
+var TransactionSendErrors = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, int]]{
+	Name:        "TransactionSendErrors",
+	Description: "The number of transactions of an application rejected when being sent",
+}
+
+func init() {
+	if err := monitoring.RegisterSource(TransactionSendErrors, newTransactionSendErrorsSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newTransactionSendErrorsSource is an internal factory for the TransactionSendErrors metric.
+func newTransactionSendErrorsSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, int]] {
+	return NewPeriodicAppDataSource[int](TransactionSendErrors, monitor, &sendErrorSensorFactory{})
+}
+
+// sendErrorSensorFactory creates sensors reporting the total and per-class
+// send errors of applications.
+type sendErrorSensorFactory struct{}
+
+func (f *sendErrorSensorFactory) CreateSensor(application driver.Application) (utils.Sensor[int], error) {
+	tracker, ok := application.(driver.SendErrorTracker)
+	if !ok {
+		return nil, nil // not applicable to applications not tracking errors
+	}
+	return &sendErrorSensor{tracker: tracker, count: rpc.SendErrors.Total}, nil
+}
+
+func (f *sendErrorSensorFactory) CreatePartSensors(application driver.Application) (map[string]utils.Sensor[int], error) {
+	tracker, ok := application.(driver.SendErrorTracker)
+	if !ok {
+		return nil, nil
+	}
+	res := map[string]utils.Sensor[int]{}
+	for _, class := range rpc.SendErrorClasses {
+		class := class
+		res[class.String()] = &sendErrorSensor{
+			tracker: tracker,
+			count:   func(errors rpc.SendErrors) uint64 { return errors[class] },
+		}
+	}
+	return res, nil
+}
+
+type sendErrorSensor struct {
+	tracker driver.SendErrorTracker
+	count   func(rpc.SendErrors) uint64
+}
+
+func (s *sendErrorSensor) ReadValue() (int, error) {
+	return int(s.count(s.tracker.GetSendErrors())), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package appmon
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"go.uber.org/mock/gomock"
+)
+
+// trackingApplication is an application tracking send errors.
+type trackingApplication struct {
+	*driver.MockApplication
+	*driver.MockSendErrorTracker
+}
+
+func TestSendErrorSensors_ReportTotalAndPerClassCounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracker := driver.NewMockSendErrorTracker(ctrl)
+	application := trackingApplication{driver.NewMockApplication(ctrl), tracker}
+
+	errors := rpc.SendErrors{}
+	errors[rpc.NonceError] = 3
+	errors[rpc.PoolFullError] = 2
+	tracker.EXPECT().GetSendErrors().Return(errors).AnyTimes()
+
+	factory := &sendErrorSensorFactory{}
+	sensor, err := factory.CreateSensor(application)
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	if got, err := sensor.ReadValue(); err != nil || got != 5 {
+		t.Errorf("unexpected total, wanted 5, got %d, err %v", got, err)
+	}
+
+	sensors, err := factory.CreatePartSensors(application)
+	if err != nil || len(sensors) != len(rpc.SendErrorClasses) {
+		t.Fatalf("unexpected part sensors %v, err %v", sensors, err)
+	}
+	want := map[string]int{"nonce": 3, "underpriced": 0, "pool_full": 2, "connection": 0, "other": 0}
+	for class, count := range want {
+		if got, err := sensors[class].ReadValue(); err != nil || got != count {
+			t.Errorf("unexpected count of class %s, wanted %d, got %d, err %v", class, count, got, err)
+		}
+	}
+}
+
+func TestSendErrorSensors_AreNotCreatedForApplicationsWithoutTracking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	application := driver.NewMockApplication(ctrl)
+
+	factory := &sendErrorSensorFactory{}
+	if sensor, err := factory.CreateSensor(application); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor %v, err %v", sensor, err)
+	}
+	if sensors, err := factory.CreatePartSensors(application); err != nil || len(sensors) != 0 {
+		t.Errorf("unexpected part sensors %v, err %v", sensors, err)
+	}
+}
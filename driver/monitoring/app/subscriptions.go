@@ -0,0 +1,154 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package appmon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+	"github.com/0xsoniclabs/hyperion/load/app"
+)
+
+var (
+	// SubscriptionNotificationLatency is a metric capturing the average time between the
+	// creation of a block and the reception of notifications referring to it by applications
+	// subscribing to events. Each value is the average over the notifications received since
+	// the previous sample. Per-kind data is reported for "<app>/<kind>".
+	SubscriptionNotificationLatency = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, time.Duration]]{
+		Name:        "SubscriptionNotificationLatency",
+		Description: "The average delay of subscription notifications after the block time, per sampling interval",
+	}
+
+	// SubscriptionDroppedEvents is a metric capturing the number of events missed by the
+	// subscriptions of an application. Each value is the cumulative count since the start
+	// of the application. Per-kind data is reported for "<app>/<kind>".
+	SubscriptionDroppedEvents = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, int]]{
+		Name:        "SubscriptionDroppedEvents",
+		Description: "The cumulative number of events missed by subscriptions of an application",
+	}
+
+	// SubscriptionDuplicatedEvents is a metric capturing the number of events notified more
+	// than once to a subscription of an application. Each value is the cumulative count since
+	// the start of the application. Per-kind data is reported for "<app>/<kind>".
+	SubscriptionDuplicatedEvents = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, int]]{
+		Name:        "SubscriptionDuplicatedEvents",
+		Description: "The cumulative number of events notified repeatedly to subscriptions of an application",
+	}
+)
+
+func init() {
+	if err := monitoring.RegisterSource(SubscriptionNotificationLatency, newSubscriptionNotificationLatencySource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+	if err := monitoring.RegisterSource(SubscriptionDroppedEvents, newSubscriptionDroppedEventsSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+	if err := monitoring.RegisterSource(SubscriptionDuplicatedEvents, newSubscriptionDuplicatedEventsSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newSubscriptionNotificationLatencySource is an internal factory for the SubscriptionNotificationLatency metric.
+func newSubscriptionNotificationLatencySource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, time.Duration]] {
+	return NewPeriodicAppDataSource[time.Duration](SubscriptionNotificationLatency, monitor, &subscriptionSensorFactory[time.Duration]{
+		summarize: func(last, current app.SubscriptionStats) time.Duration {
+			measured := current.Measured - last.Measured
+			if measured == 0 {
+				return 0
+			}
+			return (current.Latency - last.Latency) / time.Duration(measured)
+		},
+	})
+}
+
+// newSubscriptionDroppedEventsSource is an internal factory for the SubscriptionDroppedEvents metric.
+func newSubscriptionDroppedEventsSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, int]] {
+	return NewPeriodicAppDataSource[int](SubscriptionDroppedEvents, monitor, &subscriptionSensorFactory[int]{
+		summarize: func(_, current app.SubscriptionStats) int {
+			return int(current.Dropped)
+		},
+	})
+}
+
+// newSubscriptionDuplicatedEventsSource is an internal factory for the SubscriptionDuplicatedEvents metric.
+func newSubscriptionDuplicatedEventsSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, int]] {
+	return NewPeriodicAppDataSource[int](SubscriptionDuplicatedEvents, monitor, &subscriptionSensorFactory[int]{
+		summarize: func(_, current app.SubscriptionStats) int {
+			return int(current.Duplicated)
+		},
+	})
+}
+
+// subscriptionSensorFactory creates sensors summarizing the notifications
+// received by applications subscribing to events.
+type subscriptionSensorFactory[T any] struct {
+	summarize func(last, current app.SubscriptionStats) T
+}
+
+func (f *subscriptionSensorFactory[T]) CreateSensor(application driver.Application) (utils.Sensor[T], error) {
+	load, ok := application.(driver.SubscriptionLoad)
+	if !ok || len(load.GetSubscriptionKinds()) == 0 {
+		return nil, nil // not applicable to applications not subscribing to events
+	}
+	return &subscriptionSensor[T]{
+		load:      load,
+		kinds:     load.GetSubscriptionKinds(),
+		summarize: f.summarize,
+	}, nil
+}
+
+func (f *subscriptionSensorFactory[T]) CreateOperationSensor(mix driver.OperationMix, operation string) (utils.Sensor[T], error) {
+	load, ok := mix.(driver.SubscriptionLoad)
+	if !ok || len(load.GetSubscriptionKinds()) == 0 {
+		return nil, nil // not applicable to applications not subscribing to events
+	}
+	return &subscriptionSensor[T]{
+		load:      load,
+		kinds:     []string{operation},
+		summarize: f.summarize,
+	}, nil
+}
+
+// subscriptionSensor summarizes the notifications of a set of subscription kinds.
+type subscriptionSensor[T any] struct {
+	load      driver.SubscriptionLoad
+	kinds     []string
+	summarize func(last, current app.SubscriptionStats) T
+	last      app.SubscriptionStats
+}
+
+func (s *subscriptionSensor[T]) ReadValue() (T, error) {
+	current := app.SubscriptionStats{}
+	for _, kind := range s.kinds {
+		stats, err := s.load.GetSubscriptionStats(kind)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		current.Notifications += stats.Notifications
+		current.Dropped += stats.Dropped
+		current.Duplicated += stats.Duplicated
+		current.Measured += stats.Measured
+		current.Latency += stats.Latency
+	}
+	res := s.summarize(s.last, current)
+	s.last = current
+	return res, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package appmon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/load/app"
+	"go.uber.org/mock/gomock"
+)
+
+// subscriptionApplication is an application subscribing to events.
+type subscriptionApplication struct {
+	*driver.MockApplication
+	*driver.MockSubscriptionLoad
+}
+
+func TestSubscriptionSensors_ReportNotificationStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	load := driver.NewMockSubscriptionLoad(ctrl)
+	application := subscriptionApplication{driver.NewMockApplication(ctrl), load}
+
+	load.EXPECT().GetSubscriptionKinds().Return([]string{"newHeads"}).AnyTimes()
+	gomock.InOrder(
+		load.EXPECT().GetSubscriptionStats("newHeads").Return(app.SubscriptionStats{Notifications: 2, Measured: 2, Latency: 4 * time.Millisecond, Dropped: 1}, nil),
+		load.EXPECT().GetSubscriptionStats("newHeads").Return(app.SubscriptionStats{Notifications: 3, Measured: 3, Latency: 10 * time.Millisecond, Dropped: 1, Duplicated: 1}, nil),
+	)
+
+	latency := &subscriptionSensorFactory[time.Duration]{
+		summarize: func(last, current app.SubscriptionStats) time.Duration {
+			return (current.Latency - last.Latency) / time.Duration(current.Measured-last.Measured)
+		},
+	}
+	sensor, err := latency.CreateSensor(application)
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	for _, want := range []time.Duration{2 * time.Millisecond, 6 * time.Millisecond} {
+		if got, err := sensor.ReadValue(); err != nil || got != want {
+			t.Errorf("unexpected latency, wanted %v, got %v, err %v", want, got, err)
+		}
+	}
+}
+
+func TestSubscriptionSensors_AreNotCreatedForOtherApplications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := &subscriptionSensorFactory[int]{}
+	sensor, err := factory.CreateSensor(driver.NewMockApplication(ctrl))
+	if err != nil || sensor != nil {
+		t.Errorf("no sensor should be created for applications not subscribing, got %v, err %v", sensor, err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package appmon
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+)
+
+// SustainableRate is a metric capturing the transaction rate in Tx/s a feedback-controlled
+// application converged to while holding its target inclusion latency or transaction pool size.
+// Each value is the average rate over the most recent control periods.
+var SustainableRate = monitoring.Metric[monitoring.App, monitoring.Series[monitoring.Time, float64]]{
+	Name:        "SustainableRate",
+	Description: "The rate sustainable by the network at the target of a feedback-controlled application",
+}
+
+func init() {
+	if err := monitoring.RegisterSource(SustainableRate, newSustainableRateSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newSustainableRateSource is an internal factory for the SustainableRate metric.
+func newSustainableRateSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.App, monitoring.Series[monitoring.Time, float64]] {
+	return NewPeriodicAppDataSource[float64](SustainableRate, monitor, &sustainableRateSensorFactory{})
+}
+
+type sustainableRateSensorFactory struct{}
+
+func (f *sustainableRateSensorFactory) CreateSensor(application driver.Application) (utils.Sensor[float64], error) {
+	controller, ok := application.(driver.RateController)
+	if !ok {
+		return nil, nil // not applicable to applications without controlled rate
+	}
+	if _, ok := controller.GetSustainableRate(); !ok {
+		return nil, nil // not applicable to applications with fixed load
+	}
+	return &sustainableRateSensor{controller: controller}, nil
+}
+
+type sustainableRateSensor struct {
+	controller driver.RateController
+}
+
+func (s *sustainableRateSensor) ReadValue() (float64, error) {
+	rate, _ := s.controller.GetSustainableRate()
+	return rate, nil
+}
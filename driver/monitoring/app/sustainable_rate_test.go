@@ -0,0 +1,55 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package appmon
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSustainableRateSensor_ReportsRateOfApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	controller := driver.NewMockRateController(ctrl)
+	application := struct {
+		*driver.MockApplication
+		*driver.MockRateController
+	}{driver.NewMockApplication(ctrl), controller}
+	controller.EXPECT().GetSustainableRate().Return(42.5, true).Times(2)
+
+	sensor, err := (&sustainableRateSensorFactory{}).CreateSensor(application)
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	if got, err := sensor.ReadValue(); err != nil || got != 42.5 {
+		t.Errorf("unexpected rate, wanted 42.5, got %f, err %v", got, err)
+	}
+}
+
+func TestSustainableRateSensor_IsNotCreatedForFixedLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	controller := driver.NewMockRateController(ctrl)
+	application := struct {
+		*driver.MockApplication
+		*driver.MockRateController
+	}{driver.NewMockApplication(ctrl), controller}
+	controller.EXPECT().GetSustainableRate().Return(0.0, false)
+
+	if sensor, err := (&sustainableRateSensorFactory{}).CreateSensor(application); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor %v, err %v", sensor, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package nodemon
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/docker"
+	mon "github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+)
+
+// NodeContainerStats collects per-node time series of the resources consumed
+// by the containers of nodes, as reported by Docker. The CPU utilization in
+// percent of a single CPU, the resident set size in bytes, and the cumulative
+// number of bytes read and written from disk and received and sent over the
+// network are reported for "<node>/cpu", "<node>/rss", "<node>/block_read",
+// "<node>/block_write", "<node>/network_rx", and "<node>/network_tx".
+var NodeContainerStats = mon.Metric[mon.Node, mon.Series[mon.Time, float64]]{
+	Name:        "NodeContainerStats",
+	Description: "The resources consumed by the containers of nodes.",
+}
+
+func init() {
+	if err := mon.RegisterSource(NodeContainerStats, newNodeContainerStatsSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newNodeContainerStatsSource is an internal factory for the NodeContainerStats metric.
+func newNodeContainerStatsSource(monitor *mon.Monitor) mon.Source[mon.Node, mon.Series[mon.Time, float64]] {
+	return NewPeriodicNodeDataSource[float64](NodeContainerStats, monitor, containerStatsSensorFactory{})
+}
+
+// containerStatsReporter is implemented by nodes running in Docker containers.
+type containerStatsReporter interface {
+	GetContainerStats() (docker.ContainerStats, error)
+}
+
+// containerStatsSensorFactory creates sensors reporting the resource usage of
+// the containers of nodes.
+type containerStatsSensorFactory struct{}
+
+func (containerStatsSensorFactory) CreateSensor(driver.Node) (utils.Sensor[float64], error) {
+	return nil, nil // usage is only reported per resource
+}
+
+func (containerStatsSensorFactory) CreatePartSensors(node driver.Node) (map[string]utils.Sensor[float64], error) {
+	reporter, ok := node.(containerStatsReporter)
+	if !ok {
+		return nil, nil // not applicable to nodes not running in containers
+	}
+	// the CPU utilization is measured relative to the stats at creation time
+	initial, err := reporter.GetContainerStats()
+	if err != nil {
+		return nil, err
+	}
+	// all sensors of a node share the stats read from Docker within a period
+	stats := &containerStatsCache{reporter: reporter, maxAge: 500 * time.Millisecond}
+	return map[string]utils.Sensor[float64]{
+		"cpu":         &cpuUsageSensor{stats: stats, previous: initial},
+		"rss":         containerStatsSensor{stats, func(s docker.ContainerStats) uint64 { return s.MemoryRss }},
+		"block_read":  containerStatsSensor{stats, func(s docker.ContainerStats) uint64 { return s.BlockRead }},
+		"block_write": containerStatsSensor{stats, func(s docker.ContainerStats) uint64 { return s.BlockWrite }},
+		"network_rx":  containerStatsSensor{stats, func(s docker.ContainerStats) uint64 { return s.NetworkRx }},
+		"network_tx":  containerStatsSensor{stats, func(s docker.ContainerStats) uint64 { return s.NetworkTx }},
+	}, nil
+}
+
+// containerStatsCache reads the stats of a container at most once within the
+// given maximum age, to serve all sensors of a node with a single request.
+type containerStatsCache struct {
+	reporter containerStatsReporter
+	maxAge   time.Duration
+	mutex    sync.Mutex
+	last     docker.ContainerStats
+	read     time.Time
+}
+
+func (c *containerStatsCache) get() (docker.ContainerStats, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.read.IsZero() && time.Since(c.read) < c.maxAge {
+		return c.last, nil
+	}
+	stats, err := c.reporter.GetContainerStats()
+	if err != nil {
+		return docker.ContainerStats{}, err
+	}
+	c.last, c.read = stats, time.Now()
+	return stats, nil
+}
+
+// containerStatsSensor reports a single value of the stats of a container.
+type containerStatsSensor struct {
+	stats *containerStatsCache
+	value func(docker.ContainerStats) uint64
+}
+
+func (s containerStatsSensor) ReadValue() (float64, error) {
+	stats, err := s.stats.get()
+	if err != nil {
+		return 0, err
+	}
+	return float64(s.value(stats)), nil
+}
+
+// cpuUsageSensor reports the CPU utilization of a container since the
+// previous reading.
+type cpuUsageSensor struct {
+	stats    *containerStatsCache
+	previous docker.ContainerStats
+}
+
+func (s *cpuUsageSensor) ReadValue() (float64, error) {
+	stats, err := s.stats.get()
+	if err != nil {
+		return 0, err
+	}
+	res := stats.GetCpuPercentage(s.previous)
+	s.previous = stats
+	return res, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package nodemon
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/docker"
+	opera "github.com/0xsoniclabs/hyperion/driver/node"
+	"go.uber.org/mock/gomock"
+)
+
+func TestContainerStatsReporter_IsImplementedByOperaNodes(t *testing.T) {
+	var inst opera.OperaNode
+	var _ containerStatsReporter = &inst
+}
+
+// containerNode is a node reporting a fixed sequence of container stats.
+type containerNode struct {
+	*driver.MockNode
+	stats []docker.ContainerStats
+	reads int
+}
+
+func (n *containerNode) GetContainerStats() (docker.ContainerStats, error) {
+	if n.reads >= len(n.stats) {
+		return docker.ContainerStats{}, fmt.Errorf("no more stats")
+	}
+	n.reads++
+	return n.stats[n.reads-1], nil
+}
+
+func TestContainerStatsSensors_ReportUsageOfContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := &containerNode{
+		MockNode: driver.NewMockNode(ctrl),
+		stats: []docker.ContainerStats{
+			{CpuUsage: 100, SystemCpuUsage: 1000, OnlineCpus: 2},
+			{CpuUsage: 600, SystemCpuUsage: 2000, OnlineCpus: 2, MemoryRss: 1, BlockRead: 2, BlockWrite: 3, NetworkRx: 4, NetworkTx: 5},
+		},
+	}
+
+	factory := containerStatsSensorFactory{}
+	if sensor, err := factory.CreateSensor(node); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor %v, err %v", sensor, err)
+	}
+	sensors, err := factory.CreatePartSensors(node)
+	if err != nil {
+		t.Fatalf("failed to create part sensors: %v", err)
+	}
+
+	// all sensors are served by a single reading of the stats
+	want := map[string]float64{"cpu": 100, "rss": 1, "block_read": 2, "block_write": 3, "network_rx": 4, "network_tx": 5}
+	if len(sensors) != len(want) {
+		t.Errorf("unexpected parts, wanted %v, got %v", want, sensors)
+	}
+	for part, value := range want {
+		if got, err := sensors[part].ReadValue(); err != nil || got != value {
+			t.Errorf("unexpected value of %s, wanted %f, got %f, err %v", part, value, got, err)
+		}
+	}
+	if node.reads != 2 {
+		t.Errorf("unexpected number of stats readings, wanted 2, got %d", node.reads)
+	}
+}
+
+func TestContainerStatsSensors_AreNotCreatedForNodesWithoutContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := driver.NewMockNode(ctrl)
+
+	if sensors, err := (containerStatsSensorFactory{}).CreatePartSensors(node); err != nil || sensors != nil {
+		t.Errorf("unexpected sensors %v, err %v", sensors, err)
+	}
+}
+
+func TestContainerStatsSensors_FailIfStatsAreUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := &containerNode{MockNode: driver.NewMockNode(ctrl)}
+
+	if _, err := (containerStatsSensorFactory{}).CreatePartSensors(node); err == nil {
+		t.Errorf("expected an error if stats are unavailable")
+	}
+}
@@ -27,16 +27,26 @@ import (
 	mon "github.com/0xsoniclabs/hyperion/driver/monitoring"
 )
 
-// SensorFactory is a factory for sensors targeting selected nodes.
+// SensorFactory is a factory for sensors targeting selected nodes. Factories
+// may return a nil sensor if the metric is not applicable to a node, in which
+// case the node is not tracked.
 type SensorFactory[T any] interface {
 	CreateSensor(driver.Node) (utils.Sensor[T], error)
 }
 
+// PartSensorFactory is an optional extension of SensorFactory creating sensors
+// for individual parts of the data of a node, e.g. categories of a counter.
+// Data of a part is reported under the subject "<node>/<part>".
+type PartSensorFactory[T any] interface {
+	CreatePartSensors(driver.Node) (map[string]utils.Sensor[T], error)
+}
+
 // periodicNodeDataSource is a generic data source periodically querying
 // node-associated sensors for data.
 type periodicNodeDataSource[T any] struct {
 	*utils.PeriodicDataSource[monitoring.Node, T]
 	factory SensorFactory[T]
+	parts   map[string][]string // < parts tracked per node label
 }
 
 // NewPeriodicNodeDataSource creates a new data source managing per-node sensor
@@ -60,6 +70,7 @@ func newPeriodicNodeDataSource[T any](
 	res := &periodicNodeDataSource[T]{
 		PeriodicDataSource: utils.NewPeriodicDataSourceWithPeriod(metric, monitor, period),
 		factory:            factory,
+		parts:              map[string][]string{},
 	}
 
 	monitor.Network().RegisterListener(res)
@@ -75,13 +86,53 @@ func (s *periodicNodeDataSource[T]) AfterNodeCreation(node driver.Node) {
 	sensor, err := s.factory.CreateSensor(node)
 	if err != nil {
 		log.Printf("failed to create sensor for metric %v / node %s: %v", s.GetMetric().Name, label, err)
+		return
+	}
+	if sensor != nil {
+		s.AddSubject(mon.Node(label), sensor)
+	}
+
+	factory, ok := s.factory.(PartSensorFactory[T])
+	if !ok {
+		return
+	}
+	sensors, err := factory.CreatePartSensors(node)
+	if err != nil {
+		log.Printf("failed to create part sensors for metric %v / node %s: %v", s.GetMetric().Name, label, err)
+	}
+	for part, sensor := range sensors {
+		s.AddSubject(mon.Node(label+"/"+part), sensor)
+		s.parts[label] = append(s.parts[label], part)
 	}
-	s.AddSubject(mon.Node(label), sensor)
 }
 
 func (s *periodicNodeDataSource[T]) AfterNodeRemoval(node driver.Node) {
 	label := node.GetLabel()
 	s.RemoveSubject(mon.Node(label))
+	for _, part := range s.parts[label] {
+		s.RemoveSubject(mon.Node(label + "/" + part))
+	}
+	delete(s.parts, label)
+}
+
+// BeforeNodeDown suspends the collection of data of a node while it is
+// temporarily unavailable, e.g. while being restarted, to keep the series of
+// the node free of gaps caused by failing sensors.
+func (s *periodicNodeDataSource[T]) BeforeNodeDown(node driver.Node) {
+	label := node.GetLabel()
+	s.SuspendSubject(mon.Node(label))
+	for _, part := range s.parts[label] {
+		s.SuspendSubject(mon.Node(label + "/" + part))
+	}
+}
+
+// AfterNodeUp continues the collection of data of a node in its series.
+func (s *periodicNodeDataSource[T]) AfterNodeUp(node driver.Node) {
+	label := node.GetLabel()
+	s.ResumeSubject(mon.Node(label))
+	for _, part := range s.parts[label] {
+		s.ResumeSubject(mon.Node(label + "/" + part))
+	}
 }
 
 func (s *periodicNodeDataSource[T]) AfterApplicationCreation(driver.Application) {
@@ -17,10 +17,12 @@
 package nodemon
 
 import (
+	"fmt"
 	"io"
 	"math"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -141,3 +143,120 @@ func TestNodeSourceRetrievesSensorData(t *testing.T) {
 		}
 	}
 }
+
+type testPartSensorFactory struct {
+	testSensorFactory
+}
+
+func (f *testPartSensorFactory) CreatePartSensors(driver.Node) (map[string]utils.Sensor[int], error) {
+	return map[string]utils.Sensor[int]{"x": &testSensor{}, "y": &testSensor{}}, nil
+}
+
+func TestNodeSourceTracksPartsOfNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	node := driver.NewMockNode(ctrl)
+	node.EXPECT().GetLabel().AnyTimes().Return("A")
+	url := driver.URL("node")
+	node.EXPECT().GetServiceUrl(gomock.Any()).AnyTimes().Return(&url)
+
+	net.EXPECT().RegisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().UnregisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().GetActiveNodes().Return([]driver.Node{node}).AnyTimes()
+	node.EXPECT().StreamLog().AnyTimes().Return(io.NopCloser(strings.NewReader("")), nil)
+
+	monitor, err := mon.NewMonitor(net, mon.MonitorConfig{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to start monitor instance: %v", err)
+	}
+	source := newPeriodicNodeDataSource[int](testNodeMetric, monitor, 50*time.Millisecond, &testPartSensorFactory{})
+
+	subjects := source.GetSubjects()
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i] < subjects[j] })
+	want := []mon.Node{"A", "A/x", "A/y"}
+	if !slices.Equal(subjects, want) {
+		t.Errorf("invalid list of subjects, wanted %v, got %v", want, subjects)
+	}
+
+	source.(driver.NetworkListener).AfterNodeRemoval(node)
+	if err := source.Shutdown(); err != nil {
+		t.Errorf("erros encountered during shutdown: %v", err)
+	}
+}
+
+// unavailableSensor fails to read values while its node is down.
+type unavailableSensor struct {
+	testSensor
+	down *atomic.Bool
+}
+
+func (s *unavailableSensor) ReadValue() (int, error) {
+	if s.down.Load() {
+		return 0, fmt.Errorf("node is down")
+	}
+	return s.testSensor.ReadValue()
+}
+
+type unavailableSensorFactory struct {
+	down *atomic.Bool
+}
+
+func (f *unavailableSensorFactory) CreateSensor(driver.Node) (utils.Sensor[int], error) {
+	return &unavailableSensor{down: f.down}, nil
+}
+
+func (f *unavailableSensorFactory) CreatePartSensors(driver.Node) (map[string]utils.Sensor[int], error) {
+	return map[string]utils.Sensor[int]{"x": &unavailableSensor{down: f.down}}, nil
+}
+
+func TestNodeSourceSuspendsNodesWhileDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	node := driver.NewMockNode(ctrl)
+	node.EXPECT().GetLabel().AnyTimes().Return("A")
+	url := driver.URL("node")
+	node.EXPECT().GetServiceUrl(gomock.Any()).AnyTimes().Return(&url)
+
+	net.EXPECT().RegisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().UnregisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().GetActiveNodes().Return([]driver.Node{node}).AnyTimes()
+	node.EXPECT().StreamLog().AnyTimes().Return(io.NopCloser(strings.NewReader("")), nil)
+
+	monitor, err := mon.NewMonitor(net, mon.MonitorConfig{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to start monitor instance: %v", err)
+	}
+	down := &atomic.Bool{}
+	source := newPeriodicNodeDataSource[int](testNodeMetric, monitor, 10*time.Millisecond, &unavailableSensorFactory{down})
+	listener, ok := source.(driver.NodeStateListener)
+	if !ok {
+		t.Fatalf("node source should be interested in node states")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	listener.BeforeNodeDown(node)
+	down.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	down.Store(false)
+	listener.AfterNodeUp(node)
+	time.Sleep(50 * time.Millisecond)
+
+	// errors of sensors of nodes being down are not reported
+	if err := source.Shutdown(); err != nil {
+		t.Errorf("erros encountered during shutdown: %v", err)
+	}
+
+	// data of the node continues in the same series
+	for _, subject := range []mon.Node{"A", "A/x"} {
+		data, exists := source.GetData(subject)
+		if data == nil || !exists {
+			t.Fatalf("no data found for subject %s", subject)
+		}
+		subrange := data.GetRange(mon.Time(0), mon.Time(math.MaxInt64))
+		for i, point := range subrange {
+			if got, want := point.Value, i+1; got != want {
+				t.Errorf("unexpected value collected for subject %s: wanted %d, got %d", subject, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package nodemon
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	mon "github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+)
+
+// NodeTransactionSendErrors collects a per-node time series of the number of transactions
+// rejected by the node when being sent to it. The value is cumulative. Counts of individual
+// classes of errors (nonce, underpriced, pool_full, connection, other) are reported for
+// "<node>/<class>".
+var NodeTransactionSendErrors = mon.Metric[mon.Node, mon.Series[mon.Time, int]]{
+	Name:        "NodeTransactionSendErrors",
+	Description: "The number of transactions rejected by nodes when being sent to them.",
+}
+
+func init() {
+	if err := mon.RegisterSource(NodeTransactionSendErrors, newNodeTransactionSendErrorsSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newNodeTransactionSendErrorsSource is an internal factory for the NodeTransactionSendErrors metric.
+func newNodeTransactionSendErrorsSource(monitor *mon.Monitor) mon.Source[mon.Node, mon.Series[mon.Time, int]] {
+	return NewPeriodicNodeDataSource[int](NodeTransactionSendErrors, monitor, &sendErrorSensorFactory{
+		network: monitor.Network(),
+	})
+}
+
+// sendErrorSensorFactory creates sensors reporting the total and per-class
+// send errors of nodes, as tracked by the network.
+type sendErrorSensorFactory struct {
+	network driver.Network
+}
+
+func (f *sendErrorSensorFactory) CreateSensor(node driver.Node) (utils.Sensor[int], error) {
+	tracker, ok := f.network.(driver.NodeSendErrorTracker)
+	if !ok {
+		return nil, nil // not applicable to networks not tracking errors
+	}
+	return &sendErrorSensor{tracker: tracker, node: node.GetLabel(), count: rpc.SendErrors.Total}, nil
+}
+
+func (f *sendErrorSensorFactory) CreatePartSensors(node driver.Node) (map[string]utils.Sensor[int], error) {
+	tracker, ok := f.network.(driver.NodeSendErrorTracker)
+	if !ok {
+		return nil, nil
+	}
+	res := map[string]utils.Sensor[int]{}
+	for _, class := range rpc.SendErrorClasses {
+		class := class
+		res[class.String()] = &sendErrorSensor{
+			tracker: tracker,
+			node:    node.GetLabel(),
+			count:   func(errors rpc.SendErrors) uint64 { return errors[class] },
+		}
+	}
+	return res, nil
+}
+
+type sendErrorSensor struct {
+	tracker driver.NodeSendErrorTracker
+	node    string
+	count   func(rpc.SendErrors) uint64
+}
+
+func (s *sendErrorSensor) ReadValue() (int, error) {
+	return int(s.count(s.tracker.GetSendErrors()[s.node])), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package nodemon
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"go.uber.org/mock/gomock"
+)
+
+// trackingNetwork is a network tracking send errors per node.
+type trackingNetwork struct {
+	*driver.MockNetwork
+	*driver.MockNodeSendErrorTracker
+}
+
+func TestSendErrorSensors_ReportCountsOfNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracker := driver.NewMockNodeSendErrorTracker(ctrl)
+	network := trackingNetwork{driver.NewMockNetwork(ctrl), tracker}
+	node := driver.NewMockNode(ctrl)
+	node.EXPECT().GetLabel().Return("A").AnyTimes()
+
+	errorsOfA := rpc.SendErrors{}
+	errorsOfA[rpc.UnderpricedError] = 4
+	errorsOfB := rpc.SendErrors{}
+	errorsOfB[rpc.ConnectionError] = 7
+	tracker.EXPECT().GetSendErrors().Return(map[string]rpc.SendErrors{"A": errorsOfA, "B": errorsOfB}).AnyTimes()
+
+	factory := &sendErrorSensorFactory{network: network}
+	sensor, err := factory.CreateSensor(node)
+	if err != nil || sensor == nil {
+		t.Fatalf("failed to create sensor: %v", err)
+	}
+	if got, err := sensor.ReadValue(); err != nil || got != 4 {
+		t.Errorf("unexpected total, wanted 4, got %d, err %v", got, err)
+	}
+
+	sensors, err := factory.CreatePartSensors(node)
+	if err != nil {
+		t.Fatalf("failed to create part sensors: %v", err)
+	}
+	if got, err := sensors["underpriced"].ReadValue(); err != nil || got != 4 {
+		t.Errorf("unexpected underpriced count, wanted 4, got %d, err %v", got, err)
+	}
+	if got, err := sensors["connection"].ReadValue(); err != nil || got != 0 {
+		t.Errorf("unexpected connection count, wanted 0, got %d, err %v", got, err)
+	}
+}
+
+func TestSendErrorSensors_AreNotCreatedForNetworksWithoutTracking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := &sendErrorSensorFactory{network: driver.NewMockNetwork(ctrl)}
+	node := driver.NewMockNode(ctrl)
+
+	if sensor, err := factory.CreateSensor(node); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor %v, err %v", sensor, err)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package nodemon
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	mon "github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+)
+
+var (
+	// NodeSyncTime records for each node joining the network after genesis
+	// the time from its creation until its block height first matched the
+	// head of the network. A single point is recorded per node, at the time
+	// the node caught up.
+	NodeSyncTime = mon.Metric[mon.Node, mon.Series[mon.Time, time.Duration]]{
+		Name:        "NodeSyncTime",
+		Description: "The time late-joining nodes took to catch up with the head of the network.",
+	}
+
+	// NodeSyncRate records for each node joining the network after genesis
+	// the number of blocks per second it processed while catching up with the
+	// head of the network, counted from the block height the node started
+	// from. A single point is recorded per node, at the time the node caught
+	// up.
+	NodeSyncRate = mon.Metric[mon.Node, mon.Series[mon.Time, float64]]{
+		Name:        "NodeSyncRate",
+		Description: "The blocks per second processed by late-joining nodes while catching up with the network.",
+	}
+)
+
+func init() {
+	if err := mon.RegisterSource(NodeSyncTime, newNodeSyncTimeSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+	if err := mon.RegisterSource(NodeSyncRate, newNodeSyncRateSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// maxSyncLag is the number of blocks a node may lag behind the head of the
+// network to be considered in sync, tolerating the offsets between the
+// sampling of the block heights of individual nodes.
+const maxSyncLag = 1
+
+// newNodeSyncTimeSource is an internal factory for the NodeSyncTime metric.
+func newNodeSyncTimeSource(monitor *mon.Monitor) mon.Source[mon.Node, mon.Series[mon.Time, time.Duration]] {
+	tracker := acquireSyncTracker(monitor)
+	return newSyncSource(NodeSyncTime, tracker, func() { releaseSyncTracker(monitor) },
+		func(s syncResult) time.Duration { return s.duration },
+	)
+}
+
+// newNodeSyncRateSource is an internal factory for the NodeSyncRate metric.
+func newNodeSyncRateSource(monitor *mon.Monitor) mon.Source[mon.Node, mon.Series[mon.Time, float64]] {
+	tracker := acquireSyncTracker(monitor)
+	return newSyncSource(NodeSyncRate, tracker, func() { releaseSyncTracker(monitor) },
+		func(s syncResult) float64 { return s.getRate() },
+	)
+}
+
+// syncTrackers holds the sync tracker shared by the sources of all sync
+// metrics of a monitor, such that both metrics describe the same sync.
+var syncTrackers = struct {
+	mutex     sync.Mutex
+	byMonitor map[*mon.Monitor]*sharedSyncTracker
+}{byMonitor: map[*mon.Monitor]*sharedSyncTracker{}}
+
+// sharedSyncTracker counts the sources using a sync tracker.
+type sharedSyncTracker struct {
+	tracker *syncTracker
+	users   int
+}
+
+// acquireSyncTracker returns the sync tracker of the given monitor, creating
+// it on first use. Each call must be matched by a call to releaseSyncTracker.
+func acquireSyncTracker(monitor *mon.Monitor) *syncTracker {
+	syncTrackers.mutex.Lock()
+	defer syncTrackers.mutex.Unlock()
+	shared, found := syncTrackers.byMonitor[monitor]
+	if !found {
+		shared = &sharedSyncTracker{
+			tracker: newSyncTracker(monitor.Network(), &blockStatusData{monitor}, time.Second),
+		}
+		syncTrackers.byMonitor[monitor] = shared
+	}
+	shared.users++
+	return shared.tracker
+}
+
+// releaseSyncTracker stops the sync tracker of the given monitor once it is
+// no longer used by any source.
+func releaseSyncTracker(monitor *mon.Monitor) {
+	syncTrackers.mutex.Lock()
+	defer syncTrackers.mutex.Unlock()
+	shared, found := syncTrackers.byMonitor[monitor]
+	if !found {
+		return
+	}
+	shared.users--
+	if shared.users == 0 {
+		delete(syncTrackers.byMonitor, monitor)
+		shared.tracker.shutdown()
+	}
+}
+
+// blockHeightData provides the block heights of nodes collected over time.
+type blockHeightData interface {
+	GetNodes() []mon.Node
+	GetData(mon.Node) mon.Series[mon.Time, mon.BlockStatus]
+}
+
+// blockStatusData provides the block heights collected by the NodeBlockStatus
+// source of a monitor.
+type blockStatusData struct {
+	monitor *mon.Monitor
+}
+
+func (d *blockStatusData) GetNodes() []mon.Node {
+	return mon.GetSubjects(d.monitor, NodeBlockStatus)
+}
+
+func (d *blockStatusData) GetData(node mon.Node) mon.Series[mon.Time, mon.BlockStatus] {
+	data, _ := mon.GetData(d.monitor, node, NodeBlockStatus)
+	return data
+}
+
+// syncResult summarizes the synchronization of a node with the network.
+type syncResult struct {
+	duration time.Duration // < from the creation of the node until it was in sync
+	blocks   uint64        // < number of blocks processed since the start of the node
+}
+
+// getRate returns the number of blocks processed per second.
+func (r syncResult) getRate() float64 {
+	if r.duration <= 0 {
+		return 0
+	}
+	return float64(r.blocks) / r.duration.Seconds()
+}
+
+// syncEvent records that a node caught up with the head of the network.
+type syncEvent struct {
+	node     mon.Node
+	position mon.Time
+	result   syncResult
+}
+
+// syncObserver is notified about nodes catching up with the network.
+type syncObserver interface {
+	onSync(syncEvent)
+}
+
+// syncTracker tracks the synchronization of nodes joining the network after
+// the creation of the tracker and reports to its observers once a node caught
+// up with the head of the network, which is the highest block of all nodes in
+// sync. Nodes present at the creation of the tracker are considered genesis
+// nodes and are not tracked.
+type syncTracker struct {
+	network   driver.Network
+	data      blockHeightData
+	pending   map[mon.Node]pendingSync // < nodes catching up
+	events    []syncEvent              // < all syncs so far, replayed to new observers
+	observers []syncObserver
+	mutex     sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// pendingSync describes a node catching up with the network.
+type pendingSync struct {
+	created     time.Time // < creation time of the node
+	startHeight uint64    // < block height the node started from
+}
+
+func newSyncTracker(network driver.Network, data blockHeightData, period time.Duration) *syncTracker {
+	res := &syncTracker{
+		network: network,
+		data:    data,
+		pending: map[mon.Node]pendingSync{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	network.RegisterListener(res)
+
+	go func() {
+		defer close(res.done)
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				res.update()
+			case <-res.stop:
+				return
+			}
+		}
+	}()
+	return res
+}
+
+// subscribe registers the given observer, which is notified about all syncs
+// recorded so far and all future syncs.
+func (t *syncTracker) subscribe(observer syncObserver) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.observers = append(t.observers, observer)
+	for _, event := range t.events {
+		observer.onSync(event)
+	}
+}
+
+// unsubscribe stops notifying the given observer.
+func (t *syncTracker) unsubscribe(observer syncObserver) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.observers = slices.DeleteFunc(t.observers, func(other syncObserver) bool {
+		return other == observer
+	})
+}
+
+func (t *syncTracker) AfterNodeCreation(node driver.Node) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.pending[mon.Node(node.GetLabel())] = pendingSync{
+		created:     time.Now(),
+		startHeight: node.GetStartBlockHeight(),
+	}
+}
+
+func (t *syncTracker) AfterNodeRemoval(node driver.Node) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.pending, mon.Node(node.GetLabel()))
+}
+
+func (t *syncTracker) AfterApplicationCreation(driver.Application) {
+	// ignored
+}
+
+// update records the synchronization of all pending nodes which caught up
+// with the head of the network.
+func (t *syncTracker) update() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.pending) == 0 {
+		return
+	}
+
+	head := uint64(0)
+	for _, node := range t.data.GetNodes() {
+		if _, syncing := t.pending[node]; syncing {
+			continue
+		}
+		if latest := t.getLatest(node); latest != nil {
+			head = max(head, latest.Value.BlockHeight)
+		}
+	}
+	if head == 0 {
+		return // < no block height of nodes in sync known yet
+	}
+
+	for node, state := range t.pending {
+		series := t.data.GetData(node)
+		if series == nil {
+			continue
+		}
+		latest := series.GetLatest()
+		if latest == nil || latest.Value.BlockHeight+maxSyncLag < head {
+			continue
+		}
+		// only samples taken after the creation of the node are considered,
+		// to ignore samples of former nodes of the same name
+		points := series.GetRange(mon.NewTime(state.created), latest.Position+1)
+		if len(points) == 0 {
+			continue
+		}
+		last := points[len(points)-1]
+		result := syncResult{duration: last.Position.Time().Sub(state.created)}
+		if last.Value.BlockHeight > state.startHeight {
+			result.blocks = last.Value.BlockHeight - state.startHeight
+		}
+		event := syncEvent{node: node, position: last.Position, result: result}
+		t.events = append(t.events, event)
+		for _, observer := range t.observers {
+			observer.onSync(event)
+		}
+		delete(t.pending, node)
+	}
+}
+
+func (t *syncTracker) getLatest(node mon.Node) *mon.DataPoint[mon.Time, mon.BlockStatus] {
+	series := t.data.GetData(node)
+	if series == nil {
+		return nil
+	}
+	return series.GetLatest()
+}
+
+func (t *syncTracker) shutdown() {
+	t.network.UnregisterListener(t)
+	close(t.stop)
+	<-t.done
+}
+
+// syncSource records a property of the synchronization of nodes reported by
+// a sync tracker.
+type syncSource[T any] struct {
+	*utils.SyncedSeriesSource[mon.Node, mon.Time, T]
+	tracker *syncTracker
+	value   func(syncResult) T
+	release func() // < called on shutdown to release the tracker
+}
+
+func newSyncSource[T any](
+	metric mon.Metric[mon.Node, mon.Series[mon.Time, T]],
+	tracker *syncTracker,
+	release func(),
+	value func(syncResult) T,
+) *syncSource[T] {
+	res := &syncSource[T]{
+		SyncedSeriesSource: utils.NewSyncedSeriesSource(metric),
+		tracker:            tracker,
+		value:              value,
+		release:            release,
+	}
+	tracker.subscribe(res)
+	return res
+}
+
+func (s *syncSource[T]) onSync(event syncEvent) {
+	if err := s.GetOrAddSubject(event.node).Append(event.position, s.value(event.result)); err != nil {
+		log.Printf("cannot add to series: %s", err)
+	}
+}
+
+func (s *syncSource[T]) Shutdown() error {
+	s.tracker.unsubscribe(s)
+	s.release()
+	return s.SyncedSeriesSource.Shutdown()
+}
@@ -0,0 +1,257 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package nodemon
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	mon "github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeBlockHeights provides block heights of nodes recorded by tests.
+type fakeBlockHeights map[mon.Node]*mon.SyncedSeries[mon.Time, mon.BlockStatus]
+
+func (f fakeBlockHeights) GetNodes() []mon.Node {
+	res := []mon.Node{}
+	for node := range f {
+		res = append(res, node)
+	}
+	return res
+}
+
+func (f fakeBlockHeights) GetData(node mon.Node) mon.Series[mon.Time, mon.BlockStatus] {
+	if series, found := f[node]; found {
+		return series
+	}
+	return nil
+}
+
+func (f fakeBlockHeights) add(t *testing.T, node mon.Node, time time.Time, height uint64) {
+	t.Helper()
+	series, found := f[node]
+	if !found {
+		series = &mon.SyncedSeries[mon.Time, mon.BlockStatus]{}
+		f[node] = series
+	}
+	if err := series.Append(mon.NewTime(time), mon.BlockStatus{BlockHeight: height}); err != nil {
+		t.Fatalf("failed to add block height: %v", err)
+	}
+}
+
+func newTestSyncTracker(t *testing.T, data blockHeightData) *syncTracker {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	net.EXPECT().RegisterListener(gomock.Any())
+	net.EXPECT().UnregisterListener(gomock.Any())
+	tracker := newSyncTracker(net, data, time.Hour)
+	t.Cleanup(tracker.shutdown)
+	return tracker
+}
+
+func newTestSyncSource[T any](t *testing.T, metric mon.Metric[mon.Node, mon.Series[mon.Time, T]], tracker *syncTracker, value func(syncResult) T) *syncSource[T] {
+	source := newSyncSource(metric, tracker, func() {}, value)
+	t.Cleanup(func() { _ = source.Shutdown() })
+	return source
+}
+
+func newLabeledNode(t *testing.T, label string) driver.Node {
+	return newNodeStartingAt(t, label, 0)
+}
+
+func newNodeStartingAt(t *testing.T, label string, height uint64) driver.Node {
+	node := driver.NewMockNode(gomock.NewController(t))
+	node.EXPECT().GetLabel().Return(label).AnyTimes()
+	node.EXPECT().GetStartBlockHeight().Return(height).AnyTimes()
+	return node
+}
+
+func TestSyncSource_RecordsSyncOfLateJoiningNodes(t *testing.T) {
+	data := fakeBlockHeights{}
+	tracker := newTestSyncTracker(t, data)
+	source := newTestSyncSource(t, NodeSyncTime, tracker, func(s syncResult) time.Duration { return s.duration })
+	rates := newTestSyncSource(t, NodeSyncRate, tracker, func(s syncResult) float64 { return s.getRate() })
+
+	start := time.Now()
+	data.add(t, "validator-0", start.Add(-time.Second), 100)
+	tracker.AfterNodeCreation(newLabeledNode(t, "A"))
+
+	// the node is catching up
+	data.add(t, "A", start.Add(1*time.Second), 10)
+	data.add(t, "validator-0", start.Add(1*time.Second), 110)
+	tracker.update()
+	if subjects := source.GetSubjects(); len(subjects) != 0 {
+		t.Errorf("unexpected subjects before node caught up: %v", subjects)
+	}
+
+	// the node is in sync
+	data.add(t, "A", start.Add(3*time.Second), 130)
+	data.add(t, "validator-0", start.Add(3*time.Second), 130)
+	tracker.update()
+
+	series, found := source.GetData("A")
+	if !found {
+		t.Fatalf("no sync time recorded")
+	}
+	latest := series.GetLatest()
+	if latest == nil || latest.Position != mon.NewTime(start.Add(3*time.Second)) {
+		t.Fatalf("unexpected sync time record: %v", latest)
+	}
+	if got := latest.Value; got < 2900*time.Millisecond || got > 3*time.Second {
+		t.Errorf("unexpected sync time, wanted ~3s, got %v", got)
+	}
+
+	rate, found := rates.GetData("A")
+	if !found || rate.GetLatest() == nil {
+		t.Fatalf("no sync rate recorded")
+	}
+	// blocks processed before the first sample are included
+	if got := rate.GetLatest().Value; got < 43 || got > 45 {
+		t.Errorf("unexpected sync rate, wanted ~43 blocks/s, got %v", got)
+	}
+
+	// the sync is only recorded once
+	data.add(t, "A", start.Add(4*time.Second), 140)
+	tracker.update()
+	if got := len(series.GetRange(0, mon.NewTime(start.Add(time.Hour)))); got != 1 {
+		t.Errorf("unexpected number of records, wanted 1, got %d", got)
+	}
+}
+
+func TestSyncSource_RateOfRestoredNodesIsCountedFromTheirStartHeight(t *testing.T) {
+	data := fakeBlockHeights{}
+	tracker := newTestSyncTracker(t, data)
+	rates := newTestSyncSource(t, NodeSyncRate, tracker, func(s syncResult) float64 { return s.getRate() })
+
+	start := time.Now()
+	tracker.AfterNodeCreation(newNodeStartingAt(t, "A", 1000))
+	data.add(t, "A", start.Add(2*time.Second), 1100)
+	data.add(t, "validator-0", start.Add(2*time.Second), 1100)
+	tracker.update()
+
+	rate, found := rates.GetData("A")
+	if !found || rate.GetLatest() == nil {
+		t.Fatalf("no sync rate recorded")
+	}
+	if got := rate.GetLatest().Value; got < 49 || got > 51 {
+		t.Errorf("unexpected sync rate, wanted ~50 blocks/s, got %v", got)
+	}
+}
+
+func TestSyncSource_ToleratesLagOfOneBlock(t *testing.T) {
+	data := fakeBlockHeights{}
+	tracker := newTestSyncTracker(t, data)
+	source := newTestSyncSource(t, NodeSyncTime, tracker, func(s syncResult) time.Duration { return s.duration })
+
+	start := time.Now()
+	tracker.AfterNodeCreation(newLabeledNode(t, "A"))
+	data.add(t, "A", start.Add(time.Second), 99)
+	data.add(t, "validator-0", start.Add(time.Second), 100)
+	tracker.update()
+	if _, found := source.GetData("A"); !found {
+		t.Errorf("node lagging by one block should be considered in sync")
+	}
+}
+
+func TestSyncSource_IgnoresGenesisAndRemovedNodes(t *testing.T) {
+	data := fakeBlockHeights{}
+	tracker := newTestSyncTracker(t, data)
+	source := newTestSyncSource(t, NodeSyncTime, tracker, func(s syncResult) time.Duration { return s.duration })
+
+	start := time.Now()
+	node := newLabeledNode(t, "A")
+	tracker.AfterNodeCreation(node)
+	tracker.AfterNodeRemoval(node)
+	data.add(t, "A", start.Add(time.Second), 100)
+	data.add(t, "validator-0", start.Add(time.Second), 100)
+	tracker.update()
+	if subjects := source.GetSubjects(); len(subjects) != 0 {
+		t.Errorf("unexpected subjects: %v", subjects)
+	}
+}
+
+func TestSyncSource_NodesCatchingUpDoNotDefineTheHead(t *testing.T) {
+	data := fakeBlockHeights{}
+	tracker := newTestSyncTracker(t, data)
+	source := newTestSyncSource(t, NodeSyncTime, tracker, func(s syncResult) time.Duration { return s.duration })
+
+	start := time.Now()
+	tracker.AfterNodeCreation(newLabeledNode(t, "A"))
+	tracker.AfterNodeCreation(newLabeledNode(t, "B"))
+	data.add(t, "A", start.Add(time.Second), 50)
+	data.add(t, "B", start.Add(time.Second), 10)
+	data.add(t, "validator-0", start.Add(time.Second), 100)
+	tracker.update()
+	if subjects := source.GetSubjects(); len(subjects) != 0 {
+		t.Errorf("unexpected subjects: %v", subjects)
+	}
+}
+
+func TestSyncSource_SourcesSubscribingLateReceivePreviousSyncs(t *testing.T) {
+	data := fakeBlockHeights{}
+	tracker := newTestSyncTracker(t, data)
+	times := newTestSyncSource(t, NodeSyncTime, tracker, func(s syncResult) time.Duration { return s.duration })
+
+	start := time.Now()
+	tracker.AfterNodeCreation(newLabeledNode(t, "A"))
+	data.add(t, "A", start.Add(time.Second), 100)
+	data.add(t, "validator-0", start.Add(time.Second), 100)
+	tracker.update()
+
+	rates := newTestSyncSource(t, NodeSyncRate, tracker, func(s syncResult) float64 { return s.getRate() })
+	for name, source := range map[string]interface{ GetSubjects() []mon.Node }{"times": times, "rates": rates} {
+		if got := source.GetSubjects(); len(got) != 1 || got[0] != "A" {
+			t.Errorf("unexpected subjects of %s: %v", name, got)
+		}
+	}
+}
+
+func TestSyncSource_SourcesOfMonitorShareTracker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	net.EXPECT().RegisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().UnregisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().GetActiveNodes().Return([]driver.Node{}).AnyTimes()
+	monitor, err := mon.NewMonitor(net, mon.MonitorConfig{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create monitor: %v", err)
+	}
+
+	times := newNodeSyncTimeSource(monitor).(*syncSource[time.Duration])
+	rates := newNodeSyncRateSource(monitor).(*syncSource[float64])
+	if times.tracker != rates.tracker {
+		t.Errorf("sources of the same monitor should share their tracker")
+	}
+	if err := errors.Join(times.Shutdown(), rates.Shutdown()); err != nil {
+		t.Fatalf("failed to shut down sources: %v", err)
+	}
+	if _, found := syncTrackers.byMonitor[monitor]; found {
+		t.Errorf("tracker should be released after all sources are shut down")
+	}
+}
+
+func TestSyncResult_GetRate(t *testing.T) {
+	if got := (syncResult{duration: 2 * time.Second, blocks: 100}).getRate(); got != 50 {
+		t.Errorf("unexpected rate, wanted 50, got %f", got)
+	}
+	if got := (syncResult{}).getRate(); got != 0 {
+		t.Errorf("unexpected rate for zero duration, wanted 0, got %f", got)
+	}
+}
@@ -103,6 +103,7 @@ type PrometheusLogProvider interface {
 // was produced at the node.
 type PrometheusLogDispatcher struct {
 	nodes     map[Node]chan Time
+	down      map[Node]bool // < nodes temporarily unavailable, not to be read
 	nodesLock sync.Mutex
 
 	listeners     map[PrometheusLogKey]map[TimeLogListener]bool
@@ -144,6 +145,7 @@ func newPrometheusLogDispatcher(network driver.Network, period time.Duration, lo
 	res := &PrometheusLogDispatcher{
 		network:   network,
 		nodes:     make(map[Node]chan Time, 50),
+		down:      map[Node]bool{},
 		listeners: make(map[PrometheusLogKey]map[TimeLogListener]bool, 50),
 		logReader: logReader,
 		period:    period,
@@ -226,12 +228,27 @@ func (n *PrometheusLogDispatcher) AfterNodeRemoval(node driver.Node) {
 	nodeId := Node(node.GetLabel())
 	ch := n.nodes[nodeId]
 	delete(n.nodes, nodeId)
+	delete(n.down, nodeId)
 	close(ch)
 	// also drain the channel not to trigger more reads
 	for range ch {
 	}
 }
 
+// BeforeNodeDown stops fetching logs of a node while it is unavailable.
+func (n *PrometheusLogDispatcher) BeforeNodeDown(node driver.Node) {
+	n.nodesLock.Lock()
+	defer n.nodesLock.Unlock()
+	n.down[Node(node.GetLabel())] = true
+}
+
+// AfterNodeUp continues fetching logs of a node after it is available again.
+func (n *PrometheusLogDispatcher) AfterNodeUp(node driver.Node) {
+	n.nodesLock.Lock()
+	defer n.nodesLock.Unlock()
+	delete(n.down, Node(node.GetLabel()))
+}
+
 func (n *PrometheusLogDispatcher) AfterApplicationCreation(driver.Application) {
 	// ignored
 }
@@ -259,8 +276,10 @@ func (n *PrometheusLogDispatcher) startPeriodicDispatch() {
 				return
 			case t := <-n.ticker.C:
 				n.nodesLock.Lock()
-				for _, ch := range n.nodes {
-					ch <- NewTime(t)
+				for node, ch := range n.nodes {
+					if !n.down[node] {
+						ch <- NewTime(t)
+					}
 				}
 				n.nodesLock.Unlock()
 			}
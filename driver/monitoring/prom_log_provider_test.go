@@ -135,6 +135,47 @@ func TestLogsDispatchedLogsOrdered(t *testing.T) {
 	wg.Wait()
 }
 
+func TestLogsNotFetchedWhileNodeIsDown(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+
+	node1 := driver.NewMockNode(ctrl)
+	aUrl := driver.URL("A")
+	node1.EXPECT().GetServiceUrl(gomock.Any()).AnyTimes().Return(&aUrl)
+	node1.EXPECT().GetLabel().AnyTimes().Return("A")
+
+	net.EXPECT().RegisterListener(gomock.Any())
+	net.EXPECT().GetActiveNodes().AnyTimes().Return([]driver.Node{node1})
+
+	var down atomic.Bool
+	var reads atomic.Int64
+	testFunc := func(url driver.URL) ([]PrometheusLogValue, error) {
+		if down.Load() {
+			t.Errorf("logs of node being down should not be fetched")
+		}
+		reads.Add(1)
+		return nil, nil
+	}
+
+	dispatcher := newPrometheusLogDispatcher(net, 10*time.Millisecond, testFunc)
+	defer dispatcher.Shutdown()
+	var _ driver.NodeStateListener = dispatcher
+
+	dispatcher.BeforeNodeDown(node1)
+	time.Sleep(20 * time.Millisecond) // < let fetches in progress finish
+	down.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	down.Store(false)
+	dispatcher.AfterNodeUp(node1)
+
+	start := reads.Load()
+	for reads.Load() < start+3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestLogsDispatchedShutdown(t *testing.T) {
 	t.Parallel()
 
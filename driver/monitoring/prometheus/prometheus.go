@@ -108,13 +108,15 @@ func Start(net driver.Network, dn *docker.Network) (*Prometheus, error) {
 }
 
 // AddNode adds a new target to the Prometheus configuration to be observed.
+// The target is named after the label of the node, such that adding a node
+// again replaces its target, e.g. after its host changed by an upgrade.
 func (p *Prometheus) AddNode(node driver.Node) error {
 	cfg, err := renderConfigForNode(node)
 	if err != nil {
 		return err
 	}
 	_, err = p.container.Exec(
-		[]string{"sh", "-c", fmt.Sprintf("echo '%s' > /etc/prometheus/opera-%s.json", cfg, node.Hostname())})
+		[]string{"sh", "-c", fmt.Sprintf("echo '%s' > %s", cfg, getTargetFile(node))})
 	if err != nil {
 		return err
 	}
@@ -147,6 +149,24 @@ func (p *Prometheus) AfterApplicationCreation(driver.Application) {
 	// ignored
 }
 
+func (p *Prometheus) BeforeNodeDown(driver.Node) {
+	// ignored
+}
+
+// AfterNodeUp updates the target of the node, since nodes coming up again
+// after an upgrade run on a new host.
+func (p *Prometheus) AfterNodeUp(node driver.Node) {
+	if err := p.AddNode(node); err != nil {
+		log.Printf("failed to update node %s in Prometheus: %s", node.GetLabel(), err)
+	}
+}
+
+// getTargetFile returns the path of the file describing the target of the
+// given node within the Prometheus container.
+func getTargetFile(node driver.Node) string {
+	return fmt.Sprintf("/etc/prometheus/opera-%s.json", node.GetLabel())
+}
+
 // initializeConfig initializes the Prometheus configuration file by echoing config content
 // into container's config location.
 func (p *Prometheus) initializeConfig() error {
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -107,6 +108,47 @@ func TestNodeCanBeAdded(t *testing.T) {
 	}
 }
 
+func TestPrometheus_IsNodeStateListener(t *testing.T) {
+	var prom Prometheus
+	var _ driver.NodeStateListener = &prom
+}
+
+func TestTargetIsUpdatedAfterUpgrade(t *testing.T) {
+	t.Parallel()
+	net := createLocalNetwork(t)
+	prom := startPrometheus(t, net)
+
+	nodes := net.GetActiveNodes()
+	if len(nodes) == 0 {
+		t.Fatalf("no active nodes")
+	}
+	node := nodes[0]
+	before := node.Hostname()
+
+	if err := net.UpgradeNode(node, driver.DefaultClientDockerImageName); err != nil {
+		t.Fatalf("failed to upgrade node: %v", err)
+	}
+	after := node.Hostname()
+	if before == after {
+		t.Fatalf("host of node did not change by upgrade")
+	}
+
+	target, err := prom.container.Exec([]string{"cat", getTargetFile(node)})
+	if err != nil {
+		t.Fatalf("failed to read target of node: %v", err)
+	}
+	if !strings.Contains(target, after) || strings.Contains(target, before) {
+		t.Errorf("target does not refer to the upgraded host %s, got %s", after, target)
+	}
+	targets, err := prom.container.Exec([]string{"sh", "-c", "cat /etc/prometheus/opera-*.json"})
+	if err != nil {
+		t.Fatalf("failed to read targets: %v", err)
+	}
+	if strings.Contains(targets, before) {
+		t.Errorf("targets still refer to the host before the upgrade %s, got %s", before, targets)
+	}
+}
+
 // startPrometheus starts a prometheus node and returns it.
 func startPrometheus(t *testing.T, net *local.LocalNetwork) *Prometheus {
 	prom, err := Start(net, net.GetDockerNetwork())
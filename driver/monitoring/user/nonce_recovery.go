@@ -0,0 +1,101 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring"
+	"github.com/0xsoniclabs/hyperion/driver/monitoring/utils"
+	"github.com/0xsoniclabs/hyperion/load/app"
+)
+
+var (
+	// StuckNonces is a metric capturing how often the accounts of a user were found to have
+	// unconfirmed transactions without any progress since the previous check, which indicates
+	// a nonce gap caused by a dropped transaction. The value is cumulative.
+	StuckNonces = monitoring.Metric[monitoring.User, monitoring.Series[monitoring.Time, int]]{
+		Name:        "StuckNonces",
+		Description: "The number of times transactions of a user were detected to be stuck",
+	}
+
+	// ResyncedNonces is a metric capturing how often the local nonces of the accounts of a
+	// user were corrected to match the nonces on the network. The value is cumulative.
+	ResyncedNonces = monitoring.Metric[monitoring.User, monitoring.Series[monitoring.Time, int]]{
+		Name:        "ResyncedNonces",
+		Description: "The number of times the nonces of a user were re-synchronized with the network",
+	}
+)
+
+func init() {
+	if err := monitoring.RegisterSource(StuckNonces, newStuckNoncesSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+	if err := monitoring.RegisterSource(ResyncedNonces, newResyncedNoncesSource); err != nil {
+		panic(fmt.Sprintf("failed to register metric source: %v", err))
+	}
+}
+
+// newStuckNoncesSource is an internal factory for the StuckNonces metric.
+func newStuckNoncesSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.User, monitoring.Series[monitoring.Time, int]] {
+	return NewPeriodicUserDataSource[int](StuckNonces, monitor, &nonceRecoverySensorFactory{
+		count: func(stats app.NonceStats) uint64 { return stats.Stuck },
+	})
+}
+
+// newResyncedNoncesSource is an internal factory for the ResyncedNonces metric.
+func newResyncedNoncesSource(monitor *monitoring.Monitor) monitoring.Source[monitoring.User, monitoring.Series[monitoring.Time, int]] {
+	return NewPeriodicUserDataSource[int](ResyncedNonces, monitor, &nonceRecoverySensorFactory{
+		count: func(stats app.NonceStats) uint64 { return stats.Resynced },
+	})
+}
+
+// nonceRecoverySensorFactory creates sensors reporting a counter of the
+// nonce recovery of individual users.
+type nonceRecoverySensorFactory struct {
+	count func(app.NonceStats) uint64
+}
+
+func (f *nonceRecoverySensorFactory) CreateSensor(application driver.Application, user int) (utils.Sensor[int], error) {
+	recovery, ok := application.(driver.NonceRecovery)
+	if !ok || application.Config().NonceRecovery == nil {
+		return nil, nil // not applicable to applications without nonce recovery
+	}
+	if _, err := recovery.GetNonceStats(user); err != nil {
+		return nil, nil // not applicable to users without accounts
+	}
+	return &nonceRecoverySensor{
+		recovery: recovery,
+		user:     user,
+		count:    f.count,
+	}, nil
+}
+
+type nonceRecoverySensor struct {
+	recovery driver.NonceRecovery
+	user     int
+	count    func(app.NonceStats) uint64
+}
+
+func (s *nonceRecoverySensor) ReadValue() (int, error) {
+	stats, err := s.recovery.GetNonceStats(s.user)
+	if err != nil {
+		return 0, err
+	}
+	return int(s.count(stats)), nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/load/app"
+	"go.uber.org/mock/gomock"
+)
+
+// nonceRecoveryApp is an application recovering nonces of its users.
+type nonceRecoveryApp struct {
+	*driver.MockApplication
+	*driver.MockNonceRecovery
+}
+
+func TestNonceRecoverySensorsReportCountersOfUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	application := nonceRecoveryApp{driver.NewMockApplication(ctrl), driver.NewMockNonceRecovery(ctrl)}
+	application.MockApplication.EXPECT().Config().Return(&driver.ApplicationConfig{
+		NonceRecovery: &driver.NonceRecoveryConfig{},
+	}).AnyTimes()
+	application.MockNonceRecovery.EXPECT().GetNonceStats(1).Return(app.NonceStats{Stuck: 4, Resynced: 2}, nil).Times(4)
+
+	for metric, factory := range map[string]*nonceRecoverySensorFactory{
+		"stuck":    {count: func(stats app.NonceStats) uint64 { return stats.Stuck }},
+		"resynced": {count: func(stats app.NonceStats) uint64 { return stats.Resynced }},
+	} {
+		sensor, err := factory.CreateSensor(application, 1)
+		if err != nil || sensor == nil {
+			t.Fatalf("creation of sensor failed: %v", err)
+		}
+		want := map[string]int{"stuck": 4, "resynced": 2}[metric]
+		if got, err := sensor.ReadValue(); err != nil || got != want {
+			t.Errorf("sensor fetched wrong %s value, wanted %d, got %d, err %v", metric, want, got, err)
+		}
+	}
+}
+
+func TestNonceRecoverySensorsAreOnlyCreatedIfApplicable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := &nonceRecoverySensorFactory{count: func(stats app.NonceStats) uint64 { return stats.Stuck }}
+
+	// applications without nonce recovery are not monitored
+	plain := driver.NewMockApplication(ctrl)
+	if sensor, err := factory.CreateSensor(plain, 0); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor for application without nonce recovery, got %v, err %v", sensor, err)
+	}
+
+	disabled := nonceRecoveryApp{driver.NewMockApplication(ctrl), driver.NewMockNonceRecovery(ctrl)}
+	disabled.MockApplication.EXPECT().Config().Return(&driver.ApplicationConfig{})
+	if sensor, err := factory.CreateSensor(disabled, 0); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor for application with disabled nonce recovery, got %v, err %v", sensor, err)
+	}
+
+	// users without accounts are not monitored
+	readOnly := nonceRecoveryApp{driver.NewMockApplication(ctrl), driver.NewMockNonceRecovery(ctrl)}
+	readOnly.MockApplication.EXPECT().Config().Return(&driver.ApplicationConfig{NonceRecovery: &driver.NonceRecoveryConfig{}})
+	readOnly.MockNonceRecovery.EXPECT().GetNonceStats(0).Return(app.NonceStats{}, fmt.Errorf("user does not hold accounts"))
+	if sensor, err := factory.CreateSensor(readOnly, 0); err != nil || sensor != nil {
+		t.Errorf("unexpected sensor for user without accounts, got %v, err %v", sensor, err)
+	}
+}
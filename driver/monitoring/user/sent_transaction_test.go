@@ -52,3 +52,18 @@ func TestSentTransactionSensorReportsProperValue(t *testing.T) {
 	}
 
 }
+
+func TestSentTransactionSensorReportsProperValuePerOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := &sentTransactionsSensorFactory{}
+	mix := driver.NewMockOperationMix(ctrl)
+	mix.EXPECT().GetSentTransactionsOf("transfer", 2).Return(uint64(7), nil)
+
+	sensor, err := factory.CreateOperationSensor(mix, "transfer", 2)
+	if err != nil {
+		t.Fatalf("creation of sensor failed: %v", err)
+	}
+	if res, err := sensor.ReadValue(); err != nil || res != 7 {
+		t.Errorf("sensor fetched wrong value, wanted %d, got %d, err %v", 7, res, err)
+	}
+}
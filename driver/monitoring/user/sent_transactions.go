@@ -55,6 +55,14 @@ func (f *sentTransactionsSensorFactory) CreateSensor(app driver.Application, use
 	}, nil
 }
 
+func (f *sentTransactionsSensorFactory) CreateOperationSensor(mix driver.OperationMix, operation string, user int) (utils.Sensor[int], error) {
+	return &operationSentTransactionsSensor{
+		mix:       mix,
+		operation: operation,
+		user:      user,
+	}, nil
+}
+
 type sentTransactionsSensor struct {
 	app  driver.Application
 	user int
@@ -67,3 +75,17 @@ func (s *sentTransactionsSensor) ReadValue() (int, error) {
 	}
 	return int(count), nil
 }
+
+type operationSentTransactionsSensor struct {
+	mix       driver.OperationMix
+	operation string
+	user      int
+}
+
+func (s *operationSentTransactionsSensor) ReadValue() (int, error) {
+	count, err := s.mix.GetSentTransactionsOf(s.operation, s.user)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
@@ -27,10 +27,19 @@ import (
 )
 
 // SensorFactory is a factory for sensors targeting selected users.
+// Factories may return a nil sensor if the metric is not applicable to a
+// user, in which case the user is not monitored by the source.
 type SensorFactory[T any] interface {
 	CreateSensor(driver.Application, int) (utils.Sensor[T], error)
 }
 
+// OperationSensorFactory is an optional extension of SensorFactory creating
+// sensors for individual operations of users of applications mixing
+// operations. Data of an operation is reported for the app "<app>/<operation>".
+type OperationSensorFactory[T any] interface {
+	CreateOperationSensor(driver.OperationMix, string, int) (utils.Sensor[T], error)
+}
+
 // periodicUserDataSource is a generic data source periodically querying
 // user-associated sensors for data.
 type periodicUserDataSource[T any] struct {
@@ -85,9 +94,37 @@ func (s *periodicUserDataSource[T]) AfterApplicationCreation(app driver.Applicat
 			log.Printf("failed to create sensor for metric %v / app %s / user %d: %v", s.GetMetric().Name, label, i, err)
 			return
 		}
+		if sensor == nil {
+			continue
+		}
 		s.AddSubject(mon.User{
 			App: label,
 			Id:  i,
 		}, sensor)
 	}
+
+	factory, ok := s.factory.(OperationSensorFactory[T])
+	if !ok {
+		return
+	}
+	mix, ok := app.(driver.OperationMix)
+	if !ok {
+		return
+	}
+	for _, operation := range mix.GetOperations() {
+		for i := 0; i < app.Config().Users; i++ {
+			sensor, err := factory.CreateOperationSensor(mix, operation, i)
+			if err != nil {
+				log.Printf("failed to create sensor for metric %v / app %s / operation %s / user %d: %v", s.GetMetric().Name, label, operation, i, err)
+				continue
+			}
+			if sensor == nil {
+				continue
+			}
+			s.AddSubject(mon.User{
+				App: label + mon.App("/"+operation),
+				Id:  i,
+			}, sensor)
+		}
+	}
 }
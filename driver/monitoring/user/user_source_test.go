@@ -17,6 +17,7 @@
 package user
 
 import (
+	"fmt"
 	"math"
 	"sort"
 	"testing"
@@ -54,6 +55,63 @@ func (f *testSensorFactory) CreateSensor(driver.Application, int) (utils.Sensor[
 	return &testSensor{}, nil
 }
 
+// failingOperationSensorFactory fails to create sensors for the operation "x"
+// of its first user.
+type failingOperationSensorFactory struct {
+	testSensorFactory
+}
+
+func (f *failingOperationSensorFactory) CreateOperationSensor(_ driver.OperationMix, operation string, user int) (utils.Sensor[int], error) {
+	if operation == "x" && user == 0 {
+		return nil, fmt.Errorf("injected error")
+	}
+	return &testSensor{}, nil
+}
+
+// mixApplication is an application providing per-operation data.
+type mixApplication struct {
+	*driver.MockApplication
+	*driver.MockOperationMix
+}
+
+func TestUserSourceTracksRemainingOperationsIfSensorCreationFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+
+	config := driver.ApplicationConfig{Name: "A", Users: 2}
+	app := mixApplication{
+		MockApplication:  driver.NewMockApplication(ctrl),
+		MockOperationMix: driver.NewMockOperationMix(ctrl),
+	}
+	app.MockApplication.EXPECT().Config().AnyTimes().Return(&config)
+	app.MockOperationMix.EXPECT().GetOperations().Return([]string{"x", "y"})
+
+	net.EXPECT().RegisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().UnregisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().GetActiveNodes().Return([]driver.Node{}).AnyTimes() // Because of the monitors default log consumer
+	net.EXPECT().GetActiveApplications().Return([]driver.Application{app}).AnyTimes()
+
+	monitor, err := mon.NewMonitor(net, mon.MonitorConfig{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to start monitor instance: %v", err)
+	}
+	source := newPeriodicUserDataSource[int](testAccountMetric, monitor, 50*time.Millisecond, &failingOperationSensorFactory{})
+	defer source.Shutdown()
+
+	subjects := source.GetSubjects()
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i].Less(&subjects[j]) })
+	want := []mon.User{
+		{App: mon.App("A"), Id: 0},
+		{App: mon.App("A"), Id: 1},
+		{App: mon.App("A/x"), Id: 1},
+		{App: mon.App("A/y"), Id: 0},
+		{App: mon.App("A/y"), Id: 1},
+	}
+	if !slices.Equal(subjects, want) {
+		t.Errorf("invalid list of subjects, wanted %v, got %v", want, subjects)
+	}
+}
+
 func TestAppSourceRetrievesSensorData(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	net := driver.NewMockNetwork(ctrl)
@@ -19,6 +19,7 @@ package utils
 import (
 	"errors"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver/monitoring"
@@ -44,8 +45,9 @@ type PeriodicDataSource[S comparable, T any] struct {
 // Any done that occur are stored in the 'done' field of type 'error'.
 // This structure is typically used in the context of controlling the execution of a subject.
 type process struct {
-	stop chan bool
-	done chan error
+	stop      chan bool
+	done      chan error
+	suspended *atomic.Bool
 }
 
 // Stop stops the instance by closing the stop channel and
@@ -108,7 +110,7 @@ func (s *PeriodicDataSource[S, T]) AddSubject(subject S, sensor Sensor[T]) error
 		return err
 	}
 
-	subjectStop := process{make(chan bool), make(chan error, 1)}
+	subjectStop := process{make(chan bool), make(chan error, 1), new(atomic.Bool)}
 	s.subjects[subject] = subjectStop
 
 	// Start background routine collecting sensor data.
@@ -123,6 +125,9 @@ func (s *PeriodicDataSource[S, T]) AddSubject(subject S, sensor Sensor[T]) error
 		for {
 			select {
 			case now := <-ticker.C:
+				if subjectStop.suspended.Load() {
+					continue
+				}
 				value, err := sensor.ReadValue()
 				if err != nil {
 					errs = append(errs, err)
@@ -150,3 +155,21 @@ func (s *PeriodicDataSource[S, T]) RemoveSubject(subject S) error {
 
 	return nil
 }
+
+// SuspendSubject pauses the collection of data for the given subject, e.g.
+// while the subject is temporarily unavailable. Data already collected is
+// retained and the collection continues in the same series once the subject
+// is resumed using ResumeSubject.
+func (s *PeriodicDataSource[S, T]) SuspendSubject(subject S) {
+	if process, exists := s.subjects[subject]; exists {
+		process.suspended.Store(true)
+	}
+}
+
+// ResumeSubject continues the collection of data for a subject suspended by
+// SuspendSubject.
+func (s *PeriodicDataSource[S, T]) ResumeSubject(subject S) {
+	if process, exists := s.subjects[subject]; exists {
+		process.suspended.Store(false)
+	}
+}
@@ -184,6 +184,54 @@ func TestPeriodicSourceErrors(t *testing.T) {
 	}
 }
 
+func TestPeriodicSourceSubjectSuspendedAndResumed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := driver.NewMockNetwork(ctrl)
+	net.EXPECT().RegisterListener(gomock.Any()).AnyTimes()
+	net.EXPECT().GetActiveNodes().AnyTimes().Return([]driver.Node{})
+
+	monitor, err := monitoring.NewMonitor(net, monitoring.MonitorConfig{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to initiate monitor: %v", err)
+	}
+
+	testMetric := monitoring.Metric[monitoring.Node, monitoring.Series[monitoring.Time, int]]{
+		Name:        "TestMetric",
+		Description: "Test Metric",
+	}
+
+	source := NewPeriodicDataSourceWithPeriod[monitoring.Node, int](testMetric, monitor, 10*time.Millisecond)
+	source.SuspendSubject("A") // < unknown subjects are ignored
+
+	sensor1 := &buggySensor{}
+	node1 := monitoring.Node("A")
+	if err := source.AddSubject(node1, sensor1); err != nil {
+		t.Errorf("error to add subject: %s", err)
+	}
+	source.SuspendSubject(node1)
+
+	sensor2 := &testSensor{}
+	node2 := monitoring.Node("B")
+	if err := source.AddSubject(node2, sensor2); err != nil {
+		t.Errorf("error to add subject: %s", err)
+	}
+	source.SuspendSubject(node2)
+	source.ResumeSubject(node2)
+
+	// only the sensor of the resumed subject is queried
+	for sensor2.count() < 5 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	if got := sensor1.count(); got != 0 {
+		t.Errorf("sensor of suspended subject was queried %d times", got)
+	}
+
+	// suspended subjects do not report errors
+	if err := source.Shutdown(); err != nil {
+		t.Errorf("error to shutdown: %s", err)
+	}
+}
+
 type testSensor struct {
 	counts atomic.Int32
 }
@@ -17,10 +17,12 @@
 package driver
 
 import (
+	"math/big"
 	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver/parser"
 	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/0xsoniclabs/hyperion/load/app"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -75,6 +77,78 @@ type Network interface {
 	ApplyNetworkRules(rules NetworkRules) error
 }
 
+// SendReporter is an optional extension of Networks reporting the outcome of
+// sending individual transactions.
+type SendReporter interface {
+	// SendTransactionAndReport sends the given transaction like
+	// SendTransaction and calls the report function with the result of the
+	// submission, which is nil if the transaction was accepted by the node.
+	SendTransactionAndReport(tx *types.Transaction, report func(error))
+}
+
+// NodeSendErrorTracker is an optional extension of Networks counting the
+// errors reported by nodes when sending transactions to them.
+type NodeSendErrorTracker interface {
+	// GetSendErrors returns the errors reported so far by each node, indexed
+	// by the label of the node.
+	GetSendErrors() map[string]rpc.SendErrors
+}
+
+// NodeController is an optional extension of Networks controlling the
+// availability of individual nodes. Registered listeners implementing
+// NodeStateListener are notified whenever nodes go down and come up again.
+type NodeController interface {
+	// RestartNode restarts the given node, gracefully or by killing it.
+	RestartNode(node Node, graceful bool) error
+	// PauseNode freezes the given node until it is resumed.
+	PauseNode(Node) error
+	// ResumeNode continues the given node after it has been paused.
+	ResumeNode(Node) error
+}
+
+// ValidatorDialer is an optional extension of Networks connecting to their
+// validators, which offer the txpool API unlike other types of nodes.
+type ValidatorDialer interface {
+	// DialRandomValidatorRpc establishes an RPC connection with a randomly
+	// selected validator of the network.
+	DialRandomValidatorRpc() (rpc.Client, error)
+}
+
+// NodeUpgrader is an optional extension of Networks replacing the clients of
+// nodes by other versions. Registered listeners implementing NodeStateListener
+// are notified when upgraded nodes go down and come up again.
+type NodeUpgrader interface {
+	// UpgradeNode gracefully stops the client of the given node and starts a
+	// client of the given image in its place. The node retains its label,
+	// data directory, and peers.
+	UpgradeNode(node Node, image string) error
+}
+
+// StakeController is an optional extension of Networks changing the stake of
+// validators through the SFC contract of the network. Transactions are signed
+// by the validators of the nodes, and the node keeps running after changes.
+type StakeController interface {
+	// DelegateStake increases the self-stake of the validator of the given
+	// node by the given amount of wei.
+	DelegateStake(node Node, amount *big.Int) error
+	// UndelegateStake decreases the self-stake of the validator of the given
+	// node by the given amount of wei.
+	UndelegateStake(node Node, amount *big.Int) error
+	// DeactivateValidator undelegates the complete self-stake of the validator
+	// of the given node, such that it leaves the validator set with the next
+	// epoch instead of counting as an offline validator.
+	DeactivateValidator(node Node) error
+}
+
+// SnapshotSaver is an optional extension of Networks saving the data of their
+// nodes when shutting down, such that later networks can start from the state
+// reached by the network. See NetworkConfig.RestoreSnapshot.
+type SnapshotSaver interface {
+	// ShutdownAndSaveSnapshot shuts down the network like Shutdown and saves
+	// the data directories of all its nodes to the given directory.
+	ShutdownAndSaveSnapshot(dir string) error
+}
+
 // NetworkConfig is a collection of network parameters to be used by factories
 // creating network instances.
 type NetworkConfig struct {
@@ -86,6 +160,13 @@ type NetworkConfig struct {
 	NetworkRules NetworkRules
 	// OutputDir is the directory where temp data are written.
 	OutputDir string
+	// Topology defines which nodes are connected as peers. Nil is interpreted
+	// as a full mesh.
+	Topology *parser.Topology
+	// RestoreSnapshot is the directory of a snapshot saved by a SnapshotSaver
+	// to start the nodes of the network from. Nodes not part of the snapshot
+	// start from the genesis. Empty to start all nodes from the genesis.
+	RestoreSnapshot string
 }
 
 // NetworkRules defines a set of network rules that can be applied to the network.
@@ -102,6 +183,38 @@ type NetworkListener interface {
 	AfterApplicationCreation(Application)
 }
 
+// NodeStateListener is an optional extension of NetworkListeners notified
+// whenever nodes become temporarily unavailable, e.g. while being restarted or
+// paused, and available again. Nodes remain part of the network meanwhile.
+type NodeStateListener interface {
+	// BeforeNodeDown is called before a node becomes unavailable.
+	BeforeNodeDown(Node)
+	// AfterNodeUp is called after a node is available again.
+	AfterNodeUp(Node)
+}
+
+// NodeType defines the role of a node in the network, which determines the
+// configuration of its client, e.g. the offered APIs and the retained state.
+type NodeType string
+
+const (
+	// ValidatorNode participates in the consensus of the network.
+	ValidatorNode NodeType = "validator"
+	// ObserverNode follows the network, offering the APIs required to control
+	// and monitor it and to submit transactions. It is the default type of
+	// nodes.
+	ObserverNode NodeType = "observer"
+	// RpcNode serves requests of users on pruned state and accepts large
+	// amounts of pending transactions. Historic states are not available, so
+	// traces and queries of past blocks fail on such nodes.
+	RpcNode NodeType = "rpc"
+	// ArchiveNode serves requests of users on historic states through its
+	// HTTP service. It is the type of node to direct traces and queries of
+	// past blocks at. It offers no WebSocket service, so it neither receives
+	// transactions of applications nor serves subscriptions.
+	ArchiveNode NodeType = "archive"
+)
+
 type NodeConfig struct {
 	Name       string
 	Failing    bool
@@ -109,6 +222,39 @@ type NodeConfig struct {
 	Cheater    bool
 	Image      string
 	DataVolume *string
+	// Type defines the role of the node. If empty, the type is derived from
+	// the Validator flag.
+	Type NodeType
+	// Env lists environment variables of the client overriding its defaults.
+	Env map[string]string
+	// Args lists command line flags passed to the client, named without
+	// leading dashes. Empty values produce flags without values.
+	Args map[string]string
+	// Resources limits the resources available to the node.
+	Resources NodeResources
+}
+
+// NodeResources limits the resources available to the client of a node. Zero
+// values are interpreted as unlimited.
+type NodeResources struct {
+	Cpus      float64 `yaml:"cpus"`        // < number of CPUs, fractions are supported
+	Memory    int64   `yaml:"memory"`      // < in bytes
+	DiskIoBps int64   `yaml:"disk_io_bps"` // < read and write throughput of the disk, in bytes per second
+}
+
+// NewNodeResources creates resource limits from a parser.Resources, which is
+// expected to have passed its Check. Nil is interpreted as unlimited.
+func NewNodeResources(r *parser.Resources) NodeResources {
+	if r == nil {
+		return NodeResources{}
+	}
+	res := NodeResources{}
+	if r.Cpus != nil {
+		res.Cpus = float64(*r.Cpus)
+	}
+	res.Memory, _ = r.GetMemory()       // < checked by the parser
+	res.DiskIoBps, _ = r.GetDiskIoBps() // < checked by the parser
+	return res
 }
 
 type ApplicationConfig struct {
@@ -123,16 +269,61 @@ type ApplicationConfig struct {
 	// Users defines the number of users sending transactions to the app.
 	Users int
 
+	// Options defines type-specific parameters of the on-chain app.
+	Options app.Options
+
+	// ClosedLoop switches the users of the app to a closed-loop model, in
+	// which users wait for their transactions to be processed before sending
+	// new ones. If nil, users send transactions at the configured rate.
+	ClosedLoop *ClosedLoopConfig
+
+	// Fees defines the strategy for the fees of the transactions of the app's
+	// users. If nil, fixed default fees are used.
+	Fees *app.FeeOptions
+
+	// TxTypes lists the types of the transactions of the app's users and
+	// their relative weights. If empty, dynamic-fee transactions are used.
+	TxTypes []app.TxTypeWeight
+
+	// NonceRecovery enables the periodic detection and recovery of nonce gaps
+	// of the accounts of the app's users. If nil, no recovery is performed.
+	NonceRecovery *NonceRecoveryConfig
+
+	// Accounts configures the reuse, funding and sweeping of the accounts of
+	// the app's users. If nil, fresh accounts are funded in every run.
+	Accounts *app.AccountOptions
+
 	// TODO: add other parameters as needed
 	//  - application type
 }
 
+// ClosedLoopConfig defines the behavior of users in closed-loop mode. Each
+// user keeps at most InFlight transactions pending and sends a new one only
+// after the receipt of a pending transaction was received or it timed out,
+// followed by an optional think time.
+type ClosedLoopConfig struct {
+	InFlight  int
+	Timeout   time.Duration
+	ThinkTime time.Duration
+}
+
+// NonceRecoveryConfig defines the recovery of nonce gaps of user accounts.
+// Every Period, the local nonces of the accounts are compared with the nonces
+// on the network. Gaps caused by dropped transactions are resolved by either
+// re-sending the unconfirmed transactions, if Resend is set, or by resetting
+// the local nonce.
+type NonceRecoveryConfig struct {
+	Period time.Duration
+	Resend bool
+}
+
 // Validator is a configuration for a group of network start-up validators.
 type Validator struct {
 	Name      string
 	Failing   bool
 	Instances int
 	ImageName string
+	Resources NodeResources
 }
 
 // NewValidator creates a new Validator from a parser.Validator.
@@ -150,6 +341,7 @@ func NewValidator(v parser.Validator) Validator {
 		Failing:   v.Failing,
 		Instances: instances,
 		ImageName: imageName,
+		Resources: NewNodeResources(v.Resources),
 	}
 }
 
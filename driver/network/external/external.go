@@ -41,6 +41,9 @@ type ExternalNetwork struct {
 	primaryAccount *app.Account
 	rpcEndpoints   []string // List of RPC endpoints to connect to
 
+	// sendErrors counts errors of sending transactions per RPC endpoint
+	sendErrors map[string]*rpcdriver.SendErrorCounter
+
 	// apps maintains a list of applications
 	apps      []driver.Application
 	appsMutex sync.Mutex
@@ -78,10 +81,15 @@ func NewExternalNetwork(config *ExternalNetworkConfig) (*ExternalNetwork, error)
 		config:         config.NetworkConfig,
 		primaryAccount: primaryAccount,
 		rpcEndpoints:   config.RpcEndpoints,
+		sendErrors:     map[string]*rpcdriver.SendErrorCounter{},
 		apps:           []driver.Application{},
 		listeners:      map[driver.NetworkListener]bool{},
 	}
 
+	for _, endpoint := range config.RpcEndpoints {
+		net.sendErrors[endpoint] = &rpcdriver.SendErrorCounter{}
+	}
+
 	// Setup app context for managing applications
 	appContext, err := app.NewContext(net, primaryAccount)
 	if err != nil {
@@ -110,7 +118,15 @@ func (n *ExternalNetwork) GetActiveNodes() []driver.Node {
 // CreateApplication creates applications that will send transactions to external chain
 func (n *ExternalNetwork) CreateApplication(config *driver.ApplicationConfig) (driver.Application, error) {
 	appId := n.nextAppId.Add(1)
-	application, err := app.NewApplication(config.Type, n.appContext, 0, appId)
+	appContext := n.appContext
+	if config.Accounts != nil {
+		pool, err := app.NewAccountPool(n.appContext, *config.Accounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create account pool; %w", err)
+		}
+		appContext = pool
+	}
+	application, err := app.NewApplication(config.Type, &config.Options, appContext, 0, appId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize application: %w", err)
 	}
@@ -120,7 +136,7 @@ func (n *ExternalNetwork) CreateApplication(config *driver.ApplicationConfig) (d
 		return nil, fmt.Errorf("failed to parse rate: %w", err)
 	}
 
-	appController, err := controller.NewAppController(application, sh, config.Users, n.appContext, n)
+	appController, err := controller.NewAppController(application, sh, config, appContext, n)
 	if err != nil {
 		return nil, err
 	}
@@ -168,30 +184,70 @@ func (n *ExternalNetwork) UnregisterListener(listener driver.NetworkListener) {
 
 // SendTransaction sends a transaction to one of the external RPC endpoints
 func (n *ExternalNetwork) SendTransaction(tx *types.Transaction) {
-	// Send to the first available endpoint
-	// You could implement load balancing here
-	client, err := n.DialRandomRpc()
+	n.SendTransactionAndReport(tx, nil)
+}
+
+// SendTransactionAndReport sends a transaction to one of the external RPC
+// endpoints and reports the result to the given function, if set. Errors are
+// counted for the endpoint the transaction was sent to, including failures to
+// connect.
+func (n *ExternalNetwork) SendTransactionAndReport(tx *types.Transaction, report func(error)) {
+	endpoint, err := n.sendTransaction(tx)
+	if counter, found := n.sendErrors[endpoint]; err != nil && found {
+		counter.Count(err)
+	}
+	if report != nil {
+		report(err)
+	}
+}
+
+// sendTransaction sends the given transaction and returns the endpoint it was
+// sent to, which is empty if no endpoint is configured.
+func (n *ExternalNetwork) sendTransaction(tx *types.Transaction) (string, error) {
+	endpoint, err := n.pickEndpoint()
+	if err != nil {
+		return "", err
+	}
+	client, err := dialEndpoint(endpoint)
 	if err != nil {
-		log.Printf("failed to dial RPC: %v", err)
-		return
+		return endpoint, err
 	}
 	defer client.Close()
+	return endpoint, client.SendTransaction(context.Background(), tx)
+}
 
-	if err := client.SendTransaction(context.Background(), tx); err != nil {
-		log.Printf("failed to send transaction: %v", err)
+// GetSendErrors returns the errors reported so far when sending transactions,
+// indexed by RPC endpoint.
+func (n *ExternalNetwork) GetSendErrors() map[string]rpcdriver.SendErrors {
+	res := make(map[string]rpcdriver.SendErrors, len(n.sendErrors))
+	for endpoint, counter := range n.sendErrors {
+		res[endpoint] = counter.Get()
 	}
+	return res
 }
 
 // DialRandomRpc connects to one of the configured RPC endpoints
 func (n *ExternalNetwork) DialRandomRpc() (rpcdriver.Client, error) {
+	endpoint, err := n.pickEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return dialEndpoint(endpoint)
+}
+
+// pickEndpoint selects the RPC endpoint to connect to.
+func (n *ExternalNetwork) pickEndpoint() (string, error) {
 	if len(n.rpcEndpoints) == 0 {
-		return nil, fmt.Errorf("no RPC endpoints configured")
+		return "", fmt.Errorf("no RPC endpoints configured")
 	}
 
 	// For simplicity, use the first endpoint
 	// You could implement random selection or load balancing
-	endpoint := n.rpcEndpoints[0]
+	return n.rpcEndpoints[0], nil
+}
 
+// dialEndpoint connects to the given RPC endpoint.
+func dialEndpoint(endpoint string) (rpcdriver.Client, error) {
 	rpcClient, err := network.RetryReturn(network.DefaultRetryAttempts, 1*time.Second, func() (*rpc.Client, error) {
 		return rpc.DialContext(context.Background(), endpoint)
 	})
@@ -282,3 +338,47 @@ func (a *externalApplication) GetSentTransactions(user int) (uint64, error) {
 func (a *externalApplication) GetReceivedTransactions() (uint64, error) {
 	return a.controller.GetReceivedTransactions()
 }
+
+func (a *externalApplication) GetOperations() []string {
+	return a.controller.GetOperations()
+}
+
+func (a *externalApplication) GetSentTransactionsOf(operation string, user int) (uint64, error) {
+	return a.controller.GetTransactionsOfOperationSentBy(operation, user)
+}
+
+func (a *externalApplication) GetReceivedTransactionsOf(operation string) (uint64, error) {
+	return a.controller.GetReceivedTransactionsOf(operation)
+}
+
+func (a *externalApplication) GetRequestMethods() []string {
+	return a.controller.GetRequestMethods()
+}
+
+func (a *externalApplication) GetRequestStats(method string) (app.RequestStats, error) {
+	return a.controller.GetRequestStats(method)
+}
+
+func (a *externalApplication) GetSubscriptionKinds() []string {
+	return a.controller.GetSubscriptionKinds()
+}
+
+func (a *externalApplication) GetSubscriptionStats(kind string) (app.SubscriptionStats, error) {
+	return a.controller.GetSubscriptionStats(kind)
+}
+
+func (a *externalApplication) GetNonceStats(user int) (app.NonceStats, error) {
+	return a.controller.GetNonceStats(user)
+}
+
+func (a *externalApplication) GetSendErrors() rpcdriver.SendErrors {
+	return a.controller.GetSendErrors()
+}
+
+func (a *externalApplication) GetEffectiveGasPrice() (uint64, error) {
+	return a.controller.GetEffectiveGasPrice()
+}
+
+func (a *externalApplication) GetSustainableRate() (float64, bool) {
+	return a.controller.GetSustainableRate()
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"testing"
+
+	rpcdriver "github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestExternalNetwork_SendErrorsAreCountedForEndpointSendingTransaction(t *testing.T) {
+	// nothing is listening on these endpoints, so sending fails
+	endpoints := []string{"http://127.0.0.1:1", "http://127.0.0.1:2"}
+	net := &ExternalNetwork{
+		rpcEndpoints: endpoints,
+		sendErrors: map[string]*rpcdriver.SendErrorCounter{
+			endpoints[0]: {},
+			endpoints[1]: {},
+		},
+	}
+	sender, err := net.pickEndpoint()
+	if err != nil {
+		t.Fatalf("failed to pick endpoint: %v", err)
+	}
+
+	var reported error
+	net.SendTransactionAndReport(types.NewTx(&types.LegacyTx{}), func(err error) { reported = err })
+	if reported == nil {
+		t.Fatalf("sending to unavailable endpoint should fail")
+	}
+	for endpoint, errors := range net.GetSendErrors() {
+		want := uint64(0)
+		if endpoint == sender {
+			want = 1
+		}
+		if got := errors.Total(); got != want {
+			t.Errorf("unexpected number of errors of endpoint %s, wanted %d, got %d", endpoint, want, got)
+		}
+	}
+}
+
+func TestExternalNetwork_SendingWithoutEndpointsFails(t *testing.T) {
+	net := &ExternalNetwork{sendErrors: map[string]*rpcdriver.SendErrorCounter{}}
+	var reported error
+	net.SendTransactionAndReport(types.NewTx(&types.LegacyTx{}), func(err error) { reported = err })
+	if reported == nil {
+		t.Errorf("sending without endpoints should fail")
+	}
+}
@@ -23,7 +23,11 @@ import (
 	"github.com/0xsoniclabs/hyperion/genesistools/genesis"
 	"github.com/0xsoniclabs/hyperion/genesistools/network"
 	"log"
+	"math/big"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 	"sync/atomic"
 
@@ -56,9 +60,19 @@ type LocalNetwork struct {
 	// validator nodes created during startup.
 	nodes map[driver.NodeID]*node.OperaNode
 
-	// nodesMutex synchronizes access to the list of nodes.
+	// nodesMutex synchronizes access to the list of nodes, their order, and
+	// their peerings.
 	nodesMutex sync.Mutex
 
+	// order lists the nodes in the network in the order they joined it.
+	order []driver.NodeID
+
+	// topology defines the peers of each node in the network.
+	topology topology
+
+	// peerings is the set of connections established between nodes.
+	peerings map[peering]bool
+
 	// apps maintains a list of all applications created on the network.
 	apps []driver.Application
 
@@ -78,6 +92,10 @@ type LocalNetwork struct {
 
 	// a context for app management operations on the network
 	appContext app.AppContext
+
+	// snapshot describes the snapshot the nodes of the network are restored
+	// from, nil if the network was started from scratch.
+	snapshot *snapshotManifest
 }
 
 func NewLocalNetwork(config *driver.NetworkConfig) (*LocalNetwork, error) {
@@ -104,6 +122,8 @@ func NewLocalNetwork(config *driver.NetworkConfig) (*LocalNetwork, error) {
 		config:         *config,
 		primaryAccount: primaryAccount,
 		nodes:          map[driver.NodeID]*node.OperaNode{},
+		topology:       newTopology(config.Topology),
+		peerings:       map[peering]bool{},
 		apps:           []driver.Application{},
 		listeners:      map[driver.NetworkListener]bool{},
 		rpcWorkerPool:  rpc.NewRpcWorkerPool(),
@@ -112,27 +132,45 @@ func NewLocalNetwork(config *driver.NetworkConfig) (*LocalNetwork, error) {
 	// Let the RPC pool to start RPC workers when a node start.
 	net.RegisterListener(net.rpcWorkerPool)
 
+	// Validate the snapshot to start from before starting any node.
+	if config.RestoreSnapshot != "" {
+		snapshot, err := readSnapshotManifest(config.RestoreSnapshot)
+		if err == nil {
+			err = snapshot.checkGenesis(config)
+		}
+		if err != nil {
+			return nil, errors.Join(
+				fmt.Errorf("cannot restore snapshot %s; %w", config.RestoreSnapshot, err),
+				net.Shutdown(),
+			)
+		}
+		net.snapshot = snapshot
+	}
+
 	// Start all validators.
 	net.validators = make([]*node.OperaNode, config.Validators.GetNumValidators())
 	errs := make([]error, config.Validators.GetNumValidators())
+	labels := getGenesisValidatorLabels(config.Validators)
 	var wg sync.WaitGroup
 	var idx int
 	for _, validator := range config.Validators {
 		for j := 0; j < validator.Instances; j++ {
 			wg.Add(1)
 			image := validator.ImageName
-			label := fmt.Sprintf("%s-%d", validator.Name, j)
+			label := labels[idx]
 			go func(idx int) {
 				defer wg.Done()
 				validatorId := idx + 1
 				nodeConfig := node.OperaNodeConfig{
 					ValidatorId:   &validatorId,
+					Type:          driver.ValidatorNode,
 					Failing:       validator.Failing,
 					Image:         image,
 					NetworkConfig: config,
 					Label:         label,
+					Resources:     validator.Resources,
 				}
-				net.validators[idx], errs[idx] = net.createNode(&nodeConfig)
+				net.validators[idx], errs[idx] = net.launchNode(&nodeConfig)
 			}(idx)
 			idx++
 		}
@@ -141,9 +179,27 @@ func NewLocalNetwork(config *driver.NetworkConfig) (*LocalNetwork, error) {
 
 	// If starting the validators failed, the network startup should fail.
 	if err := errors.Join(errs...); err != nil {
+		for _, validator := range net.validators {
+			if validator != nil {
+				err = errors.Join(err, validator.Cleanup())
+			}
+		}
 		return nil, errors.Join(err, net.Shutdown())
 	}
 
+	// Validators join the network in the order of their IDs, to make the
+	// topology of the network reproducible. If one fails to join, it and all
+	// following validators are not yet part of the network and would not be
+	// stopped by the shutdown.
+	for i, validator := range net.validators {
+		if _, err := net.startNode(validator); err != nil {
+			for _, pending := range net.validators[i:] {
+				err = errors.Join(err, pending.Cleanup())
+			}
+			return nil, errors.Join(err, net.Shutdown())
+		}
+	}
+
 	// Setup infrastructure for managing applications on the network.
 	appContext, err := app.NewContext(net, primaryAccount)
 	if err != nil {
@@ -170,15 +226,17 @@ func (n *LocalNetwork) startNode(node *node.OperaNode) (*node.OperaNode, error)
 	n.nodesMutex.Lock()
 	id, err := node.GetNodeID()
 	if err != nil {
+		n.nodesMutex.Unlock()
 		return nil, fmt.Errorf("failed to get node id; %v", err)
 	}
-	for _, other := range n.nodes {
-		if err = other.AddPeer(id); err != nil {
-			n.nodesMutex.Unlock()
-			return nil, fmt.Errorf("failed to add peer; %v", err)
-		}
-	}
 	n.nodes[id] = node
+	n.order = append(n.order, id)
+	if err := n.updatePeerings(); err != nil {
+		delete(n.nodes, id)
+		n.order = slices.DeleteFunc(n.order, func(other driver.NodeID) bool { return other == id })
+		n.nodesMutex.Unlock()
+		return nil, err
+	}
 	n.nodesMutex.Unlock()
 
 	n.listenerMutex.Lock()
@@ -190,20 +248,118 @@ func (n *LocalNetwork) startNode(node *node.OperaNode) (*node.OperaNode, error)
 	return node, nil
 }
 
+// peering is a connection between two nodes, established by node from.
+type peering struct {
+	from, to driver.NodeID
+}
+
+// updatePeerings establishes and removes connections between the nodes in the
+// network as defined by its topology. It must be called with the nodesMutex
+// held whenever nodes join or leave the network.
+func (n *LocalNetwork) updatePeerings() error {
+	labels := make([]string, len(n.order))
+	for i, id := range n.order {
+		labels[i] = n.nodes[id].GetLabel()
+	}
+	wanted := map[peering]bool{}
+	for _, edge := range n.topology.getEdges(labels) {
+		wanted[peering{n.order[edge.from], n.order[edge.to]}] = true
+	}
+
+	// Connections are dropped by the node which established them, or by the
+	// other node if the establishing node has left the network.
+	errs := []error{}
+	for peering := range n.peerings {
+		if wanted[peering] {
+			continue
+		}
+		delete(n.peerings, peering)
+		from, to := peering.from, peering.to
+		if _, present := n.nodes[from]; !present {
+			from, to = to, from
+		}
+		node, present := n.nodes[from]
+		if !present {
+			continue
+		}
+		if err := node.RemovePeer(to); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove peer; %v", err))
+		}
+	}
+	for peering := range wanted {
+		if n.peerings[peering] {
+			continue
+		}
+		if err := n.nodes[peering.from].AddPeer(peering.to); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add peer; %v", err))
+			continue
+		}
+		n.peerings[peering] = true
+	}
+	return errors.Join(errs...)
+}
+
 // createNode is an internal version of CreateNode enabling the creation
 // of validator and non-validator nodes in the network.
 func (n *LocalNetwork) createNode(nodeConfig *node.OperaNodeConfig) (*node.OperaNode, error) {
+	node, err := n.launchNode(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := n.startNode(node); err != nil {
+		return nil, errors.Join(err, node.Cleanup())
+	}
+	return node, nil
+}
+
+// launchNode starts the client of a node without adding it to the network.
+// If the network is restored from a snapshot, nodes included in the snapshot
+// start from their saved data directory.
+func (n *LocalNetwork) launchNode(nodeConfig *node.OperaNodeConfig) (*node.OperaNode, error) {
+	if n.snapshot != nil {
+		nodeConfig.DataDirArchive = n.snapshot.getArchive(n.config.RestoreSnapshot, nodeConfig.Label)
+	}
 	node, err := node.StartOperaDockerNode(n.docker, n.network, nodeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start opera docker; %v", err)
 	}
-	return n.startNode(node)
+	if n.snapshot != nil {
+		if err := n.checkGenesisHash(node); err != nil {
+			return nil, errors.Join(err, node.Cleanup())
+		}
+	}
+	if err := n.recordNodeSettings(node); err != nil {
+		return nil, errors.Join(err, node.Cleanup())
+	}
+	return node, nil
+}
+
+// recordNodeSettings writes the effective client configuration of the given
+// node to the output directory of the network, if there is one.
+func (n *LocalNetwork) recordNodeSettings(node *node.OperaNode) error {
+	if n.config.OutputDir == "" {
+		return nil
+	}
+	dir := filepath.Join(n.config.OutputDir, "node_settings")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory for node settings; %w", err)
+	}
+	if err := node.SaveSettingsTo(dir); err != nil {
+		return fmt.Errorf("failed to record settings of node %s; %w", node.GetLabel(), err)
+	}
+	return nil
 }
 
 // CreateNode creates nodes in the network during run.
 func (n *LocalNetwork) CreateNode(config *driver.NodeConfig) (driver.Node, error) {
 	newValId := 0
-	if config.Validator {
+	if config.Validator && n.snapshot != nil {
+		// Validators registered during the run the network was restored from
+		// are still registered and continue with their data directory, which
+		// is bound to their validator ID.
+		newValId = n.snapshot.getValidatorId(config.Name)
+	}
+	if config.Validator && newValId == 0 {
 		var err error
 		rpcClient, err := n.DialRandomRpc()
 		if err != nil {
@@ -224,6 +380,8 @@ func (n *LocalNetwork) CreateNode(config *driver.NodeConfig) (driver.Node, error
 			Image:         config.Image,
 			NetworkConfig: &n.config,
 			ValidatorId:   &newValId,
+			Type:          driver.ValidatorNode,
+			Resources:     config.Resources,
 		})
 		if err != nil {
 			return nil, err
@@ -242,7 +400,11 @@ func (n *LocalNetwork) CreateNode(config *driver.NodeConfig) (driver.Node, error
 		Image:         config.Image,
 		NetworkConfig: &n.config,
 		ValidatorId:   &newValId,
+		Type:          config.Type,
+		Env:           config.Env,
+		Args:          config.Args,
 		MountDataDir:  datadir,
+		Resources:     config.Resources,
 	})
 }
 
@@ -254,11 +416,10 @@ func (n *LocalNetwork) RemoveNode(node driver.Node) error {
 	}
 
 	delete(n.nodes, id)
-	for _, other := range n.nodes {
-		if err = other.RemovePeer(id); err != nil {
-			n.nodesMutex.Unlock()
-			return fmt.Errorf("failed to remove peer; %v", err)
-		}
+	n.order = slices.DeleteFunc(n.order, func(other driver.NodeID) bool { return other == id })
+	if err := n.updatePeerings(); err != nil {
+		n.nodesMutex.Unlock()
+		return err
 	}
 	n.nodesMutex.Unlock()
 
@@ -275,15 +436,226 @@ func (n *LocalNetwork) KillNode(node driver.Node) error {
 	return node.Kill()
 }
 
+// RestartNode restarts the given node and notifies listeners about the node
+// going down and coming up again. Listeners are notified about the node coming
+// up even if the restart fails, like for upgrades of nodes.
+func (n *LocalNetwork) RestartNode(node driver.Node, graceful bool) error {
+	n.notifyNodeState(node, driver.NodeStateListener.BeforeNodeDown)
+	defer n.notifyNodeState(node, driver.NodeStateListener.AfterNodeUp)
+	if err := node.Restart(graceful); err != nil {
+		return fmt.Errorf("failed to restart node %s; %w", node.GetLabel(), err)
+	}
+	return nil
+}
+
+// UpgradeNode replaces the client of the given node by the client of the given
+// image and notifies listeners about the node going down and coming up again.
+// Since the address of the node may change, connections to it are
+// re-established with its new ID.
+func (n *LocalNetwork) UpgradeNode(nd driver.Node, image string) error {
+	opera, ok := nd.(*node.OperaNode)
+	if !ok {
+		return fmt.Errorf("trying to upgrade non-sonic node")
+	}
+	oldId, err := opera.GetNodeID()
+	if err != nil {
+		return fmt.Errorf("failed to get node id; %w", err)
+	}
+
+	n.notifyNodeState(nd, driver.NodeStateListener.BeforeNodeDown)
+	defer n.notifyNodeState(nd, driver.NodeStateListener.AfterNodeUp)
+	if err := opera.Upgrade(image); err != nil {
+		return fmt.Errorf("failed to upgrade node %s; %w", nd.GetLabel(), err)
+	}
+	if err := n.updateNodeId(opera, oldId); err != nil {
+		return err
+	}
+	return n.recordNodeSettings(opera)
+}
+
+// updateNodeId registers the given node by its current ID, which may differ
+// from the given previous ID after its client was replaced. Connections of the
+// node itself are expected to be re-added by the node, while those of other
+// nodes are re-established with the new ID.
+func (n *LocalNetwork) updateNodeId(node *node.OperaNode, oldId driver.NodeID) error {
+	newId, err := node.GetNodeID()
+	if err != nil {
+		return fmt.Errorf("failed to get node id; %w", err)
+	}
+	if newId == oldId {
+		return nil
+	}
+
+	n.nodesMutex.Lock()
+	defer n.nodesMutex.Unlock()
+	i := slices.Index(n.order, oldId)
+	if i < 0 {
+		return nil // < the node is not part of the network
+	}
+	delete(n.nodes, oldId)
+	n.nodes[newId] = node
+	n.order[i] = newId
+
+	errs := []error{}
+	for peering := range n.peerings {
+		if peering.from == oldId || peering.to == oldId {
+			delete(n.peerings, peering)
+		}
+		if peering.to == oldId {
+			if err := n.nodes[peering.from].RemovePeer(oldId); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove peer; %v", err))
+			}
+		}
+	}
+	return errors.Join(append(errs, n.updatePeerings())...)
+}
+
+// DelegateStake increases the self-stake of the validator of the given node.
+func (n *LocalNetwork) DelegateStake(node driver.Node, amount *big.Int) error {
+	return n.changeStake(node, "delegate to", func(backend network.ContractBackend, validatorId int) error {
+		return network.DelegateStake(backend, validatorId, amount)
+	})
+}
+
+// UndelegateStake decreases the self-stake of the validator of the given node.
+func (n *LocalNetwork) UndelegateStake(node driver.Node, amount *big.Int) error {
+	return n.changeStake(node, "undelegate from", func(backend network.ContractBackend, validatorId int) error {
+		return network.UndelegateStake(backend, validatorId, amount)
+	})
+}
+
+// DeactivateValidator undelegates the complete self-stake of the validator of
+// the given node. The node keeps running and follows the network.
+func (n *LocalNetwork) DeactivateValidator(node driver.Node) error {
+	return n.changeStake(node, "deactivate", network.DeactivateValidator)
+}
+
+// changeStake applies the given change to the stake of the validator of the
+// given node, sending the transaction through a random node of the network.
+func (n *LocalNetwork) changeStake(nd driver.Node, action string, change func(network.ContractBackend, int) error) error {
+	opera, ok := nd.(*node.OperaNode)
+	if !ok {
+		return fmt.Errorf("trying to %s non-sonic node", action)
+	}
+	validatorId := opera.GetValidatorId()
+	if validatorId == 0 {
+		return fmt.Errorf("failed to %s node %s; node is not a validator", action, nd.GetLabel())
+	}
+
+	rpcClient, err := n.DialRandomRpc()
+	if err != nil {
+		return fmt.Errorf("failed to connect to RPC; %w", err)
+	}
+	defer rpcClient.Close()
+
+	if err := change(rpcClient, validatorId); err != nil {
+		return fmt.Errorf("failed to %s validator %d of node %s; %w", action, validatorId, nd.GetLabel(), err)
+	}
+	return nil
+}
+
+// PauseNode pauses the given node and notifies listeners about the node going
+// down. The node is to be resumed using ResumeNode.
+func (n *LocalNetwork) PauseNode(node driver.Node) error {
+	n.notifyNodeState(node, driver.NodeStateListener.BeforeNodeDown)
+	if err := node.Pause(); err != nil {
+		n.notifyNodeState(node, driver.NodeStateListener.AfterNodeUp)
+		return fmt.Errorf("failed to pause node %s; %w", node.GetLabel(), err)
+	}
+	return nil
+}
+
+// ResumeNode resumes the given paused node and notifies listeners about the
+// node coming up again.
+func (n *LocalNetwork) ResumeNode(node driver.Node) error {
+	if err := node.Resume(); err != nil {
+		return fmt.Errorf("failed to resume node %s; %w", node.GetLabel(), err)
+	}
+	n.notifyNodeState(node, driver.NodeStateListener.AfterNodeUp)
+	return nil
+}
+
+// notifyNodeState calls the given notification on all registered listeners
+// interested in state changes of nodes.
+func (n *LocalNetwork) notifyNodeState(node driver.Node, notify func(driver.NodeStateListener, driver.Node)) {
+	n.listenerMutex.Lock()
+	defer n.listenerMutex.Unlock()
+	for listener := range n.listeners {
+		if listener, ok := listener.(driver.NodeStateListener); ok {
+			notify(listener, node)
+		}
+	}
+}
+
 func (n *LocalNetwork) SendTransaction(tx *types.Transaction) {
 	n.rpcWorkerPool.SendTransaction(tx)
 }
 
+func (n *LocalNetwork) SendTransactionAndReport(tx *types.Transaction, report func(error)) {
+	n.rpcWorkerPool.SendTransactionAndReport(tx, report)
+}
+
+// GetSendErrors returns the errors reported so far by each node when sending
+// transactions to it, indexed by node label.
+func (n *LocalNetwork) GetSendErrors() map[string]rpcdriver.SendErrors {
+	return n.rpcWorkerPool.GetSendErrors()
+}
+
 func (n *LocalNetwork) DialRandomRpc() (rpcdriver.Client, error) {
 	nodes := n.GetActiveNodes()
 	return nodes[rand.Intn(len(nodes))].DialRpc()
 }
 
+// DialRandomValidatorRpc connects to a random genesis validator of the
+// network.
+func (n *LocalNetwork) DialRandomValidatorRpc() (rpcdriver.Client, error) {
+	return n.dialRandomGenesisValidatorRpc()
+}
+
+// DialNodes connects to all active nodes of the given names, or to all active
+// nodes if no names are given. Nodes are named by their group, such that the
+// name "rpc" selects the nodes labeled "rpc-0", "rpc-1", and so on.
+func (n *LocalNetwork) DialNodes(names []string) ([]rpcdriver.Client, error) {
+	clients := []rpcdriver.Client{}
+	for _, node := range n.getActiveNodesOf(names) {
+		client, err := node.DialRpc()
+		if err != nil {
+			for _, client := range clients {
+				client.Close()
+			}
+			return nil, fmt.Errorf("failed to connect to node %s; %w", node.GetLabel(), err)
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// GetWebSocketUrls lists the WebSocket endpoints of all active nodes of the
+// given names, or of all active nodes if no names are given.
+func (n *LocalNetwork) GetWebSocketUrls(names []string) ([]string, error) {
+	urls := []string{}
+	for _, nd := range n.getActiveNodesOf(names) {
+		if url := nd.GetServiceUrl(&node.OperaWsService); url != nil {
+			urls = append(urls, string(*url))
+		}
+	}
+	return urls, nil
+}
+
+// getActiveNodesOf lists the active nodes of the given names, or all active
+// nodes if no names are given.
+func (n *LocalNetwork) getActiveNodesOf(names []string) []driver.Node {
+	nodes := n.GetActiveNodes()
+	if len(names) == 0 {
+		return nodes
+	}
+	return slices.DeleteFunc(nodes, func(node driver.Node) bool {
+		return !slices.ContainsFunc(names, func(name string) bool {
+			return driver.IsNodeOfGroup(node.GetLabel(), name)
+		})
+	})
+}
+
 func (n *LocalNetwork) ApplyNetworkRules(rules driver.NetworkRules) error {
 	client, err := n.DialRandomRpc()
 	if err != nil {
@@ -358,6 +730,50 @@ func (a *localApplication) GetReceivedTransactions() (uint64, error) {
 	return a.controller.GetReceivedTransactions()
 }
 
+func (a *localApplication) GetOperations() []string {
+	return a.controller.GetOperations()
+}
+
+func (a *localApplication) GetSentTransactionsOf(operation string, user int) (uint64, error) {
+	return a.controller.GetTransactionsOfOperationSentBy(operation, user)
+}
+
+func (a *localApplication) GetReceivedTransactionsOf(operation string) (uint64, error) {
+	return a.controller.GetReceivedTransactionsOf(operation)
+}
+
+func (a *localApplication) GetRequestMethods() []string {
+	return a.controller.GetRequestMethods()
+}
+
+func (a *localApplication) GetRequestStats(method string) (app.RequestStats, error) {
+	return a.controller.GetRequestStats(method)
+}
+
+func (a *localApplication) GetSubscriptionKinds() []string {
+	return a.controller.GetSubscriptionKinds()
+}
+
+func (a *localApplication) GetSubscriptionStats(kind string) (app.SubscriptionStats, error) {
+	return a.controller.GetSubscriptionStats(kind)
+}
+
+func (a *localApplication) GetNonceStats(user int) (app.NonceStats, error) {
+	return a.controller.GetNonceStats(user)
+}
+
+func (a *localApplication) GetSendErrors() rpcdriver.SendErrors {
+	return a.controller.GetSendErrors()
+}
+
+func (a *localApplication) GetEffectiveGasPrice() (uint64, error) {
+	return a.controller.GetEffectiveGasPrice()
+}
+
+func (a *localApplication) GetSustainableRate() (float64, bool) {
+	return a.controller.GetSustainableRate()
+}
+
 func (n *LocalNetwork) CreateApplication(config *driver.ApplicationConfig) (driver.Application, error) {
 	rpcClient, err := n.dialRandomGenesisValidatorRpc()
 	if err != nil {
@@ -366,7 +782,15 @@ func (n *LocalNetwork) CreateApplication(config *driver.ApplicationConfig) (driv
 	defer rpcClient.Close()
 
 	appId := n.nextAppId.Add(1)
-	application, err := app.NewApplication(config.Type, n.appContext, 0, appId)
+	appContext := n.appContext
+	if config.Accounts != nil {
+		pool, err := app.NewAccountPool(n.appContext, *config.Accounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create account pool; %w", err)
+		}
+		appContext = pool
+	}
+	application, err := app.NewApplication(config.Type, &config.Options, appContext, 0, appId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize on-chain app; %v", err)
 	}
@@ -376,7 +800,7 @@ func (n *LocalNetwork) CreateApplication(config *driver.ApplicationConfig) (driv
 		return nil, fmt.Errorf("failed to parse shaper; %v", err)
 	}
 
-	appController, err := controller.NewAppController(application, sh, config.Users, n.appContext, n)
+	appController, err := controller.NewAppController(application, sh, config, appContext, n)
 	if err != nil {
 		return nil, err
 	}
@@ -432,6 +856,19 @@ func (n *LocalNetwork) UnregisterListener(listener driver.NetworkListener) {
 }
 
 func (n *LocalNetwork) Shutdown() error {
+	return n.shutdown("")
+}
+
+// ShutdownAndSaveSnapshot shuts down the network like Shutdown. After stopping
+// the nodes, their data directories are saved as a snapshot to the given
+// directory, replacing any previous snapshot in it.
+func (n *LocalNetwork) ShutdownAndSaveSnapshot(dir string) error {
+	return n.shutdown(dir)
+}
+
+// shutdown stops all applications and nodes of the network, saving a
+// snapshot of the nodes to the given directory unless it is empty.
+func (n *LocalNetwork) shutdown(snapshotDir string) error {
 	var errs []error
 
 	// First stop all generators.
@@ -447,12 +884,28 @@ func (n *LocalNetwork) Shutdown() error {
 		n.appContext.Close()
 	}
 
-	// Second, shut down the nodes.
+	// Second, shut down the nodes, saving their state if requested. The
+	// manifest is created while the nodes are still running.
+	var manifest *snapshotManifest
+	if snapshotDir != "" {
+		var err error
+		manifest, err = n.createSnapshotManifest()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create snapshot; %w", err))
+		}
+	}
 	for _, node := range n.nodes {
 		// TODO: shutdown nodes in parallel.
 		if err := node.Stop(); err != nil {
 			errs = append(errs, err)
 		}
+	}
+	if manifest != nil {
+		if err := n.saveSnapshot(snapshotDir, manifest); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save snapshot; %w", err))
+		}
+	}
+	for _, node := range n.nodes {
 		if err := node.Cleanup(); err != nil {
 			errs = append(errs, err)
 		}
@@ -18,11 +18,13 @@ package local
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"github.com/0xsoniclabs/hyperion/driver/parser"
 	"math/big"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -363,6 +365,221 @@ func TestLocalNetwork_NotifiesListenersOnNodeStartup(t *testing.T) {
 
 }
 
+func TestLocalNetwork_IsNodeController(t *testing.T) {
+	var net LocalNetwork
+	var _ driver.NodeController = &net
+}
+
+// stateListener is a network listener interested in node state changes.
+type stateListener struct {
+	*driver.MockNetworkListener
+	*driver.MockNodeStateListener
+}
+
+func newStateListener(ctrl *gomock.Controller) stateListener {
+	return stateListener{
+		driver.NewMockNetworkListener(ctrl),
+		driver.NewMockNodeStateListener(ctrl),
+	}
+}
+
+func TestLocalNetwork_RestartNode_NotifiesListenersAboutDownAndUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := driver.NewMockNode(ctrl)
+	listener := newStateListener(ctrl)
+	net := &LocalNetwork{listeners: map[driver.NetworkListener]bool{}}
+	net.RegisterListener(listener)
+	net.RegisterListener(driver.NewMockNetworkListener(ctrl)) // < not notified
+
+	gomock.InOrder(
+		listener.MockNodeStateListener.EXPECT().BeforeNodeDown(node),
+		node.EXPECT().Restart(false),
+		listener.MockNodeStateListener.EXPECT().AfterNodeUp(node),
+	)
+
+	if err := net.RestartNode(node, false); err != nil {
+		t.Fatalf("failed to restart node: %v", err)
+	}
+}
+
+func TestLocalNetwork_RestartNode_FailingRestartIsReportedAndListenersAreNotified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := driver.NewMockNode(ctrl)
+	listener := newStateListener(ctrl)
+	net := &LocalNetwork{listeners: map[driver.NetworkListener]bool{}}
+	net.RegisterListener(listener)
+
+	injected := fmt.Errorf("injected error")
+	gomock.InOrder(
+		listener.MockNodeStateListener.EXPECT().BeforeNodeDown(node),
+		node.EXPECT().Restart(true).Return(injected),
+		listener.MockNodeStateListener.EXPECT().AfterNodeUp(node),
+	)
+	node.EXPECT().GetLabel().Return("A")
+
+	if err := net.RestartNode(node, true); !errors.Is(err, injected) {
+		t.Errorf("unexpected error, wanted %v, got %v", injected, err)
+	}
+}
+
+func TestLocalNetwork_IsNodeUpgrader(t *testing.T) {
+	var net LocalNetwork
+	var _ driver.NodeUpgrader = &net
+}
+
+func TestLocalNetwork_IsValidatorDialer(t *testing.T) {
+	var net LocalNetwork
+	var _ driver.ValidatorDialer = &net
+}
+
+func TestLocalNetwork_UpgradeNode_RejectsNonSonicNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := &LocalNetwork{listeners: map[driver.NetworkListener]bool{}}
+	if err := net.UpgradeNode(driver.NewMockNode(ctrl), "sonic"); err == nil {
+		t.Errorf("upgrade of non-sonic node should fail")
+	}
+}
+
+func TestLocalNetwork_UpgradeNode_RetainsLabelAndPeers(t *testing.T) {
+	t.Parallel()
+	config := driver.NetworkConfig{Validators: driver.NewDefaultValidators(2)}
+	net, err := NewLocalNetwork(&config)
+	if err != nil {
+		t.Fatalf("failed to create new local network: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = net.Shutdown()
+	})
+
+	ctrl := gomock.NewController(t)
+	listener := newStateListener(ctrl)
+	net.RegisterListener(listener)
+	validator := net.validators[0]
+	gomock.InOrder(
+		listener.MockNodeStateListener.EXPECT().BeforeNodeDown(validator),
+		listener.MockNodeStateListener.EXPECT().AfterNodeUp(validator),
+	)
+
+	if err := net.UpgradeNode(validator, driver.DefaultClientDockerImageName); err != nil {
+		t.Fatalf("failed to upgrade node: %v", err)
+	}
+	if got, want := validator.GetLabel(), "validator-0"; got != want {
+		t.Errorf("unexpected label after upgrade, wanted %v, got %v", want, got)
+	}
+	id, err := validator.GetNodeID()
+	if err != nil {
+		t.Fatalf("failed to get node id: %v", err)
+	}
+	net.nodesMutex.Lock()
+	defer net.nodesMutex.Unlock()
+	if net.nodes[id] != validator {
+		t.Errorf("upgraded node is not registered by its new id")
+	}
+	if got, want := len(net.peerings), 1; got != want {
+		t.Errorf("unexpected number of peerings, wanted %d, got %d", want, got)
+	}
+	for peering := range net.peerings {
+		if peering.from != id && peering.to != id {
+			t.Errorf("peering %v does not involve upgraded node", peering)
+		}
+	}
+}
+
+func TestLocalNetwork_IsStakeController(t *testing.T) {
+	var net LocalNetwork
+	var _ driver.StakeController = &net
+}
+
+func TestLocalNetwork_StakeChanges_RejectNonSonicNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	net := &LocalNetwork{}
+	if err := net.DelegateStake(driver.NewMockNode(ctrl), big.NewInt(1)); err == nil {
+		t.Errorf("delegating to non-sonic node should fail")
+	}
+	if err := net.UndelegateStake(driver.NewMockNode(ctrl), big.NewInt(1)); err == nil {
+		t.Errorf("undelegating from non-sonic node should fail")
+	}
+	if err := net.DeactivateValidator(driver.NewMockNode(ctrl)); err == nil {
+		t.Errorf("deactivating non-sonic node should fail")
+	}
+}
+
+func TestLocalNetwork_StakeChanges_RejectNonValidatorNodes(t *testing.T) {
+	net := &LocalNetwork{}
+	if err := net.DeactivateValidator(&node.OperaNode{}); err == nil || !strings.Contains(err.Error(), "not a validator") {
+		t.Errorf("deactivating non-validator node should fail, got %v", err)
+	}
+}
+
+func TestLocalNetwork_DeactivateValidator_WithdrawsSelfStake(t *testing.T) {
+	t.Parallel()
+	config := driver.NetworkConfig{Validators: driver.NewDefaultValidators(2)}
+	net, err := NewLocalNetwork(&config)
+	if err != nil {
+		t.Fatalf("failed to create new local network: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = net.Shutdown()
+	})
+
+	validator := net.validators[1]
+	oneToken := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	if err := net.DelegateStake(validator, oneToken); err != nil {
+		t.Fatalf("failed to delegate stake: %v", err)
+	}
+	if err := net.UndelegateStake(validator, oneToken); err != nil {
+		t.Fatalf("failed to undelegate stake: %v", err)
+	}
+	if err := net.DeactivateValidator(validator); err != nil {
+		t.Fatalf("failed to deactivate validator: %v", err)
+	}
+	if err := net.DeactivateValidator(validator); err == nil {
+		t.Errorf("deactivating validator without self-stake should fail")
+	}
+}
+
+func TestLocalNetwork_PauseAndResumeNode_NotifiesListenersAboutDownAndUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := driver.NewMockNode(ctrl)
+	listener := newStateListener(ctrl)
+	net := &LocalNetwork{listeners: map[driver.NetworkListener]bool{}}
+	net.RegisterListener(listener)
+
+	gomock.InOrder(
+		listener.MockNodeStateListener.EXPECT().BeforeNodeDown(node),
+		node.EXPECT().Pause(),
+		node.EXPECT().Resume(),
+		listener.MockNodeStateListener.EXPECT().AfterNodeUp(node),
+	)
+
+	if err := net.PauseNode(node); err != nil {
+		t.Fatalf("failed to pause node: %v", err)
+	}
+	if err := net.ResumeNode(node); err != nil {
+		t.Fatalf("failed to resume node: %v", err)
+	}
+}
+
+func TestLocalNetwork_PauseNode_FailingPauseBringsNodeUpAgain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	node := driver.NewMockNode(ctrl)
+	listener := newStateListener(ctrl)
+	net := &LocalNetwork{listeners: map[driver.NetworkListener]bool{}}
+	net.RegisterListener(listener)
+
+	injected := fmt.Errorf("injected error")
+	gomock.InOrder(
+		listener.MockNodeStateListener.EXPECT().BeforeNodeDown(node),
+		node.EXPECT().Pause().Return(injected),
+		listener.MockNodeStateListener.EXPECT().AfterNodeUp(node),
+	)
+	node.EXPECT().GetLabel().Return("A")
+
+	if err := net.PauseNode(node); !errors.Is(err, injected) {
+		t.Errorf("unexpected error, wanted %v, got %v", injected, err)
+	}
+}
+
 func TestLocalNetwork_NotifiesListenersOnAppStartup(t *testing.T) {
 	t.Parallel()
 	config := driver.NetworkConfig{Validators: driver.DefaultValidators}
@@ -451,6 +668,62 @@ func TestLocalNetwork_CanRemoveNode(t *testing.T) {
 	}
 }
 
+func TestLocalNetwork_EnforcesTopologyOfJoiningAndLeavingNodes(t *testing.T) {
+	t.Parallel()
+	config := driver.NetworkConfig{
+		Validators: driver.NewDefaultValidators(3),
+		Topology:   &parser.Topology{Type: "ring"},
+	}
+	net, err := NewLocalNetwork(&config)
+	if err != nil {
+		t.Fatalf("failed to create new local network: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = net.Shutdown()
+	})
+
+	// getPeerings lists the connections between nodes by their labels.
+	getPeerings := func() []string {
+		net.nodesMutex.Lock()
+		defer net.nodesMutex.Unlock()
+		res := []string{}
+		for peering := range net.peerings {
+			res = append(res, net.nodes[peering.from].GetLabel()+"->"+net.nodes[peering.to].GetLabel())
+		}
+		slices.Sort(res)
+		return res
+	}
+
+	want := []string{"validator-0->validator-1", "validator-0->validator-2", "validator-1->validator-2"}
+	if got := getPeerings(); !slices.Equal(got, want) {
+		t.Errorf("unexpected peerings, wanted %v, got %v", want, got)
+	}
+
+	node, err := net.CreateNode(&driver.NodeConfig{
+		Name:  "T",
+		Image: driver.DefaultClientDockerImageName,
+	})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = node.Stop()
+		_ = node.Cleanup()
+	})
+	want = []string{"validator-0->T", "validator-0->validator-1", "validator-1->validator-2", "validator-2->T"}
+	if got := getPeerings(); !slices.Equal(got, want) {
+		t.Errorf("unexpected peerings after node joined, wanted %v, got %v", want, got)
+	}
+
+	if err := net.RemoveNode(node); err != nil {
+		t.Fatalf("failed to remove node: %v", err)
+	}
+	want = []string{"validator-0->validator-1", "validator-0->validator-2", "validator-1->validator-2"}
+	if got := getPeerings(); !slices.Equal(got, want) {
+		t.Errorf("unexpected peerings after node left, wanted %v, got %v", want, got)
+	}
+}
+
 func TestLocalNetwork_Num_Validators_Started(t *testing.T) {
 	t.Parallel()
 	for i := 1; i < 3; i++ {
@@ -710,3 +983,92 @@ func TestLocalNetwork_MountDataDir_Can_Be_Reused(t *testing.T) {
 	}
 
 }
+
+func TestLocalNetwork_CanSaveAndRestoreSnapshots(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "snapshot")
+
+	config := driver.NetworkConfig{Validators: driver.NewDefaultValidators(2)}
+	net, err := NewLocalNetwork(&config)
+	if err != nil {
+		t.Fatalf("failed to create new local network: %v", err)
+	}
+	if _, err := net.CreateNode(&driver.NodeConfig{
+		Name:  "rpc",
+		Type:  driver.RpcNode,
+		Image: driver.DefaultClientDockerImageName,
+	}); err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if _, err := net.CreateNode(&driver.NodeConfig{
+		Name:      "late",
+		Type:      driver.ValidatorNode,
+		Validator: true,
+		Image:     driver.DefaultClientDockerImageName,
+	}); err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	if err := net.ShutdownAndSaveSnapshot(dir); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	manifest, err := readSnapshotManifest(dir)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	labels := []string{}
+	for _, node := range manifest.Nodes {
+		labels = append(labels, node.Label)
+		if _, err := os.Stat(filepath.Join(dir, node.Archive)); err != nil {
+			t.Errorf("missing archive of node %s: %v", node.Label, err)
+		}
+	}
+	if want := []string{"validator-0", "validator-1", "rpc", "late"}; !slices.Equal(labels, want) {
+		t.Errorf("unexpected nodes in snapshot, wanted %v, got %v", want, labels)
+	}
+	if got, want := manifest.getValidatorId("late"), 3; got != want {
+		t.Errorf("unexpected validator ID of mid-run validator, wanted %d, got %d", want, got)
+	}
+
+	// networks of a different genesis are rejected
+	config = driver.NetworkConfig{Validators: driver.NewDefaultValidators(3), RestoreSnapshot: dir}
+	if _, err := NewLocalNetwork(&config); err == nil || !strings.Contains(err.Error(), "inconsistent genesis") {
+		t.Errorf("expected inconsistent genesis to be reported, got %v", err)
+	}
+
+	// networks of the same genesis continue the chain
+	config = driver.NetworkConfig{Validators: driver.NewDefaultValidators(2), RestoreSnapshot: dir}
+	net, err = NewLocalNetwork(&config)
+	if err != nil {
+		t.Fatalf("failed to restore local network: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = net.Shutdown()
+	})
+	client, err := net.dialRandomGenesisValidatorRpc()
+	if err != nil {
+		t.Fatalf("failed to connect to network: %v", err)
+	}
+	defer client.Close()
+	_, height, err := getBlockHash(client, "latest")
+	if err != nil {
+		t.Fatalf("failed to get block height: %v", err)
+	}
+	if height < manifest.BlockHeight {
+		t.Errorf("restored network is at block %d, snapshot was taken at block %d", height, manifest.BlockHeight)
+	}
+
+	// validators registered during the saved run keep their validator ID
+	late, err := net.CreateNode(&driver.NodeConfig{
+		Name:      "late",
+		Type:      driver.ValidatorNode,
+		Validator: true,
+		Image:     driver.DefaultClientDockerImageName,
+	})
+	if err != nil {
+		t.Fatalf("failed to restore validator: %v", err)
+	}
+	if got, want := late.(*node.OperaNode).GetValidatorId(), 3; got != want {
+		t.Errorf("unexpected validator ID of restored validator, wanted %d, got %d", want, got)
+	}
+}
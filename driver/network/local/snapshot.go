@@ -0,0 +1,258 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/node"
+	rpcdriver "github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotManifestFile is the name of the file describing a snapshot within
+// the directory of the snapshot.
+const snapshotManifestFile = "manifest.yml"
+
+// snapshotManifest describes a snapshot of the data directories of the nodes
+// of a network. Besides locating the archives of the nodes, it records the
+// genesis of the network, which networks restored from the snapshot have to
+// reproduce for their nodes to be able to continue the chain.
+type snapshotManifest struct {
+	Created           time.Time           `yaml:"created"`
+	GenesisValidators []string            `yaml:"genesis_validators"` // < labels, ordered by validator ID
+	NetworkRules      driver.NetworkRules `yaml:"network_rules,omitempty"`
+	GenesisHash       string              `yaml:"genesis_hash"`
+	BlockHeight       uint64              `yaml:"block_height"` // < for information only
+	Nodes             []snapshotNode      `yaml:"nodes"`
+}
+
+// snapshotNode describes the archive of the data directory of a single node.
+type snapshotNode struct {
+	Label       string `yaml:"label"`
+	Image       string `yaml:"image"`
+	Archive     string `yaml:"archive"`                // < relative to the snapshot directory
+	ValidatorId int    `yaml:"validator_id,omitempty"` // < 0 if the node runs no validator
+}
+
+// readSnapshotManifest reads the manifest of the snapshot in the given
+// directory.
+func readSnapshotManifest(dir string) (*snapshotManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot manifest; %w", err)
+	}
+	res := &snapshotManifest{}
+	if err := yaml.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot manifest; %w", err)
+	}
+	return res, nil
+}
+
+// write stores the manifest in the given snapshot directory.
+func (m *snapshotManifest) write(dir string) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot manifest; %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, snapshotManifestFile), data, 0644)
+}
+
+// checkGenesis tests whether a network of the given configuration starts
+// from the same genesis as the network the snapshot was taken of. Validator
+// IDs are assigned in the order of the validators, such that each restored
+// validator continues with its own data directory.
+func (m *snapshotManifest) checkGenesis(config *driver.NetworkConfig) error {
+	labels := getGenesisValidatorLabels(config.Validators)
+	if got, want := len(labels), len(m.GenesisValidators); got != want {
+		return fmt.Errorf("inconsistent genesis: snapshot has %d genesis validators, network has %d", want, got)
+	}
+	if !slices.Equal(labels, m.GenesisValidators) {
+		return fmt.Errorf("inconsistent genesis: snapshot has genesis validators %v, network has %v", m.GenesisValidators, labels)
+	}
+	if !maps.Equal(config.NetworkRules, m.NetworkRules) {
+		return fmt.Errorf("inconsistent genesis: snapshot has network rules %v, network has %v", m.NetworkRules, config.NetworkRules)
+	}
+	return nil
+}
+
+// getArchive returns the path of the archive of the data directory of the
+// node with the given label, or an empty string if the node is not part of
+// the snapshot in the given directory.
+func (m *snapshotManifest) getArchive(dir, label string) string {
+	for _, node := range m.Nodes {
+		if node.Label == label {
+			return filepath.Join(dir, node.Archive)
+		}
+	}
+	return ""
+}
+
+// getValidatorId returns the ID of the validator run by the node with the
+// given label when the snapshot was taken, or 0 if the node ran no validator
+// or is not part of the snapshot.
+func (m *snapshotManifest) getValidatorId(label string) int {
+	for _, node := range m.Nodes {
+		if node.Label == label {
+			return node.ValidatorId
+		}
+	}
+	return 0
+}
+
+// getGenesisValidatorLabels lists the labels of the given validators in the
+// order of their validator IDs.
+func getGenesisValidatorLabels(validators driver.Validators) []string {
+	res := []string{}
+	for _, validator := range validators {
+		for i := 0; i < validator.Instances; i++ {
+			res = append(res, fmt.Sprintf("%s-%d", validator.Name, i))
+		}
+	}
+	return res
+}
+
+// getBlockHash obtains the hash and number of the given block, which is a
+// block number in hex or a tag like "latest".
+func getBlockHash(client rpcdriver.Client, block string) (common.Hash, uint64, error) {
+	var result struct {
+		Hash   common.Hash
+		Number hexutil.Uint64
+	}
+	if err := client.Call(&result, "eth_getBlockByNumber", block, false); err != nil {
+		return common.Hash{}, 0, fmt.Errorf("failed to get block %s; %w", block, err)
+	}
+	return result.Hash, uint64(result.Number), nil
+}
+
+// checkGenesisHash tests whether the given node has the genesis block of the
+// snapshot the network was restored from.
+func (n *LocalNetwork) checkGenesisHash(node *node.OperaNode) error {
+	client, err := node.DialRpc()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	hash, _, err := getBlockHash(client, "0x0")
+	if err != nil {
+		return err
+	}
+	if got, want := hash.Hex(), n.snapshot.GenesisHash; got != want {
+		return fmt.Errorf("inconsistent genesis: node %s has genesis block %s, snapshot has %s", node.GetLabel(), got, want)
+	}
+	return nil
+}
+
+// createSnapshotManifest describes a snapshot of the current nodes of the
+// network. It has to be called while the genesis validators are running.
+func (n *LocalNetwork) createSnapshotManifest() (*snapshotManifest, error) {
+	client, err := n.dialRandomGenesisValidatorRpc()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to network; %w", err)
+	}
+	defer client.Close()
+	genesis, _, err := getBlockHash(client, "0x0")
+	if err != nil {
+		return nil, err
+	}
+	_, height, err := getBlockHash(client, "latest")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &snapshotManifest{
+		Created:           time.Now().UTC(),
+		GenesisValidators: getGenesisValidatorLabels(n.config.Validators),
+		NetworkRules:      n.config.NetworkRules,
+		GenesisHash:       genesis.Hex(),
+		BlockHeight:       height,
+	}
+	for _, id := range n.order {
+		node := n.nodes[id]
+		res.Nodes = append(res.Nodes, snapshotNode{
+			Label:       node.GetLabel(),
+			Image:       node.GetSettings().Image,
+			Archive:     node.GetLabel() + ".tar.gz",
+			ValidatorId: node.GetValidatorId(),
+		})
+	}
+	return res, nil
+}
+
+// saveSnapshot exports the data directories of the nodes described by the
+// given manifest to the given directory, replacing any previous snapshot in
+// it. The nodes have to be stopped, but not cleaned up. The snapshot is
+// assembled in a temporary directory first, such that failed attempts leave
+// previous snapshots untouched.
+func (n *LocalNetwork) saveSnapshot(dir string, manifest *snapshotManifest) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory; %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot directory; %w", err)
+	}
+
+	for _, id := range n.order {
+		node := n.nodes[id]
+		archive := manifest.getArchive(tmp, node.GetLabel())
+		log.Printf("Saving datadir of node %s to snapshot ...", node.GetLabel())
+		if err := exportDataDir(node, archive); err != nil {
+			return errors.Join(err, os.RemoveAll(tmp))
+		}
+	}
+	if err := manifest.write(tmp); err != nil {
+		return errors.Join(err, os.RemoveAll(tmp))
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return errors.Join(fmt.Errorf("failed to remove previous snapshot; %w", err), os.RemoveAll(tmp))
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return errors.Join(fmt.Errorf("failed to move snapshot in place; %w", err), os.RemoveAll(tmp))
+	}
+	return nil
+}
+
+// exportDataDir writes a compressed archive of the data directory of the given
+// node to the given file.
+func exportDataDir(node *node.OperaNode, file string) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create archive of node %s; %w", node.GetLabel(), err)
+	}
+	zip := gzip.NewWriter(out)
+	if err := node.ExportDataDir(zip); err != nil {
+		return errors.Join(
+			fmt.Errorf("failed to export datadir of node %s; %w", node.GetLabel(), err),
+			zip.Close(),
+			out.Close(),
+		)
+	}
+	return errors.Join(zip.Close(), out.Close())
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLocalNetwork_IsSnapshotSaver(t *testing.T) {
+	var net LocalNetwork
+	var _ driver.SnapshotSaver = &net
+}
+
+func TestSnapshotManifest_CanBeWrittenAndRead(t *testing.T) {
+	dir := t.TempDir()
+	manifest := &snapshotManifest{
+		Created:           time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		GenesisValidators: []string{"validator-0", "validator-1"},
+		NetworkRules:      driver.NetworkRules{"MAX_BLOCK_GAS": "1000"},
+		GenesisHash:       common.Hash{1, 2, 3}.Hex(),
+		BlockHeight:       42,
+		Nodes: []snapshotNode{
+			{Label: "validator-0", Image: "sonic", Archive: "validator-0.tar.gz", ValidatorId: 1},
+			{Label: "late", Image: "sonic", Archive: "late.tar.gz", ValidatorId: 3},
+			{Label: "rpc", Image: "sonic:v2", Archive: "rpc.tar.gz"},
+		},
+	}
+	if err := manifest.write(dir); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	restored, err := readSnapshotManifest(dir)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	if !restored.Created.Equal(manifest.Created) {
+		t.Errorf("unexpected creation time, wanted %v, got %v", manifest.Created, restored.Created)
+	}
+	restored.Created = manifest.Created
+	if got, want := restored.GenesisHash, manifest.GenesisHash; got != want {
+		t.Errorf("unexpected genesis hash, wanted %v, got %v", want, got)
+	}
+	if got, want := restored.BlockHeight, manifest.BlockHeight; got != want {
+		t.Errorf("unexpected block height, wanted %v, got %v", want, got)
+	}
+	if got, want := restored.Nodes, manifest.Nodes; !slices.Equal(got, want) {
+		t.Errorf("unexpected nodes, wanted %v, got %v", want, got)
+	}
+	if err := restored.checkGenesis(&driver.NetworkConfig{
+		Validators:   driver.NewDefaultValidators(2),
+		NetworkRules: driver.NetworkRules{"MAX_BLOCK_GAS": "1000"},
+	}); err != nil {
+		t.Errorf("restored manifest should describe the same genesis: %v", err)
+	}
+}
+
+func TestSnapshotManifest_MissingManifestIsReported(t *testing.T) {
+	if _, err := readSnapshotManifest(t.TempDir()); err == nil || !strings.Contains(err.Error(), "failed to read snapshot manifest") {
+		t.Errorf("expected missing manifest to be reported, got %v", err)
+	}
+}
+
+func TestSnapshotManifest_CheckGenesis_DetectsInconsistencies(t *testing.T) {
+	manifest := &snapshotManifest{
+		GenesisValidators: []string{"validator-0", "validator-1"},
+	}
+	if err := manifest.checkGenesis(&driver.NetworkConfig{Validators: driver.NewDefaultValidators(2)}); err != nil {
+		t.Errorf("unexpected error for consistent genesis: %v", err)
+	}
+
+	tests := map[string]struct {
+		config driver.NetworkConfig
+		issue  string
+	}{
+		"more validators": {
+			driver.NetworkConfig{Validators: driver.NewDefaultValidators(3)},
+			"snapshot has 2 genesis validators, network has 3",
+		},
+		"renamed validators": {
+			driver.NetworkConfig{Validators: driver.Validators{
+				{Name: "validator", Instances: 1},
+				{Name: "weak", Instances: 1},
+			}},
+			"snapshot has genesis validators [validator-0 validator-1], network has [validator-0 weak-0]",
+		},
+		"different rules": {
+			driver.NetworkConfig{
+				Validators:   driver.NewDefaultValidators(2),
+				NetworkRules: driver.NetworkRules{"MAX_BLOCK_GAS": "1000"},
+			},
+			"snapshot has network rules",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := manifest.checkGenesis(&test.config); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestSnapshotManifest_GetArchive_LocatesArchivesOfNodes(t *testing.T) {
+	manifest := &snapshotManifest{
+		Nodes: []snapshotNode{{Label: "rpc", Archive: "rpc.tar.gz"}},
+	}
+	if got, want := manifest.getArchive("/snapshots/aged", "rpc"), "/snapshots/aged/rpc.tar.gz"; got != want {
+		t.Errorf("unexpected archive, wanted %v, got %v", want, got)
+	}
+	if got := manifest.getArchive("/snapshots/aged", "archive"); got != "" {
+		t.Errorf("nodes not included in the snapshot should have no archive, got %v", got)
+	}
+}
+
+func TestSnapshotManifest_GetValidatorId_LocatesValidatorsOfNodes(t *testing.T) {
+	manifest := &snapshotManifest{
+		Nodes: []snapshotNode{
+			{Label: "late", Archive: "late.tar.gz", ValidatorId: 3},
+			{Label: "rpc", Archive: "rpc.tar.gz"},
+		},
+	}
+	tests := map[string]int{"late": 3, "rpc": 0, "unknown": 0}
+	for label, want := range tests {
+		if got := manifest.getValidatorId(label); got != want {
+			t.Errorf("unexpected validator ID of %s, wanted %d, got %d", label, want, got)
+		}
+	}
+}
+
+func TestGetGenesisValidatorLabels_ListsValidatorsInOrderOfIds(t *testing.T) {
+	validators := driver.Validators{
+		{Name: "validator", Instances: 2},
+		{Name: "weak", Instances: 1},
+	}
+	want := []string{"validator-0", "validator-1", "weak-0"}
+	if got := getGenesisValidatorLabels(validators); !slices.Equal(got, want) {
+		t.Errorf("unexpected labels, wanted %v, got %v", want, got)
+	}
+}
+
+func TestGetBlockHash_ReturnsHashAndNumberOfBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	hash := common.Hash{1, 2, 3}
+	client.EXPECT().Call(gomock.Any(), "eth_getBlockByNumber", "latest", false).DoAndReturn(
+		func(result any, method string, args ...any) error {
+			block := result.(*struct {
+				Hash   common.Hash
+				Number hexutil.Uint64
+			})
+			block.Hash = hash
+			block.Number = 12
+			return nil
+		})
+
+	gotHash, gotNumber, err := getBlockHash(client, "latest")
+	if err != nil {
+		t.Fatalf("failed to get block hash: %v", err)
+	}
+	if gotHash != hash || gotNumber != 12 {
+		t.Errorf("unexpected block, wanted %v/%d, got %v/%d", hash, 12, gotHash, gotNumber)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/parser"
+)
+
+// topology defines which nodes of a network are connected as peers.
+type topology interface {
+	// getEdges lists the connections between the given nodes, which are
+	// identified by their labels and ordered by the time they joined the
+	// network. Changes of the nodes may change any edge, yet topologies are
+	// expected to keep the changes small.
+	getEdges(labels []string) []edge
+}
+
+// edge is a connection between two nodes, identified by their position in the
+// list of nodes. The earlier node, from, is the one establishing the connection.
+type edge struct {
+	from, to int // < from < to
+}
+
+// newTopology creates the topology defined by the given configuration, which
+// is expected to have passed its Check. Nil is interpreted as a full mesh.
+func newTopology(config *parser.Topology) topology {
+	if config == nil {
+		return fullTopology{}
+	}
+	switch strings.ToLower(config.Type) {
+	case "ring":
+		return ringTopology{}
+	case "line":
+		return lineTopology{}
+	case "star":
+		return starTopology{center: config.Center}
+	case "random":
+		k := 3
+		if config.K != nil {
+			k = *config.K
+		}
+		return randomTopology{k: k, seed: config.Seed}
+	case "custom":
+		return customTopology{peers: config.Peers}
+	}
+	return fullTopology{}
+}
+
+// fullTopology connects every node to every other node.
+type fullTopology struct{}
+
+func (fullTopology) getEdges(labels []string) []edge {
+	res := []edge{}
+	for to := range labels {
+		for from := 0; from < to; from++ {
+			res = append(res, edge{from, to})
+		}
+	}
+	return res
+}
+
+// lineTopology connects every node to its predecessor.
+type lineTopology struct{}
+
+func (lineTopology) getEdges(labels []string) []edge {
+	res := []edge{}
+	for to := 1; to < len(labels); to++ {
+		res = append(res, edge{to - 1, to})
+	}
+	return res
+}
+
+// ringTopology is a line connecting the last node to the first one.
+type ringTopology struct{}
+
+func (ringTopology) getEdges(labels []string) []edge {
+	res := lineTopology{}.getEdges(labels)
+	if len(labels) > 2 {
+		res = append(res, edge{0, len(labels) - 1})
+	}
+	return res
+}
+
+// starTopology connects every node to the center node, which is the first
+// node of the center group or, if there is no such node, the first node.
+type starTopology struct {
+	center string
+}
+
+func (t starTopology) getEdges(labels []string) []edge {
+	center := 0
+	if t.center != "" {
+		center = max(0, slices.IndexFunc(labels, func(label string) bool {
+			return driver.IsNodeOfGroup(label, t.center)
+		}))
+	}
+	res := []edge{}
+	for i := range labels {
+		if i < center {
+			res = append(res, edge{i, center})
+		} else if i > center {
+			res = append(res, edge{center, i})
+		}
+	}
+	return res
+}
+
+// randomTopology connects every node to k randomly selected earlier nodes,
+// such that the network is connected. The selection is derived from the labels
+// of the nodes and the seed, to keep it reproducible and to retain the peers
+// of nodes as long as their selected peers are part of the network.
+type randomTopology struct {
+	k    int
+	seed int64
+}
+
+func (t randomTopology) getEdges(labels []string) []edge {
+	res := []edge{}
+	for to := 1; to < len(labels); to++ {
+		// rendezvous hashing ranks the candidates of each node independently
+		candidates := make([]int, to)
+		scores := make([]uint64, to)
+		for from := range candidates {
+			candidates[from] = from
+			scores[from] = t.score(labels[to], labels[from])
+		}
+		slices.SortFunc(candidates, func(a, b int) int {
+			switch {
+			case scores[a] < scores[b]:
+				return -1
+			case scores[a] > scores[b]:
+				return 1
+			}
+			return a - b
+		})
+		for _, from := range candidates[:min(t.k, to)] {
+			res = append(res, edge{from, to})
+		}
+	}
+	return res
+}
+
+// score computes the rank of a candidate peer for a node.
+func (t randomTopology) score(node, candidate string) uint64 {
+	hash := fnv.New64a()
+	hash.Write(binary.BigEndian.AppendUint64(nil, uint64(t.seed)))
+	hash.Write([]byte(node))
+	hash.Write([]byte{0})
+	hash.Write([]byte(candidate))
+	return hash.Sum64()
+}
+
+// customTopology connects the nodes listed in an adjacency list. The list
+// refers to groups of nodes, connecting all instances of the respective groups.
+// Connections are symmetric, so listing them for one of the nodes suffices.
+type customTopology struct {
+	peers map[string][]string
+}
+
+func (t customTopology) getEdges(labels []string) []edge {
+	res := []edge{}
+	for to := range labels {
+		for from := 0; from < to; from++ {
+			if t.isConnected(labels[from], labels[to]) || t.isConnected(labels[to], labels[from]) {
+				res = append(res, edge{from, to})
+			}
+		}
+	}
+	return res
+}
+
+// isConnected checks whether the adjacency list connects node a to node b.
+func (t customTopology) isConnected(a, b string) bool {
+	for name, peers := range t.peers {
+		if !driver.IsNodeOfGroup(a, name) {
+			continue
+		}
+		if slices.ContainsFunc(peers, func(peer string) bool { return driver.IsNodeOfGroup(b, peer) }) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver/parser"
+)
+
+func TestTopology_GetEdges(t *testing.T) {
+	labels := []string{"validator-0", "validator-1", "validator-2", "rpc-0", "rpc-1"}
+	tests := map[string]struct {
+		config *parser.Topology
+		want   []edge
+	}{
+		"default": {nil, []edge{{0, 1}, {0, 2}, {1, 2}, {0, 3}, {1, 3}, {2, 3}, {0, 4}, {1, 4}, {2, 4}, {3, 4}}},
+		"full":    {&parser.Topology{Type: "full"}, []edge{{0, 1}, {0, 2}, {1, 2}, {0, 3}, {1, 3}, {2, 3}, {0, 4}, {1, 4}, {2, 4}, {3, 4}}},
+		"line":    {&parser.Topology{Type: "line"}, []edge{{0, 1}, {1, 2}, {2, 3}, {3, 4}}},
+		"ring":    {&parser.Topology{Type: "Ring"}, []edge{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {0, 4}}},
+		"star":    {&parser.Topology{Type: "star"}, []edge{{0, 1}, {0, 2}, {0, 3}, {0, 4}}},
+		"star with center": {
+			&parser.Topology{Type: "star", Center: "rpc"},
+			[]edge{{0, 3}, {1, 3}, {2, 3}, {3, 4}},
+		},
+		"custom": {
+			&parser.Topology{Type: "custom", Peers: map[string][]string{"validator": {"validator"}, "rpc-1": {"validator-2"}}},
+			[]edge{{0, 1}, {0, 2}, {1, 2}, {2, 4}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := newTopology(test.config).getEdges(labels)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("unexpected edges, wanted %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestTopology_SmallNetworksAreSupported(t *testing.T) {
+	for _, kind := range []string{"full", "line", "ring", "star", "random"} {
+		t.Run(kind, func(t *testing.T) {
+			topology := newTopology(&parser.Topology{Type: kind})
+			if got := topology.getEdges(nil); len(got) != 0 {
+				t.Errorf("unexpected edges in empty network: %v", got)
+			}
+			if got := topology.getEdges([]string{"A"}); len(got) != 0 {
+				t.Errorf("unexpected edges in network of a single node: %v", got)
+			}
+			if got, want := topology.getEdges([]string{"A", "B"}), []edge{{0, 1}}; !slices.Equal(got, want) {
+				t.Errorf("unexpected edges in network of two nodes, wanted %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestTopology_RandomConnectsEachNodeToKEarlierNodes(t *testing.T) {
+	labels := []string{}
+	for i := range 20 {
+		labels = append(labels, fmt.Sprintf("node-%d", i))
+	}
+	k := 3
+	edges := newTopology(&parser.Topology{Type: "random", K: &k, Seed: 7}).getEdges(labels)
+
+	peers := make([]int, len(labels))
+	for _, edge := range edges {
+		if edge.from >= edge.to {
+			t.Errorf("edge %v does not connect to an earlier node", edge)
+		}
+		peers[edge.to]++
+	}
+	for i, got := range peers {
+		if want := min(i, k); got != want {
+			t.Errorf("unexpected number of peers of node %d, wanted %d, got %d", i, want, got)
+		}
+	}
+}
+
+func TestTopology_RandomIsReproducibleAndStable(t *testing.T) {
+	labels := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	k := 2
+	topology := newTopology(&parser.Topology{Type: "random", K: &k, Seed: 1})
+	edges := topology.getEdges(labels)
+	if again := topology.getEdges(labels); !slices.Equal(edges, again) {
+		t.Errorf("edges are not reproducible, got %v and %v", edges, again)
+	}
+
+	// adding a node retains all existing edges
+	extended := topology.getEdges(append(slices.Clone(labels), "I"))
+	for _, edge := range edges {
+		if !slices.Contains(extended, edge) {
+			t.Errorf("edge %v lost when adding a node", edge)
+		}
+	}
+
+	other := newTopology(&parser.Topology{Type: "random", K: &k, Seed: 2})
+	if slices.Equal(edges, other.getEdges(labels)) {
+		t.Errorf("edges are independent of the seed")
+	}
+}
@@ -25,30 +25,59 @@ import (
 	"github.com/0xsoniclabs/hyperion/driver"
 	"github.com/0xsoniclabs/hyperion/driver/network"
 	"github.com/0xsoniclabs/hyperion/driver/node"
+	rpcdriver "github.com/0xsoniclabs/hyperion/driver/rpc"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type RpcWorkerPool struct {
-	txs     chan *types.Transaction
-	workers map[driver.Node]*workerGroup
-	ctx     context.Context
-	cancel  context.CancelFunc
+	txs         chan sendRequest
+	workers     map[driver.Node]*workerGroup
+	errors      map[string]*rpcdriver.SendErrorCounter // < send errors per node label
+	errorsMutex sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// sendRequest is a transaction to be sent by one of the workers of the pool.
+// If set, report is called with the result of the submission.
+type sendRequest struct {
+	tx     *types.Transaction
+	report func(error)
 }
 
 func NewRpcWorkerPool() *RpcWorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &RpcWorkerPool{
-		txs:     make(chan *types.Transaction),
+		txs:     make(chan sendRequest),
 		workers: make(map[driver.Node]*workerGroup, 10),
+		errors:  make(map[string]*rpcdriver.SendErrorCounter, 10),
 		ctx:     ctx,
 		cancel:  cancel,
 	}
 }
 
 func (p *RpcWorkerPool) SendTransaction(tx *types.Transaction) {
-	p.txs <- tx
+	p.txs <- sendRequest{tx: tx}
+}
+
+// SendTransactionAndReport sends the transaction using one of the workers,
+// which reports the result of the submission to the given function.
+func (p *RpcWorkerPool) SendTransactionAndReport(tx *types.Transaction, report func(error)) {
+	p.txs <- sendRequest{tx: tx, report: report}
+}
+
+// GetSendErrors returns the errors reported so far by each node the pool
+// sent transactions to, indexed by the label of the node.
+func (p *RpcWorkerPool) GetSendErrors() map[string]rpcdriver.SendErrors {
+	p.errorsMutex.Lock()
+	defer p.errorsMutex.Unlock()
+	res := make(map[string]rpcdriver.SendErrors, len(p.errors))
+	for label, counter := range p.errors {
+		res[label] = counter.Get()
+	}
+	return res
 }
 
 func (p *RpcWorkerPool) AfterNodeCreation(newNode driver.Node) {
@@ -60,10 +89,18 @@ func (p *RpcWorkerPool) AfterNodeCreation(newNode driver.Node) {
 	if rpcUrl == nil {
 		return
 	}
+	p.errorsMutex.Lock()
+	counter, found := p.errors[newNode.GetLabel()]
+	if !found {
+		counter = &rpcdriver.SendErrorCounter{}
+		p.errors[newNode.GetLabel()] = counter
+	}
+	p.errorsMutex.Unlock()
+
 	wg := workerGroup{}
 	p.workers[newNode] = &wg
 	for i := 0; i < 150; i++ {
-		wg.add(*rpcUrl, p.txs)
+		wg.add(*rpcUrl, p.txs, counter)
 	}
 }
 
@@ -95,8 +132,8 @@ func (p *RpcWorkerPool) Close() error {
 // When the group is closed, it should not be re-used and should be forgotten.
 type workerGroup []*worker
 
-func (wg *workerGroup) add(rpcUrl driver.URL, txs chan *types.Transaction) {
-	w := newWorker(rpcUrl, txs)
+func (wg *workerGroup) add(rpcUrl driver.URL, txs chan sendRequest, errors *rpcdriver.SendErrorCounter) {
+	w := newWorker(rpcUrl, txs, errors)
 	*wg = append(*wg, w)
 }
 
@@ -118,22 +155,25 @@ func (wg *workerGroup) close() {
 // The worker can be closed, and it stops listening and sending the transactions.
 // The worker is initialised (i.e. the RPC connection is established) before
 // it starts dispatching asynchronously. This process can be interrupted by
-// closing the worker before it starts dispatching.
+// closing the worker before it starts dispatching. Errors reported by the
+// client when sending transactions are counted by the given counter.
 type worker struct {
 	rpcUrl driver.URL
 	done   chan bool
-	txs    chan *types.Transaction
+	txs    chan sendRequest
+	errors *rpcdriver.SendErrorCounter
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
-func newWorker(rpcUrl driver.URL, txs chan *types.Transaction) *worker {
+func newWorker(rpcUrl driver.URL, txs chan sendRequest, errors *rpcdriver.SendErrorCounter) *worker {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	w := &worker{
 		rpcUrl: rpcUrl,
 		done:   make(chan bool),
 		txs:    txs,
+		errors: errors,
 		ctx:    ctx,
 		cancel: cancel,
 	}
@@ -172,10 +212,13 @@ func (p *worker) runRpcSenderLoop() error {
 	defer rpcClient.Close()
 	for {
 		select {
-		case tx := <-p.txs:
-			err := rpcClient.SendTransaction(context.Background(), tx)
+		case request := <-p.txs:
+			err := rpcClient.SendTransaction(context.Background(), request.tx)
 			if err != nil {
-				log.Printf("failed to send tx; %v", err)
+				p.errors.Count(err)
+			}
+			if request.report != nil {
+				request.report(err)
 			}
 		case <-p.ctx.Done():
 			return nil
@@ -17,20 +17,29 @@
 package rpc
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/node"
+	rpcdriver "github.com/0xsoniclabs/hyperion/driver/rpc"
 	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
 )
 
 func TestRetryRpcReturnGracefully(t *testing.T) {
 	t.Parallel()
 
 	start := time.Now()
-	txs := make(chan *types.Transaction)
-	w := newWorker("wrong", txs)
+	txs := make(chan sendRequest)
+	w := newWorker("wrong", txs, &rpcdriver.SendErrorCounter{})
 
 	time.Sleep(6 * time.Second)
 	w.close()
@@ -80,16 +89,84 @@ func TestClosePool(t *testing.T) {
 }
 
 func TestCloseWorkerStartStop(t *testing.T) {
-	txs := make(chan *types.Transaction)
-	w := newWorker("wrong", txs)
+	txs := make(chan sendRequest)
+	w := newWorker("wrong", txs, &rpcdriver.SendErrorCounter{})
 	w.close()
 }
 
 func TestCloseWorkerGroupStartStop(t *testing.T) {
-	txs := make(chan *types.Transaction)
+	txs := make(chan sendRequest)
 	wg := workerGroup{}
 	for i := 0; i < 150; i++ {
-		wg.add("wrong", txs)
+		wg.add("wrong", txs, &rpcdriver.SendErrorCounter{})
 	}
 	wg.close()
 }
+
+func TestWorker_SendErrorsAreCountedAndReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`)
+	}))
+	defer server.Close()
+
+	txs := make(chan sendRequest)
+	counter := &rpcdriver.SendErrorCounter{}
+	w := newWorker(driver.URL(server.URL), txs, counter)
+	defer w.close()
+
+	reported := make(chan error, 1)
+	txs <- sendRequest{
+		tx:     types.NewTx(&types.LegacyTx{}),
+		report: func(err error) { reported <- err },
+	}
+
+	select {
+	case err := <-reported:
+		if err == nil || rpcdriver.ClassifySendError(err) != rpcdriver.NonceError {
+			t.Errorf("unexpected reported error %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("result of submission was not reported")
+	}
+	want := rpcdriver.SendErrors{}
+	want[rpcdriver.NonceError] = 1
+	if got := counter.Get(); got != want {
+		t.Errorf("unexpected error counts, wanted %v, got %v", want, got)
+	}
+}
+
+func TestRpcWorkerPool_SendErrorsAreListedPerNode(t *testing.T) {
+	pool := NewRpcWorkerPool()
+	pool.errors["A"] = &rpcdriver.SendErrorCounter{}
+	pool.errors["A"].Count(errors.New("txpool is full"))
+	pool.errors["B"] = &rpcdriver.SendErrorCounter{}
+
+	got := pool.GetSendErrors()
+	if len(got) != 2 || got["A"][rpcdriver.PoolFullError] != 1 || got["B"].Total() != 0 {
+		t.Errorf("unexpected errors per node %v", got)
+	}
+}
+
+func TestRpcWorkerPool_NodesOfferingWebSocketServiceGetWorkers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	url := driver.URL("ws://wrong")
+	withWs := driver.NewMockNode(ctrl)
+	withWs.EXPECT().GetServiceUrl(&node.OperaWsService).Return(&url)
+	withWs.EXPECT().GetLabel().Return("A").AnyTimes()
+	withoutWs := driver.NewMockNode(ctrl)
+	withoutWs.EXPECT().GetServiceUrl(&node.OperaWsService).Return(nil)
+
+	pool := NewRpcWorkerPool()
+	pool.AfterNodeCreation(withWs)
+	pool.AfterNodeCreation(withoutWs)
+	defer pool.Close()
+
+	if workers, found := pool.workers[withWs]; !found || len(*workers) == 0 {
+		t.Errorf("node offering a WebSocket service did not get workers")
+	}
+	if _, found := pool.workers[withoutWs]; found {
+		t.Errorf("node without WebSocket service should not get workers")
+	}
+}
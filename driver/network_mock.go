@@ -5,10 +5,12 @@
 //
 //	mockgen -source network.go -destination network_mock.go -package driver
 //
+
 // Package driver is a generated GoMock package.
 package driver
 
 import (
+	big "math/big"
 	reflect "reflect"
 
 	rpc "github.com/0xsoniclabs/hyperion/driver/rpc"
@@ -20,6 +22,7 @@ import (
 type MockNetwork struct {
 	ctrl     *gomock.Controller
 	recorder *MockNetworkMockRecorder
+	isgomock struct{}
 }
 
 // MockNetworkMockRecorder is the mock recorder for MockNetwork.
@@ -190,10 +193,293 @@ func (mr *MockNetworkMockRecorder) UnregisterListener(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterListener", reflect.TypeOf((*MockNetwork)(nil).UnregisterListener), arg0)
 }
 
+// MockSendReporter is a mock of SendReporter interface.
+type MockSendReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSendReporterMockRecorder
+	isgomock struct{}
+}
+
+// MockSendReporterMockRecorder is the mock recorder for MockSendReporter.
+type MockSendReporterMockRecorder struct {
+	mock *MockSendReporter
+}
+
+// NewMockSendReporter creates a new mock instance.
+func NewMockSendReporter(ctrl *gomock.Controller) *MockSendReporter {
+	mock := &MockSendReporter{ctrl: ctrl}
+	mock.recorder = &MockSendReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSendReporter) EXPECT() *MockSendReporterMockRecorder {
+	return m.recorder
+}
+
+// SendTransactionAndReport mocks base method.
+func (m *MockSendReporter) SendTransactionAndReport(tx *types.Transaction, report func(error)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SendTransactionAndReport", tx, report)
+}
+
+// SendTransactionAndReport indicates an expected call of SendTransactionAndReport.
+func (mr *MockSendReporterMockRecorder) SendTransactionAndReport(tx, report any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendTransactionAndReport", reflect.TypeOf((*MockSendReporter)(nil).SendTransactionAndReport), tx, report)
+}
+
+// MockNodeSendErrorTracker is a mock of NodeSendErrorTracker interface.
+type MockNodeSendErrorTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodeSendErrorTrackerMockRecorder
+	isgomock struct{}
+}
+
+// MockNodeSendErrorTrackerMockRecorder is the mock recorder for MockNodeSendErrorTracker.
+type MockNodeSendErrorTrackerMockRecorder struct {
+	mock *MockNodeSendErrorTracker
+}
+
+// NewMockNodeSendErrorTracker creates a new mock instance.
+func NewMockNodeSendErrorTracker(ctrl *gomock.Controller) *MockNodeSendErrorTracker {
+	mock := &MockNodeSendErrorTracker{ctrl: ctrl}
+	mock.recorder = &MockNodeSendErrorTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNodeSendErrorTracker) EXPECT() *MockNodeSendErrorTrackerMockRecorder {
+	return m.recorder
+}
+
+// GetSendErrors mocks base method.
+func (m *MockNodeSendErrorTracker) GetSendErrors() map[string]rpc.SendErrors {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSendErrors")
+	ret0, _ := ret[0].(map[string]rpc.SendErrors)
+	return ret0
+}
+
+// GetSendErrors indicates an expected call of GetSendErrors.
+func (mr *MockNodeSendErrorTrackerMockRecorder) GetSendErrors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSendErrors", reflect.TypeOf((*MockNodeSendErrorTracker)(nil).GetSendErrors))
+}
+
+// MockNodeController is a mock of NodeController interface.
+type MockNodeController struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodeControllerMockRecorder
+	isgomock struct{}
+}
+
+// MockNodeControllerMockRecorder is the mock recorder for MockNodeController.
+type MockNodeControllerMockRecorder struct {
+	mock *MockNodeController
+}
+
+// NewMockNodeController creates a new mock instance.
+func NewMockNodeController(ctrl *gomock.Controller) *MockNodeController {
+	mock := &MockNodeController{ctrl: ctrl}
+	mock.recorder = &MockNodeControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNodeController) EXPECT() *MockNodeControllerMockRecorder {
+	return m.recorder
+}
+
+// PauseNode mocks base method.
+func (m *MockNodeController) PauseNode(arg0 Node) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseNode", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseNode indicates an expected call of PauseNode.
+func (mr *MockNodeControllerMockRecorder) PauseNode(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseNode", reflect.TypeOf((*MockNodeController)(nil).PauseNode), arg0)
+}
+
+// RestartNode mocks base method.
+func (m *MockNodeController) RestartNode(node Node, graceful bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestartNode", node, graceful)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestartNode indicates an expected call of RestartNode.
+func (mr *MockNodeControllerMockRecorder) RestartNode(node, graceful any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartNode", reflect.TypeOf((*MockNodeController)(nil).RestartNode), node, graceful)
+}
+
+// ResumeNode mocks base method.
+func (m *MockNodeController) ResumeNode(arg0 Node) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeNode", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResumeNode indicates an expected call of ResumeNode.
+func (mr *MockNodeControllerMockRecorder) ResumeNode(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeNode", reflect.TypeOf((*MockNodeController)(nil).ResumeNode), arg0)
+}
+
+// MockNodeUpgrader is a mock of NodeUpgrader interface.
+type MockNodeUpgrader struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodeUpgraderMockRecorder
+	isgomock struct{}
+}
+
+// MockNodeUpgraderMockRecorder is the mock recorder for MockNodeUpgrader.
+type MockNodeUpgraderMockRecorder struct {
+	mock *MockNodeUpgrader
+}
+
+// NewMockNodeUpgrader creates a new mock instance.
+func NewMockNodeUpgrader(ctrl *gomock.Controller) *MockNodeUpgrader {
+	mock := &MockNodeUpgrader{ctrl: ctrl}
+	mock.recorder = &MockNodeUpgraderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNodeUpgrader) EXPECT() *MockNodeUpgraderMockRecorder {
+	return m.recorder
+}
+
+// UpgradeNode mocks base method.
+func (m *MockNodeUpgrader) UpgradeNode(node Node, image string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpgradeNode", node, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpgradeNode indicates an expected call of UpgradeNode.
+func (mr *MockNodeUpgraderMockRecorder) UpgradeNode(node, image any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpgradeNode", reflect.TypeOf((*MockNodeUpgrader)(nil).UpgradeNode), node, image)
+}
+
+// MockStakeController is a mock of StakeController interface.
+type MockStakeController struct {
+	ctrl     *gomock.Controller
+	recorder *MockStakeControllerMockRecorder
+	isgomock struct{}
+}
+
+// MockStakeControllerMockRecorder is the mock recorder for MockStakeController.
+type MockStakeControllerMockRecorder struct {
+	mock *MockStakeController
+}
+
+// NewMockStakeController creates a new mock instance.
+func NewMockStakeController(ctrl *gomock.Controller) *MockStakeController {
+	mock := &MockStakeController{ctrl: ctrl}
+	mock.recorder = &MockStakeControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStakeController) EXPECT() *MockStakeControllerMockRecorder {
+	return m.recorder
+}
+
+// DeactivateValidator mocks base method.
+func (m *MockStakeController) DeactivateValidator(node Node) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateValidator", node)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivateValidator indicates an expected call of DeactivateValidator.
+func (mr *MockStakeControllerMockRecorder) DeactivateValidator(node any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateValidator", reflect.TypeOf((*MockStakeController)(nil).DeactivateValidator), node)
+}
+
+// DelegateStake mocks base method.
+func (m *MockStakeController) DelegateStake(node Node, amount *big.Int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelegateStake", node, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DelegateStake indicates an expected call of DelegateStake.
+func (mr *MockStakeControllerMockRecorder) DelegateStake(node, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelegateStake", reflect.TypeOf((*MockStakeController)(nil).DelegateStake), node, amount)
+}
+
+// UndelegateStake mocks base method.
+func (m *MockStakeController) UndelegateStake(node Node, amount *big.Int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndelegateStake", node, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UndelegateStake indicates an expected call of UndelegateStake.
+func (mr *MockStakeControllerMockRecorder) UndelegateStake(node, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndelegateStake", reflect.TypeOf((*MockStakeController)(nil).UndelegateStake), node, amount)
+}
+
+// MockSnapshotSaver is a mock of SnapshotSaver interface.
+type MockSnapshotSaver struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotSaverMockRecorder
+	isgomock struct{}
+}
+
+// MockSnapshotSaverMockRecorder is the mock recorder for MockSnapshotSaver.
+type MockSnapshotSaverMockRecorder struct {
+	mock *MockSnapshotSaver
+}
+
+// NewMockSnapshotSaver creates a new mock instance.
+func NewMockSnapshotSaver(ctrl *gomock.Controller) *MockSnapshotSaver {
+	mock := &MockSnapshotSaver{ctrl: ctrl}
+	mock.recorder = &MockSnapshotSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSnapshotSaver) EXPECT() *MockSnapshotSaverMockRecorder {
+	return m.recorder
+}
+
+// ShutdownAndSaveSnapshot mocks base method.
+func (m *MockSnapshotSaver) ShutdownAndSaveSnapshot(dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShutdownAndSaveSnapshot", dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ShutdownAndSaveSnapshot indicates an expected call of ShutdownAndSaveSnapshot.
+func (mr *MockSnapshotSaverMockRecorder) ShutdownAndSaveSnapshot(dir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShutdownAndSaveSnapshot", reflect.TypeOf((*MockSnapshotSaver)(nil).ShutdownAndSaveSnapshot), dir)
+}
+
 // MockNetworkListener is a mock of NetworkListener interface.
 type MockNetworkListener struct {
 	ctrl     *gomock.Controller
 	recorder *MockNetworkListenerMockRecorder
+	isgomock struct{}
 }
 
 // MockNetworkListenerMockRecorder is the mock recorder for MockNetworkListener.
@@ -248,3 +534,51 @@ func (mr *MockNetworkListenerMockRecorder) AfterNodeRemoval(arg0 any) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterNodeRemoval", reflect.TypeOf((*MockNetworkListener)(nil).AfterNodeRemoval), arg0)
 }
+
+// MockNodeStateListener is a mock of NodeStateListener interface.
+type MockNodeStateListener struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodeStateListenerMockRecorder
+	isgomock struct{}
+}
+
+// MockNodeStateListenerMockRecorder is the mock recorder for MockNodeStateListener.
+type MockNodeStateListenerMockRecorder struct {
+	mock *MockNodeStateListener
+}
+
+// NewMockNodeStateListener creates a new mock instance.
+func NewMockNodeStateListener(ctrl *gomock.Controller) *MockNodeStateListener {
+	mock := &MockNodeStateListener{ctrl: ctrl}
+	mock.recorder = &MockNodeStateListenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNodeStateListener) EXPECT() *MockNodeStateListenerMockRecorder {
+	return m.recorder
+}
+
+// AfterNodeUp mocks base method.
+func (m *MockNodeStateListener) AfterNodeUp(arg0 Node) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AfterNodeUp", arg0)
+}
+
+// AfterNodeUp indicates an expected call of AfterNodeUp.
+func (mr *MockNodeStateListenerMockRecorder) AfterNodeUp(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterNodeUp", reflect.TypeOf((*MockNodeStateListener)(nil).AfterNodeUp), arg0)
+}
+
+// BeforeNodeDown mocks base method.
+func (m *MockNodeStateListener) BeforeNodeDown(arg0 Node) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BeforeNodeDown", arg0)
+}
+
+// BeforeNodeDown indicates an expected call of BeforeNodeDown.
+func (mr *MockNodeStateListenerMockRecorder) BeforeNodeDown(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeforeNodeDown", reflect.TypeOf((*MockNodeStateListener)(nil).BeforeNodeDown), arg0)
+}
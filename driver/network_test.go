@@ -10,6 +10,10 @@ var one int = 1
 var two int = 2
 var three int = 3
 
+var half float32 = 0.5
+var memory = "1GB"
+var disk = "10MB"
+
 func TestNewValidator(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -64,6 +68,19 @@ func TestNewValidator(t *testing.T) {
 				ImageName: DefaultClientDockerImageName,
 			},
 		},
+		{
+			name: "Limited resources",
+			input: parser.Validator{
+				Name:      "validator1",
+				Resources: &parser.Resources{Cpus: &half, Memory: &memory, DiskIoBps: &disk},
+			},
+			expected: Validator{
+				Name:      "validator1",
+				Instances: 1,
+				ImageName: DefaultClientDockerImageName,
+				Resources: NodeResources{Cpus: 0.5, Memory: 1 << 30, DiskIoBps: 10_000_000},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -157,3 +174,9 @@ func TestGetNumValidators(t *testing.T) {
 		})
 	}
 }
+
+func TestNewNodeResources_NilIsUnlimited(t *testing.T) {
+	if got, want := NewNodeResources(nil), (NodeResources{}); got != want {
+		t.Errorf("unexpected resources: got %v, want %v", got, want)
+	}
+}
@@ -18,6 +18,8 @@ package driver
 
 import (
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/0xsoniclabs/hyperion/driver/network"
 	"github.com/0xsoniclabs/hyperion/driver/rpc"
@@ -33,6 +35,11 @@ type Node interface {
 	// to label data and should be unique within a single scenario run.
 	GetLabel() string
 
+	// GetStartBlockHeight returns the block height the node started from, which
+	// is 0 for nodes starting from genesis and the height of the snapshot for
+	// nodes restored from a snapshot.
+	GetStartBlockHeight() uint64
+
 	// IsExpectedFailure returns true if the node is supposed to fail during network execution.
 	IsExpectedFailure() bool
 
@@ -68,6 +75,18 @@ type Node interface {
 	// Kill shuts down this node disgracefully by using SigKill.
 	Kill() error
 
+	// Restart stops and starts this node again, gracefully using its regular
+	// shutdown procedure or, if graceful is false, by killing it. The data
+	// directory, the exported services, and the peers of the node are retained.
+	Restart(graceful bool) error
+
+	// Pause freezes all processes of this node without terminating them. While
+	// paused, the node does not respond to any requests.
+	Pause() error
+
+	// Resume continues the execution of a node frozen by Pause.
+	Resume() error
+
 	// Cleanup releases all underlying resources. After the cleanup no more
 	// operations on this node are expected to succeed.
 	Cleanup() error
@@ -80,3 +99,17 @@ type NodeID string
 
 // URL is a mere alias type for a string supposed to encode a URL.
 type URL string
+
+// IsNodeOfGroup checks whether the given label belongs to a node of the named
+// group of nodes, which are labeled "<name>-<instance>".
+func IsNodeOfGroup(label, name string) bool {
+	if label == name {
+		return true
+	}
+	instance, found := strings.CutPrefix(label, name+"-")
+	if !found {
+		return false
+	}
+	_, err := strconv.Atoi(instance)
+	return err == nil
+}
@@ -23,8 +23,11 @@ import (
 	"golang.org/x/exp/maps"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	rpcdriver "github.com/0xsoniclabs/hyperion/driver/rpc"
@@ -32,7 +35,9 @@ import (
 	"github.com/0xsoniclabs/hyperion/driver"
 	"github.com/0xsoniclabs/hyperion/driver/docker"
 	"github.com/0xsoniclabs/hyperion/driver/network"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/yaml.v3"
 )
 
 var OperaRpcService = network.ServiceDescription{
@@ -74,6 +79,27 @@ type OperaNode struct {
 	failing   bool
 	container *docker.Container
 	label     string
+	// validatorId is the ID of the validator run by the node, 0 if none.
+	validatorId int
+
+	// startBlockHeight is the block height of the data directory the client
+	// started from, 0 if it started from genesis.
+	startBlockHeight uint64
+
+	peersMutex sync.Mutex
+	peers      map[driver.NodeID]bool // < peers to re-connect to after restarts
+
+	settings ClientSettings
+}
+
+// ClientSettings summarizes the effective configuration of the client of a
+// node, as recorded in the output of runs.
+type ClientSettings struct {
+	Image       string               `yaml:"image"`
+	Type        driver.NodeType      `yaml:"type"`
+	Environment map[string]string    `yaml:"env"`
+	Args        []string             `yaml:"args,omitempty"`
+	Resources   driver.NodeResources `yaml:"resources"`
 }
 
 type OperaNodeConfig struct {
@@ -85,6 +111,13 @@ type OperaNodeConfig struct {
 	Image string
 	// The ID of the validator, nil if the node should not be a validator.
 	ValidatorId *int
+	// Type defines the role of the node, which determines the configuration
+	// of its client. If empty, it is derived from the ValidatorId.
+	Type driver.NodeType
+	// Env lists environment variables overriding the defaults of the client.
+	Env map[string]string
+	// Args lists command line flags passed to the client, without dashes.
+	Args map[string]string
 	// The configuration of the network the configured node should be part of.
 	NetworkConfig *driver.NetworkConfig
 	// ValidatorPubkey is nil if not a validator, else used as pubkey for the validator.
@@ -92,8 +125,17 @@ type OperaNodeConfig struct {
 	// MountDataDir is the directory where the node should store its state.
 	// Temporary location is used if nil.
 	MountDataDir *string
+	// Resources limits the resources available to the client.
+	Resources driver.NodeResources
+	// DataDirArchive is a tar archive of a datadir, as produced by
+	// ExportDataDir, to start the client from. A fresh datadir initialized
+	// with the genesis of the network is used if empty.
+	DataDirArchive string
 }
 
+// dataDir is the location of the state of the client in its container.
+const dataDir = "/datadir"
+
 // labelPattern restricts labels for nodes to non-empty alpha-numerical strings
 // with underscores and hyphens.
 var labelPattern = regexp.MustCompile("[A-Za-z0-9_-]+")
@@ -104,33 +146,44 @@ func StartOperaDockerNode(client *docker.Client, dn *docker.Network, config *Ope
 		return nil, fmt.Errorf("invalid label for node: '%v'", config.Label)
 	}
 
+	profile, err := getClientProfile(config)
+	if err != nil {
+		return nil, err
+	}
+
 	shutdownTimeout := 180 * time.Second
 
-	validatorId := "0"
+	validatorId := 0
 	if config.ValidatorId != nil {
-		validatorId = fmt.Sprintf("%d", *config.ValidatorId)
+		validatorId = *config.ValidatorId
+	}
+
+	envs := map[string]string{
+		"VALIDATOR_ID":     fmt.Sprintf("%d", validatorId),
+		"VALIDATORS_COUNT": fmt.Sprintf("%d", config.NetworkConfig.Validators.GetNumValidators()),
+		"NETWORK_LATENCY":  fmt.Sprintf("%v", config.NetworkConfig.RoundTripTime/2),
+		"STATE_DB_DATADIR": dataDir,
+	}
+	maps.Copy(envs, profile.environment())             // configure the client for the node type
+	maps.Copy(envs, config.NetworkConfig.NetworkRules) // put in the network rules
+	maps.Copy(envs, config.Env)                        // apply overrides of the scenario
+	args := renderClientArgs(config.Args)
+	if len(args) > 0 {
+		envs["CLIENT_ARGS"] = strings.Join(args, " ")
 	}
 
 	host, err := network.RetryReturn(network.DefaultRetryAttempts, 1*time.Second, func() (*docker.Container, error) {
-		ports, err := network.GetFreePorts(len(operaServices.Services()))
+		services := profile.services()
+		ports, err := network.GetFreePorts(len(services))
 		if err != nil {
 			return nil, err
 		}
 
 		portForwarding := make(map[network.Port]network.Port, len(ports))
-		for i, service := range operaServices.Services() {
+		for i, service := range services {
 			portForwarding[service.Port] = ports[i]
 		}
 
-		envs := map[string]string{
-			"VALIDATOR_ID":     validatorId,
-			"VALIDATORS_COUNT": fmt.Sprintf("%d", config.NetworkConfig.Validators.GetNumValidators()),
-			"NETWORK_LATENCY":  fmt.Sprintf("%v", config.NetworkConfig.RoundTripTime/2),
-		}
-
-		const dataDir = "/datadir"
-		envs["STATE_DB_DATADIR"] = dataDir
-
 		// when configured, mount the datadir to the host
 		var dataDirBinding *string
 		if config.MountDataDir != nil {
@@ -142,8 +195,6 @@ func StartOperaDockerNode(client *docker.Client, dn *docker.Network, config *Ope
 			*dataDirBinding = fmt.Sprintf("%s:%s", *config.MountDataDir, dataDir)
 		}
 
-		maps.Copy(envs, config.NetworkConfig.NetworkRules) // put in the network rules
-
 		return client.Start(&docker.ContainerConfig{
 			ImageName:       config.Image,
 			ShutdownTimeout: &shutdownTimeout,
@@ -151,6 +202,10 @@ func StartOperaDockerNode(client *docker.Client, dn *docker.Network, config *Ope
 			Environment:     envs,
 			Network:         dn,
 			DataDirBinding:  dataDirBinding,
+			Cpus:            config.Resources.Cpus,
+			Memory:          config.Resources.Memory,
+			DiskIoBps:       config.Resources.DiskIoBps,
+			InitialFiles:    config.DataDirArchive,
 		})
 	})
 
@@ -158,28 +213,85 @@ func StartOperaDockerNode(client *docker.Client, dn *docker.Network, config *Ope
 		return nil, err
 	}
 	node := &OperaNode{
-		host:      host,
-		failing:   config.Failing,
-		container: host,
-		label:     config.Label,
+		host:        host,
+		failing:     config.Failing,
+		container:   host,
+		label:       config.Label,
+		validatorId: validatorId,
+		peers:       map[driver.NodeID]bool{},
+		settings: ClientSettings{
+			Image:       config.Image,
+			Type:        getNodeType(config),
+			Environment: envs,
+			Args:        args,
+			Resources:   config.Resources,
+		},
 	}
 
 	// Wait until the OperaNode inside the Container is ready.
 	if err := network.Retry(network.DefaultRetryAttempts, 1*time.Second, func() error {
 		_, err := node.GetNodeID()
 		return err
-	}); err == nil {
-		return node, nil
+	}); err != nil {
+		// The node did not show up in time, so we consider the start to have failed.
+		return nil, errors.Join(fmt.Errorf("failed to get node online"), node.host.Cleanup())
 	}
 
-	// The node did not show up in time, so we consider the start to have failed.
-	return nil, errors.Join(fmt.Errorf("failed to get node online"), node.host.Cleanup())
+	// Nodes restored from an archive continue from the block height of the
+	// archive, which is recorded before the node gets connected to any peers.
+	if config.DataDirArchive != "" {
+		height, err := node.getBlockHeight()
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to get block height of restored node; %w", err), node.host.Cleanup())
+		}
+		node.startBlockHeight = height
+	}
+	return node, nil
+}
+
+// getBlockHeight obtains the latest block height of the client of the node.
+func (n *OperaNode) getBlockHeight() (uint64, error) {
+	client, err := n.DialRpc()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	var height hexutil.Uint64
+	if err := client.Call(&height, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(height), nil
+}
+
+// GetSettings returns the effective configuration of the client of the node.
+func (n *OperaNode) GetSettings() ClientSettings {
+	return n.settings
+}
+
+// SaveSettingsTo writes the effective configuration of the client of the node
+// to a file named after the node's label in the given directory.
+func (n *OperaNode) SaveSettingsTo(directory string) error {
+	data, err := yaml.Marshal(n.settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings of node %s; %w", n.label, err)
+	}
+	return os.WriteFile(filepath.Join(directory, n.label+".yml"), data, 0644)
+}
+
+// GetValidatorId returns the ID of the validator run by the node, which is 0
+// if the node is not a validator.
+func (n *OperaNode) GetValidatorId() int {
+	return n.validatorId
 }
 
 func (n *OperaNode) GetLabel() string {
 	return n.label
 }
 
+func (n *OperaNode) GetStartBlockHeight() uint64 {
+	return n.startBlockHeight
+}
+
 func (n *OperaNode) IsExpectedFailure() bool {
 	return n.failing
 }
@@ -236,6 +348,12 @@ func (n *OperaNode) Stop() error {
 	return n.host.Stop()
 }
 
+// ExportDataDir writes a tar archive of the datadir of the client to the given
+// writer. To obtain a consistent state, the node should be stopped before.
+func (n *OperaNode) ExportDataDir(out io.Writer) error {
+	return n.container.CopyFrom(dataDir, out)
+}
+
 func (n *OperaNode) Cleanup() error {
 	return n.host.Cleanup()
 }
@@ -258,6 +376,13 @@ func (n *OperaNode) DialRpc() (rpcdriver.Client, error) {
 // AddPeer informs the client instance represented by the OperaNode about the
 // existence of another node, to which it may establish a connection.
 func (n *OperaNode) AddPeer(id driver.NodeID) error {
+	n.peersMutex.Lock()
+	n.peers[id] = true
+	n.peersMutex.Unlock()
+	return n.addPeer(id)
+}
+
+func (n *OperaNode) addPeer(id driver.NodeID) error {
 	rpcClient, err := n.DialRpc()
 	if err != nil {
 		return err
@@ -270,6 +395,9 @@ func (n *OperaNode) AddPeer(id driver.NodeID) error {
 // RemovePeer informs the client instance represented by the OperaNode
 // that the input node is no more available in the network.
 func (n *OperaNode) RemovePeer(id driver.NodeID) error {
+	n.peersMutex.Lock()
+	delete(n.peers, id)
+	n.peersMutex.Unlock()
 	rpcClient, err := n.DialRpc()
 	if err != nil {
 		return err
@@ -284,6 +412,70 @@ func (n *OperaNode) Kill() error {
 	return n.container.SendSignal(docker.SigKill)
 }
 
+// Restart restarts the client of this node, retaining its data directory and
+// port mappings. Since peers added to the client are not persisted, they are
+// added again once the restarted client is online.
+func (n *OperaNode) Restart(graceful bool) error {
+	if err := n.container.Restart(graceful); err != nil {
+		return err
+	}
+	if err := network.Retry(network.DefaultRetryAttempts, 1*time.Second, func() error {
+		_, err := n.GetNodeID()
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to get node %s online after restart; %w", n.label, err)
+	}
+	return n.addPeers()
+}
+
+// addPeers adds all peers of this node to its client again, e.g. after the
+// client was restarted.
+func (n *OperaNode) addPeers() error {
+	n.peersMutex.Lock()
+	peers := maps.Keys(n.peers)
+	n.peersMutex.Unlock()
+	var errs []error
+	for _, id := range peers {
+		if err := n.addPeer(id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to re-add peer; %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Upgrade replaces the client of this node by the client of the given image.
+// The client is stopped gracefully and the new client continues with its data
+// directory and port mappings. Like after restarts, peers are added again once
+// the new client is online.
+func (n *OperaNode) Upgrade(image string) error {
+	if err := n.container.Upgrade(image, dataDir); err != nil {
+		return err
+	}
+	n.settings.Image = image
+	if err := network.Retry(network.DefaultRetryAttempts, 1*time.Second, func() error {
+		_, err := n.GetNodeID()
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to get node %s online after upgrade; %w", n.label, err)
+	}
+	return n.addPeers()
+}
+
+// Pause freezes the client of this node.
+func (n *OperaNode) Pause() error {
+	return n.container.Pause()
+}
+
+// Resume continues the client of this node after it was paused.
+func (n *OperaNode) Resume() error {
+	return n.container.Resume()
+}
+
+// GetContainerStats returns the resources consumed by the container of this node.
+func (n *OperaNode) GetContainerStats() (docker.ContainerStats, error) {
+	return n.container.GetStats()
+}
+
 // GetRoundTripTime returns the median network round-trip time to the given host.
 func (n *OperaNode) GetRoundTripTime(host string) (time.Duration, error) {
 	output, err := n.container.Exec([]string{"ping", "-c", "5", host})
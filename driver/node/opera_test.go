@@ -246,3 +246,82 @@ func TestClient_Stop_Graceful(t *testing.T) {
 		t.Errorf("container did not stop gracefully")
 	}
 }
+
+func TestOperaNode_RestartRetainsNodeIdAndServices(t *testing.T) {
+	for _, graceful := range []bool{true, false} {
+		t.Run(fmt.Sprintf("graceful=%t", graceful), func(t *testing.T) {
+			client, err := docker.NewClient()
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			t.Cleanup(func() {
+				_ = client.Close()
+			})
+
+			node, err := StartOperaDockerNode(client, nil, &OperaNodeConfig{
+				Label:         "test",
+				Image:         driver.DefaultClientDockerImageName,
+				NetworkConfig: &driver.NetworkConfig{Validators: driver.DefaultValidators},
+			})
+			if err != nil {
+				t.Fatalf("failed to create client node: %v", err)
+			}
+			t.Cleanup(func() {
+				_ = node.Cleanup()
+			})
+
+			before, err := node.GetNodeID()
+			if err != nil {
+				t.Fatalf("failed to get node id: %v", err)
+			}
+			urlBefore := node.GetServiceUrl(&OperaRpcService)
+
+			if err := node.Restart(graceful); err != nil {
+				t.Fatalf("failed to restart node: %v", err)
+			}
+
+			after, err := node.GetNodeID()
+			if err != nil {
+				t.Fatalf("failed to get node id after restart: %v", err)
+			}
+			if before != after {
+				t.Errorf("node id changed by restart, before %v, after %v", before, after)
+			}
+			if urlAfter := node.GetServiceUrl(&OperaRpcService); *urlBefore != *urlAfter {
+				t.Errorf("rpc service moved by restart, before %v, after %v", *urlBefore, *urlAfter)
+			}
+		})
+	}
+}
+
+func TestOperaNode_PausedNodeCanBeResumed(t *testing.T) {
+	client, err := docker.NewClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	node, err := StartOperaDockerNode(client, nil, &OperaNodeConfig{
+		Label:         "test",
+		Image:         driver.DefaultClientDockerImageName,
+		NetworkConfig: &driver.NetworkConfig{Validators: driver.DefaultValidators},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client node: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = node.Cleanup()
+	})
+
+	if err := node.Pause(); err != nil {
+		t.Fatalf("failed to pause node: %v", err)
+	}
+	if err := node.Resume(); err != nil {
+		t.Fatalf("failed to resume node: %v", err)
+	}
+	if _, err := node.GetNodeID(); err != nil {
+		t.Errorf("resumed node does not respond: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/network"
+)
+
+// clientProfile summarizes the configuration of the client of a node, which
+// depends on the type of the node. It is passed to the client's container
+// through environment variables evaluated by the start script of the image.
+type clientProfile struct {
+	httpApis []string // < APIs offered through the HTTP service
+	wsApis   []string // < APIs offered through the WebSocket service, disabled if empty
+	archive  bool     // < whether historic states are retained
+
+	// Capacity of the transaction pool, client defaults are used if zero.
+	txPoolGlobalSlots int
+	txPoolGlobalQueue int
+}
+
+// clientProfiles defines the client configuration of each node type. All
+// nodes offer the admin and eth APIs used to connect and monitor them. Nodes
+// with a WebSocket service receive the transactions of the applications.
+//
+// Only archive nodes are meant to answer queries on historic states, such as
+// debug_traceTransaction or eth_call on past blocks. Rpc nodes run on pruned
+// state, which only covers the head of the chain, so scenarios should direct
+// such requests at archive nodes.
+var clientProfiles = map[driver.NodeType]clientProfile{
+	// Validators and observers retain the full history, which is the mode all
+	// nodes were run in before node types were introduced. Scenarios and their
+	// evaluation rely on querying any of these nodes for historic states.
+	// Validators offer the txpool API observed by feedback loops of shapers.
+	driver.ValidatorNode: {
+		httpApis: []string{"admin", "eth", "ftm", "debug", "net", "web3", "txpool"},
+		wsApis:   []string{"admin", "eth", "ftm"},
+		archive:  true,
+	},
+	// Observers are the default type of nodes, which receive the load of the
+	// applications through their WebSocket service.
+	driver.ObserverNode: {
+		httpApis: []string{"admin", "eth", "ftm", "net", "web3"},
+		wsApis:   []string{"admin", "eth", "ftm"},
+		archive:  true,
+	},
+	// Rpc nodes serve reads on the current state, subscriptions, and large
+	// numbers of pending transactions, like public RPC endpoints.
+	driver.RpcNode: {
+		httpApis:          []string{"admin", "eth", "ftm", "net", "web3", "txpool"},
+		wsApis:            []string{"admin", "eth", "ftm", "net", "web3", "txpool"},
+		txPoolGlobalSlots: 20_000,
+		txPoolGlobalQueue: 10_000,
+	},
+	// Archive nodes serve reads on any state, including traces of transactions,
+	// through their HTTP service. They offer no WebSocket service, such that
+	// they receive no load besides the reads directed at them.
+	driver.ArchiveNode: {
+		httpApis: []string{"admin", "eth", "ftm", "debug", "net", "web3"},
+		archive:  true,
+	},
+}
+
+// getClientProfile obtains the client configuration of the node of the given
+// configuration.
+func getClientProfile(config *OperaNodeConfig) (clientProfile, error) {
+	nodeType := getNodeType(config)
+	profile, found := clientProfiles[nodeType]
+	if !found {
+		return clientProfile{}, fmt.Errorf("unknown node type '%s'", nodeType)
+	}
+	return profile, nil
+}
+
+// getNodeType obtains the type of the node of the given configuration, which
+// defaults to validator for nodes with a validator ID and observer otherwise.
+func getNodeType(config *OperaNodeConfig) driver.NodeType {
+	if config.Type != "" {
+		return config.Type
+	}
+	if config.ValidatorId != nil && *config.ValidatorId != 0 {
+		return driver.ValidatorNode
+	}
+	return driver.ObserverNode
+}
+
+// renderClientArgs converts the given flags into sorted command line
+// arguments of the form --name=value, or --name for empty values.
+func renderClientArgs(args map[string]string) []string {
+	res := make([]string, 0, len(args))
+	for name, value := range args {
+		if value == "" {
+			res = append(res, "--"+name)
+		} else {
+			res = append(res, fmt.Sprintf("--%s=%s", name, value))
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// environment lists the environment variables configuring the client.
+func (p clientProfile) environment() map[string]string {
+	res := map[string]string{
+		"HTTP_API": strings.Join(p.httpApis, ","),
+		"WS_API":   strings.Join(p.wsApis, ","),
+		"ARCHIVE":  fmt.Sprintf("%t", p.archive),
+	}
+	if p.txPoolGlobalSlots > 0 {
+		res["TXPOOL_GLOBAL_SLOTS"] = fmt.Sprintf("%d", p.txPoolGlobalSlots)
+	}
+	if p.txPoolGlobalQueue > 0 {
+		res["TXPOOL_GLOBAL_QUEUE"] = fmt.Sprintf("%d", p.txPoolGlobalQueue)
+	}
+	return res
+}
+
+// services lists the services exported by the client.
+func (p clientProfile) services() []*network.ServiceDescription {
+	res := []*network.ServiceDescription{}
+	for _, service := range operaServices.Services() {
+		if service == &OperaWsService && len(p.wsApis) == 0 {
+			continue
+		}
+		res = append(res, service)
+	}
+	return res
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"gopkg.in/yaml.v3"
+)
+
+func TestClientProfile_TypeIsDerivedFromValidatorIdIfMissing(t *testing.T) {
+	zero, one := 0, 1
+	tests := map[string]struct {
+		validatorId *int
+		want        clientProfile
+	}{
+		"no id":       {nil, clientProfiles[driver.ObserverNode]},
+		"zero id":     {&zero, clientProfiles[driver.ObserverNode]},
+		"positive id": {&one, clientProfiles[driver.ValidatorNode]},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := getClientProfile(&OperaNodeConfig{ValidatorId: test.validatorId})
+			if err != nil {
+				t.Fatalf("failed to get profile: %v", err)
+			}
+			if !slices.Equal(got.httpApis, test.want.httpApis) || got.archive != test.want.archive {
+				t.Errorf("unexpected profile, wanted %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestClientProfile_UnknownTypeIsRejected(t *testing.T) {
+	_, err := getClientProfile(&OperaNodeConfig{Type: "light"})
+	if err == nil || !strings.Contains(err.Error(), "unknown node type") {
+		t.Errorf("unknown node type was not detected, got %v", err)
+	}
+}
+
+func TestClientProfile_NodeTypesAreConfiguredDifferently(t *testing.T) {
+	get := func(nodeType driver.NodeType) map[string]string {
+		t.Helper()
+		profile, err := getClientProfile(&OperaNodeConfig{Type: nodeType})
+		if err != nil {
+			t.Fatalf("failed to get profile: %v", err)
+		}
+		return profile.environment()
+	}
+	apis := func(env map[string]string, service string) []string {
+		return strings.Split(env[service], ",")
+	}
+
+	validator := get(driver.ValidatorNode)
+	observer := get(driver.ObserverNode)
+	rpc := get(driver.RpcNode)
+	archive := get(driver.ArchiveNode)
+
+	all := map[string]map[string]string{"validator": validator, "observer": observer, "rpc": rpc, "archive": archive}
+	for name, env := range all {
+		if !slices.Contains(apis(env, "HTTP_API"), "admin") || !slices.Contains(apis(env, "HTTP_API"), "eth") {
+			t.Errorf("node control and monitoring APIs of %s nodes are missing, got %v", name, env["HTTP_API"])
+		}
+		for other, otherEnv := range all {
+			if name != other && env["HTTP_API"] == otherEnv["HTTP_API"] {
+				t.Errorf("%s and %s nodes offer the same APIs %v", name, other, env["HTTP_API"])
+			}
+		}
+	}
+
+	// traces are only offered by nodes retaining the history
+	if slices.Contains(apis(rpc, "HTTP_API"), "debug") || slices.Contains(apis(observer, "HTTP_API"), "debug") {
+		t.Errorf("rpc and observer nodes should not offer the debug API")
+	}
+	if !slices.Contains(apis(archive, "HTTP_API"), "debug") {
+		t.Errorf("archive nodes should offer the debug API, got %v", archive["HTTP_API"])
+	}
+
+	// the txpool API is offered by validators observed by feedback loops
+	if !slices.Contains(apis(validator, "HTTP_API"), "txpool") || !slices.Contains(apis(rpc, "HTTP_API"), "txpool") {
+		t.Errorf("validator and rpc nodes should offer the txpool API")
+	}
+	if slices.Contains(apis(observer, "HTTP_API"), "txpool") || slices.Contains(apis(archive, "HTTP_API"), "txpool") {
+		t.Errorf("observer and archive nodes should not offer the txpool API")
+	}
+
+	// load is received through WebSocket services, which archives do not offer
+	if validator["WS_API"] == "" || observer["WS_API"] == "" || rpc["WS_API"] == "" {
+		t.Errorf("validator, observer and rpc nodes should offer a WebSocket service")
+	}
+	if archive["WS_API"] != "" {
+		t.Errorf("archive nodes should not offer a WebSocket service, got %v", archive["WS_API"])
+	}
+	if slices.Contains(apis(validator, "WS_API"), "txpool") || !slices.Contains(apis(rpc, "WS_API"), "txpool") {
+		t.Errorf("unexpected WebSocket APIs, validator %v, rpc %v", validator["WS_API"], rpc["WS_API"])
+	}
+
+	if rpc["ARCHIVE"] != "false" || archive["ARCHIVE"] != "true" || observer["ARCHIVE"] != "true" {
+		t.Errorf("unexpected archive configuration, rpc %v, archive %v, observer %v",
+			rpc["ARCHIVE"], archive["ARCHIVE"], observer["ARCHIVE"])
+	}
+	for name, env := range map[string]map[string]string{"validator": validator, "observer": observer, "archive": archive} {
+		if _, found := env["TXPOOL_GLOBAL_SLOTS"]; found {
+			t.Errorf("%s nodes should use the default txpool size", name)
+		}
+	}
+	if rpc["TXPOOL_GLOBAL_SLOTS"] == "" || rpc["TXPOOL_GLOBAL_QUEUE"] == "" {
+		t.Errorf("rpc nodes should have an enlarged txpool")
+	}
+}
+
+func TestClientProfile_WebSocketServiceIsOnlyExportedIfEnabled(t *testing.T) {
+	for nodeType, profile := range clientProfiles {
+		services := profile.services()
+		if !slices.Contains(services, &OperaRpcService) || !slices.Contains(services, &OperaDebugService) {
+			t.Errorf("node type %s does not export RPC and debug services", nodeType)
+		}
+		if got, want := slices.Contains(services, &OperaWsService), len(profile.wsApis) > 0; got != want {
+			t.Errorf("unexpected export of WebSocket service for node type %s, wanted %t, got %t", nodeType, want, got)
+		}
+	}
+}
+
+func TestClientProfile_DefaultNodesKeepWebSocketServiceAndClientMode(t *testing.T) {
+	// nodes without type receive the load of applications through their
+	// WebSocket service and use the default mode of the client
+	profile, err := getClientProfile(&OperaNodeConfig{})
+	if err != nil {
+		t.Fatalf("failed to get profile: %v", err)
+	}
+	if !slices.Contains(profile.services(), &OperaWsService) {
+		t.Errorf("default nodes should export a WebSocket service")
+	}
+	if got := profile.environment()["ARCHIVE"]; got == "false" {
+		t.Errorf("default nodes should not run in validator mode")
+	}
+}
+
+func TestRenderClientArgs_ProducesSortedFlags(t *testing.T) {
+	got := renderClientArgs(map[string]string{"verbosity": "4", "cache": "8192", "nousb": ""})
+	want := []string{"--cache=8192", "--nousb", "--verbosity=4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected arguments, wanted %v, got %v", want, got)
+	}
+	if got := renderClientArgs(nil); len(got) != 0 {
+		t.Errorf("unexpected arguments for no flags: %v", got)
+	}
+}
+
+func TestOperaNode_SaveSettingsTo(t *testing.T) {
+	node := &OperaNode{
+		label: "A",
+		settings: ClientSettings{
+			Image:       "sonic",
+			Type:        driver.RpcNode,
+			Environment: map[string]string{"TXPOOL_GLOBAL_SLOTS": "1024"},
+			Args:        []string{"--cache=8192"},
+			Resources:   driver.NodeResources{Cpus: 0.5, Memory: 1 << 30},
+		},
+	}
+	dir := t.TempDir()
+	if err := node.SaveSettingsTo(dir); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "A.yml"))
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+	var got ClientSettings
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed to decode settings: %v", err)
+	}
+	if got.Image != "sonic" || got.Type != driver.RpcNode ||
+		!maps.Equal(got.Environment, node.settings.Environment) || !slices.Equal(got.Args, node.settings.Args) ||
+		got.Resources != node.settings.Resources {
+		t.Errorf("unexpected settings, wanted %v, got %v", node.settings, got)
+	}
+}
@@ -112,6 +112,20 @@ func (mr *MockNodeMockRecorder) GetServiceUrl(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceUrl", reflect.TypeOf((*MockNode)(nil).GetServiceUrl), arg0)
 }
 
+// GetStartBlockHeight mocks base method.
+func (m *MockNode) GetStartBlockHeight() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStartBlockHeight")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// GetStartBlockHeight indicates an expected call of GetStartBlockHeight.
+func (mr *MockNodeMockRecorder) GetStartBlockHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStartBlockHeight", reflect.TypeOf((*MockNode)(nil).GetStartBlockHeight))
+}
+
 // Hostname mocks base method.
 func (m *MockNode) Hostname() string {
 	m.ctrl.T.Helper()
@@ -182,6 +196,48 @@ func (mr *MockNodeMockRecorder) MetricsPort() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsPort", reflect.TypeOf((*MockNode)(nil).MetricsPort))
 }
 
+// Pause mocks base method.
+func (m *MockNode) Pause() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Pause indicates an expected call of Pause.
+func (mr *MockNodeMockRecorder) Pause() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockNode)(nil).Pause))
+}
+
+// Restart mocks base method.
+func (m *MockNode) Restart(graceful bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restart", graceful)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restart indicates an expected call of Restart.
+func (mr *MockNodeMockRecorder) Restart(graceful any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restart", reflect.TypeOf((*MockNode)(nil).Restart), graceful)
+}
+
+// Resume mocks base method.
+func (m *MockNode) Resume() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockNodeMockRecorder) Resume() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockNode)(nil).Resume))
+}
+
 // Stop mocks base method.
 func (m *MockNode) Stop() error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,40 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package driver
+
+import "testing"
+
+func TestIsNodeOfGroup(t *testing.T) {
+	tests := []struct {
+		label string
+		name  string
+		want  bool
+	}{
+		{"rpc-0", "rpc", true},
+		{"rpc-12", "rpc", true},
+		{"rpc", "rpc", true},
+		{"rpc-archive-1", "rpc", false},
+		{"rpc-archive-1", "rpc-archive", true},
+		{"validator-0", "rpc", false},
+		{"rpc0", "rpc", false},
+	}
+	for _, test := range tests {
+		if got := IsNodeOfGroup(test.label, test.name); got != test.want {
+			t.Errorf("IsNodeOfGroup(%q, %q) = %v, wanted %v", test.label, test.name, got, test.want)
+		}
+	}
+}
@@ -20,10 +20,16 @@ import (
 	"errors"
 	"fmt"
 	"github.com/0xsoniclabs/hyperion/genesistools/genesis"
+	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/0xsoniclabs/hyperion/load/app"
+	"github.com/docker/go-units"
+	"github.com/tyler-smith/go-bip39"
 )
 
 const namePatternStr = "^[A-Za-z0-9-]+$"
@@ -43,6 +49,12 @@ func (s *Scenario) Check() error {
 		errs = append(errs, fmt.Errorf("round trip time must be >= 0, is %v", *s.RoundTripTime))
 	}
 
+	for _, validator := range s.Validators {
+		if err := validator.Resources.Check(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid resources of validator %s; %w", validator.Name, err))
+		}
+	}
+
 	names := map[string]bool{}
 	for _, node := range s.Nodes {
 		if err := node.Check(s); err != nil {
@@ -94,9 +106,238 @@ func (s *Scenario) Check() error {
 		}
 	}
 
+	if err := s.Topology.Check(s); err != nil {
+		errs = append(errs, fmt.Errorf("invalid topology; %w", err))
+	}
+
+	for _, upgrade := range s.Upgrades {
+		if err := upgrade.Check(s); err != nil {
+			errs = append(errs, fmt.Errorf("invalid upgrade of node %s; %w", upgrade.Node, err))
+		}
+	}
+
+	for _, change := range s.StakeChanges {
+		if err := change.Check(s); err != nil {
+			errs = append(errs, fmt.Errorf("invalid stake change of node %s; %w", change.Node, err))
+		}
+	}
+
+	if err := s.Snapshot.Check(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid snapshot; %w", err))
+	}
+
 	return errors.Join(errs...)
 }
 
+// Check tests semantic constraints on the upgrade of nodes of a scenario.
+func (u *Upgrade) Check(scenario *Scenario) error {
+	errs := []error{}
+	if !scenario.hasNodeGroup(u.Node) {
+		errs = append(errs, fmt.Errorf("unknown node %s", u.Node))
+	}
+	if strings.TrimSpace(u.Image) == "" {
+		errs = append(errs, fmt.Errorf("image must not be empty"))
+	}
+	if u.Time < 0 || u.Time > scenario.Duration {
+		errs = append(errs, fmt.Errorf("time must be in [0, %v], is %v", scenario.Duration, u.Time))
+	}
+	if u.Interval != nil && *u.Interval < 0 {
+		errs = append(errs, fmt.Errorf("interval must be >= 0, is %v", *u.Interval))
+	}
+	if u.Interval != nil && *u.Interval > 0 {
+		// the last node of a group has to be upgraded within the scenario
+		instances := scenario.countNodesOfGroup(u.Node)
+		if last := u.Time + float32(max(instances-1, 0))*(*u.Interval); last > scenario.Duration {
+			errs = append(errs, fmt.Errorf("upgrade of the last of %d nodes at time %v is after the end of the scenario at %v", instances, last, scenario.Duration))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check tests semantic constraints on the stake change of a validator.
+func (c *StakeChange) Check(scenario *Scenario) error {
+	errs := []error{}
+	if !scenario.hasValidatorGroup(c.Node) {
+		errs = append(errs, fmt.Errorf("unknown validator %s", c.Node))
+	}
+	switch c.Action {
+	case "deactivate":
+		if c.Amount != "" {
+			errs = append(errs, fmt.Errorf("amount is not supported by action %s", c.Action))
+		}
+		if scenario.coversAllValidators(c.Node) {
+			errs = append(errs, fmt.Errorf("cannot deactivate all validators of the network"))
+		}
+	case "delegate", "undelegate":
+		if c.Amount == "" {
+			errs = append(errs, fmt.Errorf("amount of action %s must be > 0", c.Action))
+		} else if amount, err := c.GetAmount(); err != nil {
+			errs = append(errs, err)
+		} else if amount.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("amount of action %s must be > 0", c.Action))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown action '%s', supported are deactivate, delegate and undelegate", c.Action))
+	}
+	if c.Time < 0 || c.Time > scenario.Duration {
+		errs = append(errs, fmt.Errorf("time must be in [0, %v], is %v", scenario.Duration, c.Time))
+	}
+	return errors.Join(errs...)
+}
+
+// Check tests semantic constraints on the snapshots of a scenario. Nil is
+// valid and disables snapshots.
+func (s *Snapshot) Check() error {
+	if s == nil {
+		return nil
+	}
+	errs := []error{}
+	if s.Restore != "" && !namePattern.MatchString(s.Restore) {
+		errs = append(errs, fmt.Errorf("restored snapshot name must match %s, got %s", namePatternStr, s.Restore))
+	}
+	if s.Save != "" && !namePattern.MatchString(s.Save) {
+		errs = append(errs, fmt.Errorf("saved snapshot name must match %s, got %s", namePatternStr, s.Save))
+	}
+	return errors.Join(errs...)
+}
+
+// topologyTypes lists the supported types of topologies.
+var topologyTypes = []string{"full", "ring", "line", "star", "random", "custom"}
+
+// Check tests semantic constraints on the topology of a scenario. Nil is valid
+// and interpreted as a full mesh.
+func (t *Topology) Check(scenario *Scenario) error {
+	if t == nil {
+		return nil
+	}
+	errs := []error{}
+	kind := strings.ToLower(t.Type)
+	if kind != "" && !slices.Contains(topologyTypes, kind) {
+		errs = append(errs, fmt.Errorf("type must be one of %s, got %s", strings.Join(topologyTypes, ", "), t.Type))
+	}
+	if t.Center != "" {
+		if kind != "star" {
+			errs = append(errs, fmt.Errorf("center is only supported by star topologies"))
+		} else if !scenario.hasNodeGroup(t.Center) {
+			errs = append(errs, fmt.Errorf("unknown center %s", t.Center))
+		}
+	}
+	if t.K != nil {
+		if kind != "random" {
+			errs = append(errs, fmt.Errorf("k is only supported by random topologies"))
+		} else if *t.K <= 0 {
+			errs = append(errs, fmt.Errorf("k must be > 0, is %d", *t.K))
+		}
+	}
+	if len(t.Peers) > 0 && kind != "custom" {
+		errs = append(errs, fmt.Errorf("peers are only supported by custom topologies"))
+	}
+	if kind == "custom" && len(t.Peers) == 0 {
+		errs = append(errs, fmt.Errorf("custom topologies must define peers"))
+	}
+	for name, peers := range t.Peers {
+		for _, peer := range append([]string{name}, peers...) {
+			if !scenario.hasNodeGroup(peer) {
+				errs = append(errs, fmt.Errorf("unknown peer %s", peer))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasNodeGroup checks whether the scenario defines a group of validators or
+// nodes with the given name, or an instance "<group>-<instance>" of a group.
+func (s *Scenario) hasNodeGroup(name string) bool {
+	return s.hasGroup(name, func(*Node) bool { return true })
+}
+
+// hasValidatorGroup is like hasNodeGroup, but only considers validators and
+// nodes running validators.
+func (s *Scenario) hasValidatorGroup(name string) bool {
+	return s.hasGroup(name, (*Node).IsValidator)
+}
+
+// coversAllValidators checks whether the given name of a validator or of a
+// group of validators includes all validators of the scenario.
+func (s *Scenario) coversAllValidators(name string) bool {
+	labels := s.getLabels((*Node).IsValidator)
+	return len(labels) > 0 && !slices.ContainsFunc(labels, func(label string) bool {
+		return !isNodeOfGroup(label, name)
+	})
+}
+
+// countNodesOfGroup returns the number of nodes of the scenario included by
+// the given name of a node or of a group of nodes.
+func (s *Scenario) countNodesOfGroup(name string) int {
+	count := 0
+	for _, label := range s.getLabels(func(*Node) bool { return true }) {
+		if isNodeOfGroup(label, name) {
+			count++
+		}
+	}
+	return count
+}
+
+// getLabels lists the labels of the validators of the scenario and of all
+// instances of its nodes selected by the given filter.
+func (s *Scenario) getLabels(include func(*Node) bool) []string {
+	labels := []string{}
+	for _, validator := range s.Validators {
+		for i := 0; i < getInstances(validator.Instances); i++ {
+			labels = append(labels, fmt.Sprintf("%s-%d", validator.Name, i))
+		}
+	}
+	if len(s.Validators) == 0 {
+		labels = append(labels, "validator-0") // < the default validator
+	}
+	for _, node := range s.Nodes {
+		if include(&node) {
+			for i := 0; i < getInstances(node.Instances); i++ {
+				labels = append(labels, fmt.Sprintf("%s-%d", node.Name, i))
+			}
+		}
+	}
+	return labels
+}
+
+// getInstances returns the number of instances of a group, where nil is
+// interpreted as 1.
+func getInstances(instances *int) int {
+	if instances == nil {
+		return 1
+	}
+	return max(*instances, 0)
+}
+
+func (s *Scenario) hasGroup(name string, include func(*Node) bool) bool {
+	groups := []string{}
+	for _, validator := range s.Validators {
+		groups = append(groups, validator.Name)
+	}
+	if len(groups) == 0 {
+		groups = append(groups, "validator") // < the default validators
+	}
+	for _, node := range s.Nodes {
+		if include(&node) {
+			groups = append(groups, node.Name)
+		}
+	}
+	return slices.ContainsFunc(groups, func(group string) bool {
+		return isNodeOfGroup(name, group)
+	})
+}
+
+// isNodeOfGroup checks whether the given label names a node of the given
+// group, which are labeled "<group>-<instance>", or the group itself.
+func isNodeOfGroup(label, group string) bool {
+	instance, found := strings.CutPrefix(label, group+"-")
+	if !found {
+		return label == group
+	}
+	_, err := strconv.Atoi(instance)
+	return err == nil
+}
+
 // Check tests semantic constraints on the node configuration of a scenario.
 func (n *Node) Check(scenario *Scenario) error {
 	errs := []error{}
@@ -118,6 +359,75 @@ func (n *Node) Check(scenario *Scenario) error {
 		errs = append(errs, err)
 	}
 
+	if err := n.Client.Check(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := n.Resources.Check(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid resources of node %s; %w", n.Name, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// minMemory is the lowest memory limit of nodes accepted by Docker.
+const minMemory = 6 * units.MiB
+
+// Check tests semantic constraints on resource limits. Nil is valid and
+// interpreted as unlimited resources.
+func (r *Resources) Check() error {
+	if r == nil {
+		return nil
+	}
+	errs := []error{}
+	if r.Cpus != nil && *r.Cpus <= 0 {
+		errs = append(errs, fmt.Errorf("number of cpus must be > 0, is %v", *r.Cpus))
+	}
+	if memory, err := r.GetMemory(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid memory limit; %w", err))
+	} else if r.Memory != nil && memory < minMemory {
+		errs = append(errs, fmt.Errorf("memory limit must be >= 6MB, is %s", *r.Memory))
+	}
+	if bps, err := r.GetDiskIoBps(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid disk throughput limit; %w", err))
+	} else if r.DiskIoBps != nil && bps <= 0 {
+		errs = append(errs, fmt.Errorf("disk throughput limit must be > 0, is %s", *r.DiskIoBps))
+	}
+	return errors.Join(errs...)
+}
+
+// reservedClientEnv lists environment variables of clients controlled by the
+// driver, which may not be overridden by scenarios.
+var reservedClientEnv = []string{
+	"VALIDATOR_ID",
+	"VALIDATORS_COUNT",
+	"NETWORK_LATENCY",
+	"STATE_DB_DATADIR",
+	"CLIENT_ARGS",
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var argNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Check tests semantic constraints on the client configuration of a node.
+func (c *ClientType) Check() error {
+	errs := []error{}
+	for name := range c.Env {
+		if !envNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid name of client environment variable: '%s'", name))
+		}
+		if slices.Contains(reservedClientEnv, name) {
+			errs = append(errs, fmt.Errorf("client environment variable %s is controlled by the driver and can not be set", name))
+		}
+	}
+	for name, value := range c.Args {
+		if !argNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid name of client argument: '%s', must match %v without leading dashes", name, argNamePattern))
+		}
+		if strings.ContainsFunc(value, unicode.IsSpace) {
+			errs = append(errs, fmt.Errorf("value of client argument %s must not contain whitespace, got '%s'", name, value))
+		}
+	}
 	return errors.Join(errs...)
 }
 
@@ -131,10 +441,11 @@ func isTypeValid(t string) error {
 	case
 		"validator",
 		"rpc",
+		"archive",
 		"observer":
 		return nil
 	}
-	return fmt.Errorf("type of node must be observer, rpc or validator, was set to %s", t)
+	return fmt.Errorf("type of node must be observer, rpc, archive or validator, was set to %s", t)
 }
 
 // Check tests semantic constraints on the application configuration of a scenario.
@@ -148,7 +459,13 @@ func (a *Application) Check(scenario *Scenario) error {
 	if a.Type == "" {
 		errs = append(errs, fmt.Errorf("application type must be specified"))
 	} else if !app.IsSupportedApplicationType(a.Type) {
-		errs = append(errs, fmt.Errorf("unknown application type: %v", a.Type))
+		errs = append(errs, fmt.Errorf("unknown application type: %v, supported types are %v", a.Type, app.GetApplicationTypes()))
+	} else if config, err := a.GetConfig(); err != nil {
+		errs = append(errs, err)
+	} else if nodes, ok := config.(app.NodeReferences); ok {
+		if err := checkNodeReferences(scenario, nodes.GetNodeNames()); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if a.Instances != nil && *a.Instances < 0 {
@@ -166,10 +483,146 @@ func (a *Application) Check(scenario *Scenario) error {
 	if err := a.Rate.Check(scenario); err != nil {
 		errs = append(errs, err)
 	}
+	// the sustainable rate of an application is the one of its feedback loop
+	if count := a.Rate.countTargets(); count > 1 {
+		errs = append(errs, fmt.Errorf("rate of application must contain at most one target, got %d", count))
+	}
+
+	if a.ClosedLoop != nil {
+		if err := a.ClosedLoop.Check(); err != nil {
+			errs = append(errs, err)
+		}
+		if name := strings.ToLower(a.Type); name == "rpc" || name == "subscription" || name == "replay" {
+			errs = append(errs, fmt.Errorf("closed-loop mode is only supported by applications sending transactions on demand, got type %v", a.Type))
+		}
+	}
+
+	if a.Fees != nil {
+		if err := a.Fees.Check(); err != nil {
+			errs = append(errs, err)
+		}
+		if name := strings.ToLower(a.Type); name == "rpc" || name == "subscription" {
+			errs = append(errs, fmt.Errorf("fees are only supported by applications sending transactions, got type %v", a.Type))
+		}
+	}
+
+	if len(a.TxTypes) > 0 {
+		if err := checkTxTypes(a.TxTypes); err != nil {
+			errs = append(errs, err)
+		}
+		if name := strings.ToLower(a.Type); name == "rpc" || name == "subscription" {
+			errs = append(errs, fmt.Errorf("transaction types are only supported by applications sending transactions, got type %v", a.Type))
+		}
+	}
+
+	if a.NonceRecovery != nil && a.NonceRecovery.Period != nil && *a.NonceRecovery.Period < 0 {
+		errs = append(errs, fmt.Errorf("nonce recovery period must be >= 0, got %f", *a.NonceRecovery.Period))
+	}
+
+	if a.Accounts != nil {
+		if err := a.Accounts.Check(); err != nil {
+			errs = append(errs, err)
+		}
+		if name := strings.ToLower(a.Type); name == "rpc" || name == "subscription" {
+			errs = append(errs, fmt.Errorf("account pools are only supported by applications sending transactions, got type %v", a.Type))
+		}
+	}
 
 	return errors.Join(errs...)
 }
 
+// Check tests semantic constraints on the configuration of a closed loop.
+func (c *ClosedLoop) Check() error {
+	errs := []error{}
+	if c.InFlight != nil && *c.InFlight < 1 {
+		errs = append(errs, fmt.Errorf("number of in-flight transactions must be >= 1, got %d", *c.InFlight))
+	}
+	if c.Timeout != nil && *c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("receipt timeout must be > 0, got %f", *c.Timeout))
+	}
+	if c.ThinkTime != nil && *c.ThinkTime < 0 {
+		errs = append(errs, fmt.Errorf("think time must be >= 0, got %f", *c.ThinkTime))
+	}
+	return errors.Join(errs...)
+}
+
+// Check tests semantic constraints on the fee strategy of an application.
+func (f *Fees) Check() error {
+	errs := []error{}
+	if !app.IsSupportedFeeStrategy(f.Strategy) {
+		errs = append(errs, fmt.Errorf("unknown fee strategy: %v", f.Strategy))
+	}
+	prices := map[string]*float32{"fee cap": f.FeeCap, "tip": f.Tip, "minimum tip": f.MinTip, "maximum tip": f.MaxTip}
+	for _, name := range []string{"fee cap", "tip", "minimum tip", "maximum tip"} {
+		if price := prices[name]; price != nil && *price < 0 {
+			errs = append(errs, fmt.Errorf("%s must be >= 0, got %f", name, *price))
+		}
+	}
+	if f.MinTip != nil && f.MaxTip != nil && *f.MinTip > *f.MaxTip {
+		errs = append(errs, fmt.Errorf("minimum tip must be <= maximum tip, got %f > %f", *f.MinTip, *f.MaxTip))
+	}
+	if strings.ToLower(f.Strategy) == "escalating" && f.Tip != nil && *f.Tip <= 0 {
+		errs = append(errs, fmt.Errorf("tip of escalating fees must be > 0, got %f", *f.Tip))
+	}
+	if f.Multiplier != nil && *f.Multiplier <= 0 {
+		errs = append(errs, fmt.Errorf("base fee multiplier must be > 0, got %f", *f.Multiplier))
+	}
+	if f.Replacements != nil && *f.Replacements < 1 {
+		errs = append(errs, fmt.Errorf("number of replacements must be >= 1, got %d", *f.Replacements))
+	}
+	if f.Bump != nil && *f.Bump < 1 {
+		errs = append(errs, fmt.Errorf("fee bump must be >= 1 percent, got %d", *f.Bump))
+	}
+	if f.ReplaceAfter != nil && *f.ReplaceAfter <= 0 {
+		errs = append(errs, fmt.Errorf("replacement delay must be > 0, got %f", *f.ReplaceAfter))
+	}
+	return errors.Join(errs...)
+}
+
+// checkTxTypes tests semantic constraints on the transaction types of an
+// application.
+func checkTxTypes(txTypes []TxType) error {
+	errs := []error{}
+	seen := map[string]bool{}
+	for _, txType := range txTypes {
+		if !app.IsSupportedTxType(txType.Type) {
+			errs = append(errs, fmt.Errorf("unsupported transaction type: %v", txType.Type))
+		}
+		name := strings.ToLower(txType.Type)
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("transaction types must be unique, %s encountered multiple times", txType.Type))
+		}
+		seen[name] = true
+		if txType.Weight <= 0 {
+			errs = append(errs, fmt.Errorf("weight of transaction type %v must be > 0, got %f", txType.Type, txType.Weight))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkNodeReferences tests that the given names refer to groups of nodes
+// defined in the scenario.
+func checkNodeReferences(scenario *Scenario, names []string) error {
+	groups := map[string]bool{}
+	for _, validator := range scenario.Validators {
+		groups[validator.Name] = true
+	}
+	if len(scenario.Validators) == 0 {
+		groups["validator"] = true
+	}
+	for _, node := range scenario.Nodes {
+		groups[node.Name] = true
+	}
+
+	errs := []error{}
+	for _, name := range names {
+		if !groups[name] {
+			errs = append(errs, fmt.Errorf("unknown node %q referenced", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Check tests semantic constraints on the cheat configuration of a scenario.
 func (c *Cheat) Check(scenario *Scenario) error {
 	errs := []error{}
@@ -200,6 +653,27 @@ func (r *Rate) Check(scenario *Scenario) error {
 	if r.Auto != nil {
 		count++
 	}
+	if r.Schedule != nil {
+		count++
+	}
+	if r.Trace != nil {
+		count++
+	}
+	if r.Sum != nil {
+		count++
+	}
+	if r.Sequence != nil {
+		count++
+	}
+	if r.Poisson != nil {
+		count++
+	}
+	if r.Bursty != nil {
+		count++
+	}
+	if r.Target != nil {
+		count++
+	}
 	if count != 1 {
 		return fmt.Errorf("application must specify exactly one load shape, got %d", count)
 	}
@@ -216,9 +690,197 @@ func (r *Rate) Check(scenario *Scenario) error {
 	if r.Auto != nil {
 		return r.Auto.Check()
 	}
+	if r.Schedule != nil {
+		return r.Schedule.Check()
+	}
+	if r.Trace != nil {
+		return r.Trace.Check()
+	}
+	if r.Sum != nil {
+		return checkSum(scenario, r.Sum)
+	}
+	if r.Sequence != nil {
+		return checkSequence(scenario, r.Sequence)
+	}
+	if r.Poisson != nil && r.Poisson.Rate < 0 {
+		return fmt.Errorf("poisson transaction rate must be >= 0, got %f", r.Poisson.Rate)
+	}
+	if r.Bursty != nil {
+		return r.Bursty.Check()
+	}
+	if r.Target != nil {
+		return r.Target.Check()
+	}
 	return nil
 }
 
+// countTargets returns the number of feedback-controlled traffic patterns
+// in the rate, including those nested in sums and sequences.
+func (r *Rate) countTargets() int {
+	count := 0
+	if r.Target != nil {
+		count++
+	}
+	for i := range r.Sum {
+		count += r.Sum[i].countTargets()
+	}
+	for i := range r.Sequence {
+		count += r.Sequence[i].Rate.countTargets()
+	}
+	return count
+}
+
+// Check tests semantic constraints on the configuration of a feedback-controlled traffic pattern.
+func (t *Target) Check() error {
+	errs := []error{}
+
+	if (t.Latency == nil) == (t.TxPool == nil) {
+		errs = append(errs, fmt.Errorf("target must specify exactly one of latency and txpool"))
+	}
+	if t.Latency != nil && *t.Latency <= 0 {
+		errs = append(errs, fmt.Errorf("target latency must be > 0, got %f", *t.Latency))
+	}
+	if t.Percentile != nil && (*t.Percentile <= 0 || *t.Percentile > 1) {
+		errs = append(errs, fmt.Errorf("latency percentile must be in (0,1], got %f", *t.Percentile))
+	}
+	if t.TxPool != nil && *t.TxPool < 0 {
+		errs = append(errs, fmt.Errorf("target txpool size must be >= 0, got %d", *t.TxPool))
+	}
+	if t.Initial != nil && *t.Initial <= 0 {
+		errs = append(errs, fmt.Errorf("initial transaction rate must be > 0, got %f", *t.Initial))
+	}
+	if t.Max != nil && *t.Max <= 0 {
+		errs = append(errs, fmt.Errorf("maximum transaction rate must be > 0, got %f", *t.Max))
+	}
+	for name, gain := range map[string]*float32{"kp": t.Kp, "ki": t.Ki, "kd": t.Kd} {
+		if gain != nil && *gain < 0 {
+			errs = append(errs, fmt.Errorf("gain %s must be >= 0, got %f", name, *gain))
+		}
+	}
+	isZero := func(gain *float32, def float32) bool {
+		return (gain == nil && def == 0) || (gain != nil && *gain == 0)
+	}
+	if isZero(t.Kp, 0.5) && isZero(t.Ki, 0.3) && isZero(t.Kd, 0) {
+		errs = append(errs, fmt.Errorf("at least one of the gains kp, ki, and kd must be > 0"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check tests semantic constraints on the configuration of a bursty traffic pattern.
+func (b *Bursty) Check() error {
+	errs := []error{}
+
+	if b.Rate < 0 {
+		errs = append(errs, fmt.Errorf("transaction rate of bursts must be >= 0, got %f", b.Rate))
+	}
+	if b.On <= 0 {
+		errs = append(errs, fmt.Errorf("mean duration of bursts must be > 0, got %f", b.On))
+	}
+	if b.Off < 0 {
+		errs = append(errs, fmt.Errorf("mean duration of pauses must be >= 0, got %f", b.Off))
+	}
+	switch strings.ToLower(b.Distribution) {
+	case "", "exponential", "pareto":
+	default:
+		errs = append(errs, fmt.Errorf("unknown distribution: %v", b.Distribution))
+	}
+	if b.Shape != nil && *b.Shape <= 1 {
+		errs = append(errs, fmt.Errorf("shape of Pareto distribution must be > 1, got %f", *b.Shape))
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkInterpolation tests whether the given interpolation mode is known.
+func checkInterpolation(interpolation string) error {
+	switch strings.ToLower(interpolation) {
+	case "", "step", "linear":
+		return nil
+	}
+	return fmt.Errorf("unknown interpolation: %v", interpolation)
+}
+
+// Check tests semantic constraints on the configuration of a scheduled traffic pattern.
+func (s *Schedule) Check() error {
+	errs := []error{}
+
+	if len(s.Points) == 0 {
+		errs = append(errs, fmt.Errorf("schedule must contain at least one point"))
+	}
+	for i, point := range s.Points {
+		if point.Time < 0 {
+			errs = append(errs, fmt.Errorf("time of schedule point must be >= 0, got %f", point.Time))
+		}
+		if i > 0 && point.Time <= s.Points[i-1].Time {
+			errs = append(errs, fmt.Errorf("times of schedule points must be increasing, got %f after %f", point.Time, s.Points[i-1].Time))
+		}
+		if point.Rate < 0 {
+			errs = append(errs, fmt.Errorf("transaction rate of schedule point must be >= 0, got %f", point.Rate))
+		}
+	}
+	if err := checkInterpolation(s.Interpolation); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check tests semantic constraints on the configuration of a trace-driven traffic pattern.
+func (t *Trace) Check() error {
+	errs := []error{}
+
+	if t.File == "" {
+		errs = append(errs, fmt.Errorf("rate trace file must be specified"))
+	} else if info, err := os.Stat(t.File); err != nil {
+		errs = append(errs, fmt.Errorf("rate trace file %v is not accessible; %w", t.File, err))
+	} else if info.IsDir() {
+		errs = append(errs, fmt.Errorf("rate trace file %v is a directory", t.File))
+	}
+	if t.Scale != nil && *t.Scale <= 0 {
+		errs = append(errs, fmt.Errorf("rate trace scale must be > 0, got %f", *t.Scale))
+	}
+	if err := checkInterpolation(t.Interpolation); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkSum tests semantic constraints on the rates of a sum of traffic patterns.
+func checkSum(scenario *Scenario, rates []Rate) error {
+	if len(rates) == 0 {
+		return fmt.Errorf("sum of rates must contain at least one rate")
+	}
+	errs := []error{}
+	for _, rate := range rates {
+		if err := rate.Check(scenario); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkSequence tests semantic constraints on the phases of a sequence of traffic patterns.
+func checkSequence(scenario *Scenario, phases []Phase) error {
+	if len(phases) == 0 {
+		return fmt.Errorf("sequence of rates must contain at least one phase")
+	}
+	errs := []error{}
+	for i, phase := range phases {
+		if phase.Duration == nil && i != len(phases)-1 {
+			errs = append(errs, fmt.Errorf("only the last phase of a sequence may omit its duration"))
+		}
+		if phase.Duration != nil && *phase.Duration <= 0 {
+			errs = append(errs, fmt.Errorf("duration of phase must be > 0, got %f", *phase.Duration))
+		}
+		if err := phase.Rate.Check(scenario); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Check tests semantic constraints on the configuration of a slope traffic pattern.
 func (s *Slope) Check() error {
 	errs := []error{}
@@ -301,3 +963,17 @@ func checkTimeInterval(start, end *float32, duration float32) error {
 	}
 	return errors.Join(errs...)
 }
+
+// Check tests semantic constraints on the configuration of an account pool.
+func (a *Accounts) Check() error {
+	errs := []error{}
+
+	if a.Mnemonic != "" && !bip39.IsMnemonicValid(a.Mnemonic) {
+		errs = append(errs, fmt.Errorf("invalid mnemonic of account pool"))
+	}
+	if a.TopUp != nil && *a.TopUp < 0 {
+		errs = append(errs, fmt.Errorf("top-up threshold must be >= 0, got %f", *a.TopUp))
+	}
+
+	return errors.Join(errs...)
+}
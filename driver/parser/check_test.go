@@ -18,9 +18,13 @@ package parser
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/0xsoniclabs/hyperion/load/app"
 )
 
 func TestTimeRange_UnconstraintInputIsAccepted(t *testing.T) {
@@ -200,6 +204,189 @@ func TestRateCheck_MultipleOptionsIsDetected(t *testing.T) {
 	}
 }
 
+func TestRateCheck_ScheduleIssuesAreDetected(t *testing.T) {
+	valid := Schedule{Points: []RatePoint{{Time: 0, Rate: 10}, {Time: 10, Rate: 20}}, Interpolation: "linear"}
+	if err := valid.Check(); err != nil {
+		t.Errorf("issue reported for valid schedule: %v", err)
+	}
+	tests := map[string]struct {
+		schedule Schedule
+		issue    string
+	}{
+		"no points":             {Schedule{}, "at least one point"},
+		"negative time":         {Schedule{Points: []RatePoint{{Time: -1}}}, "time of schedule point must be >= 0"},
+		"unordered":             {Schedule{Points: []RatePoint{{Time: 2}, {Time: 1}}}, "must be increasing"},
+		"negative rate":         {Schedule{Points: []RatePoint{{Rate: -1}}}, "rate of schedule point must be >= 0"},
+		"unknown interpolation": {Schedule{Points: []RatePoint{{}}, Interpolation: "cubic"}, "unknown interpolation"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.schedule.Check(); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestRateCheck_TraceIssuesAreDetected(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rate.csv")
+	if err := os.WriteFile(file, []byte("0,10\n"), 0600); err != nil {
+		t.Fatalf("failed to create trace file: %v", err)
+	}
+	trace := Trace{File: file}
+	if err := trace.Check(); err != nil {
+		t.Errorf("issue reported for valid trace: %v", err)
+	}
+	zero := float32(0)
+	tests := map[string]struct {
+		trace Trace
+		issue string
+	}{
+		"no file":      {Trace{}, "rate trace file must be specified"},
+		"missing file": {Trace{File: file + ".missing"}, "is not accessible"},
+		"directory":    {Trace{File: filepath.Dir(file)}, "is a directory"},
+		"zero scale":   {Trace{File: file, Scale: &zero}, "rate trace scale must be > 0"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.trace.Check(); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestRateCheck_CombinatorIssuesAreDetected(t *testing.T) {
+	scenario := Scenario{}
+	ten := float32(10)
+	negative := float32(-1)
+	valid := Rate{Sequence: []Phase{
+		{Duration: &ten, Rate: Rate{Constant: &ten}},
+		{Rate: Rate{Sum: []Rate{{Constant: &ten}, {Slope: &Slope{}}}}},
+	}}
+	if err := valid.Check(&scenario); err != nil {
+		t.Errorf("issue reported for valid combination: %v", err)
+	}
+	tests := map[string]struct {
+		rate  Rate
+		issue string
+	}{
+		"empty sum":             {Rate{Sum: []Rate{}}, "at least one rate"},
+		"invalid summand":       {Rate{Sum: []Rate{{Constant: &negative}}}, "constant transaction rate must be >= 0"},
+		"empty sequence":        {Rate{Sequence: []Phase{}}, "at least one phase"},
+		"unlimited early phase": {Rate{Sequence: []Phase{{Rate: Rate{Constant: &ten}}, {Rate: Rate{Constant: &ten}}}}, "only the last phase"},
+		"negative duration":     {Rate{Sequence: []Phase{{Duration: &negative, Rate: Rate{Constant: &ten}}}}, "duration of phase must be > 0"},
+		"invalid phase":         {Rate{Sequence: []Phase{{Rate: Rate{}}}}, "exactly one load shape"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.rate.Check(&scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestRateCheck_ArrivalProcessIssuesAreDetected(t *testing.T) {
+	scenario := Scenario{}
+	shape := float32(2)
+	valid := []Rate{
+		{Poisson: &Poisson{Rate: 10}},
+		{Bursty: &Bursty{Rate: 100, On: 1, Off: 5}},
+		{Bursty: &Bursty{Rate: 100, On: 1, Distribution: "Pareto", Shape: &shape}},
+	}
+	for _, rate := range valid {
+		if err := rate.Check(&scenario); err != nil {
+			t.Errorf("issue reported for valid arrival process: %v", err)
+		}
+	}
+	one := float32(1)
+	tests := map[string]struct {
+		rate  Rate
+		issue string
+	}{
+		"negative poisson rate": {Rate{Poisson: &Poisson{Rate: -1}}, "poisson transaction rate must be >= 0"},
+		"negative burst rate":   {Rate{Bursty: &Bursty{Rate: -1, On: 1}}, "transaction rate of bursts must be >= 0"},
+		"no bursts":             {Rate{Bursty: &Bursty{Rate: 1}}, "mean duration of bursts must be > 0"},
+		"negative pauses":       {Rate{Bursty: &Bursty{Rate: 1, On: 1, Off: -1}}, "mean duration of pauses must be >= 0"},
+		"unknown distribution":  {Rate{Bursty: &Bursty{Rate: 1, On: 1, Distribution: "normal"}}, "unknown distribution"},
+		"infinite mean":         {Rate{Bursty: &Bursty{Rate: 1, On: 1, Shape: &one}}, "shape of Pareto distribution must be > 1"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.rate.Check(&scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestRateCheck_InvalidTargetsAreDetected(t *testing.T) {
+	scenario := Scenario{}
+	latency := float32(1)
+	pool := 1000
+	valid := []Rate{
+		{Target: &Target{Latency: &latency}},
+		{Target: &Target{TxPool: &pool}},
+		{Target: &Target{TxPool: &pool, Kp: &latency, Ki: new(float32)}},
+	}
+	for _, rate := range valid {
+		if err := rate.Check(&scenario); err != nil {
+			t.Errorf("issue reported for valid target: %v", err)
+		}
+	}
+	zero := float32(0)
+	negative := -1
+	tests := map[string]struct {
+		rate  Rate
+		issue string
+	}{
+		"no target":          {Rate{Target: &Target{}}, "exactly one of latency and txpool"},
+		"two targets":        {Rate{Target: &Target{Latency: &latency, TxPool: &pool}}, "exactly one of latency and txpool"},
+		"zero latency":       {Rate{Target: &Target{Latency: &zero}}, "target latency must be > 0"},
+		"zero percentile":    {Rate{Target: &Target{Latency: &latency, Percentile: &zero}}, "latency percentile must be in (0,1]"},
+		"negative pool size": {Rate{Target: &Target{TxPool: &negative}}, "target txpool size must be >= 0"},
+		"zero maximum":       {Rate{Target: &Target{TxPool: &pool, Max: &zero}}, "maximum transaction rate must be > 0"},
+		"zero gains":         {Rate{Target: &Target{TxPool: &pool, Kp: &zero, Ki: &zero}}, "at least one of the gains"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.rate.Check(&scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestApplication_AtMostOneTargetIsAccepted(t *testing.T) {
+	latency, duration := float32(1), float32(30)
+	target := Rate{Target: &Target{Latency: &latency}}
+	warmUp := Rate{Constant: new(float32)}
+	tests := map[string]struct {
+		rate  Rate
+		valid bool
+	}{
+		"target":                 {target, true},
+		"target in sequence":     {Rate{Sequence: []Phase{{Duration: &duration, Rate: warmUp}, {Rate: target}}}, true},
+		"target in sum":          {Rate{Sum: []Rate{warmUp, target}}, true},
+		"targets in sequence":    {Rate{Sequence: []Phase{{Duration: &duration, Rate: target}, {Rate: target}}}, false},
+		"targets in sum":         {Rate{Sum: []Rate{target, target}}, false},
+		"targets in nested rate": {Rate{Sum: []Rate{target, {Sequence: []Phase{{Rate: target}}}}}, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := Application{Name: "test", Type: "counter", Rate: test.rate}
+			err := app.Check(&Scenario{Duration: 60})
+			if test.valid && err != nil {
+				t.Errorf("valid rate should be accepted, but got error: %v", err)
+			}
+			if !test.valid && (err == nil || !strings.Contains(err.Error(), "at most one target")) {
+				t.Errorf("multiple targets were not detected, got %v", err)
+			}
+		})
+	}
+}
+
 func TestRateCheck_NegativeConstantRateIsDetected(t *testing.T) {
 	scenario := Scenario{}
 	rate := Rate{}
@@ -263,6 +450,91 @@ func TestApplication_InvalidApplicationTypeIsDetected(t *testing.T) {
 	}
 }
 
+// pluginOptions are the options of an application type registered the way
+// external workloads are.
+type pluginOptions struct {
+	Contracts int      `yaml:"contracts"`
+	Mode      string   `yaml:"mode,omitempty"`
+	Nodes     []string `yaml:"nodes,omitempty"`
+}
+
+func (o *pluginOptions) GetNodeNames() []string {
+	return o.Nodes
+}
+
+func init() {
+	err := app.Register("parser-test-plugin", func(app.AppContext, *pluginOptions, uint32, uint32) (app.Application, error) {
+		return nil, nil
+	}, app.ConfigSchema[pluginOptions]{
+		Check: func(options *pluginOptions) error {
+			if options.Contracts < 1 {
+				return fmt.Errorf("number of contracts must be >= 1, got %d", options.Contracts)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestApplication_ConfigOfRegisteredTypeIsDecodedAndChecked(t *testing.T) {
+	tests := map[string]string{
+		"":                               "number of contracts must be >= 1",
+		"config: {contracts: 0}":         "number of contracts must be >= 1",
+		"config: {contracts: 2, foo: 1}": "field foo not found",
+		"config: {contracts: [1]}":       "cannot unmarshal",
+		"config: {contracts: 2}":         "",
+	}
+	for config, issue := range tests {
+		t.Run(config, func(t *testing.T) {
+			scenario, err := ParseBytes([]byte(`
+name: Test
+duration: 10
+applications:
+  - name: plugin
+    type: parser-test-plugin
+    rate:
+      constant: 1
+    ` + config))
+			if err != nil {
+				t.Fatalf("failed to parse scenario: %v", err)
+			}
+			err = scenario.Applications[0].Check(&scenario)
+			if issue == "" {
+				if err != nil {
+					t.Fatalf("unexpected issue with valid config: %v", err)
+				}
+				options, err := scenario.Applications[0].GetConfig()
+				if got, ok := options.(*pluginOptions); err != nil || !ok || got.Contracts != 2 {
+					t.Errorf("unexpected options, got %v, err %v", options, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), issue) {
+				t.Errorf("expected error containing %q, got %v", issue, err)
+			}
+		})
+	}
+}
+
+func TestApplication_ConfigOfTypeWithoutOptionsIsRejected(t *testing.T) {
+	scenario, err := ParseBytes([]byte(`
+name: Test
+duration: 10
+applications:
+  - name: counter
+    type: counter
+    rate:
+      constant: 1
+    config: {contracts: 2}
+`))
+	if err != nil {
+		t.Fatalf("failed to parse scenario: %v", err)
+	}
+	if err := scenario.Applications[0].Check(&scenario); err == nil || !strings.Contains(err.Error(), "does not accept a config") {
+		t.Errorf("config of type without options was not rejected, got %v", err)
+	}
+}
+
 func TestApplication_NegativeInstanceCounterIsNotAllowed(t *testing.T) {
 	scenario := Scenario{}
 	app := Application{Name: "test", Type: "counter", Instances: new(int), Rate: Rate{Constant: new(float32)}}
@@ -321,6 +593,293 @@ func TestApplication_DetectsShapeIssue(t *testing.T) {
 	}
 }
 
+// parseApplication parses a scenario with a single application of the given
+// type and config section, whose nodes include a group named rpc.
+func parseApplication(t *testing.T, appType string, config string) Scenario {
+	t.Helper()
+	scenario, err := ParseBytes([]byte(`
+name: Test
+duration: 10
+nodes:
+  - name: rpc
+applications:
+  - name: test
+    type: ` + appType + `
+    rate:
+      constant: 1
+    ` + config))
+	if err != nil {
+		t.Fatalf("failed to parse scenario: %v", err)
+	}
+	return scenario
+}
+
+func TestApplication_OptionsOfBuiltInTypesAreChecked(t *testing.T) {
+	tests := map[string]struct {
+		appType string
+		config  string
+		issue   string
+	}{
+		"deploy":             {"deploy", "config: {code_size: 100, storage_slots: 10}", ""},
+		"code size":          {"deploy", "config: {code_size: -1}", "invalid code size"},
+		"storage slots":      {"deploy", "config: {storage_slots: -1}", "invalid number of storage slots"},
+		"unknown key":        {"deploy", "config: {size: 100}", "field size not found"},
+		"mix":                {"mix", "config: {operations: [{type: transfer, weight: 60}, {type: erc20, weight: 40}]}", ""},
+		"missing mix":        {"mix", "", "at least one operation"},
+		"nested mix":         {"mix", "config: {operations: [{type: mix, weight: 1}]}", "can not be nested"},
+		"unknown operation":  {"mix", "config: {operations: [{type: unknown, weight: 1}]}", "unknown application type"},
+		"rpc":                {"rpc", "config: {methods: [{method: eth_call, weight: 1}], nodes: [rpc, validator]}", ""},
+		"rpc method":         {"rpc", "config: {methods: [{method: eth_unknown, weight: 1}]}", "unsupported RPC method"},
+		"rpc node":           {"rpc", "config: {nodes: [archive]}", "unknown node"},
+		"subscription":       {"subscription", "config: {kinds: [newHeads, logs], nodes: [rpc]}", ""},
+		"subscription kind":  {"subscription", "config: {kinds: [syncing]}", "unsupported subscription kind"},
+		"subscription node":  {"subscription", "config: {nodes: [archive]}", "unknown node"},
+		"rpc operation":      {"mix", "config: {operations: [{type: rpc, weight: 1}]}", "can not be mixed"},
+		"node of operation":  {"mix", "config: {operations: [{type: parser-test-plugin, weight: 1, config: {contracts: 1, nodes: [archive]}}]}", "unknown node"},
+		"missing replay":     {"replay", "", "no replay file specified"},
+		"unknown replay":     {"replay", "config: {file: /missing/trace.jsonl}", "not accessible"},
+		"config for counter": {"counter", "config: {code_size: 100}", "does not accept a config"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			scenario := parseApplication(t, test.appType, test.config)
+			err := scenario.Applications[0].Check(&scenario)
+			if test.issue == "" {
+				if err != nil {
+					t.Errorf("valid options should be accepted, but got error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestApplication_ConfigOfMixOperationIsDecodedAndChecked(t *testing.T) {
+	tests := map[string]string{
+		"":                               "number of contracts must be >= 1",
+		"config: {contracts: 0}":         "number of contracts must be >= 1",
+		"config: {contracts: 2, foo: 1}": "field foo not found",
+		"config: {contracts: 2}":         "",
+	}
+	for config, issue := range tests {
+		t.Run(config, func(t *testing.T) {
+			scenario := parseApplication(t, "mix", `config:
+      operations:
+        - type: counter
+          weight: 1
+        - type: parser-test-plugin
+          weight: 1
+          `+config)
+			err := scenario.Applications[0].Check(&scenario)
+			if issue == "" {
+				if err != nil {
+					t.Fatalf("unexpected issue with valid config: %v", err)
+				}
+				options, err := scenario.Applications[0].GetConfig()
+				if got, ok := options.(*app.MixOptions); err != nil || !ok || len(got.Operations) != 2 {
+					t.Errorf("unexpected options, got %v, err %v", options, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), issue) {
+				t.Errorf("expected error containing %q, got %v", issue, err)
+			}
+		})
+	}
+}
+
+func TestApplication_DetectsClosedLoopIssues(t *testing.T) {
+	inFlight, timeout, think := 4, float32(5), float32(0.5)
+	app := Application{
+		Name: "test",
+		Type: "counter",
+		Rate: Rate{Constant: new(float32)},
+		ClosedLoop: &ClosedLoop{
+			InFlight:  &inFlight,
+			Timeout:   &timeout,
+			ThinkTime: &think,
+		},
+	}
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("valid closed loop should be accepted, but got error: %v", err)
+	}
+	app.ClosedLoop = &ClosedLoop{}
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("default closed loop should be accepted, but got error: %v", err)
+	}
+
+	zero, negative := 0, float32(-1)
+	tests := map[string]struct {
+		closedLoop ClosedLoop
+		issue      string
+	}{
+		"no in-flight txs":    {ClosedLoop{InFlight: &zero}, "in-flight transactions must be >= 1"},
+		"negative timeout":    {ClosedLoop{Timeout: &negative}, "timeout must be > 0"},
+		"negative think time": {ClosedLoop{ThinkTime: &negative}, "think time must be >= 0"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app.ClosedLoop = &test.closedLoop
+			if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+
+	app.Type = "rpc"
+	app.ClosedLoop = &ClosedLoop{}
+	if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), "only supported by applications sending transactions") {
+		t.Errorf("closed loop of read-only application was not detected, got %v", err)
+	}
+
+	app.Type = "replay"
+	app.ClosedLoop = &ClosedLoop{}
+	if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), "only supported by applications sending transactions") {
+		t.Errorf("closed loop of replay application was not detected, got %v", err)
+	}
+}
+
+func TestApplication_DetectsNonceRecoveryIssues(t *testing.T) {
+	period := float32(5)
+	app := Application{
+		Name:          "test",
+		Type:          "counter",
+		Rate:          Rate{Constant: new(float32)},
+		NonceRecovery: &NonceRecovery{Period: &period},
+	}
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("valid nonce recovery should be accepted, but got error: %v", err)
+	}
+	period = 0
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("disabled nonce recovery should be accepted, but got error: %v", err)
+	}
+	period = -1
+	if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), "nonce recovery period must be >= 0") {
+		t.Errorf("negative period was not detected, got %v", err)
+	}
+}
+
+func TestApplication_DetectsFeeIssues(t *testing.T) {
+	tip := float32(2)
+	app := Application{
+		Name: "test",
+		Type: "transfer",
+		Rate: Rate{Constant: new(float32)},
+		Fees: &Fees{Strategy: "escalating", Tip: &tip},
+	}
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("valid fees should be accepted, but got error: %v", err)
+	}
+
+	negative := float32(-1)
+	zero := 0
+	tests := map[string]struct {
+		fees  Fees
+		issue string
+	}{
+		"unknown strategy": {Fees{Strategy: "unknown"}, "unknown fee strategy"},
+		"negative tip":     {Fees{Tip: &negative}, "tip must be >= 0"},
+		"inverted range":   {Fees{MinTip: &tip, MaxTip: new(float32)}, "minimum tip must be <= maximum tip"},
+		"zero multiplier":  {Fees{Multiplier: new(float32)}, "base fee multiplier must be > 0"},
+		"zero escalating":  {Fees{Strategy: "escalating", Tip: new(float32)}, "tip of escalating fees must be > 0"},
+		"no replacements":  {Fees{Replacements: &zero}, "number of replacements must be >= 1"},
+		"zero bump":        {Fees{Bump: &zero}, "fee bump must be >= 1 percent"},
+		"negative delay":   {Fees{ReplaceAfter: &negative}, "replacement delay must be > 0"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app.Fees = &test.fees
+			if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+
+	app.Type = "rpc"
+	app.Fees = &Fees{}
+	if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), "only supported by applications sending transactions") {
+		t.Errorf("fees of read-only application were not detected, got %v", err)
+	}
+}
+
+func TestApplication_DetectsTxTypeIssues(t *testing.T) {
+	app := Application{
+		Name:    "test",
+		Type:    "transfer",
+		Rate:    Rate{Constant: new(float32)},
+		TxTypes: []TxType{{Type: "legacy", Weight: 1}, {Type: "set_code", Weight: 2}},
+	}
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("valid transaction types should be accepted, but got error: %v", err)
+	}
+
+	tests := map[string]struct {
+		txTypes []TxType
+		issue   string
+	}{
+		"unknown type": {[]TxType{{Type: "blob", Weight: 1}}, "unsupported transaction type: blob"},
+		"duplicate":    {[]TxType{{Type: "legacy", Weight: 1}, {Type: "Legacy", Weight: 1}}, "transaction types must be unique"},
+		"zero weight":  {[]TxType{{Type: "legacy"}}, "weight of transaction type legacy must be > 0"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app.TxTypes = test.txTypes
+			if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+
+	app.Type = "subscription"
+	app.TxTypes = []TxType{{Type: "legacy", Weight: 1}}
+	if err := app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), "only supported by applications sending transactions") {
+		t.Errorf("transaction types of read-only application were not detected, got %v", err)
+	}
+}
+
+func TestApplication_ReplayFileOfScenarioFileIsChecked(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "trace.jsonl"), nil, 0600); err != nil {
+		t.Fatalf("failed to create replay file: %v", err)
+	}
+	tests := map[string]string{
+		"{file: trace.jsonl, speed: 2}":   "",
+		"{file: missing.jsonl}":           "not accessible",
+		"{file: .}":                       "is a directory",
+		"{file: trace.jsonl, speed: -1}":  "speed must be positive",
+		"{file: trace.jsonl, speeds: -1}": "field speeds not found",
+	}
+	for config, issue := range tests {
+		t.Run(config, func(t *testing.T) {
+			path := filepath.Join(dir, "scenario.yml")
+			err := os.WriteFile(path, []byte(`
+name: Test
+duration: 10
+applications:
+  - name: replay
+    type: replay
+    rate:
+      constant: 0
+    config: `+config), 0600)
+			if err != nil {
+				t.Fatalf("failed to write scenario: %v", err)
+			}
+			scenario, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("failed to parse scenario: %v", err)
+			}
+			err = scenario.Applications[0].Check(&scenario)
+			if issue == "" {
+				if err != nil {
+					t.Errorf("valid replay should be accepted, but got error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), issue) {
+				t.Errorf("expected error containing %q, got %v", issue, err)
+			}
+		})
+	}
+}
+
 func TestNode_InvalidNameIsDetected(t *testing.T) {
 	scenario := Scenario{}
 	node := Node{}
@@ -364,6 +923,41 @@ func TestNode_DetectsTimingIssue(t *testing.T) {
 	}
 }
 
+func TestNode_NodeTypesAreChecked(t *testing.T) {
+	scenario := Scenario{}
+	for _, nodeType := range []string{"", "validator", "rpc", "archive", "observer"} {
+		node := Node{Name: "test", Client: ClientType{Type: nodeType}}
+		if err := node.Check(&scenario); err != nil {
+			t.Errorf("node type '%s' should be valid, but got error: %v", nodeType, err)
+		}
+	}
+	node := Node{Name: "test", Client: ClientType{Type: "light"}}
+	if err := node.Check(&scenario); err == nil || !strings.Contains(err.Error(), "type of node must be") {
+		t.Errorf("invalid node type was not detected")
+	}
+}
+
+func TestNode_DetectsClientSettingIssues(t *testing.T) {
+	scenario := Scenario{}
+	tests := map[string]struct {
+		client ClientType
+		issue  string
+	}{
+		"invalid env name":  {ClientType{Env: map[string]string{"1-A": "x"}}, "invalid name of client environment variable"},
+		"reserved env name": {ClientType{Env: map[string]string{"VALIDATOR_ID": "1"}}, "controlled by the driver"},
+		"invalid arg name":  {ClientType{Args: map[string]string{"--cache": "1"}}, "invalid name of client argument"},
+		"arg with space":    {ClientType{Args: map[string]string{"cache": "1 2"}}, "must not contain whitespace"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			node := Node{Name: "test", Client: test.client}
+			if err := node.Check(&scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected issue '%s', got %v", test.issue, err)
+			}
+		})
+	}
+}
+
 func TestScenario_MissingNameIsDetected(t *testing.T) {
 	scenario := Scenario{}
 	if err := scenario.Check(); err == nil || !strings.Contains(err.Error(), "scenario name must not be empty") {
@@ -518,3 +1112,367 @@ func TestScenario_NegativeNetworkRuleUpdateTimeIsDetected(t *testing.T) {
 		t.Errorf("negative network rule update time was not detected")
 	}
 }
+
+func TestApplication_DetectsAccountPoolIssues(t *testing.T) {
+	topUp := float32(10)
+	app := Application{
+		Name:     "test",
+		Type:     "counter",
+		Rate:     Rate{Constant: new(float32)},
+		Accounts: &Accounts{Mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow", TopUp: &topUp, Sweep: true},
+	}
+	if err := app.Check(&Scenario{}); err != nil {
+		t.Errorf("valid account pool should be accepted, but got error: %v", err)
+	}
+
+	negative := float32(-1)
+	tests := map[string]struct {
+		app   Application
+		issue string
+	}{
+		"invalid mnemonic":   {Application{Type: "counter", Accounts: &Accounts{Mnemonic: "not a mnemonic"}}, "invalid mnemonic"},
+		"negative threshold": {Application{Type: "counter", Accounts: &Accounts{TopUp: &negative}}, "top-up threshold must be >= 0"},
+		"rpc application":    {Application{Type: "rpc", Accounts: &Accounts{}}, "only supported by applications sending transactions"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.app.Name = "test"
+			test.app.Rate = Rate{Constant: new(float32)}
+			if err := test.app.Check(&Scenario{}); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestResources_DetectsIssues(t *testing.T) {
+	cpus := float32(0.5)
+	memory := "4GB"
+	disk := "50MB"
+	valid := &Resources{Cpus: &cpus, Memory: &memory, DiskIoBps: &disk}
+	if err := valid.Check(); err != nil {
+		t.Errorf("valid resources should be accepted, but got error: %v", err)
+	}
+	if got, err := valid.GetMemory(); err != nil || got != 4<<30 {
+		t.Errorf("unexpected memory limit, wanted %d, got %d, err %v", 4<<30, got, err)
+	}
+	if got, err := valid.GetDiskIoBps(); err != nil || got != 50_000_000 {
+		t.Errorf("unexpected disk limit, wanted %d, got %d, err %v", 50_000_000, got, err)
+	}
+	if err := (*Resources)(nil).Check(); err != nil {
+		t.Errorf("missing resources should be accepted, but got error: %v", err)
+	}
+
+	zero := float32(0)
+	tiny := "1KB"
+	invalid := "lots"
+	tests := map[string]struct {
+		resources Resources
+		issue     string
+	}{
+		"no cpus":        {Resources{Cpus: &zero}, "number of cpus must be > 0"},
+		"little memory":  {Resources{Memory: &tiny}, "memory limit must be >= 6MB"},
+		"invalid memory": {Resources{Memory: &invalid}, "invalid memory limit"},
+		"invalid disk":   {Resources{DiskIoBps: &invalid}, "invalid disk throughput limit"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.resources.Check(); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestScenario_InvalidResourcesOfValidatorsAreDetected(t *testing.T) {
+	zero := float32(0)
+	scenario := Scenario{
+		Name:       "Test",
+		Duration:   60,
+		Validators: []Validator{{Name: "weak", Resources: &Resources{Cpus: &zero}}},
+	}
+	if err := scenario.Check(); err == nil || !strings.Contains(err.Error(), "invalid resources of validator weak") {
+		t.Errorf("expected error on resources of validator, got %v", err)
+	}
+}
+
+func TestTopology_DetectsIssues(t *testing.T) {
+	scenario := &Scenario{
+		Validators: []Validator{{Name: "validator"}},
+		Nodes:      []Node{{Name: "rpc"}},
+	}
+	k := 2
+	valid := []*Topology{
+		nil,
+		{Type: "full"},
+		{Type: "Ring"},
+		{Type: "star", Center: "rpc"},
+		{Type: "star", Center: "validator-1"},
+		{Type: "random", K: &k, Seed: 42},
+		{Type: "custom", Peers: map[string][]string{"validator": {"rpc", "validator"}}},
+	}
+	for _, topology := range valid {
+		if err := topology.Check(scenario); err != nil {
+			t.Errorf("topology %v should be accepted, but got error: %v", topology, err)
+		}
+	}
+
+	zero := 0
+	tests := map[string]struct {
+		topology Topology
+		issue    string
+	}{
+		"unknown type":      {Topology{Type: "mesh"}, "type must be one of"},
+		"unknown center":    {Topology{Type: "star", Center: "hub"}, "unknown center hub"},
+		"misplaced center":  {Topology{Type: "ring", Center: "rpc"}, "center is only supported by star topologies"},
+		"zero k":            {Topology{Type: "random", K: &zero}, "k must be > 0"},
+		"misplaced k":       {Topology{Type: "line", K: &k}, "k is only supported by random topologies"},
+		"missing peers":     {Topology{Type: "custom"}, "custom topologies must define peers"},
+		"misplaced peers":   {Topology{Type: "full", Peers: map[string][]string{"rpc": {"validator"}}}, "peers are only supported by custom topologies"},
+		"unknown peer":      {Topology{Type: "custom", Peers: map[string][]string{"rpc": {"archive"}}}, "unknown peer archive"},
+		"unknown peer node": {Topology{Type: "custom", Peers: map[string][]string{"archive": {"rpc"}}}, "unknown peer archive"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.topology.Check(scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestScenario_InvalidTopologyIsDetected(t *testing.T) {
+	scenario := Scenario{
+		Name:     "Test",
+		Duration: 60,
+		Topology: &Topology{Type: "star", Center: "hub"},
+	}
+	if err := scenario.Check(); err == nil || !strings.Contains(err.Error(), "invalid topology") {
+		t.Errorf("expected error on topology, got %v", err)
+	}
+}
+
+func TestSnapshot_DetectsIssues(t *testing.T) {
+	valid := []*Snapshot{
+		nil,
+		{},
+		{Restore: "aged-chain"},
+		{Save: "aged-chain"},
+		{Restore: "aged-chain", Save: "aged-chain"},
+	}
+	for _, snapshot := range valid {
+		if err := snapshot.Check(); err != nil {
+			t.Errorf("snapshot %v should be accepted, but got error: %v", snapshot, err)
+		}
+	}
+
+	tests := map[string]struct {
+		snapshot Snapshot
+		issue    string
+	}{
+		"path restored": {Snapshot{Restore: "../aged"}, "restored snapshot name must match"},
+		"path saved":    {Snapshot{Save: "/tmp/aged"}, "saved snapshot name must match"},
+		"space":         {Snapshot{Save: "aged chain"}, "saved snapshot name must match"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.snapshot.Check(); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestScenario_InvalidSnapshotIsDetected(t *testing.T) {
+	scenario := Scenario{
+		Name:     "Test",
+		Duration: 60,
+		Snapshot: &Snapshot{Save: "../aged"},
+	}
+	if err := scenario.Check(); err == nil || !strings.Contains(err.Error(), "invalid snapshot") {
+		t.Errorf("expected error on snapshot, got %v", err)
+	}
+}
+
+func TestUpgrade_DetectsIssues(t *testing.T) {
+	scenario := &Scenario{
+		Duration:   60,
+		Validators: []Validator{{Name: "validator"}},
+		Nodes:      []Node{{Name: "rpc"}},
+	}
+	interval, negative := float32(5), float32(-1)
+	valid := []Upgrade{
+		{Node: "validator", Image: "sonic:new", Time: 10},
+		{Node: "validator-1", Image: "sonic:new", Time: 0},
+		{Node: "rpc-0", Image: "sonic:new", Time: 60},
+		{Node: "rpc", Image: "sonic:new", Time: 10, Interval: &interval},
+	}
+	for _, upgrade := range valid {
+		if err := upgrade.Check(scenario); err != nil {
+			t.Errorf("upgrade %v should be accepted, but got error: %v", upgrade, err)
+		}
+	}
+
+	tests := map[string]struct {
+		upgrade Upgrade
+		issue   string
+	}{
+		"unknown node":      {Upgrade{Node: "archive", Image: "sonic:new"}, "unknown node archive"},
+		"missing image":     {Upgrade{Node: "rpc"}, "image must not be empty"},
+		"negative time":     {Upgrade{Node: "rpc", Image: "sonic:new", Time: -1}, "time must be in [0, 60]"},
+		"late time":         {Upgrade{Node: "rpc", Image: "sonic:new", Time: 61}, "time must be in [0, 60]"},
+		"negative interval": {Upgrade{Node: "rpc", Image: "sonic:new", Interval: &negative}, "interval must be >= 0"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.upgrade.Check(scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestUpgrade_UpgradesOfGroupsMustEndWithinScenario(t *testing.T) {
+	three := 3
+	scenario := &Scenario{
+		Duration:   60,
+		Validators: []Validator{{Name: "validator", Instances: &three}},
+		Nodes:      []Node{{Name: "rpc", Instances: &three}},
+	}
+	interval := float32(25)
+	valid := []Upgrade{
+		{Node: "validator", Image: "sonic:new", Time: 10, Interval: &interval},
+		{Node: "rpc-2", Image: "sonic:new", Time: 60, Interval: &interval},
+	}
+	for _, upgrade := range valid {
+		if err := upgrade.Check(scenario); err != nil {
+			t.Errorf("upgrade %v should be accepted, but got error: %v", upgrade, err)
+		}
+	}
+	upgrade := Upgrade{Node: "rpc", Image: "sonic:new", Time: 11, Interval: &interval}
+	if err := upgrade.Check(scenario); err == nil || !strings.Contains(err.Error(), "upgrade of the last of 3 nodes at time 61 is after the end of the scenario at 60") {
+		t.Errorf("expected late upgrade to be reported, got %v", err)
+	}
+}
+
+func TestScenario_InvalidUpgradeIsDetected(t *testing.T) {
+	scenario := Scenario{
+		Name:     "Test",
+		Duration: 60,
+		Upgrades: []Upgrade{{Node: "archive", Image: "sonic:new"}},
+	}
+	if err := scenario.Check(); err == nil || !strings.Contains(err.Error(), "invalid upgrade of node archive") {
+		t.Errorf("expected error on upgrade, got %v", err)
+	}
+}
+
+func TestStakeChange_DetectsIssues(t *testing.T) {
+	scenario := &Scenario{
+		Duration:   60,
+		Validators: []Validator{{Name: "validator"}},
+		Nodes: []Node{
+			{Name: "leaving", Client: ClientType{Type: "validator"}},
+			{Name: "rpc", Client: ClientType{Type: "rpc"}},
+		},
+	}
+	valid := []StakeChange{
+		{Node: "validator", Action: "deactivate", Time: 10},
+		{Node: "leaving-0", Action: "deactivate", Time: 60},
+		{Node: "validator-1", Action: "delegate", Amount: "1000"},
+		{Node: "leaving", Action: "undelegate", Amount: "0.000000000000000001", Time: 30},
+	}
+	for _, change := range valid {
+		if err := change.Check(scenario); err != nil {
+			t.Errorf("stake change %v should be accepted, but got error: %v", change, err)
+		}
+	}
+
+	tests := map[string]struct {
+		change StakeChange
+		issue  string
+	}{
+		"unknown node":          {StakeChange{Node: "archive", Action: "deactivate"}, "unknown validator archive"},
+		"non-validator node":    {StakeChange{Node: "rpc", Action: "deactivate"}, "unknown validator rpc"},
+		"unknown action":        {StakeChange{Node: "validator", Action: "slash"}, "unknown action 'slash'"},
+		"amount of deactivate":  {StakeChange{Node: "validator", Action: "deactivate", Amount: "1000"}, "amount is not supported"},
+		"missing amount":        {StakeChange{Node: "validator", Action: "delegate"}, "amount of action delegate must be > 0"},
+		"zero amount":           {StakeChange{Node: "validator", Action: "undelegate", Amount: "0"}, "amount of action undelegate must be > 0"},
+		"negative amount":       {StakeChange{Node: "validator", Action: "undelegate", Amount: "-1"}, "amount of action undelegate must be > 0"},
+		"non-numeric amount":    {StakeChange{Node: "validator", Action: "delegate", Amount: "lots"}, "must be a decimal number of tokens"},
+		"fraction of a wei":     {StakeChange{Node: "validator", Action: "delegate", Amount: "0.0000000000000000001"}, "must not be more precise than 1 wei"},
+		"negative time":         {StakeChange{Node: "validator", Action: "deactivate", Time: -1}, "time must be in [0, 60]"},
+		"time after the end":    {StakeChange{Node: "validator", Action: "deactivate", Time: 61}, "time must be in [0, 60]"},
+		"empty action and node": {StakeChange{}, "unknown validator"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.change.Check(scenario); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestStakeChange_DeactivationOfAllValidatorsIsDetected(t *testing.T) {
+	three := 3
+	tests := map[string]struct {
+		scenario Scenario
+		node     string
+	}{
+		"default validator": {
+			Scenario{Duration: 60},
+			"validator",
+		},
+		"single validator": {
+			Scenario{Duration: 60, Validators: []Validator{{Name: "validator"}}},
+			"validator-0",
+		},
+		"group of validators": {
+			Scenario{
+				Duration:   60,
+				Validators: []Validator{{Name: "validator", Instances: &three}},
+				Nodes:      []Node{{Name: "rpc", Client: ClientType{Type: "rpc"}}},
+			},
+			"validator",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			change := StakeChange{Node: test.node, Action: "deactivate"}
+			if err := change.Check(&test.scenario); err == nil || !strings.Contains(err.Error(), "cannot deactivate all validators") {
+				t.Errorf("expected deactivation of all validators to be reported, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStakeChange_GetAmount_ConvertsTokensExactlyToWei(t *testing.T) {
+	tests := map[string]string{
+		"1":                    "1000000000000000000",
+		"0.1":                  "100000000000000000",
+		"1.5e6":                "1500000000000000000000000",
+		"123456789.123456789":  "123456789123456789000000000",
+		"0.000000000000000001": "1",
+	}
+	for amount, want := range tests {
+		change := StakeChange{Amount: amount}
+		got, err := change.GetAmount()
+		if err != nil {
+			t.Fatalf("failed to get amount %s: %v", amount, err)
+		}
+		if got.String() != want {
+			t.Errorf("unexpected amount of %s tokens, wanted %s wei, got %v", amount, want, got)
+		}
+	}
+}
+
+func TestScenario_InvalidStakeChangeIsDetected(t *testing.T) {
+	scenario := Scenario{
+		Name:         "Test",
+		Duration:     60,
+		StakeChanges: []StakeChange{{Node: "archive", Action: "deactivate"}},
+	}
+	if err := scenario.Check(); err == nil || !strings.Contains(err.Error(), "invalid stake change of node archive") {
+		t.Errorf("expected error on stake change, got %v", err)
+	}
+}
@@ -18,10 +18,15 @@ package parser
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"math/big"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/0xsoniclabs/hyperion/load/app"
+	"github.com/docker/go-units"
 	"gopkg.in/yaml.v3"
 )
 
@@ -36,6 +41,10 @@ type Scenario struct {
 	Applications  []Application  `yaml:",omitempty"`
 	Cheats        []Cheat        `yaml:",omitempty"`
 	NetworkRules  NetworkRules   `yaml:"network_rules,omitempty"`
+	Topology      *Topology      `yaml:",omitempty"` // nil == full mesh
+	Snapshot      *Snapshot      `yaml:",omitempty"` // nil == start from genesis, save nothing
+	Upgrades      []Upgrade      `yaml:",omitempty"`
+	StakeChanges  []StakeChange  `yaml:"stake_changes,omitempty"`
 }
 
 func (s *Scenario) GetRoundTripTime() time.Duration {
@@ -61,12 +70,99 @@ type NetworkRulesUpdate struct {
 	Rules networkRules
 }
 
+// Topology defines which nodes of the network are connected as peers. Nodes
+// are ordered by the time they join the network, starting with the genesis
+// validators. Peers are named by node or group names, covering all instances
+// of a group.
+type Topology struct {
+	Type   string              // full (default), ring, line, star, random, or custom
+	Center string              `yaml:",omitempty"` // hub of star topologies, the first node if empty
+	K      *int                `yaml:",omitempty"` // number of earlier nodes each node connects to in random topologies, 3 if nil
+	Seed   int64               `yaml:",omitempty"` // seed of random topologies
+	Peers  map[string][]string `yaml:",omitempty"` // adjacency list of custom topologies
+}
+
+// Upgrade replaces the client of a node by another image at a given time. If
+// a group of nodes is named, its nodes are upgraded one after another, like in
+// rolling upgrades of networks. Upgraded nodes retain their label, data
+// directory, and peers.
+type Upgrade struct {
+	Node     string   // name of a node, e.g. validator-0, or of a group of nodes
+	Image    string   // image of the new client
+	Time     float32  // time of the upgrade of the first node
+	Interval *float32 `yaml:",omitempty"` // seconds between upgrades of successive nodes of a group, nil is interpreted as 0
+}
+
+// StakeChange changes the stake of a validator at a given time through the SFC
+// contract of the network, signed by the validator itself. Validators are
+// deactivated gracefully by undelegating their complete self-stake, such that
+// they leave the validator set with the next epoch instead of counting as
+// offline. Nodes keep running after all changes. If a group of validators is
+// named, the change is applied to all of its validators.
+type StakeChange struct {
+	Node   string  // name of a validator, e.g. validator-0, or of a group of validators
+	Action string  // deactivate, delegate, or undelegate
+	Amount string  `yaml:",omitempty"` // decimal number of tokens to delegate or undelegate, e.g. 1000 or 0.5, empty for deactivate
+	Time   float32 // time of the change
+}
+
+// GetAmount returns the amount of the change in wei. The amount is converted
+// exactly, such that amounts with more than 18 decimals are rejected.
+func (c *StakeChange) GetAmount() (*big.Int, error) {
+	tokens, ok := new(big.Rat).SetString(c.Amount)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q, must be a decimal number of tokens", c.Amount)
+	}
+	wei := tokens.Mul(tokens, new(big.Rat).SetInt(big.NewInt(1e18)))
+	if !wei.IsInt() {
+		return nil, fmt.Errorf("invalid amount %q, must not be more precise than 1 wei", c.Amount)
+	}
+	return wei.Num(), nil
+}
+
+// Snapshot names snapshots of the data directories of all nodes to start the
+// validators and nodes of a scenario from and to save at the end of the
+// scenario, e.g. to reuse the state of a chain aged by a long-running load in
+// later scenarios. Empty names disable the respective step.
+type Snapshot struct {
+	Restore string `yaml:",omitempty"` // snapshot to start from
+	Save    string `yaml:",omitempty"` // snapshot to save at the end
+}
+
 // Validator is a configuration for a group of network start-up validators.
 type Validator struct {
 	Name      string
 	Failing   bool
-	Instances *int   `yaml:",omitempty"` // nil is interpreted as 1
-	ImageName string `yaml:",omitempty"` // empty is interpreted as DefaultClientDockerImageName
+	Instances *int       `yaml:",omitempty"` // nil is interpreted as 1
+	ImageName string     `yaml:",omitempty"` // empty is interpreted as DefaultClientDockerImageName
+	Resources *Resources `yaml:",omitempty"` // nil is interpreted as unlimited
+}
+
+// Resources limits the resources available to each instance of a group of
+// nodes, e.g. to simulate low-spec validators. Missing limits are interpreted
+// as unlimited. The disk throughput is limited on the disk of the data_volume
+// of a node if it has one.
+type Resources struct {
+	Cpus      *float32 `yaml:",omitempty"`            // number of CPUs, fractions are supported
+	Memory    *string  `yaml:",omitempty"`            // size with binary units, e.g. 4GB or 512MB
+	DiskIoBps *string  `yaml:"disk_io_bps,omitempty"` // read and write throughput per second, e.g. 50MB
+}
+
+// GetMemory returns the memory limit in bytes, or 0 if unlimited.
+func (r *Resources) GetMemory() (int64, error) {
+	if r == nil || r.Memory == nil {
+		return 0, nil
+	}
+	return units.RAMInBytes(*r.Memory)
+}
+
+// GetDiskIoBps returns the disk throughput limit in bytes per second, or 0 if
+// unlimited.
+func (r *Resources) GetDiskIoBps() (int64, error) {
+	if r == nil || r.DiskIoBps == nil {
+		return 0, nil
+	}
+	return units.FromHumanSize(*r.DiskIoBps)
 }
 
 // Node is a configuration for a group of nodes with similar properties.
@@ -80,6 +176,7 @@ type Node struct {
 	Start     *float32   `yaml:",omitempty"` // nil is interpreted as 0
 	End       *float32   `yaml:",omitempty"` // nil is interpreted as end-of-scenario
 	Client    ClientType `yaml:",omitempty"`
+	Resources *Resources `yaml:",omitempty"` // nil is interpreted as unlimited
 }
 
 // IsValidator returns true if the node is defined as validator in Features
@@ -107,8 +204,16 @@ func (n *Node) IsStaticValidator(s *Scenario) bool {
 // Type can be used to configure the launching command of the client
 type ClientType struct {
 	ImageName  string  `yaml:",omitempty"`            // nil is interpreted as main
-	Type       string  `yaml:",omitempty"`            // nil is interpreted as observer
+	Type       string  `yaml:",omitempty"`            // one of validator, rpc, archive or observer; nil is interpreted as observer
 	DataVolume *string `yaml:"data_volume,omitempty"` // nil is interpreted as empty
+
+	// Env lists environment variables of the client's container, overriding
+	// the defaults of the node type, e.g. TXPOOL_GLOBAL_SLOTS read by the
+	// start scripts of the client.
+	Env map[string]string `yaml:",omitempty"`
+	// Args lists command line flags passed to the client, named without the
+	// leading dashes, e.g. cache: 8192. Empty values produce plain flags.
+	Args map[string]string `yaml:",omitempty"`
 }
 
 // Application is a load generator in the simulated network. Each application defines
@@ -122,21 +227,128 @@ type Application struct {
 	Start     *float32 `yaml:",omitempty"` // nil is interpreted as 0
 	End       *float32 `yaml:",omitempty"` // nil is interpreted as end-of-scenario
 	Rate      Rate
+
+	// ClosedLoop switches users to a closed-loop model, in which the rate is
+	// ignored and users wait for receipts before sending new transactions.
+	ClosedLoop *ClosedLoop `yaml:"closed_loop,omitempty"` // nil is interpreted as open-loop mode
+
+	// Fees configures the strategy for the fees of the users' transactions.
+	Fees *Fees `yaml:",omitempty"` // nil is interpreted as fixed default fees
+
+	// TxTypes lists the types of the users' transactions and their weights.
+	TxTypes []TxType `yaml:"tx_types,omitempty"` // nil is interpreted as dynamic-fee transactions only
+
+	// NonceRecovery configures the periodic detection and recovery of nonce
+	// gaps of the users' accounts caused by dropped transactions.
+	NonceRecovery *NonceRecovery `yaml:"nonce_recovery,omitempty"` // nil disables the recovery
+
+	// Accounts configures the reuse of the users' accounts across runs, their
+	// funding, and the return of their funds at the end of the application.
+	Accounts *Accounts `yaml:",omitempty"` // nil is interpreted as fresh accounts funded in every run
+
+	// Config holds the type-specific options of the application, decoded by
+	// the registry of application types into the options registered for the
+	// application's type. Types without options do not accept a config.
+	Config yaml.Node `yaml:",omitempty"` // empty is interpreted as the zero options of the type
+
+	dir string // < directory relative paths in the config are resolved against
+}
+
+// GetConfig decodes the config section of the application into the options
+// type registered for the application's type and checks them. The result is
+// nil for application types without options.
+func (a *Application) GetConfig() (any, error) {
+	return app.DecodeConfig(a.Type, app.NewYamlConfigDecoder(&a.Config, a.dir))
 }
 
-// Rate defines the shape of traffic to be generated. There are three types
-// currently supported:
+// ClosedLoop defines the behavior of users in closed-loop mode. Each user
+// keeps up to InFlight transactions pending. After receiving the receipt of
+// a transaction, or after the timeout is reached, the user waits for the
+// think time before sending the next transaction.
+type ClosedLoop struct {
+	InFlight  *int     `yaml:"in_flight,omitempty"`  // nil is interpreted as 1
+	Timeout   *float32 `yaml:",omitempty"`           // in seconds, nil is interpreted as 10
+	ThinkTime *float32 `yaml:"think_time,omitempty"` // in seconds, nil is interpreted as 0
+}
+
+// Fees defines the strategy for the fees of the transactions of an
+// application. All prices are in gwei. Strategies are:
+//   - fixed: all transactions use the fee cap and the tip
+//   - base_fee: the fee cap is the gas price suggested by the network
+//     scaled by the multiplier, the tip is fixed
+//   - random_tip: tips are drawn uniformly from [min_tip, max_tip]
+//   - escalating: transactions are replaced by copies with fees raised by
+//     bump percent, every replace_after seconds, up to replacements times
+type Fees struct {
+	Strategy     string   `yaml:",omitempty"`              // empty is interpreted as fixed
+	FeeCap       *float32 `yaml:"fee_cap,omitempty"`       // nil is interpreted as 10000
+	Tip          *float32 `yaml:",omitempty"`              // nil is interpreted as 0, or 1 for escalating, which requires > 0
+	MinTip       *float32 `yaml:"min_tip,omitempty"`       // nil is interpreted as 0
+	MaxTip       *float32 `yaml:"max_tip,omitempty"`       // nil is interpreted as 10
+	Multiplier   *float32 `yaml:",omitempty"`              // nil is interpreted as 2
+	Replacements *int     `yaml:",omitempty"`              // nil is interpreted as 3
+	Bump         *int     `yaml:",omitempty"`              // in percent, nil is interpreted as 10
+	ReplaceAfter *float32 `yaml:"replace_after,omitempty"` // in seconds, nil is interpreted as 1
+}
+
+// TxType is a transaction type emitted by an application and its relative
+// weight among the transactions of the application. Supported types are
+// legacy, access_list, dynamic_fee, and set_code. Set-code transactions are
+// skipped if not supported by the network.
+type TxType struct {
+	Type   string
+	Weight float32
+}
+
+// NonceRecovery defines the recovery of nonce gaps of the accounts of users.
+// The nonces of the accounts are checked periodically. If transactions are
+// found to be stuck, they are either re-sent or the nonce of the account is
+// reset to the pending nonce on the network.
+type NonceRecovery struct {
+	Period *float32 `yaml:",omitempty"` // in seconds, nil is interpreted as 10, 0 disables the recovery
+	Resend *bool    `yaml:",omitempty"` // nil is interpreted as false
+}
+
+// Accounts defines a pool of reusable accounts for the users of an application.
+// Keys are derived from a BIP-39 mnemonic, such that runs using the same
+// mnemonic reuse the same accounts. Accounts are only funded if their balance
+// is below the top-up threshold, and their remaining balance may be swept back
+// to the treasury when the application is stopped.
+type Accounts struct {
+	Mnemonic string   `yaml:",omitempty"`       // empty is interpreted as Hyperion's default mnemonic
+	TopUp    *float32 `yaml:"top_up,omitempty"` // threshold in tokens, nil is interpreted as the funded amount
+	Sweep    bool     `yaml:",omitempty"`       // return remaining funds to the treasury at the end
+}
+
+// Rate defines the shape of traffic to be generated. The following types
+// are currently supported:
 //   - constant ... traffic is created at a constant rate
 //   - slope    ... traffic rate starts at 0 and is linearly increased
 //   - wave     ... traffic rate follows a sin-wave pattern
+//   - auto     ... traffic rate is adjusted to max out throughput
+//   - schedule ... traffic rate follows a list of (time, rate) points
+//   - trace    ... traffic rate follows (time, rate) points of a CSV file
+//   - sum      ... traffic is the sum of a list of rates
+//   - sequence ... traffic follows a list of rates one after the other
+//   - poisson  ... traffic arrives at random instants of a Poisson process
+//   - bursty   ... traffic arrives in random bursts separated by pauses
+//   - target   ... traffic rate is adjusted to hold a target inclusion latency
+//     or transaction pool size
 //
 // Only one of those options can be set for a single source.
 type Rate struct {
 	// Only one of the next fields may be set.
-	Constant *float32 `yaml:",omitempty"`
-	Slope    *Slope   `yaml:",omitempty"`
-	Wave     *Wave    `yaml:",omitempty"`
-	Auto     *Auto    `yaml:",omitempty"`
+	Constant *float32  `yaml:",omitempty"`
+	Slope    *Slope    `yaml:",omitempty"`
+	Wave     *Wave     `yaml:",omitempty"`
+	Auto     *Auto     `yaml:",omitempty"`
+	Schedule *Schedule `yaml:",omitempty"`
+	Trace    *Trace    `yaml:",omitempty"`
+	Sum      []Rate    `yaml:",omitempty"`
+	Sequence []Phase   `yaml:",omitempty"`
+	Poisson  *Poisson  `yaml:",omitempty"`
+	Bursty   *Bursty   `yaml:",omitempty"`
+	Target   *Target   `yaml:",omitempty"`
 }
 
 // Slope defines the parameters of a linearly increasing traffic pattern.
@@ -159,6 +371,72 @@ type Auto struct {
 	Decrease *float32 `yaml:",omitempty"` // decrease in overload case in percent, nil = 0.2 (=20%)
 }
 
+// Schedule defines a piecewise traffic pattern by the rates at given points in
+// time. Between points, the rate is either kept constant (step) or linearly
+// interpolated (linear). Before the first and after the last point, the rate
+// of the first and last point is used, respectively.
+type Schedule struct {
+	Points        []RatePoint
+	Interpolation string `yaml:",omitempty"` // step or linear, empty = step
+}
+
+// RatePoint is the traffic rate of a schedule at a point in time.
+type RatePoint struct {
+	Time float32 // seconds since the start of the application
+	Rate float32 // Tx/s
+}
+
+// Trace defines a traffic pattern following a recorded rate-over-time trace.
+// The trace is a CSV file with rows of a time in seconds and a rate in Tx/s,
+// interpreted like the points of a schedule.
+type Trace struct {
+	File          string   // path of the CSV file, relative to the scenario file
+	Scale         *float32 `yaml:",omitempty"` // factor applied to all rates, nil = 1
+	Interpolation string   `yaml:",omitempty"` // step or linear, empty = step
+}
+
+// Phase is a traffic pattern used for a limited time in a sequence.
+type Phase struct {
+	Duration *float32 `yaml:",omitempty"` // seconds, nil = unlimited (only for the last phase)
+	Rate     Rate     `yaml:",inline"`
+}
+
+// Poisson defines traffic arriving at the instants of a Poisson process,
+// such that the gaps between transactions are exponentially distributed.
+type Poisson struct {
+	Rate float32 // average Tx/s
+	Seed *int64  `yaml:",omitempty"` // nil = random seed
+}
+
+// Bursty defines traffic alternating between bursts, in which transactions
+// arrive following a Poisson process, and pauses without any transactions.
+// The durations of bursts and pauses are random, following an exponential
+// or a heavy-tailed Pareto distribution.
+type Bursty struct {
+	Rate         float32  // Tx/s within bursts
+	On           float32  // mean duration of bursts in seconds
+	Off          float32  // mean duration of pauses in seconds
+	Distribution string   `yaml:",omitempty"` // exponential or pareto, empty = exponential
+	Shape        *float32 `yaml:",omitempty"` // shape of Pareto distributions, must be > 1, nil = 1.5
+	Seed         *int64   `yaml:",omitempty"` // nil = random seed
+}
+
+// Target defines a traffic pattern whose rate is adjusted by a PID loop to
+// hold a percentile of the inclusion latency of transactions or the size of
+// the transaction pool at a target value. Exactly one of Latency and TxPool
+// must be set. The average rate the loop converges to is reported by the
+// SustainableRate metric.
+type Target struct {
+	Latency    *float32 `yaml:",omitempty"`       // target latency in seconds
+	Percentile *float32 `yaml:",omitempty"`       // percentile of latencies in (0,1], nil = 0.95
+	TxPool     *int     `yaml:"txpool,omitempty"` // target number of transactions in the pool
+	Initial    *float32 `yaml:",omitempty"`       // initial Tx/s, nil = 10
+	Max        *float32 `yaml:",omitempty"`       // maximum Tx/s, nil = unbounded
+	Kp         *float32 `yaml:",omitempty"`       // proportional gain, nil = 0.5
+	Ki         *float32 `yaml:",omitempty"`       // integral gain, nil = 0.3
+	Kd         *float32 `yaml:",omitempty"`       // derivative gain, nil = 0
+}
+
 // Cheat is a configuration to simulate cheating at a particular timing.
 // For example, 2 validators with the same keys started at the same time can be considered
 // an attempt to cheat.
@@ -185,10 +463,36 @@ func ParseBytes(data []byte) (Scenario, error) {
 }
 
 // ParseFile parses the YAML encoded scenario in the given file.
+// Relative paths of rate trace files and of files in the configs of
+// applications are resolved relative to the directory of the scenario file.
 func ParseFile(path string) (Scenario, error) {
-	if reader, err := os.Open(path); err == nil {
-		return Parse(reader)
-	} else {
+	reader, err := os.Open(path)
+	if err != nil {
 		return Scenario{}, err
 	}
+	defer reader.Close()
+	res, err := Parse(reader)
+	if err != nil {
+		return res, err
+	}
+	for i := range res.Applications {
+		app := &res.Applications[i]
+		app.dir = filepath.Dir(path)
+		app.Rate.resolvePaths(filepath.Dir(path))
+	}
+	return res, nil
+}
+
+// resolvePaths resolves relative paths of trace files of this rate and all
+// nested rates relative to the given directory.
+func (r *Rate) resolvePaths(dir string) {
+	if r.Trace != nil && r.Trace.File != "" && !filepath.IsAbs(r.Trace.File) {
+		r.Trace.File = filepath.Join(dir, r.Trace.File)
+	}
+	for i := range r.Sum {
+		r.Sum[i].resolvePaths(dir)
+	}
+	for i := range r.Sequence {
+		r.Sequence[i].Rate.resolvePaths(dir)
+	}
 }
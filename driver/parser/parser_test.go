@@ -17,8 +17,15 @@
 package parser
 
 import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/0xsoniclabs/hyperion/load/app"
 )
 
 func TestParseEmpty(t *testing.T) {
@@ -159,6 +166,201 @@ func TestParseWithClientTypeWorks(t *testing.T) {
 	}
 }
 
+var withClientSettings = `
+name: Client Settings
+duration: 60
+nodes:
+  - name: A
+    client:
+      env:
+        TXPOOL_GLOBAL_SLOTS: 1024
+      args:
+        cache: 8192
+        verbosity: 4
+        nousb:
+`
+
+func TestParseWithClientSettingsWorks(t *testing.T) {
+	scenario, err := ParseBytes([]byte(withClientSettings))
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	client := scenario.Nodes[0].Client
+	if got, want := client.Env, map[string]string{"TXPOOL_GLOBAL_SLOTS": "1024"}; !maps.Equal(got, want) {
+		t.Errorf("unexpected env: got: %v, want: %v", got, want)
+	}
+	if got, want := client.Args, map[string]string{"cache": "8192", "verbosity": "4", "nousb": ""}; !maps.Equal(got, want) {
+		t.Errorf("unexpected args: got: %v, want: %v", got, want)
+	}
+	if err := scenario.Check(); err != nil {
+		t.Errorf("client settings should be valid, but got error: %v", err)
+	}
+}
+
+var withResources = `
+name: Resources
+duration: 60
+validators:
+  - name: weak
+    resources:
+      cpus: 0.5
+      memory: 2GB
+      disk_io_bps: 20MB
+nodes:
+  - name: A
+    resources:
+      cpus: 2
+`
+
+func TestParseWithResourcesWorks(t *testing.T) {
+	scenario, err := ParseBytes([]byte(withResources))
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	validator := scenario.Validators[0].Resources
+	if validator == nil || validator.Cpus == nil || *validator.Cpus != 0.5 {
+		t.Errorf("unexpected resources of validator: %v", validator)
+	}
+	if got, err := validator.GetMemory(); err != nil || got != 2<<30 {
+		t.Errorf("unexpected memory of validator: %d, err %v", got, err)
+	}
+	if got, err := validator.GetDiskIoBps(); err != nil || got != 20_000_000 {
+		t.Errorf("unexpected disk throughput of validator: %d, err %v", got, err)
+	}
+	node := scenario.Nodes[0].Resources
+	if node == nil || node.Cpus == nil || *node.Cpus != 2 || node.Memory != nil || node.DiskIoBps != nil {
+		t.Errorf("unexpected resources of node: %v", node)
+	}
+	if err := scenario.Check(); err != nil {
+		t.Errorf("resources should be valid, but got error: %v", err)
+	}
+}
+
+var withTopology = `
+name: Topology
+duration: 60
+validators:
+  - name: validator
+    instances: 4
+topology:
+  type: custom
+  peers:
+    validator-0: [validator-1, validator-2]
+    validator-3: [validator]
+`
+
+func TestParseWithTopologyWorks(t *testing.T) {
+	scenario, err := ParseBytes([]byte(withTopology))
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	topology := scenario.Topology
+	if topology == nil || topology.Type != "custom" {
+		t.Fatalf("unexpected topology: %v", topology)
+	}
+	if got, want := topology.Peers["validator-0"], []string{"validator-1", "validator-2"}; !slices.Equal(got, want) {
+		t.Errorf("unexpected peers: got: %v, want: %v", got, want)
+	}
+	if err := scenario.Check(); err != nil {
+		t.Errorf("topology should be valid, but got error: %v", err)
+	}
+}
+
+var withUpgrades = `
+name: Upgrades
+duration: 300
+validators:
+  - name: validator
+    instances: 3
+    imagename: sonic:v2.0.0
+upgrades:
+  - node: validator
+    image: sonic:latest
+    time: 60
+    interval: 30
+`
+
+func TestParseWithUpgradesWorks(t *testing.T) {
+	scenario, err := ParseBytes([]byte(withUpgrades))
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	if got, want := len(scenario.Upgrades), 1; got != want {
+		t.Fatalf("unexpected number of upgrades, wanted %d, got %d", want, got)
+	}
+	upgrade := scenario.Upgrades[0]
+	if upgrade.Node != "validator" || upgrade.Image != "sonic:latest" || upgrade.Time != 60 {
+		t.Errorf("unexpected upgrade: %v", upgrade)
+	}
+	if upgrade.Interval == nil || *upgrade.Interval != 30 {
+		t.Errorf("unexpected interval: %v", upgrade.Interval)
+	}
+	if err := scenario.Check(); err != nil {
+		t.Errorf("upgrades should be valid, but got error: %v", err)
+	}
+}
+
+var withStakeChanges = `
+name: Stake Changes
+duration: 300
+validators:
+  - name: validator
+    instances: 3
+stake_changes:
+  - node: validator-2
+    action: delegate
+    amount: 1000000.000000000000000001
+    time: 60
+  - node: validator-2
+    action: deactivate
+    time: 120
+`
+
+func TestParseWithStakeChangesWorks(t *testing.T) {
+	scenario, err := ParseBytes([]byte(withStakeChanges))
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	if got, want := len(scenario.StakeChanges), 2; got != want {
+		t.Fatalf("unexpected number of stake changes, wanted %d, got %d", want, got)
+	}
+	delegate := scenario.StakeChanges[0]
+	if delegate.Node != "validator-2" || delegate.Action != "delegate" || delegate.Time != 60 {
+		t.Errorf("unexpected stake change: %v", delegate)
+	}
+	if delegate.Amount != "1000000.000000000000000001" {
+		t.Errorf("unexpected amount: %v", delegate.Amount)
+	}
+	if deactivate := scenario.StakeChanges[1]; deactivate.Action != "deactivate" || deactivate.Amount != "" {
+		t.Errorf("unexpected stake change: %v", deactivate)
+	}
+	if err := scenario.Check(); err != nil {
+		t.Errorf("stake changes should be valid, but got error: %v", err)
+	}
+}
+
+var withSnapshot = `
+name: Snapshot
+duration: 60
+snapshot:
+  restore: aged-chain
+  save: aged-chain-2
+`
+
+func TestParseWithSnapshotWorks(t *testing.T) {
+	scenario, err := ParseBytes([]byte(withSnapshot))
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	want := Snapshot{Restore: "aged-chain", Save: "aged-chain-2"}
+	if scenario.Snapshot == nil || *scenario.Snapshot != want {
+		t.Fatalf("unexpected snapshot: got: %v, want: %v", scenario.Snapshot, want)
+	}
+	if err := scenario.Check(); err != nil {
+		t.Errorf("snapshot should be valid, but got error: %v", err)
+	}
+}
+
 var withCheats = smallExample + `
 
 cheats:
@@ -228,3 +430,109 @@ network_rules:
           YET_ANOTHER_RULE: abcdef
 
 `
+
+func TestParseFile_ReplayFilesAreRelativeToScenario(t *testing.T) {
+	dir := t.TempDir()
+	absolute := filepath.Join(t.TempDir(), "trace.rlp")
+	for _, file := range []string{filepath.Join(dir, "traces", "trace.jsonl"), absolute} {
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "scenario.yml")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(replayExample, absolute)), 0600); err != nil {
+		t.Fatalf("failed to write scenario: %v", err)
+	}
+	scenario, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	getOptions := func(i int) *app.ReplayOptions {
+		t.Helper()
+		config, err := scenario.Applications[i].GetConfig()
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		return config.(*app.ReplayOptions)
+	}
+	if got, want := getOptions(0).File, filepath.Join(dir, "traces", "trace.jsonl"); got != want {
+		t.Errorf("unexpected relative replay file, wanted %v, got %v", want, got)
+	}
+	if got, want := getOptions(1).File, absolute; got != want {
+		t.Errorf("unexpected absolute replay file, wanted %v, got %v", want, got)
+	}
+	if got, want := getOptions(1).Speed, float64(2); got != want {
+		t.Errorf("unexpected replay speed, wanted %v, got %v", want, got)
+	}
+}
+
+var replayExample = `
+name: Replay Example
+
+applications:
+  - name: relative
+    type: replay
+    config:
+      file: traces/trace.jsonl
+  - name: absolute
+    type: replay
+    config:
+      file: %s
+      speed: 2
+`
+
+func TestParseFile_ComposedRatesAreParsedAndTracesAreRelativeToScenario(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scenario.yml")
+	if err := os.WriteFile(path, []byte(composedRateExample), 0600); err != nil {
+		t.Fatalf("failed to write scenario: %v", err)
+	}
+	scenario, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("parsing of input failed: %v", err)
+	}
+	sequence := scenario.Applications[0].Rate.Sequence
+	if len(sequence) != 3 {
+		t.Fatalf("unexpected number of phases, wanted 3, got %d", len(sequence))
+	}
+	if sequence[0].Duration == nil || *sequence[0].Duration != 30 || sequence[0].Rate.Constant == nil || *sequence[0].Rate.Constant != 20 {
+		t.Errorf("unexpected first phase %v", sequence[0])
+	}
+	if schedule := sequence[1].Rate.Schedule; schedule == nil || len(schedule.Points) != 2 || schedule.Interpolation != "linear" {
+		t.Errorf("unexpected schedule %v", schedule)
+	}
+	sum := sequence[2].Rate.Sum
+	if sequence[2].Duration != nil || len(sum) != 2 || sum[1].Trace == nil {
+		t.Fatalf("unexpected last phase %v", sequence[2])
+	}
+	if got, want := sum[1].Trace.File, filepath.Join(dir, "traces", "rate.csv"); got != want {
+		t.Errorf("unexpected relative trace file, wanted %v, got %v", want, got)
+	}
+}
+
+var composedRateExample = `
+name: Composed Rate Example
+
+applications:
+  - name: composed
+    type: counter
+    rate:
+      sequence:
+        - duration: 30
+          constant: 20
+        - duration: 30
+          schedule:
+            interpolation: linear
+            points:
+              - time: 0
+                rate: 20
+              - time: 30
+                rate: 100
+        - sum:
+          - constant: 10
+          - trace:
+              file: traces/rate.csv
+`
@@ -0,0 +1,134 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// SendErrorClass is a category of errors reported when sending transactions.
+type SendErrorClass int
+
+const (
+	NonceError       SendErrorClass = iota // < the nonce of the transaction is too low or too high
+	UnderpricedError                       // < the fees of the transaction are insufficient
+	PoolFullError                          // < the transaction pool or an account slot limit is exhausted
+	ConnectionError                        // < the connection to the node failed
+	OtherError                             // < any other rejection of the transaction
+	numSendErrorClasses
+)
+
+// SendErrorClasses lists all classes of send errors.
+var SendErrorClasses = []SendErrorClass{NonceError, UnderpricedError, PoolFullError, ConnectionError, OtherError}
+
+func (c SendErrorClass) String() string {
+	switch c {
+	case NonceError:
+		return "nonce"
+	case UnderpricedError:
+		return "underpriced"
+	case PoolFullError:
+		return "pool_full"
+	case ConnectionError:
+		return "connection"
+	default:
+		return "other"
+	}
+}
+
+// ClassifySendError determines the class of an error returned when sending a
+// transaction. Errors of nodes are only available as messages over RPC, thus
+// the classification is based on the messages of the transaction pool.
+func ClassifySendError(err error) SendErrorClass {
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return ConnectionError
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "nonce"):
+		return NonceError
+	case strings.Contains(msg, "underpriced"),
+		strings.Contains(msg, "fee cap"),
+		strings.Contains(msg, "less than block base fee"):
+		return UnderpricedError
+	case strings.Contains(msg, "pool is full"),
+		strings.Contains(msg, "txpool is full"),
+		strings.Contains(msg, "limit exceeded"):
+		return PoolFullError
+	case strings.Contains(msg, "connection"),
+		strings.Contains(msg, "broken pipe"),
+		strings.Contains(msg, "timeout"):
+		return ConnectionError
+	}
+	return OtherError
+}
+
+// SendErrors summarizes the errors reported when sending transactions, with
+// one count per class of errors.
+type SendErrors [numSendErrorClasses]uint64
+
+// Total returns the number of errors of all classes.
+func (e SendErrors) Total() uint64 {
+	res := uint64(0)
+	for _, count := range e {
+		res += count
+	}
+	return res
+}
+
+// Add returns the sum of two summaries of errors.
+func (e SendErrors) Add(other SendErrors) SendErrors {
+	for i := range e {
+		e[i] += other[i]
+	}
+	return e
+}
+
+// SendErrorCounter counts errors reported when sending transactions by their
+// class. It is safe to be used concurrently. The zero value is ready to use.
+type SendErrorCounter struct {
+	counts [numSendErrorClasses]atomic.Uint64
+}
+
+// Count classifies the given error and increments the count of its class.
+// The error must not be nil.
+func (c *SendErrorCounter) Count(err error) SendErrorClass {
+	class := ClassifySendError(err)
+	c.counts[class].Add(1)
+	return class
+}
+
+// Get returns the current counts of all classes.
+func (c *SendErrorCounter) Get() SendErrors {
+	var res SendErrors
+	for i := range res {
+		res[i] = c.counts[i].Load()
+	}
+	return res
+}
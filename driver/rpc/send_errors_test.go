@@ -0,0 +1,82 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestClassifySendError_ErrorsOfTxPoolAreClassified(t *testing.T) {
+	tests := map[string]SendErrorClass{
+		"nonce too low":                            NonceError,
+		"nonce too high":                           NonceError,
+		"transaction underpriced":                  UnderpricedError,
+		"replacement transaction underpriced":      UnderpricedError,
+		"max fee per gas less than block base fee": UnderpricedError,
+		"txpool is full":                           PoolFullError,
+		"account limit exceeded":                   PoolFullError,
+		"dial tcp: connection refused":             ConnectionError,
+		"already known":                            OtherError,
+		"exceeds block gas limit":                  OtherError,
+	}
+	for msg, want := range tests {
+		if got := ClassifySendError(errors.New(msg)); got != want {
+			t.Errorf("unexpected class of %q, wanted %v, got %v", msg, want, got)
+		}
+	}
+}
+
+func TestClassifySendError_ConnectionErrorsAreDetectedByType(t *testing.T) {
+	tests := []error{
+		io.EOF,
+		fmt.Errorf("failed; %w", context.DeadlineExceeded),
+		&net.OpError{Op: "dial", Err: errors.New("nonce")},
+	}
+	for _, err := range tests {
+		if got := ClassifySendError(err); got != ConnectionError {
+			t.Errorf("unexpected class of %v, wanted %v, got %v", err, ConnectionError, got)
+		}
+	}
+}
+
+func TestSendErrorCounter_ErrorsAreCountedByClass(t *testing.T) {
+	counter := SendErrorCounter{}
+	counter.Count(errors.New("nonce too low"))
+	counter.Count(errors.New("nonce too high"))
+	if got := counter.Count(errors.New("txpool is full")); got != PoolFullError {
+		t.Errorf("unexpected class, wanted %v, got %v", PoolFullError, got)
+	}
+
+	got := counter.Get()
+	want := SendErrors{}
+	want[NonceError] = 2
+	want[PoolFullError] = 1
+	if got != want {
+		t.Errorf("unexpected counts, wanted %v, got %v", want, got)
+	}
+	if got.Total() != 3 {
+		t.Errorf("unexpected total, wanted 3, got %d", got.Total())
+	}
+	if sum := got.Add(got); sum.Total() != 6 || sum[NonceError] != 4 {
+		t.Errorf("unexpected sum %v", sum)
+	}
+}
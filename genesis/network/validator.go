@@ -60,3 +60,109 @@ func RegisterValidatorNode(backend ContractBackend) (int, error) {
 
 	return newValId, nil
 }
+
+// DelegateStake increases the self-stake of the validator with the given ID by
+// the given amount of wei, paid from the account of the validator.
+func DelegateStake(backend ContractBackend, validatorId int, amount *big.Int) error {
+	SFCContract, txOpts, err := getValidatorTransactor(backend, validatorId)
+	if err != nil {
+		return err
+	}
+	txOpts.Value = amount
+
+	tx, err := SFCContract.Delegate(txOpts, big.NewInt(int64(validatorId)))
+	if err != nil {
+		return fmt.Errorf("failed to delegate to validator %d; %v", validatorId, err)
+	}
+	return waitForSuccess(backend, tx)
+}
+
+// UndelegateStake decreases the self-stake of the validator with the given ID
+// by the given amount of wei. The SFC rejects undelegations leaving less than
+// the minimum self-stake unless the complete self-stake is undelegated.
+func UndelegateStake(backend ContractBackend, validatorId int, amount *big.Int) error {
+	SFCContract, txOpts, err := getValidatorTransactor(backend, validatorId)
+	if err != nil {
+		return err
+	}
+	return undelegate(backend, SFCContract, txOpts, validatorId, amount)
+}
+
+// DeactivateValidator gracefully ends the validator with the given ID by
+// undelegating its complete self-stake. The SFC marks the validator as
+// withdrawn, such that it is no longer part of the validator set starting
+// with the next epoch. The deactivateValidator method of the SFC can not be
+// used for this, as it is reserved to the node driver.
+func DeactivateValidator(backend ContractBackend, validatorId int) error {
+	SFCContract, txOpts, err := getValidatorTransactor(backend, validatorId)
+	if err != nil {
+		return err
+	}
+
+	stake, err := SFCContract.GetSelfStake(nil, big.NewInt(int64(validatorId)))
+	if err != nil {
+		return fmt.Errorf("failed to get self-stake of validator %d; %v", validatorId, err)
+	}
+	if stake.Sign() == 0 {
+		return fmt.Errorf("validator %d has no self-stake", validatorId)
+	}
+	return undelegate(backend, SFCContract, txOpts, validatorId, stake)
+}
+
+// undelegate undelegates the given amount of wei from the validator with the
+// given ID using the first unused withdrawal request ID of the sender.
+func undelegate(backend ContractBackend, SFCContract *sfc100.Contract, txOpts *bind.TransactOpts, validatorId int, amount *big.Int) error {
+	valId := big.NewInt(int64(validatorId))
+	wrId := big.NewInt(0)
+	for ; ; wrId.Add(wrId, big.NewInt(1)) {
+		request, err := SFCContract.GetWithdrawalRequest(nil, txOpts.From, valId, wrId)
+		if err != nil {
+			return fmt.Errorf("failed to get withdrawal request; %v", err)
+		}
+		if request.Amount == nil || request.Amount.Sign() == 0 {
+			break
+		}
+	}
+
+	tx, err := SFCContract.Undelegate(txOpts, valId, wrId, amount)
+	if err != nil {
+		return fmt.Errorf("failed to undelegate from validator %d; %v", validatorId, err)
+	}
+	return waitForSuccess(backend, tx)
+}
+
+// getValidatorTransactor returns a representation of the deployed SFC contract
+// and options to send transactions signed by the validator with the given ID.
+// Like RegisterValidatorNode, it uses the sfc100 bindings of Sonic, since the
+// SFC bindings in load/contracts/abi of the main module only cover the calls
+// of the load generator and can not be imported by this module.
+func getValidatorTransactor(backend ContractBackend, validatorId int) (*sfc100.Contract, *bind.TransactOpts, error) {
+	if validatorId <= 0 {
+		return nil, nil, fmt.Errorf("invalid validator ID %d", validatorId)
+	}
+
+	SFCContract, err := sfc100.NewContract(sfc.ContractAddress, backend)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get SFC contract representation; %v", err)
+	}
+
+	privateKeyECDSA := evmcore.FakeKey(uint32(validatorId))
+	txOpts, err := bind.NewKeyedTransactorWithChainID(privateKeyECDSA, big.NewInt(int64(opera.FakeNetRules(opera.SonicFeatures).NetworkID)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create txOpts; %v", err)
+	}
+	return SFCContract, txOpts, nil
+}
+
+// waitForSuccess waits for the receipt of the given transaction and fails if
+// the transaction was reverted.
+func waitForSuccess(backend ContractBackend, tx *types.Transaction) error {
+	receipt, err := backend.WaitTransactionReceipt(tx.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get receipt; %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("transaction reverted")
+	}
+	return nil
+}
@@ -88,3 +88,169 @@ func mockBackendForCreateValidator(t *testing.T, test func(backend *MockContract
 
 	test(backend)
 }
+
+func TestDelegateStake_Success(t *testing.T) {
+	backend := mockBackendForTransaction(t, types.ReceiptStatusSuccessful)
+	backend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil)
+	if err := DelegateStake(backend, 2, big.NewInt(1000)); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestDelegateStake_Failure_TransactionReverted(t *testing.T) {
+	backend := mockBackendForTransaction(t, types.ReceiptStatusFailed)
+	backend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil)
+	if err := DelegateStake(backend, 2, big.NewInt(1000)); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestDelegateStake_Failure_InvalidValidatorId(t *testing.T) {
+	backend := NewMockContractBackend(gomock.NewController(t))
+	if err := DelegateStake(backend, 0, big.NewInt(1000)); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestUndelegateStake_UsesFirstUnusedWithdrawalRequestId(t *testing.T) {
+	backend := mockBackendForTransaction(t, types.ReceiptStatusSuccessful)
+	used := packUint256s(t, big.NewInt(1), big.NewInt(2), big.NewInt(3))
+	unused := packUint256s(t, big.NewInt(0), big.NewInt(0), big.NewInt(0))
+	gomock.InOrder(
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(used, nil),
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(unused, nil),
+	)
+	backend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, tx *types.Transaction) error {
+		args := unpackSfcCall(t, "undelegate", tx.Data())
+		if want, got := big.NewInt(1), args[1].(*big.Int); want.Cmp(got) != 0 {
+			t.Errorf("unexpected withdrawal request id, wanted %v, got %v", want, got)
+		}
+		if want, got := big.NewInt(1000), args[2].(*big.Int); want.Cmp(got) != 0 {
+			t.Errorf("unexpected amount, wanted %v, got %v", want, got)
+		}
+		return nil
+	})
+
+	if err := UndelegateStake(backend, 2, big.NewInt(1000)); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestDeactivateValidator_UndelegatesCompleteSelfStake(t *testing.T) {
+	backend := mockBackendForTransaction(t, types.ReceiptStatusSuccessful)
+	stake := packUint256s(t, big.NewInt(5000))
+	unused := packUint256s(t, big.NewInt(0), big.NewInt(0), big.NewInt(0))
+	gomock.InOrder(
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(stake, nil),
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(unused, nil),
+	)
+	backend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, tx *types.Transaction) error {
+		args := unpackSfcCall(t, "undelegate", tx.Data())
+		if want, got := big.NewInt(2), args[0].(*big.Int); want.Cmp(got) != 0 {
+			t.Errorf("unexpected validator id, wanted %v, got %v", want, got)
+		}
+		if want, got := big.NewInt(5000), args[2].(*big.Int); want.Cmp(got) != 0 {
+			t.Errorf("unexpected amount, wanted %v, got %v", want, got)
+		}
+		return nil
+	})
+
+	if err := DeactivateValidator(backend, 2); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestDeactivateValidator_SkipsExistingWithdrawalRequests(t *testing.T) {
+	backend := mockBackendForTransaction(t, types.ReceiptStatusSuccessful)
+	stake := packUint256s(t, big.NewInt(5000))
+	used := packUint256s(t, big.NewInt(1), big.NewInt(2), big.NewInt(3))
+	unused := packUint256s(t, big.NewInt(0), big.NewInt(0), big.NewInt(0))
+	gomock.InOrder(
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(stake, nil),
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(used, nil).Times(2),
+		backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(unused, nil),
+	)
+	backend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, tx *types.Transaction) error {
+		args := unpackSfcCall(t, "undelegate", tx.Data())
+		if want, got := big.NewInt(2), args[1].(*big.Int); want.Cmp(got) != 0 {
+			t.Errorf("unexpected withdrawal request id, wanted %v, got %v", want, got)
+		}
+		if want, got := big.NewInt(5000), args[2].(*big.Int); want.Cmp(got) != 0 {
+			t.Errorf("unexpected amount, wanted %v, got %v", want, got)
+		}
+		return nil
+	})
+
+	if err := DeactivateValidator(backend, 2); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestDeactivateValidator_Failure_NoSelfStake(t *testing.T) {
+	backend := NewMockContractBackend(gomock.NewController(t))
+	backend.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(packUint256s(t, big.NewInt(0)), nil)
+
+	if err := DeactivateValidator(backend, 2); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+// mockBackendForTransaction creates a backend accepting the calls needed to
+// send a transaction to the SFC contract, which produces a receipt with the
+// given status. Calls of the SFC contract and sending the transaction are to
+// be expected by the tests.
+func mockBackendForTransaction(t *testing.T, status uint64) *MockContractBackend {
+	bytecode, err := convertContractBytecode(sfc100.ContractMetaData.Bin)
+	if err != nil {
+		t.Fatalf("failed to decode contract bytecode: %v", err)
+	}
+	header := types.Header{BaseFee: big.NewInt(123)}
+
+	backend := NewMockContractBackend(gomock.NewController(t))
+	backend.EXPECT().HeaderByNumber(gomock.Any(), gomock.Any()).Return(&header, nil).AnyTimes()
+	backend.EXPECT().SuggestGasTipCap(gomock.Any()).Return(big.NewInt(123), nil).AnyTimes()
+	backend.EXPECT().PendingCodeAt(gomock.Any(), gomock.Any()).Return(bytecode, nil).AnyTimes()
+	backend.EXPECT().EstimateGas(gomock.Any(), gomock.Any()).Return(uint64(123), nil).AnyTimes()
+	backend.EXPECT().PendingNonceAt(gomock.Any(), gomock.Any()).Return(uint64(0), nil).AnyTimes()
+	backend.EXPECT().WaitTransactionReceipt(gomock.Any()).Return(&types.Receipt{Status: status}, nil).AnyTimes()
+	return backend
+}
+
+func packUint256s(t *testing.T, values ...*big.Int) []byte {
+	t.Helper()
+	uint256Type, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		t.Fatalf("failed to create uint256 type: %v", err)
+	}
+	args := make(abi.Arguments, len(values))
+	params := make([]any, len(values))
+	for i, value := range values {
+		args[i] = abi.Argument{Type: uint256Type}
+		params[i] = value
+	}
+	packed, err := args.Pack(params...)
+	if err != nil {
+		t.Fatalf("failed to pack values: %v", err)
+	}
+	return packed
+}
+
+func unpackSfcCall(t *testing.T, method string, data []byte) []any {
+	t.Helper()
+	contractAbi, err := sfc100.ContractMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	if len(data) < 4 {
+		t.Fatalf("transaction data too short")
+	}
+	called, err := contractAbi.MethodById(data[:4])
+	if err != nil || called.Name != method {
+		t.Fatalf("unexpected method called, wanted %s, got %v", method, called)
+	}
+	args, err := called.Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack arguments: %v", err)
+	}
+	return args
+}
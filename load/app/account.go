@@ -36,6 +36,9 @@ type AccountFactory struct {
 	chainID         *big.Int
 	numAccounts     int64
 	feederId, appId uint32
+
+	keys *KeyGenerator // < derives keys from a mnemonic if set, see AccountPool
+	pool *AccountPool  // < records created accounts if set, see AccountPool
 }
 
 // NewAccountFactory creates a new AccountFactory, generating accounts for given feeder and app.
@@ -52,11 +55,7 @@ func NewAccountFactory(chainID *big.Int, feederId, appId uint32) (*AccountFactor
 // CreateAccount generates the next account in the sequence generated by the AccountFactory.
 func (f *AccountFactory) CreateAccount(rpcClient rpc.Client) (*Account, error) {
 	id := atomic.AddInt64(&f.numAccounts, 1)
-	d := make([]byte, 32)
-	binary.BigEndian.PutUint64(d[:24], uint64(id))
-	binary.BigEndian.PutUint32(d[24:], f.feederId)
-	binary.BigEndian.PutUint32(d[28:], f.appId)
-	privateKey, err := crypto.ToECDSA(d)
+	privateKey, err := f.createPrivateKey(id)
 	if err != nil {
 		return nil, err
 	}
@@ -67,12 +66,31 @@ func (f *AccountFactory) CreateAccount(rpcClient rpc.Client) (*Account, error) {
 		return nil, fmt.Errorf("failed to get address nonce; %v", err)
 	}
 
-	return &Account{
+	account := &Account{
 		privateKey: privateKey,
 		address:    address,
 		chainID:    f.chainID,
 		nonce:      nonce,
-	}, nil
+
+		nonceTracker: nonceTracker{confirmed: nonce},
+	}
+	if f.pool != nil {
+		f.pool.add(account)
+	}
+	return account, nil
+}
+
+// createPrivateKey derives the key of the account with the given position in
+// the sequence of the factory.
+func (f *AccountFactory) createPrivateKey(id int64) (*ecdsa.PrivateKey, error) {
+	if f.keys != nil {
+		return f.keys.GeneratePrivateKey(uint32(id))
+	}
+	d := make([]byte, 32)
+	binary.BigEndian.PutUint64(d[:24], uint64(id))
+	binary.BigEndian.PutUint32(d[24:], f.feederId)
+	binary.BigEndian.PutUint32(d[28:], f.appId)
+	return crypto.ToECDSA(d)
 }
 
 // Account represents an account from which we can send transactions.
@@ -85,6 +103,10 @@ type Account struct {
 	chainID    *big.Int
 	nonce      uint64
 	publicKey  []byte
+
+	nonceTracker nonceTracker                // < unconfirmed transactions, see RecoverNonce
+	fees         atomic.Pointer[FeeStrategy] // < the fees of new transactions, see SetFeeStrategy
+	txTypes      atomic.Pointer[TxTypeMix]   // < the types of new transactions, see SetTxTypes
 }
 
 // NewAccount creates an Account instance from the provided private key
@@ -104,8 +126,20 @@ func NewAccount(id int, privateKeyHex string, publicKey []byte, chainID int64) (
 	}, nil
 }
 
+// GetAddress returns the address of the account.
+func (a *Account) GetAddress() common.Address {
+	return a.address
+}
+
 // getNextNonce provides a nonce to be used for next transactions sent using this account
 func (a *Account) getNextNonce() uint64 {
 	current := atomic.AddUint64(&a.nonce, 1)
 	return current - 1
 }
+
+// releaseNonce returns a nonce obtained from getNextNonce which is not used
+// by any transaction. This is only possible as long as no later nonce has
+// been handed out, otherwise the gap remains. It returns true on success.
+func (a *Account) releaseNonce(nonce uint64) bool {
+	return atomic.CompareAndSwapUint64(&a.nonce, nonce+1, nonce)
+}
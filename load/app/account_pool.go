@@ -0,0 +1,275 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// sweepTimeout is the maximum time waited for pending transactions of the
+// accounts of a pool before their balances are swept.
+const sweepTimeout = 10 * time.Second
+
+// AccountOptions configures the life-cycle of the accounts of the users of an
+// application, allowing accounts and their funds to be reused across runs.
+type AccountOptions struct {
+	// Mnemonic is the BIP-39 phrase the keys of the accounts are derived from.
+	// Empty is interpreted as the default Mnemonic.
+	Mnemonic string
+	// TopUpThreshold is the balance in wei below which accounts are funded.
+	// Nil is interpreted as the amount funded by the application.
+	TopUpThreshold *big.Int
+	// Sweep enables returning the remaining balances of the accounts to the
+	// treasury when the application is stopped.
+	Sweep bool
+}
+
+// AccountPool is an AppContext managing the accounts of the users of a single
+// application. Keys of accounts are derived from a mnemonic, such that runs
+// using the same mnemonic and application IDs reuse the same accounts. Accounts
+// are only funded if their balance is below a threshold, and their remaining
+// funds may be swept back to the treasury at the end of the application.
+type AccountPool struct {
+	AppContext
+	options AccountOptions
+
+	mutex    sync.Mutex
+	accounts []*Account // < accounts created by factories of the pool
+}
+
+// NewAccountPool creates a pool managing accounts according to the given
+// options, delegating all other operations to the given context.
+func NewAccountPool(context AppContext, options AccountOptions) (*AccountPool, error) {
+	if options.Mnemonic == "" {
+		options.Mnemonic = Mnemonic
+	}
+	if _, err := NewKeyGenerator(options.Mnemonic, 0, 0); err != nil {
+		return nil, err
+	}
+	return &AccountPool{
+		AppContext: context,
+		options:    options,
+	}, nil
+}
+
+// newAccountFactory creates the factory for the accounts of the users of an
+// application. If the given context is an AccountPool, accounts are derived
+// from the mnemonic of the pool.
+func newAccountFactory(context AppContext, chainID *big.Int, feederId, appId uint32) (*AccountFactory, error) {
+	pool, ok := context.(*AccountPool)
+	if !ok {
+		return NewAccountFactory(chainID, feederId, appId)
+	}
+	keys, err := NewKeyGenerator(pool.options.Mnemonic, feederId, appId)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountFactory{
+		chainID:  chainID,
+		feederId: feederId,
+		appId:    appId,
+		keys:     keys,
+		pool:     pool,
+	}, nil
+}
+
+func (p *AccountPool) add(account *Account) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.accounts = append(p.accounts, account)
+}
+
+// FundAccounts tops up each of the given accounts holding less than the
+// top-up threshold to the given amount of funds. Only the shortfall of each
+// account is transferred from the treasury.
+func (p *AccountPool) FundAccounts(accounts []common.Address, value *big.Int) error {
+	threshold := p.options.TopUpThreshold
+	if threshold == nil {
+		threshold = value
+	}
+	client := p.GetClient()
+
+	// Accounts lacking the same amount are funded together.
+	amounts := []*big.Int{}
+	lacking := map[string][]common.Address{}
+	count := 0
+	for _, address := range accounts {
+		balance, err := client.BalanceAt(context.Background(), address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of account %v; %w", address, err)
+		}
+		if balance.Cmp(threshold) >= 0 {
+			continue
+		}
+		// balances are never negative, so the shortfall is at most value
+		shortfall := new(big.Int).Sub(value, balance)
+		if shortfall.Sign() <= 0 {
+			continue
+		}
+		key := shortfall.String()
+		if _, found := lacking[key]; !found {
+			amounts = append(amounts, shortfall)
+		}
+		lacking[key] = append(lacking[key], address)
+		count++
+	}
+	log.Printf("topping up %d of %d accounts", count, len(accounts))
+	for _, amount := range amounts {
+		if err := p.AppContext.FundAccounts(lacking[amount.String()], amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep transfers the remaining balances of all accounts of the pool back to
+// the treasury using the given client, if enabled. Pending transactions of the
+// accounts are awaited for a limited time before their balances are collected.
+// Accounts with transactions still pending after that time are not swept and
+// reported in the returned error.
+func (p *AccountPool) Sweep(client rpc.Client) error {
+	if !p.options.Sweep {
+		return nil
+	}
+	p.mutex.Lock()
+	accounts := slices.Clone(p.accounts)
+	p.mutex.Unlock()
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	regularPrice, err := GetGasPrice(client)
+	if err != nil {
+		return err
+	}
+	// Sweeps are priced with headroom to remain includable with rising base
+	// fees, since they are the last transactions of their accounts.
+	gasPrice := getPriorityGasPrice(regularPrice)
+	treasury := p.GetTreasure().GetAddress()
+	deadline := time.Now().Add(sweepTimeout)
+
+	errs := []error{}
+	txs := make([]*types.Transaction, 0, len(accounts))
+	for _, account := range accounts {
+		tx, err := createSweepTx(client, account, treasury, gasPrice, deadline)
+		if err == nil && tx != nil {
+			err = client.SendTransaction(context.Background(), tx)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to sweep account %v; %w", account.address, err))
+			continue
+		}
+		if tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+
+	returned := new(big.Int)
+	for _, tx := range txs {
+		receipt, err := client.WaitTransactionReceipt(tx.Hash())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get receipt of sweep; %w", err))
+			continue
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			errs = append(errs, fmt.Errorf("sweep transaction %v reverted", tx.Hash()))
+			continue
+		}
+		returned.Add(returned, tx.Value())
+	}
+	log.Printf("swept %d accounts, returned %v wei to the treasury", len(txs), returned)
+	return errors.Join(errs...)
+}
+
+// createSweepTx creates a transfer of the balance of the given account to
+// the treasury, minus the fees of the transfer. The result is nil if the
+// balance does not cover the fees. An error is returned if transactions of
+// the account are still pending at the deadline, since a sweep would conflict
+// with them.
+func createSweepTx(client rpc.Client, account *Account, treasury common.Address, gasPrice *big.Int, deadline time.Time) (*types.Transaction, error) {
+	nonce, err := waitForPendingTransactions(client, account, deadline)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := client.BalanceAt(context.Background(), account.address, nil)
+	if err != nil {
+		return nil, err
+	}
+	fees := new(big.Int).Mul(gasPrice, big.NewInt(int64(params.TxGas)))
+	if balance.Cmp(fees) <= 0 {
+		return nil, nil
+	}
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      params.TxGas,
+		To:       &treasury,
+		Value:    new(big.Int).Sub(balance, fees),
+	})
+	return types.SignTx(tx, types.LatestSignerForChainID(account.chainID), account.privateKey)
+}
+
+// waitForPendingTransactions waits until all transactions sent from the given
+// account are processed. It returns the nonce of the account at the latest
+// block, or an error if transactions are still pending at the deadline.
+func waitForPendingTransactions(client rpc.Client, account *Account, deadline time.Time) (uint64, error) {
+	for {
+		nonce, err := client.NonceAt(context.Background(), account.address, nil)
+		if err != nil {
+			return 0, err
+		}
+		sent := atomic.LoadUint64(&account.nonce)
+		if nonce >= sent {
+			return nonce, nil
+		}
+		if !time.Now().Before(deadline) {
+			return 0, fmt.Errorf("%d transactions still pending", sent-nonce)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// DialNodes connects to the nodes of the given names if supported by the
+// context of the pool.
+func (p *AccountPool) DialNodes(names []string) ([]rpc.Client, error) {
+	if dialer, ok := p.AppContext.(NodeDialer); ok {
+		return dialer.DialNodes(names)
+	}
+	return nil, fmt.Errorf("network does not support connecting to selected nodes")
+}
+
+// GetWebSocketUrls lists the WebSocket endpoints of the nodes of the given
+// names if supported by the context of the pool.
+func (p *AccountPool) GetWebSocketUrls(names []string) ([]string, error) {
+	if provider, ok := p.AppContext.(WebSocketProvider); ok {
+		return provider.GetWebSocketUrls(names)
+	}
+	return nil, fmt.Errorf("network does not provide WebSocket endpoints")
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccountPool_AccountsAreDerivedFromMnemonic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	client.EXPECT().NonceAt(gomock.Any(), gomock.Any(), nil).Return(uint64(0), nil).AnyTimes()
+
+	createAddresses := func(context AppContext) []common.Address {
+		factory, err := newAccountFactory(context, big.NewInt(0xfa3), 1, 2)
+		if err != nil {
+			t.Fatalf("failed to create factory: %v", err)
+		}
+		res := []common.Address{}
+		for i := 0; i < 3; i++ {
+			account, err := factory.CreateAccount(client)
+			if err != nil {
+				t.Fatalf("failed to create account: %v", err)
+			}
+			res = append(res, account.GetAddress())
+		}
+		return res
+	}
+
+	pool, err := NewAccountPool(NewMockAppContext(ctrl), AccountOptions{})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	first := createAddresses(pool)
+	if len(pool.accounts) != 3 {
+		t.Errorf("accounts were not recorded by the pool, got %d", len(pool.accounts))
+	}
+
+	again, err := NewAccountPool(NewMockAppContext(ctrl), AccountOptions{Mnemonic: Mnemonic})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	if second := createAddresses(again); !equalAddresses(first, second) {
+		t.Errorf("accounts are not reused, got %v and %v", first, second)
+	}
+
+	other, err := NewAccountPool(NewMockAppContext(ctrl), AccountOptions{
+		Mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	if third := createAddresses(other); equalAddresses(first, third) {
+		t.Errorf("accounts of different mnemonics are equal")
+	}
+	if fourth := createAddresses(NewMockAppContext(ctrl)); equalAddresses(first, fourth) {
+		t.Errorf("accounts without pool are derived from mnemonic")
+	}
+}
+
+func equalAddresses(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAccountPool_InvalidMnemonicIsRejected(t *testing.T) {
+	if _, err := NewAccountPool(nil, AccountOptions{Mnemonic: "not a mnemonic"}); err == nil {
+		t.Errorf("invalid mnemonic was not rejected")
+	}
+}
+
+func TestAccountPool_OnlyAccountsBelowThresholdAreFunded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	appContext := NewMockAppContext(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+
+	poor, rich, empty := common.Address{1}, common.Address{2}, common.Address{3}
+	client.EXPECT().BalanceAt(gomock.Any(), poor, nil).Return(big.NewInt(99), nil)
+	client.EXPECT().BalanceAt(gomock.Any(), rich, nil).Return(big.NewInt(100), nil)
+	client.EXPECT().BalanceAt(gomock.Any(), empty, nil).Return(big.NewInt(0), nil)
+	appContext.EXPECT().FundAccounts([]common.Address{poor}, big.NewInt(401))
+	appContext.EXPECT().FundAccounts([]common.Address{empty}, big.NewInt(500))
+
+	pool, err := NewAccountPool(appContext, AccountOptions{TopUpThreshold: big.NewInt(100)})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	if err := pool.FundAccounts([]common.Address{poor, rich, empty}, big.NewInt(500)); err != nil {
+		t.Errorf("failed to fund accounts: %v", err)
+	}
+
+	// without a threshold, accounts holding the funded amount are skipped
+	client.EXPECT().BalanceAt(gomock.Any(), rich, nil).Return(big.NewInt(500), nil)
+	pool.options.TopUpThreshold = nil
+	if err := pool.FundAccounts([]common.Address{rich}, big.NewInt(500)); err != nil {
+		t.Errorf("failed to fund accounts: %v", err)
+	}
+}
+
+func TestAccountPool_PartlyFundedAccountsReceiveOnlyTheirShortfall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	appContext := NewMockAppContext(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+
+	a, b, c, d := common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+	client.EXPECT().BalanceAt(gomock.Any(), a, nil).Return(big.NewInt(300), nil)
+	client.EXPECT().BalanceAt(gomock.Any(), b, nil).Return(big.NewInt(0), nil)
+	client.EXPECT().BalanceAt(gomock.Any(), c, nil).Return(big.NewInt(300), nil)
+	client.EXPECT().BalanceAt(gomock.Any(), d, nil).Return(big.NewInt(400), nil)
+	gomock.InOrder(
+		appContext.EXPECT().FundAccounts([]common.Address{a, c}, big.NewInt(200)),
+		appContext.EXPECT().FundAccounts([]common.Address{b}, big.NewInt(500)),
+		appContext.EXPECT().FundAccounts([]common.Address{d}, big.NewInt(100)),
+	)
+
+	pool, err := NewAccountPool(appContext, AccountOptions{TopUpThreshold: big.NewInt(450)})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	if err := pool.FundAccounts([]common.Address{a, b, c, d}, big.NewInt(500)); err != nil {
+		t.Errorf("failed to fund accounts: %v", err)
+	}
+}
+
+func TestAccountPool_BalancesAreSweptToTreasury(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	appContext := NewMockAppContext(ctrl)
+	client := rpc.NewMockClient(ctrl)
+
+	treasury, err := NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appContext.EXPECT().GetTreasure().Return(treasury).AnyTimes()
+
+	pool, err := NewAccountPool(appContext, AccountOptions{Sweep: true})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	client.EXPECT().NonceAt(gomock.Any(), gomock.Any(), nil).Return(uint64(4), nil).AnyTimes()
+	factory, err := newAccountFactory(pool, big.NewInt(0xfa3), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funded, err := factory.CreateAccount(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drained, err := factory.CreateAccount(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.EXPECT().SuggestGasPrice(gomock.Any()).Return(big.NewInt(5), nil)
+	fees := int64(4 * 5 * params.TxGas)
+	client.EXPECT().BalanceAt(gomock.Any(), funded.GetAddress(), nil).Return(big.NewInt(1_000_000), nil)
+	client.EXPECT().BalanceAt(gomock.Any(), drained.GetAddress(), nil).Return(big.NewInt(fees), nil)
+	client.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx *types.Transaction) error {
+		sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil || sender != funded.GetAddress() {
+			t.Errorf("unexpected sender of sweep %v, err %v", sender, err)
+		}
+		if *tx.To() != treasury.GetAddress() || tx.Nonce() != 4 || tx.Value().Int64() != 1_000_000-fees {
+			t.Errorf("unexpected sweep to %v with nonce %d and value %v", tx.To(), tx.Nonce(), tx.Value())
+		}
+		return nil
+	})
+	client.EXPECT().WaitTransactionReceipt(gomock.Any()).Return(&types.Receipt{Status: types.ReceiptStatusSuccessful}, nil)
+
+	if err := pool.Sweep(client); err != nil {
+		t.Errorf("failed to sweep accounts: %v", err)
+	}
+
+	// sweeping is disabled by default
+	pool.options.Sweep = false
+	if err := pool.Sweep(client); err != nil {
+		t.Errorf("failed to skip sweeping: %v", err)
+	}
+}
+
+func TestAccountPool_AccountsWithPendingTransactionsAreNotSwept(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	account, err := NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.nonce = 5
+	client.EXPECT().NonceAt(gomock.Any(), account.GetAddress(), nil).Return(uint64(3), nil)
+
+	tx, err := createSweepTx(client, account, common.Address{1}, big.NewInt(1), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "2 transactions still pending") {
+		t.Errorf("pending transactions were not reported, got %v", err)
+	}
+	if tx != nil {
+		t.Errorf("sweep conflicting with pending transactions was created")
+	}
+}
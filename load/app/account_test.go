@@ -38,3 +38,23 @@ func TestAccount_CreateAccount_AccountsUniq(t *testing.T) {
 	}
 
 }
+
+func TestAccount_ReleasedNoncesAreReused(t *testing.T) {
+	account := &Account{}
+	first := account.getNextNonce()
+	if !account.releaseNonce(first) {
+		t.Fatalf("unused nonce could not be released")
+	}
+	if got := account.getNextNonce(); got != first {
+		t.Errorf("released nonce was not reused, wanted %d, got %d", first, got)
+	}
+
+	// nonces can not be released once later nonces are handed out
+	second := account.getNextNonce()
+	if account.releaseNonce(first) {
+		t.Errorf("nonce was released although a later nonce is in use")
+	}
+	if got := account.getNextNonce(); got != second+1 {
+		t.Errorf("unexpected nonce, wanted %d, got %d", second+1, got)
+	}
+}
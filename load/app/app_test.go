@@ -19,6 +19,9 @@ package app_test
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -26,6 +29,7 @@ import (
 	"github.com/0xsoniclabs/hyperion/driver/network"
 	"github.com/0xsoniclabs/hyperion/driver/network/local"
 	"github.com/0xsoniclabs/hyperion/load/app"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -78,6 +82,61 @@ func TestGenerators(t *testing.T) {
 		}
 		testGenerator(t, uniswapApp, context)
 	})
+	t.Run("Deployment", func(t *testing.T) {
+		deploymentApp, err := app.NewDeploymentApplication(context, app.DeploymentOptions{StorageSlots: 10}, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testGenerator(t, deploymentApp, context)
+	})
+	t.Run("Transfer", func(t *testing.T) {
+		transferApp, err := app.NewTransferApplication(context, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testGenerator(t, transferApp, context)
+	})
+	t.Run("Mix", func(t *testing.T) {
+		mixApp, err := app.NewMixApplication(context, app.MixOptions{
+			Operations: []app.MixOperation{
+				{Type: "transfer", Weight: 3},
+				{Type: "counter", Weight: 1},
+			},
+		}, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testGenerator(t, mixApp, context)
+	})
+	t.Run("Replay", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "trace.jsonl")
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer := app.NewReplayWriter(path, file)
+		recipient := common.Address{1}
+		for i := range 10 {
+			err := writer.Write(app.ReplayRecord{
+				Time:  time.Duration(i) * time.Millisecond,
+				From:  common.Address{byte(i % 3)},
+				To:    &recipient,
+				Value: big.NewInt(1),
+				Gas:   21_000,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		replayApp, err := app.NewReplayApplication(context, app.ReplayOptions{File: path}, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testGenerator(t, replayApp, context)
+	})
 }
 
 func testGenerator(t *testing.T, app app.Application, ctxt app.AppContext) {
@@ -58,6 +58,7 @@ func NewContext(factory RpcClientFactory, treasury *Account) (*appContext, error
 	// Create a context to interact with the network.
 	res := &appContext{
 		rpcClient: rpcClient,
+		factory:   factory,
 		treasury:  treasury,
 	}
 
@@ -76,6 +77,7 @@ func NewContext(factory RpcClientFactory, treasury *Account) (*appContext, error
 
 type appContext struct {
 	rpcClient rpc.Client       // < access to the network
+	factory   RpcClientFactory // < the source of additional connections
 	treasury  *Account         // < the account paying for management tasks
 	helper    *contract.Helper // < a contract used for on-chain operations
 }
@@ -88,6 +90,31 @@ func (c *appContext) GetClient() rpc.Client {
 	return c.rpcClient
 }
 
+// DialNodes connects to the nodes of the given names if supported by the
+// network. Without names, a connection to a random node is established.
+func (c *appContext) DialNodes(names []string) ([]rpc.Client, error) {
+	if dialer, ok := c.factory.(NodeDialer); ok {
+		return dialer.DialNodes(names)
+	}
+	if len(names) > 0 {
+		return nil, fmt.Errorf("network does not support connecting to selected nodes")
+	}
+	client, err := c.factory.DialRandomRpc()
+	if err != nil {
+		return nil, err
+	}
+	return []rpc.Client{client}, nil
+}
+
+// GetWebSocketUrls lists the WebSocket endpoints of the nodes of the given
+// names if supported by the network.
+func (c *appContext) GetWebSocketUrls(names []string) ([]string, error) {
+	if provider, ok := c.factory.(WebSocketProvider); ok {
+		return provider.GetWebSocketUrls(names)
+	}
+	return nil, fmt.Errorf("network does not provide WebSocket endpoints")
+}
+
 func (c *appContext) GetTreasure() *Account {
 	return c.treasury
 }
@@ -45,7 +45,7 @@ func NewCounterApplication(ctxt AppContext, feederId, appId uint32) (Application
 		return nil, fmt.Errorf("failed to deploy Counter contract; %w", err)
 	}
 
-	accountFactory, err := NewAccountFactory(chainId, feederId, appId)
+	accountFactory, err := newAccountFactory(ctxt, chainId, feederId, appId)
 	if err != nil {
 		return nil, err
 	}
@@ -139,3 +139,7 @@ func (g *CounterUser) GenerateTx() (*types.Transaction, error) {
 func (g *CounterUser) GetSentTransactions() uint64 {
 	return g.sentTxs.Load()
 }
+
+func (g *CounterUser) GetAccounts() []*Account {
+	return []*Account{g.sender}
+}
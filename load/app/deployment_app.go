@@ -0,0 +1,291 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	contract "github.com/0xsoniclabs/hyperion/load/contracts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// DefaultDeploymentCodeSize is the size of the deployed contract code in
+	// bytes used if no size is configured.
+	DefaultDeploymentCodeSize = 1024
+	// MaxDeploymentCodeSize is the maximum size of contract code accepted by
+	// the network (see EIP-170).
+	MaxDeploymentCodeSize = 24576
+	// MaxDeploymentStorageSlots is the maximum number of storage slots a
+	// deployment may initialize, keeping transactions within block limits.
+	MaxDeploymentStorageSlots = 1000
+)
+
+// DeploymentOptions defines the contracts created by deployment applications.
+type DeploymentOptions struct {
+	// CodeSize is the size of the deployed contract code in bytes, 0 is
+	// interpreted as DefaultDeploymentCodeSize.
+	CodeSize int `yaml:"code_size,omitempty"`
+	// StorageSlots is the number of storage slots initialized by the
+	// constructor of each deployed contract.
+	StorageSlots int `yaml:"storage_slots,omitempty"`
+}
+
+// Check tests the options for values outside of their supported ranges.
+func (o *DeploymentOptions) Check() error {
+	errs := []error{}
+	if o.CodeSize < 0 || o.CodeSize > MaxDeploymentCodeSize {
+		errs = append(errs, fmt.Errorf("invalid code size %d, must be 0 (default) or in range [1,%d]", o.CodeSize, MaxDeploymentCodeSize))
+	}
+	if o.StorageSlots < 0 || o.StorageSlots > MaxDeploymentStorageSlots {
+		errs = append(errs, fmt.Errorf("invalid number of storage slots %d, must be in range [0,%d]", o.StorageSlots, MaxDeploymentStorageSlots))
+	}
+	return errors.Join(errs...)
+}
+
+// NewDeploymentApplication creates an application whose users repeatedly deploy
+// contracts of a configurable code size, optionally initializing storage in the
+// constructor. It is intended to measure the cost of contract creation.
+// To count successful deployments, the constructor of each contract increments
+// a Counter contract deployed once for the application.
+func NewDeploymentApplication(ctxt AppContext, options DeploymentOptions, feederId, appId uint32) (Application, error) {
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+	if options.CodeSize == 0 {
+		options.CodeSize = DefaultDeploymentCodeSize
+	}
+
+	client := ctxt.GetClient()
+	chainId, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID; %w", err)
+	}
+
+	// Deploy the Counter contract tracking the number of successful deployments.
+	_, receipt, err := DeployContract(ctxt, contract.DeployCounter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy Counter contract; %w", err)
+	}
+
+	accountFactory, err := newAccountFactory(ctxt, chainId, feederId, appId)
+	if err != nil {
+		return nil, err
+	}
+
+	counterAbi, err := contract.CounterMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	// The code of deployed contracts is random to make it incompressible.
+	code := make([]byte, options.CodeSize)
+	if _, err := crand.Read(code); err != nil {
+		return nil, fmt.Errorf("failed to generate contract code; %w", err)
+	}
+	code[0] = 0x00 // < STOP, the code is never meant to be executed
+
+	initCode := newDeploymentInitCode(receipt.ContractAddress, counterAbi.Methods["incrementCounter"].ID, code, options.StorageSlots)
+
+	return &DeploymentApplication{
+		initCode:        initCode,
+		gasLimit:        getDeploymentGasLimit(len(initCode), len(code), options.StorageSlots),
+		contractAddress: receipt.ContractAddress,
+		accountFactory:  accountFactory,
+	}, nil
+}
+
+// DeploymentApplication represents a set of users deploying contracts. An
+// instance is connected to a Counter contract counting successful deployments.
+type DeploymentApplication struct {
+	initCode        []byte
+	gasLimit        uint64
+	contractAddress common.Address
+	accountFactory  *AccountFactory
+}
+
+// CreateUsers creates a list of new users for the app.
+func (f *DeploymentApplication) CreateUsers(appContext AppContext, numUsers int) ([]User, error) {
+	users := make([]User, numUsers)
+	addresses := make([]common.Address, numUsers)
+	for i := 0; i < numUsers; i++ {
+		// Generate a new account for each worker - avoid account nonces related bottlenecks
+		workerAccount, err := f.accountFactory.CreateAccount(appContext.GetClient())
+		if err != nil {
+			return nil, err
+		}
+		users[i] = &DeploymentUser{
+			sender:   workerAccount,
+			initCode: f.initCode,
+			gasLimit: f.gasLimit,
+		}
+		addresses[i] = workerAccount.address
+	}
+
+	fundsPerUser := big.NewInt(1_000)
+	fundsPerUser = new(big.Int).Mul(fundsPerUser, big.NewInt(1_000_000_000_000_000_000)) // to wei
+	err := appContext.FundAccounts(addresses, fundsPerUser)
+	return users, err
+}
+
+func (f *DeploymentApplication) GetReceivedTransactions(rpcClient rpc.Client) (uint64, error) {
+	// get a representation of the deployed contract
+	counterContract, err := contract.NewCounter(f.contractAddress, rpcClient)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get Counter contract representation; %w", err)
+	}
+	count, err := counterContract.GetCount(nil)
+	if err != nil {
+		return 0, err
+	}
+	return count.Uint64(), nil
+}
+
+// DeploymentUser represents a user sending contract creation transactions.
+// A generator is supposed to be used in a single thread.
+type DeploymentUser struct {
+	sender   *Account
+	initCode []byte
+	gasLimit uint64
+	sentTxs  atomic.Uint64
+}
+
+func (g *DeploymentUser) GenerateTx() (*types.Transaction, error) {
+	tx, err := createDeployTx(g.sender, g.initCode, g.gasLimit)
+	if err == nil {
+		g.sentTxs.Add(1)
+	}
+	return tx, err
+}
+
+func (g *DeploymentUser) GetSentTransactions() uint64 {
+	return g.sentTxs.Load()
+}
+
+func (g *DeploymentUser) GetAccounts() []*Account {
+	return []*Account{g.sender}
+}
+
+// newDeploymentInitCode assembles the init code of the deployed contracts. The
+// constructor calls the given method of the counter contract, reverting if the
+// call fails, initializes the storage slots [1,storageSlots], and returns the
+// given code as the code of the new contract.
+func newDeploymentInitCode(counter common.Address, selector []byte, code []byte, storageSlots int) []byte {
+	res := []byte{}
+	push1 := func(v byte) {
+		res = append(res, 0x60, v)
+	}
+	push2 := func(v uint16) {
+		res = append(res, 0x61)
+		res = binary.BigEndian.AppendUint16(res, v)
+	}
+	// The code is assembled in two passes since the jump targets and the
+	// offset of the contract code depend on the size of the init code.
+	var revertPos, loopPos, endPos, codePos uint16
+	assemble := func() {
+		res = res[:0]
+
+		// Call the counter: CALL(gas, counter, 0, 0, 4, 0, 0) with the selector in memory.
+		res = append(res, 0x63) // PUSH4
+		res = append(res, selector[:4]...)
+		push1(0xe0)
+		res = append(res, 0x1b) // SHL
+		push1(0)
+		res = append(res, 0x52) // MSTORE
+		push1(0)                // retLength
+		push1(0)                // retOffset
+		push1(4)                // argsLength
+		push1(0)                // argsOffset
+		push1(0)                // value
+		res = append(res, 0x73) // PUSH20
+		res = append(res, counter[:]...)
+		res = append(res, 0x5a) // GAS
+		res = append(res, 0xf1) // CALL
+		res = append(res, 0x15) // ISZERO
+		push2(revertPos)
+		res = append(res, 0x57) // JUMPI
+
+		// Initialize storage: for i := storageSlots; i != 0; i-- { storage[i] = 1 }
+		push2(uint16(storageSlots))
+		loopPos = uint16(len(res))
+		res = append(res, 0x5b) // JUMPDEST
+		res = append(res, 0x80) // DUP1
+		res = append(res, 0x15) // ISZERO
+		push2(endPos)
+		res = append(res, 0x57) // JUMPI
+		push1(1)
+		res = append(res, 0x81) // DUP2
+		res = append(res, 0x55) // SSTORE
+		push1(1)
+		res = append(res, 0x90) // SWAP1
+		res = append(res, 0x03) // SUB
+		push2(loopPos)
+		res = append(res, 0x56) // JUMP
+		endPos = uint16(len(res))
+		res = append(res, 0x5b) // JUMPDEST
+		res = append(res, 0x50) // POP
+
+		// Return the contract code: CODECOPY(0, codePos, size); RETURN(0, size)
+		push2(uint16(len(code)))
+		push2(codePos)
+		push1(0)
+		res = append(res, 0x39) // CODECOPY
+		push2(uint16(len(code)))
+		push1(0)
+		res = append(res, 0xf3) // RETURN
+
+		// Revert if the counter could not be incremented.
+		revertPos = uint16(len(res))
+		res = append(res, 0x5b) // JUMPDEST
+		push1(0)
+		res = append(res, 0x80) // DUP1
+		res = append(res, 0xfd) // REVERT
+
+		codePos = uint16(len(res))
+	}
+	assemble()
+	assemble()
+	return append(res, code...)
+}
+
+// getDeploymentGasLimit provides an upper bound for the gas consumed by a
+// contract creation using the given init code properties.
+func getDeploymentGasLimit(initCodeSize, codeSize, storageSlots int) uint64 {
+	const (
+		baseCost         = 53_000 // transaction + contract creation
+		calldataCost     = 16     // per non-zero byte of init code
+		initCodeWordCost = 2      // per word of init code (EIP-3860)
+		codeDepositCost  = 200    // per byte of deployed code
+		storageSlotCost  = 22_200 // per initialized slot, including loop overhead
+		counterCallCost  = 50_000 // incrementing the counter, including cold access
+	)
+	words := (initCodeSize + 31) / 32
+	return uint64(baseCost +
+		calldataCost*initCodeSize +
+		initCodeWordCost*words +
+		codeDepositCost*codeSize +
+		storageSlotCost*storageSlots +
+		counterCallCost)
+}
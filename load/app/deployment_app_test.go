@@ -0,0 +1,117 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	contract "github.com/0xsoniclabs/hyperion/load/contracts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+func TestDeploymentInitCode_CreatesContractAndIncrementsCounter(t *testing.T) {
+	counterAbi, err := contract.CounterMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, codeSize := range []int{1, 100, MaxDeploymentCodeSize} {
+		for _, slots := range []int{0, 1, 10} {
+			t.Run(fmt.Sprintf("size=%d/slots=%d", codeSize, slots), func(t *testing.T) {
+				cfg := &runtime.Config{GasLimit: 100_000_000}
+				_, counter, _, err := runtime.Create(common.FromHex(contract.CounterMetaData.Bin), cfg)
+				if err != nil {
+					t.Fatalf("failed to deploy counter: %v", err)
+				}
+
+				code := bytes.Repeat([]byte{0x01}, codeSize)
+				code[0] = 0x00
+				initCode := newDeploymentInitCode(counter, counterAbi.Methods["incrementCounter"].ID, code, slots)
+
+				gasLimit := getDeploymentGasLimit(len(initCode), len(code), slots)
+				cfg.GasLimit = gasLimit
+				deployed, address, leftOver, err := runtime.Create(initCode, cfg)
+				if err != nil {
+					t.Fatalf("failed to deploy contract: %v", err)
+				}
+				if !bytes.Equal(deployed, code) {
+					t.Errorf("unexpected deployed code, wanted %d bytes, got %d bytes", len(code), len(deployed))
+				}
+
+				// The gas limit needs to cover the intrinsic gas not charged by the runtime.
+				intrinsic := uint64(53_000 + 16*len(initCode))
+				if used := gasLimit - leftOver + intrinsic; used > gasLimit {
+					t.Errorf("gas limit too low, limit %d, used %d", gasLimit, used)
+				}
+
+				for i := 1; i <= slots+1; i++ {
+					want := common.Hash{}
+					if i <= slots {
+						want = common.BigToHash(big.NewInt(1))
+					}
+					if got := cfg.State.GetState(address, common.BigToHash(big.NewInt(int64(i)))); got != want {
+						t.Errorf("unexpected value of storage slot %d, wanted %v, got %v", i, want, got)
+					}
+				}
+
+				input, err := counterAbi.Pack("getCount")
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, _, err := runtime.Call(counter, input, cfg)
+				if err != nil {
+					t.Fatalf("failed to get count: %v", err)
+				}
+				if got, want := new(big.Int).SetBytes(result), big.NewInt(1); got.Cmp(want) != 0 {
+					t.Errorf("unexpected count of deployments, wanted %v, got %v", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestDeploymentInitCode_RevertsIfCounterCannotBeIncremented(t *testing.T) {
+	cfg := &runtime.Config{GasLimit: 1_000_000}
+	runtime.Execute(nil, nil, cfg) // initializes the state
+
+	// The counter is replaced by a contract always reverting.
+	counter := common.Address{1}
+	cfg.State.CreateAccount(counter)
+	cfg.State.SetCode(counter, []byte{0x60, 0x00, 0x80, 0xfd})
+
+	initCode := newDeploymentInitCode(counter, []byte{1, 2, 3, 4}, []byte{0x00}, 1)
+	if _, _, _, err := runtime.Create(initCode, cfg); err == nil {
+		t.Errorf("deployment should have been reverted")
+	}
+}
+
+func TestNewDeploymentApplication_RejectsInvalidCodeSize(t *testing.T) {
+	for _, codeSize := range []int{-1, MaxDeploymentCodeSize + 1} {
+		_, err := NewDeploymentApplication(nil, DeploymentOptions{CodeSize: codeSize}, 0, 0)
+		if err == nil {
+			t.Fatalf("expected code size %d to be rejected", codeSize)
+		}
+		want := fmt.Sprintf("invalid code size %d, must be 0 (default) or in range [1,%d]", codeSize, MaxDeploymentCodeSize)
+		if err.Error() != want {
+			t.Errorf("unexpected error, wanted %q, got %q", want, err)
+		}
+	}
+}
@@ -51,7 +51,7 @@ func NewERC20Application(ctxt AppContext, feederId, appId uint32) (Application,
 		return nil, fmt.Errorf("failed to generate recipients addresses; %w", err)
 	}
 
-	accountFactory, err := NewAccountFactory(primaryAccount.chainID, feederId, appId)
+	accountFactory, err := newAccountFactory(ctxt, primaryAccount.chainID, feederId, appId)
 	if err != nil {
 		return nil, err
 	}
@@ -191,3 +191,7 @@ func (g *ERC20User) GenerateTx() (*types.Transaction, error) {
 func (g *ERC20User) GetSentTransactions() uint64 {
 	return atomic.LoadUint64(&g.sentTxs)
 }
+
+func (g *ERC20User) GetAccounts() []*Account {
+	return []*Account{g.sender}
+}
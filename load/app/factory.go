@@ -17,15 +17,170 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
+	"slices"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
-type appFactoryFunc func(context AppContext, feederId, appId uint32) (Application, error)
+type appFactoryFunc func(context AppContext, options *Options, feederId, appId uint32) (Application, error)
+
+// Options collects type-specific parameters of applications.
+type Options struct {
+	// Config holds the options of the application's type registered using
+	// Register, as produced by DecodeConfig. Nil is interpreted as the zero
+	// value of the registered options type.
+	Config any
+}
+
+// Factory creates applications of a type registered using Register. The
+// options are decoded from the config section of the application in the
+// scenario and are never nil.
+type Factory[T any] func(context AppContext, options *T, feederId, appId uint32) (Application, error)
+
+// ConfigSchema describes the options of an application type registered using
+// Register. Options are decoded from the config section of applications into
+// values of type T, following the yaml tags of T. Unknown keys are rejected.
+type ConfigSchema[T any] struct {
+	// Check tests semantic constraints on decoded options. It is also applied
+	// to the zero value if no config is given. Nil if there are no constraints.
+	Check func(*T) error
+}
+
+// ConfigDecoder decodes the config section of an application into the given
+// value, which is a pointer to the registered options type.
+type ConfigDecoder func(out any) error
+
+// NewYamlConfigDecoder creates a decoder of the given YAML config section,
+// rejecting unknown keys. Relative paths of files referenced by the options
+// of built-in types are resolved relative to the given directory. The result
+// is nil for a missing config section, represented by a nil or zero node.
+func NewYamlConfigDecoder(config *yaml.Node, dir string) ConfigDecoder {
+	if config == nil || config.IsZero() {
+		return nil
+	}
+	return func(out any) error {
+		// nodes are re-encoded since only decoders reject unknown keys
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			return err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(out); err != nil {
+			return err
+		}
+		if resolver, ok := out.(configResolver); ok {
+			return resolver.resolve(dir)
+		}
+		return nil
+	}
+}
 
-func NewApplication(appType string, context AppContext, feederId, appId uint32) (Application, error) {
+// configResolver is an optional interface of options of built-in types which
+// depend on the location of the scenario they are defined in.
+type configResolver interface {
+	// resolve completes decoded options, resolving paths relative to the
+	// given directory.
+	resolve(dir string) error
+}
+
+// NodeReferences is an optional interface of options of application types
+// directing their load at a subset of the nodes of the network. The names
+// are to be checked against the nodes defined by the scenario.
+type NodeReferences interface {
+	// GetNodeNames lists the names of the referenced nodes, which is empty
+	// if all nodes are targeted.
+	GetNodeNames() []string
+}
+
+// registration is a type-erased entry in the registry of application types.
+type registration struct {
+	create appFactoryFunc
+	decode func(ConfigDecoder) (any, error) // < nil for types without config
+}
+
+// registrations maps lower-case application type names to their factories.
+var registrations = map[string]registration{}
+
+// Register announces the availability of a new application type with the
+// given name, which is matched case-insensitively. It is intended to be called
+// in initialization code, such that workloads maintained in separate modules
+// can be linked into custom Hyperion binaries. Names must be unique.
+func Register[T any](name string, factory Factory[T], schema ConfigSchema[T]) error {
+	name = strings.ToLower(name)
+	if name == "" {
+		return fmt.Errorf("application type name must not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("factory of application type '%s' must not be nil", name)
+	}
+	if _, present := registrations[name]; present {
+		return fmt.Errorf("application type collision: type '%s' registered multiple times", name)
+	}
+	registrations[name] = registration{
+		create: func(context AppContext, options *Options, feederId, appId uint32) (Application, error) {
+			config, ok := options.Config.(*T)
+			if !ok || config == nil {
+				config = new(T)
+			}
+			return factory(context, config, feederId, appId)
+		},
+		decode: func(decode ConfigDecoder) (any, error) {
+			config := new(T)
+			if err := decode(config); err != nil {
+				return nil, fmt.Errorf("invalid config of application type %s; %w", name, err)
+			}
+			if schema.Check != nil {
+				if err := schema.Check(config); err != nil {
+					return nil, fmt.Errorf("invalid config of application type %s; %w", name, err)
+				}
+			}
+			return config, nil
+		},
+	}
+	return nil
+}
+
+// DecodeConfig decodes and checks the config of an application of the given
+// type using the given decoder. A nil decoder represents a missing config
+// section. The result is to be provided to the factory as Options.Config. For
+// types without options, which do not accept a config, the result is nil.
+func DecodeConfig(appType string, decode ConfigDecoder) (any, error) {
+	entry, found := registrations[normalizeType(appType)]
+	if !found {
+		return nil, fmt.Errorf("unknown application type '%s'", appType)
+	}
+	if entry.decode == nil {
+		if decode != nil {
+			return nil, fmt.Errorf("application type %s does not accept a config", appType)
+		}
+		return nil, nil
+	}
+	if decode == nil {
+		decode = func(any) error { return nil }
+	}
+	return entry.decode(decode)
+}
+
+// GetApplicationTypes returns the sorted names of all registered application types.
+func GetApplicationTypes() []string {
+	res := make([]string, 0, len(registrations))
+	for name := range registrations {
+		res = append(res, name)
+	}
+	slices.Sort(res)
+	return res
+}
+
+func NewApplication(appType string, options *Options, context AppContext, feederId, appId uint32) (Application, error) {
+	if options == nil {
+		options = &Options{}
+	}
 	if factory := getFactory(appType); factory != nil {
-		return factory(context, feederId, appId)
+		return factory(context, options, feederId, appId)
 	}
 	return nil, fmt.Errorf("unknown application type '%s'", appType)
 }
@@ -35,15 +190,58 @@ func IsSupportedApplicationType(appType string) bool {
 }
 
 func getFactory(appType string) appFactoryFunc {
-	switch strings.ToLower(appType) {
-	case "erc20":
-		return NewERC20Application
-	case "counter", "":
-		return NewCounterApplication
-	case "store":
-		return NewStoreApplication
-	case "uniswap":
-		return NewUniswapApplication
+	if entry, found := registrations[normalizeType(appType)]; found {
+		return entry.create
 	}
 	return nil
 }
+
+// normalizeType maps application type names to their registered name. The
+// empty type is the counter application.
+func normalizeType(appType string) string {
+	if appType == "" {
+		return "counter"
+	}
+	return strings.ToLower(appType)
+}
+
+func init() {
+	registerWithoutOptions("erc20", NewERC20Application)
+	registerWithoutOptions("counter", NewCounterApplication)
+	registerWithoutOptions("store", NewStoreApplication)
+	registerWithoutOptions("uniswap", NewUniswapApplication)
+	registerWithoutOptions("transfer", NewTransferApplication)
+	mustRegister(Register("deploy", func(context AppContext, options *DeploymentOptions, feederId, appId uint32) (Application, error) {
+		return NewDeploymentApplication(context, *options, feederId, appId)
+	}, ConfigSchema[DeploymentOptions]{Check: (*DeploymentOptions).Check}))
+	mustRegister(Register("mix", func(context AppContext, options *MixOptions, feederId, appId uint32) (Application, error) {
+		return NewMixApplication(context, *options, feederId, appId)
+	}, ConfigSchema[MixOptions]{Check: (*MixOptions).Check}))
+	mustRegister(Register("rpc", func(context AppContext, options *RpcOptions, feederId, appId uint32) (Application, error) {
+		return NewRpcApplication(context, *options, feederId, appId)
+	}, ConfigSchema[RpcOptions]{Check: (*RpcOptions).Check}))
+	mustRegister(Register("subscription", func(context AppContext, options *SubscriptionOptions, feederId, appId uint32) (Application, error) {
+		return NewSubscriptionApplication(context, *options, feederId, appId)
+	}, ConfigSchema[SubscriptionOptions]{Check: (*SubscriptionOptions).Check}))
+	mustRegister(Register("replay", func(context AppContext, options *ReplayOptions, feederId, appId uint32) (Application, error) {
+		return NewReplayApplication(context, *options, feederId, appId)
+	}, ConfigSchema[ReplayOptions]{Check: (*ReplayOptions).Check}))
+}
+
+// mustRegister aborts the initialization if a built-in type could not be
+// registered, which indicates a programming error.
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// registerWithoutOptions registers an application type without
+// type-specific options, which does not accept a config section.
+func registerWithoutOptions(name string, factory func(AppContext, uint32, uint32) (Application, error)) {
+	registrations[name] = registration{
+		create: func(context AppContext, _ *Options, feederId, appId uint32) (Application, error) {
+			return factory(context, feederId, appId)
+		},
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+type testOptions struct {
+	Size int
+}
+
+// registerTestType registers an application type with testOptions, which is
+// removed from the registry at the end of the test.
+func registerTestType(t *testing.T, name string, factory Factory[testOptions]) {
+	t.Helper()
+	err := Register(name, factory, ConfigSchema[testOptions]{
+		Check: func(options *testOptions) error {
+			if options.Size < 0 {
+				return errors.New("size must be >= 0")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register type: %v", err)
+	}
+	t.Cleanup(func() { delete(registrations, strings.ToLower(name)) })
+}
+
+func TestRegister_TypedOptionsArePassedToFactory(t *testing.T) {
+	var got *testOptions
+	registerTestType(t, "Registry-Test", func(_ AppContext, options *testOptions, _, _ uint32) (Application, error) {
+		got = options
+		return nil, nil
+	})
+	if !IsSupportedApplicationType("registry-test") {
+		t.Fatalf("registered type is not supported")
+	}
+
+	config, err := DecodeConfig("registry-test", func(out any) error {
+		out.(*testOptions).Size = 5
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	if _, err := NewApplication("REGISTRY-TEST", &Options{Config: config}, nil, 1, 2); err != nil {
+		t.Fatalf("failed to create application: %v", err)
+	}
+	if got == nil || got.Size != 5 {
+		t.Errorf("unexpected options passed to factory, got %v", got)
+	}
+
+	// without a config, the zero value is passed
+	if _, err := NewApplication("registry-test", nil, nil, 1, 2); err != nil {
+		t.Fatalf("failed to create application: %v", err)
+	}
+	if got == nil || got.Size != 0 {
+		t.Errorf("unexpected options passed to factory, got %v", got)
+	}
+}
+
+func TestRegister_InvalidRegistrationsAreRejected(t *testing.T) {
+	factory := func(AppContext, *testOptions, uint32, uint32) (Application, error) { return nil, nil }
+	if err := Register("", factory, ConfigSchema[testOptions]{}); err == nil {
+		t.Errorf("empty name was not rejected")
+	}
+	if err := Register[testOptions]("registry-test", nil, ConfigSchema[testOptions]{}); err == nil {
+		t.Errorf("missing factory was not rejected")
+	}
+	if err := Register("Counter", factory, ConfigSchema[testOptions]{}); err == nil || !strings.Contains(err.Error(), "collision") {
+		t.Errorf("collision with built-in type was not detected, got %v", err)
+	}
+}
+
+func TestDecodeConfig_InvalidConfigsAreDetected(t *testing.T) {
+	registerTestType(t, "registry-test", func(AppContext, *testOptions, uint32, uint32) (Application, error) {
+		return nil, nil
+	})
+	tests := map[string]struct {
+		appType string
+		decode  ConfigDecoder
+		issue   string
+	}{
+		"unknown type":         {"unknown", nil, "unknown application type"},
+		"config for built-in":  {"counter", func(any) error { return nil }, "does not accept a config"},
+		"decoding failure":     {"registry-test", func(any) error { return errors.New("bad yaml") }, "bad yaml"},
+		"constraint violation": {"registry-test", func(out any) error { out.(*testOptions).Size = -1; return nil }, "size must be >= 0"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeConfig(test.appType, test.decode); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+	if config, err := DecodeConfig("", nil); err != nil || config != nil {
+		t.Errorf("unexpected config of default type, got %v, err %v", config, err)
+	}
+	if types := GetApplicationTypes(); len(types) < 10 || types[0] != "counter" {
+		t.Errorf("unexpected registered types, got %v", types)
+	}
+}
+
+// decodeYaml decodes the given YAML config of an application of the given
+// type using the registry, resolving paths relative to the given directory.
+func decodeYaml(t *testing.T, appType, config, dir string) (any, error) {
+	t.Helper()
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &node); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	return DecodeConfig(appType, NewYamlConfigDecoder(&node, dir))
+}
+
+func TestDecodeConfig_OptionsOfBuiltinTypesAreDecodedFromYaml(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "trace.jsonl"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		appType string
+		config  string
+		want    any
+	}{
+		"deploy": {"deploy", "{code_size: 256, storage_slots: 10}", &DeploymentOptions{CodeSize: 256, StorageSlots: 10}},
+		"rpc": {"rpc", "{methods: [{method: eth_call, weight: 2}], nodes: [rpc]}", &RpcOptions{
+			Methods: []RpcMethod{{Method: "eth_call", Weight: 2}},
+			Nodes:   []string{"rpc"},
+		}},
+		"subscription": {"subscription", "{kinds: [logs], logs: {addresses: [0x0000000000000000000000000000000000000001]}}", &SubscriptionOptions{
+			Kinds: []string{"logs"},
+			Logs:  LogFilter{Addresses: []common.Address{{19: 1}}},
+		}},
+		"replay": {"replay", "{file: trace.jsonl, speed: 2}", &ReplayOptions{File: filepath.Join(dir, "trace.jsonl"), Speed: 2}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeYaml(t, test.appType, test.config, dir)
+			if err != nil {
+				t.Fatalf("failed to decode config: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected options, wanted %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeConfig_InvalidOptionsOfBuiltinTypesAreDetected(t *testing.T) {
+	tests := map[string]struct {
+		appType string
+		config  string
+		issue   string
+	}{
+		"unknown key":        {"deploy", "{code_sizes: 256}", "field code_sizes not found"},
+		"code size":          {"deploy", "{code_size: -1}", "invalid code size"},
+		"storage slots":      {"deploy", "{storage_slots: 100000}", "invalid number of storage slots"},
+		"missing operations": {"mix", "{operations: []}", "at least one operation"},
+		"nested mix":         {"mix", "{operations: [{type: mix, weight: 1}]}", "can not be nested"},
+		"unknown operation":  {"mix", "{operations: [{type: unknown, weight: 1}]}", "unknown application type"},
+		"operation weight":   {"mix", "{operations: [{type: counter, weight: 0}]}", "must be positive"},
+		"duplicate op":       {"mix", "{operations: [{type: counter, weight: 1}, {type: Counter, weight: 1}]}", "listed multiple times"},
+		"operation config":   {"mix", "{operations: [{type: deploy, weight: 1, config: {code_size: -1}}]}", "invalid code size"},
+		"config of counter":  {"mix", "{operations: [{type: counter, weight: 1, config: {size: 1}}]}", "does not accept a config"},
+		"rpc operation":      {"mix", "{operations: [{type: rpc, weight: 1, config: {nodes: [rpc]}}]}", "can not be mixed"},
+		"unknown method":     {"rpc", "{methods: [{method: eth_unknown, weight: 1}]}", "unsupported RPC method"},
+		"method weight":      {"rpc", "{methods: [{method: eth_call, weight: 0}]}", "must be positive"},
+		"duplicate method":   {"rpc", "{methods: [{method: eth_call, weight: 1}, {method: eth_call, weight: 1}]}", "listed multiple times"},
+		"unknown kind":       {"subscription", "{kinds: [syncing]}", "unsupported subscription kind"},
+		"duplicate kind":     {"subscription", "{kinds: [logs, logs]}", "listed multiple times"},
+		"invalid address":    {"subscription", "{logs: {addresses: [0x12]}}", "Address"},
+		"invalid topic":      {"subscription", "{logs: {topics: [[0x12]]}}", "Hash"},
+		"missing file":       {"replay", "{speed: 2}", "no replay file specified"},
+		"unknown file":       {"replay", "{file: missing.jsonl}", "not accessible"},
+		"directory":          {"replay", "{file: .}", "is a directory"},
+		"negative speed":     {"replay", "{file: ., speed: -1}", "speed must be positive"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeYaml(t, test.appType, test.config, t.TempDir()); err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+
+	// applications requiring options reject missing configs
+	for _, appType := range []string{"mix", "replay"} {
+		if _, err := DecodeConfig(appType, nil); err == nil {
+			t.Errorf("missing config of %s application was not detected", appType)
+		}
+	}
+}
+
+// nodeOptions are options of a test type referencing nodes.
+type nodeOptions struct {
+	Nodes []string `yaml:"nodes"`
+}
+
+func (o *nodeOptions) GetNodeNames() []string {
+	return o.Nodes
+}
+
+func TestDecodeConfig_OperationsOfMixesAreDecodedWithTheirNodes(t *testing.T) {
+	err := Register("mix-test-nodes", func(AppContext, *nodeOptions, uint32, uint32) (Application, error) {
+		return nil, nil
+	}, ConfigSchema[nodeOptions]{})
+	if err != nil {
+		t.Fatalf("failed to register type: %v", err)
+	}
+	t.Cleanup(func() { delete(registrations, "mix-test-nodes") })
+
+	config, err := decodeYaml(t, "mix", `
+operations:
+  - type: counter
+    weight: 1
+  - type: mix-test-nodes
+    weight: 1
+    config:
+      nodes: [archive]
+`, "")
+	if err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	mix, ok := config.(*MixOptions)
+	if !ok {
+		t.Fatalf("unexpected options type %T", config)
+	}
+	if got := mix.GetNodeNames(); !slices.Equal(got, []string{"archive"}) {
+		t.Errorf("unexpected node references, got %v", got)
+	}
+	if options, ok := mix.Operations[1].options.(*nodeOptions); !ok || !slices.Equal(options.Nodes, []string{"archive"}) {
+		t.Errorf("config of operation was not decoded, got %v", mix.Operations[1].options)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	crand "crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// baseFeeRefreshInterval is the minimum time between two queries of the
+	// suggested gas price by base-fee tracking fee strategies.
+	baseFeeRefreshInterval = time.Second
+)
+
+var (
+	// defaultFeeCap is the fee cap of transactions if not configured otherwise.
+	defaultFeeCap = new(big.Int).Mul(big.NewInt(10_000), big.NewInt(1e9)) // 10,000 gwei
+	// defaultEscalationTip is the initial tip of escalating fee strategies,
+	// which needs to be non-zero for replacements to be accepted.
+	defaultEscalationTip = big.NewInt(1e9) // 1 gwei
+	// defaultMaxTip is the upper bound of random tips if not configured.
+	defaultMaxTip = big.NewInt(10e9) // 10 gwei
+)
+
+// FeeOptions defines how the fees of the transactions of an application are
+// chosen. Zero values are interpreted as the respective defaults.
+type FeeOptions struct {
+	// Strategy is one of the following:
+	//  - fixed: all transactions use FeeCap and Tip (default)
+	//  - base_fee: the fee cap tracks the gas price suggested by the network,
+	//    scaled by Multiplier, the tip is Tip
+	//  - random_tip: the tip is drawn uniformly from [MinTip, MaxTip], the
+	//    fee cap is FeeCap
+	//  - escalating: transactions start with FeeCap and Tip and are replaced
+	//    Replacements times every ReplaceAfter, raising fees by Bump percent
+	Strategy string
+	// FeeCap is the maximum fee per gas in wei, defaults to 10,000 gwei.
+	FeeCap *big.Int
+	// Tip is the priority fee per gas in wei, defaults to 0, or 1 gwei for
+	// escalating strategies, which require a positive tip. Tips of all
+	// strategies are capped by the fee cap.
+	Tip *big.Int
+	// MinTip and MaxTip bound random tips in wei, defaulting to 0 and 10 gwei.
+	MinTip, MaxTip *big.Int
+	// Multiplier scales the suggested gas price of base-fee tracking
+	// strategies, defaults to 2.
+	Multiplier float64
+	// Replacements is the number of replacements of each transaction by
+	// escalating strategies, defaults to 3.
+	Replacements int
+	// Bump is the fee increase of each replacement in percent, defaults to 10.
+	Bump uint64
+	// ReplaceAfter is the time between replacements, defaults to 1s.
+	ReplaceAfter time.Duration
+}
+
+// FeeStrategy determines the fees of newly created transactions.
+// Implementations are safe to be used concurrently.
+type FeeStrategy interface {
+	// GetFees returns the fee cap and the tip per gas of the next transaction.
+	GetFees() (feeCap, tip *big.Int)
+}
+
+// FeeEscalation is an optional extension of FeeStrategies replacing sent
+// transactions by copies paying higher fees, see Account.ReplaceTransaction.
+type FeeEscalation interface {
+	// GetReplacementSchedule returns the number of replacements of each
+	// transaction, the delay between them, and the fee increase in percent.
+	GetReplacementSchedule() (count int, delay time.Duration, bump uint64)
+}
+
+// IsSupportedFeeStrategy checks whether the given strategy name is known.
+func IsSupportedFeeStrategy(strategy string) bool {
+	switch strings.ToLower(strategy) {
+	case "", "fixed", "base_fee", "random_tip", "escalating":
+		return true
+	}
+	return false
+}
+
+// NewFeeStrategy creates the fee strategy described by the given options.
+// Strategies querying the network dial a connection on first use using the
+// given factory and should be closed once no longer needed.
+func NewFeeStrategy(options FeeOptions, factory RpcClientFactory) (FeeStrategy, error) {
+	feeCap := options.FeeCap
+	if feeCap == nil {
+		feeCap = defaultFeeCap
+	}
+	tip := options.Tip
+	if tip == nil {
+		tip = new(big.Int)
+	}
+	switch strings.ToLower(options.Strategy) {
+	case "", "fixed":
+		return &fixedFees{feeCap: feeCap, tip: capTip(feeCap, tip)}, nil
+	case "base_fee":
+		multiplier := options.Multiplier
+		if multiplier == 0 {
+			multiplier = 2
+		}
+		if multiplier < 0 {
+			return nil, fmt.Errorf("base fee multiplier must be positive, got %f", multiplier)
+		}
+		return &baseFeeTrackingFees{factory: factory, multiplier: multiplier, tip: tip}, nil
+	case "random_tip":
+		minTip, maxTip := options.MinTip, options.MaxTip
+		if minTip == nil {
+			minTip = new(big.Int)
+		}
+		if maxTip == nil {
+			maxTip = defaultMaxTip
+		}
+		if maxTip.Cmp(minTip) < 0 {
+			return nil, fmt.Errorf("invalid tip range [%v,%v]", minTip, maxTip)
+		}
+		return &randomTipFees{feeCap: feeCap, minTip: minTip, maxTip: maxTip}, nil
+	case "escalating":
+		if options.Tip == nil {
+			tip = defaultEscalationTip
+		}
+		if tip.Sign() <= 0 {
+			// bumping a zero tip keeps it zero, so replacements would be rejected
+			return nil, fmt.Errorf("tip of escalating fee strategy must be positive, got %v", tip)
+		}
+		res := &escalatingFees{
+			fixedFees: fixedFees{feeCap: feeCap, tip: capTip(feeCap, tip)},
+			count:     options.Replacements,
+			delay:     options.ReplaceAfter,
+			bump:      options.Bump,
+		}
+		if res.count == 0 {
+			res.count = 3
+		}
+		if res.delay == 0 {
+			res.delay = time.Second
+		}
+		if res.bump == 0 {
+			res.bump = 10
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unknown fee strategy '%s'", options.Strategy)
+}
+
+// capTip limits a tip to the fee cap of a transaction.
+func capTip(feeCap, tip *big.Int) *big.Int {
+	if tip.Cmp(feeCap) > 0 {
+		return feeCap
+	}
+	return tip
+}
+
+// bumpFee increases a fee by the given percentage, rounding up such that
+// the increase is at least the percentage required by transaction pools.
+func bumpFee(fee *big.Int, percent uint64) *big.Int {
+	res := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	res.Add(res, big.NewInt(99))
+	return res.Div(res, big.NewInt(100))
+}
+
+type fixedFees struct {
+	feeCap, tip *big.Int
+}
+
+func (f *fixedFees) GetFees() (*big.Int, *big.Int) {
+	return f.feeCap, f.tip
+}
+
+type randomTipFees struct {
+	feeCap, minTip, maxTip *big.Int
+}
+
+func (f *randomTipFees) GetFees() (*big.Int, *big.Int) {
+	span := new(big.Int).Sub(f.maxTip, f.minTip)
+	offset, err := crand.Int(crand.Reader, span.Add(span, big.NewInt(1)))
+	if err != nil {
+		log.Printf("failed to draw random tip; %v", err)
+		offset = new(big.Int)
+	}
+	return f.feeCap, capTip(f.feeCap, offset.Add(offset, f.minTip))
+}
+
+type escalatingFees struct {
+	fixedFees
+	count int
+	delay time.Duration
+	bump  uint64
+}
+
+func (f *escalatingFees) GetReplacementSchedule() (int, time.Duration, uint64) {
+	return f.count, f.delay, f.bump
+}
+
+// baseFeeTrackingFees derives fee caps from the gas price suggested by the
+// network, which is refreshed at most every baseFeeRefreshInterval.
+type baseFeeTrackingFees struct {
+	factory    RpcClientFactory
+	multiplier float64
+	tip        *big.Int
+
+	mutex     sync.Mutex
+	client    rpc.Client
+	feeCap    *big.Int
+	updatedAt time.Time
+}
+
+func (f *baseFeeTrackingFees) GetFees() (*big.Int, *big.Int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.feeCap == nil || time.Since(f.updatedAt) >= baseFeeRefreshInterval {
+		if err := f.refresh(); err != nil {
+			log.Printf("failed to refresh suggested gas price; %v", err)
+			if f.feeCap == nil {
+				f.feeCap = defaultFeeCap
+			}
+		}
+		f.updatedAt = time.Now()
+	}
+	return f.feeCap, capTip(f.feeCap, f.tip)
+}
+
+func (f *baseFeeTrackingFees) refresh() error {
+	if f.client == nil {
+		client, err := f.factory.DialRandomRpc()
+		if err != nil {
+			return err
+		}
+		f.client = client
+	}
+	price, err := f.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		f.client.Close()
+		f.client = nil
+		return err
+	}
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(price), big.NewFloat(f.multiplier)).Int(nil)
+	f.feeCap = scaled
+	return nil
+}
+
+func (f *baseFeeTrackingFees) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.client != nil {
+		f.client.Close()
+		f.client = nil
+	}
+	return nil
+}
+
+// SetFeeStrategy defines the strategy for the fees of transactions created
+// for this account. Without a strategy, fixed default fees are used.
+func (a *Account) SetFeeStrategy(strategy FeeStrategy) {
+	a.fees.Store(&strategy)
+}
+
+// getFees returns the fees of the next transaction of this account.
+func (a *Account) getFees() (*big.Int, *big.Int) {
+	if strategy := a.fees.Load(); strategy != nil {
+		return (*strategy).GetFees()
+	}
+	return defaultFeeCap, new(big.Int)
+}
+
+// ReplaceTransaction creates a copy of a transaction of this account with the
+// same nonce and type, raising its fee cap and tip by the given percentage.
+// Sending the copy replaces the original in the transaction pools, if still
+// pending.
+func (a *Account) ReplaceTransaction(tx *types.Transaction, bump uint64) (*types.Transaction, error) {
+	replacement := types.NewTx(newTxData(tx.Type(), a.chainID, &types.DynamicFeeTx{
+		Nonce:      tx.Nonce(),
+		GasFeeCap:  bumpFee(tx.GasFeeCap(), bump),
+		GasTipCap:  bumpFee(tx.GasTipCap(), bump),
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}, bumpFee(tx.GasPrice(), bump), tx.SetCodeAuthorizations()))
+	signed, err := types.SignTx(replacement, types.LatestSignerForChainID(a.chainID), a.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	a.trackTransaction(signed)
+	return signed, nil
+}
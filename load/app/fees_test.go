@@ -0,0 +1,201 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFeeStrategy_DefaultIsFixedFees(t *testing.T) {
+	strategy, err := NewFeeStrategy(FeeOptions{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	feeCap, tip := strategy.GetFees()
+	if feeCap.Cmp(defaultFeeCap) != 0 || tip.Sign() != 0 {
+		t.Errorf("unexpected fees, got %v/%v", feeCap, tip)
+	}
+}
+
+func TestFeeStrategy_TipsAreCappedByFeeCap(t *testing.T) {
+	strategy, err := NewFeeStrategy(FeeOptions{FeeCap: big.NewInt(10), Tip: big.NewInt(20)}, nil)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	if feeCap, tip := strategy.GetFees(); feeCap.Int64() != 10 || tip.Int64() != 10 {
+		t.Errorf("unexpected fees, got %v/%v", feeCap, tip)
+	}
+}
+
+func TestFeeStrategy_RandomTipsAreWithinRange(t *testing.T) {
+	strategy, err := NewFeeStrategy(FeeOptions{
+		Strategy: "random_tip",
+		FeeCap:   big.NewInt(15),
+		MinTip:   big.NewInt(10),
+		MaxTip:   big.NewInt(20),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	seen := map[int64]bool{}
+	for i := 0; i < 1000; i++ {
+		feeCap, tip := strategy.GetFees()
+		if tip.Int64() < 10 || tip.Int64() > 15 {
+			t.Fatalf("tip out of range: %v", tip)
+		}
+		if feeCap.Int64() != 15 {
+			t.Fatalf("invalid fee cap %v for tip %v", feeCap, tip)
+		}
+		seen[tip.Int64()] = true
+	}
+	// tips above the fee cap are capped, like for other strategies
+	if len(seen) != 6 {
+		t.Errorf("not all tips of the range were drawn, got %v", seen)
+	}
+
+	if _, err := NewFeeStrategy(FeeOptions{Strategy: "random_tip", MinTip: big.NewInt(2), MaxTip: big.NewInt(1)}, nil); err == nil {
+		t.Errorf("invalid tip range was not detected")
+	}
+}
+
+func TestFeeStrategy_RandomTipsSupportLargeRanges(t *testing.T) {
+	maxTip := new(big.Int).Lsh(big.NewInt(1), 80)
+	strategy, err := NewFeeStrategy(FeeOptions{
+		Strategy: "random_tip",
+		FeeCap:   new(big.Int).Lsh(big.NewInt(1), 100),
+		MaxTip:   maxTip,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	large := false
+	for i := 0; i < 100; i++ {
+		_, tip := strategy.GetFees()
+		if tip.Sign() < 0 || tip.Cmp(maxTip) > 0 {
+			t.Fatalf("tip out of range: %v", tip)
+		}
+		large = large || !tip.IsInt64()
+	}
+	if !large {
+		t.Errorf("tips beyond the int64 range were not drawn")
+	}
+}
+
+func TestFeeStrategy_BaseFeeTrackingScalesSuggestedGasPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := NewMockClientFactory(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	factory.EXPECT().DialRandomRpc().Return(client, nil)
+	client.EXPECT().SuggestGasPrice(gomock.Any()).Return(big.NewInt(100), nil)
+	client.EXPECT().Close()
+
+	strategy, err := NewFeeStrategy(FeeOptions{Strategy: "base_fee", Multiplier: 1.5, Tip: big.NewInt(7)}, factory)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	for i := 0; i < 3; i++ { // < the suggestion is cached
+		if feeCap, tip := strategy.GetFees(); feeCap.Int64() != 150 || tip.Int64() != 7 {
+			t.Errorf("unexpected fees, got %v/%v", feeCap, tip)
+		}
+	}
+	if err := strategy.(*baseFeeTrackingFees).Close(); err != nil {
+		t.Errorf("failed to close strategy: %v", err)
+	}
+}
+
+func TestFeeStrategy_BaseFeeTrackingFallsBackToDefaultOnFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := NewMockClientFactory(ctrl)
+	factory.EXPECT().DialRandomRpc().Return(nil, errors.New("no nodes"))
+
+	strategy, err := NewFeeStrategy(FeeOptions{Strategy: "base_fee"}, factory)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	if feeCap, _ := strategy.GetFees(); feeCap.Cmp(defaultFeeCap) != 0 {
+		t.Errorf("unexpected fee cap, got %v", feeCap)
+	}
+}
+
+func TestFeeStrategy_EscalatingStrategyDefinesReplacements(t *testing.T) {
+	strategy, err := NewFeeStrategy(FeeOptions{Strategy: "escalating"}, nil)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	if _, tip := strategy.GetFees(); tip.Cmp(defaultEscalationTip) != 0 {
+		t.Errorf("unexpected initial tip, got %v", tip)
+	}
+	escalation, ok := strategy.(FeeEscalation)
+	if !ok {
+		t.Fatalf("escalating strategy does not define replacements")
+	}
+	if count, delay, bump := escalation.GetReplacementSchedule(); count != 3 || delay != time.Second || bump != 10 {
+		t.Errorf("unexpected default schedule, got %d, %v, %d", count, delay, bump)
+	}
+}
+
+func TestFeeStrategy_EscalatingStrategyRejectsZeroTip(t *testing.T) {
+	if _, err := NewFeeStrategy(FeeOptions{Strategy: "escalating", Tip: new(big.Int)}, nil); err == nil {
+		t.Errorf("zero tip of escalating strategy was not rejected")
+	}
+}
+
+func TestFeeStrategy_UnknownStrategiesAreRejected(t *testing.T) {
+	if _, err := NewFeeStrategy(FeeOptions{Strategy: "free"}, nil); err == nil {
+		t.Errorf("unknown strategy was not rejected")
+	}
+	if IsSupportedFeeStrategy("free") || !IsSupportedFeeStrategy("Base_Fee") {
+		t.Errorf("unexpected support of strategies")
+	}
+}
+
+func TestAccount_TransactionsUseFeesOfStrategy(t *testing.T) {
+	account, err := NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategy, err := NewFeeStrategy(FeeOptions{FeeCap: big.NewInt(1000), Tip: big.NewInt(100)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.SetFeeStrategy(strategy)
+
+	tx, err := createTx(account, common.Address{1}, big.NewInt(0), nil, 21_000)
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+	if tx.GasFeeCap().Int64() != 1000 || tx.GasTipCap().Int64() != 100 {
+		t.Errorf("unexpected fees, got %v/%v", tx.GasFeeCap(), tx.GasTipCap())
+	}
+
+	replacement, err := account.ReplaceTransaction(tx, 10)
+	if err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if replacement.Nonce() != tx.Nonce() || replacement.GasFeeCap().Int64() != 1100 || replacement.GasTipCap().Int64() != 110 {
+		t.Errorf("unexpected replacement, nonce %d, fees %v/%v", replacement.Nonce(), replacement.GasFeeCap(), replacement.GasTipCap())
+	}
+	if replacement.Hash() == tx.Hash() {
+		t.Errorf("replacement is identical to original")
+	}
+}
@@ -27,16 +27,37 @@ import (
 )
 
 func createTx(from *Account, toAddress common.Address, value *big.Int, data []byte, gasLimit uint64) (*types.Transaction, error) {
-	tx := types.NewTx(&types.DynamicFeeTx{
-		Nonce:     from.getNextNonce(),
-		GasFeeCap: new(big.Int).Mul(big.NewInt(10_000), big.NewInt(1e9)),
-		GasTipCap: big.NewInt(0),
+	return newSignedTx(from, &toAddress, value, data, gasLimit)
+}
+
+// createDeployTx creates a contract creation transaction running the given init code.
+func createDeployTx(from *Account, initCode []byte, gasLimit uint64) (*types.Transaction, error) {
+	return newSignedTx(from, nil, big.NewInt(0), initCode, gasLimit)
+}
+
+// newSignedTx creates a transaction of the given account using the next nonce
+// of the account. The nonce is only consumed if the transaction is created, so
+// failures do not leave gaps in the nonces of the account.
+func newSignedTx(from *Account, toAddress *common.Address, value *big.Int, data []byte, gasLimit uint64) (*types.Transaction, error) {
+	feeCap, tip := from.getFees()
+	tx, err := from.txTypes.Load().newTx(from, &types.DynamicFeeTx{
+		GasFeeCap: feeCap,
+		GasTipCap: tip,
 		Gas:       gasLimit,
-		To:        &toAddress,
+		To:        toAddress,
 		Value:     value,
 		Data:      data,
 	})
-	return types.SignTx(tx, types.NewLondonSigner(from.chainID), from.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(from.chainID), from.privateKey)
+	if err != nil {
+		from.releaseNonce(tx.Nonce())
+		return nil, err
+	}
+	from.trackTransaction(signed)
+	return signed, nil
 }
 
 // GetGasPrice obtains optimal gasPrice for regular transactions
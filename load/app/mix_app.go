@@ -0,0 +1,292 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/yaml.v3"
+)
+
+// MixOptions defines the operations combined by mix applications.
+type MixOptions struct {
+	// Operations lists the operations of the mix, at least one is required.
+	Operations []MixOperation `yaml:"operations"`
+}
+
+// MixOperation is a single operation of a mix application. The operation
+// is described by an application type and its relative weight in the mix.
+type MixOperation struct {
+	Type   string  `yaml:"type"`
+	Weight float64 `yaml:"weight"`
+	// Config is the config section of the operation, which is decoded using
+	// the registry like the config section of applications. The zero node is
+	// interpreted as a missing config section.
+	Config yaml.Node `yaml:"config,omitempty"`
+
+	options any // < decoded config, nil if not decoded yet
+}
+
+// nonTransactionalTypes lists the built-in application types whose users do
+// not send a transaction when triggered, but issue requests, hold
+// subscriptions, or follow a schedule of their own. Users of mixes only
+// produce transactions, so these types can not be mixed.
+var nonTransactionalTypes = []string{"rpc", "subscription", "replay"}
+
+// Check tests the operations for unknown, nested, or non-transactional types
+// and invalid weights. Configs of operations are checked when they are
+// decoded.
+func (o *MixOptions) Check() error {
+	errs := []error{}
+	if len(o.Operations) == 0 {
+		errs = append(errs, fmt.Errorf("mix application requires at least one operation"))
+	}
+	types := []string{}
+	for _, operation := range o.Operations {
+		name := strings.ToLower(operation.Type)
+		if name == "mix" {
+			errs = append(errs, fmt.Errorf("mix applications can not be nested"))
+		} else if slices.Contains(nonTransactionalTypes, name) {
+			errs = append(errs, fmt.Errorf("operation %s can not be mixed, only types sending transactions are supported", name))
+		} else if !IsSupportedApplicationType(name) {
+			errs = append(errs, fmt.Errorf("unknown application type '%s' of mix operation", operation.Type))
+		}
+		if slices.Contains(types, name) {
+			errs = append(errs, fmt.Errorf("operation %s listed multiple times", name))
+		}
+		types = append(types, name)
+		if operation.Weight <= 0 {
+			errs = append(errs, fmt.Errorf("weight of operation %s must be positive, got %f", name, operation.Weight))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolve decodes the config sections of the operations, resolving paths
+// relative to the given directory. Operations of unsupported types are
+// skipped, they are reported by Check.
+func (o *MixOptions) resolve(dir string) error {
+	for i := range o.Operations {
+		operation := &o.Operations[i]
+		name := strings.ToLower(operation.Type)
+		if name == "mix" || slices.Contains(nonTransactionalTypes, name) || !IsSupportedApplicationType(name) {
+			continue
+		}
+		config, err := DecodeConfig(name, NewYamlConfigDecoder(&operation.Config, dir))
+		if err != nil {
+			return fmt.Errorf("invalid config of mix operation %s; %w", name, err)
+		}
+		operation.options = config
+	}
+	return nil
+}
+
+// GetNodeNames lists the nodes referenced by the options of all operations.
+func (o *MixOptions) GetNodeNames() []string {
+	res := []string{}
+	for _, operation := range o.Operations {
+		if nodes, ok := operation.options.(NodeReferences); ok {
+			res = append(res, nodes.GetNodeNames()...)
+		}
+	}
+	return res
+}
+
+// OperationMix is an optional interface of applications combining different
+// operations. It provides transaction counts on a per-operation granularity.
+type OperationMix interface {
+	// GetOperations lists the names of the operations in the mix.
+	GetOperations() []string
+	// GetReceivedTransactionsOf returns the number of transactions of the
+	// given operation received up to the current point in time.
+	GetReceivedTransactionsOf(operation string, rpcClient rpc.Client) (uint64, error)
+}
+
+// OperationMixUser is an optional interface of users of OperationMix
+// applications providing the number of sent transactions per operation.
+type OperationMixUser interface {
+	GetSentTransactionsOf(operation string) uint64
+}
+
+// NewMixApplication creates an application mixing the operations of other
+// application types. For each operation, an application of the respective
+// type is created, configured by the config section of the operation. Users of the mix pick the operation of each transaction randomly,
+// proportional to the configured weights.
+func NewMixApplication(ctxt AppContext, options MixOptions, feederId, appId uint32) (Application, error) {
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+
+	res := &MixApplication{
+		operations: make([]string, 0, len(options.Operations)),
+		weights:    make([]float64, 0, len(options.Operations)),
+		apps:       make([]Application, 0, len(options.Operations)),
+	}
+	for i, operation := range options.Operations {
+		name := strings.ToLower(operation.Type)
+		config := operation.options
+		if config == nil {
+			var err error
+			if config, err = DecodeConfig(name, NewYamlConfigDecoder(&operation.Config, "")); err != nil {
+				return nil, fmt.Errorf("failed to create application for operation %s; %w", name, err)
+			}
+		}
+		// Each operation uses its own feeder ID to obtain a disjoint set of accounts.
+		application, err := getFactory(name)(ctxt, &Options{Config: config}, getMixFeederId(feederId, i), appId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create application for operation %s; %w", name, err)
+		}
+		res.operations = append(res.operations, name)
+		res.weights = append(res.weights, operation.Weight)
+		res.apps = append(res.apps, application)
+	}
+	return res, nil
+}
+
+// getMixFeederId derives the feeder ID used by the i-th operation of a mix.
+// The operation index is placed in the upper bits, which are not used by
+// regular feeder IDs.
+func getMixFeederId(feederId uint32, i int) uint32 {
+	return feederId + uint32(i+1)<<24
+}
+
+// MixApplication combines a set of applications, each representing one
+// operation of the mix.
+type MixApplication struct {
+	operations []string
+	weights    []float64
+	apps       []Application
+}
+
+// CreateUsers creates a list of new users for the app. Each user is backed by
+// a user of every application in the mix.
+func (f *MixApplication) CreateUsers(appContext AppContext, numUsers int) ([]User, error) {
+	users := make([]*MixUser, numUsers)
+	for i := range users {
+		users[i] = &MixUser{
+			mix:   f,
+			users: make([]User, len(f.apps)),
+		}
+	}
+	for i, application := range f.apps {
+		opUsers, err := application.CreateUsers(appContext, numUsers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create users for operation %s; %w", f.operations[i], err)
+		}
+		for j, user := range opUsers {
+			users[j].users[i] = user
+		}
+	}
+	res := make([]User, numUsers)
+	for i, user := range users {
+		res[i] = user
+	}
+	return res, nil
+}
+
+func (f *MixApplication) GetReceivedTransactions(rpcClient rpc.Client) (uint64, error) {
+	total := uint64(0)
+	for _, operation := range f.operations {
+		count, err := f.GetReceivedTransactionsOf(operation, rpcClient)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (f *MixApplication) GetOperations() []string {
+	return f.operations
+}
+
+func (f *MixApplication) GetReceivedTransactionsOf(operation string, rpcClient rpc.Client) (uint64, error) {
+	i := f.getOperationIndex(operation)
+	if i < 0 {
+		return 0, fmt.Errorf("unknown operation %s", operation)
+	}
+	return f.apps[i].GetReceivedTransactions(rpcClient)
+}
+
+func (f *MixApplication) getOperationIndex(operation string) int {
+	for i, cur := range f.operations {
+		if cur == operation {
+			return i
+		}
+	}
+	return -1
+}
+
+// pickOperation selects a random operation index according to the weights.
+func (f *MixApplication) pickOperation() int {
+	total := 0.0
+	for _, weight := range f.weights {
+		total += weight
+	}
+	r := rand.Float64() * total
+	for i, weight := range f.weights {
+		if r < weight {
+			return i
+		}
+		r -= weight
+	}
+	return len(f.weights) - 1
+}
+
+// MixUser represents a user sending transactions of randomly selected operations.
+// A generator is supposed to be used in a single thread.
+type MixUser struct {
+	mix   *MixApplication
+	users []User // < one user per operation
+}
+
+func (g *MixUser) GenerateTx() (*types.Transaction, error) {
+	return g.users[g.mix.pickOperation()].GenerateTx()
+}
+
+func (g *MixUser) GetSentTransactions() uint64 {
+	total := uint64(0)
+	for _, user := range g.users {
+		total += user.GetSentTransactions()
+	}
+	return total
+}
+
+// GetAccounts lists the accounts of the users performing the operations.
+func (g *MixUser) GetAccounts() []*Account {
+	res := []*Account{}
+	for _, user := range g.users {
+		if holder, ok := user.(AccountHolder); ok {
+			res = append(res, holder.GetAccounts()...)
+		}
+	}
+	return res
+}
+
+func (g *MixUser) GetSentTransactionsOf(operation string) uint64 {
+	i := g.mix.getOperationIndex(operation)
+	if i < 0 {
+		return 0
+	}
+	return g.users[i].GetSentTransactions()
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewMixApplication_RejectsInvalidOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+
+	tests := map[string]struct {
+		mix   []MixOperation
+		issue string
+	}{
+		"empty":          {nil, "at least one operation"},
+		"nested":         {[]MixOperation{{Type: "mix", Weight: 1}}, "can not be nested"},
+		"zero weight":    {[]MixOperation{{Type: "counter", Weight: 0}}, "must be positive"},
+		"negative":       {[]MixOperation{{Type: "counter", Weight: -1}}, "must be positive"},
+		"unknown type":   {[]MixOperation{{Type: "unknown", Weight: 1}}, "unknown application type"},
+		"nested upper":   {[]MixOperation{{Type: "MIX", Weight: 1}}, "can not be nested"},
+		"invalid second": {[]MixOperation{{Type: "mix", Weight: 1}, {Type: "unknown", Weight: 1}}, "can not be nested"},
+		"rpc":            {[]MixOperation{{Type: "rpc", Weight: 1}}, "rpc can not be mixed"},
+		"subscription":   {[]MixOperation{{Type: "Subscription", Weight: 1}}, "subscription can not be mixed"},
+		"replay":         {[]MixOperation{{Type: "replay", Weight: 1}}, "replay can not be mixed"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewMixApplication(context, MixOptions{Operations: test.mix}, 0, 0)
+			if err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestNewMixApplication_OperationsAreCreatedWithTheirOwnConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+	var got []*testOptions
+	registerTestType(t, "mix-test-a", func(_ AppContext, options *testOptions, _, _ uint32) (Application, error) {
+		got = append(got, options)
+		return NewMockApplication(ctrl), nil
+	})
+	registerTestType(t, "mix-test-b", func(_ AppContext, options *testOptions, _, _ uint32) (Application, error) {
+		got = append(got, options)
+		return NewMockApplication(ctrl), nil
+	})
+
+	var config yaml.Node
+	if err := yaml.Unmarshal([]byte("size: 5"), &config); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewMixApplication(context, MixOptions{
+		Operations: []MixOperation{
+			{Type: "mix-test-a", Weight: 1, Config: config},
+			{Type: "mix-test-b", Weight: 1},
+		},
+	}, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create mix: %v", err)
+	}
+	if len(got) != 2 || got[0].Size != 5 || got[1].Size != 0 {
+		t.Errorf("unexpected options passed to factories, got %v", got)
+	}
+}
+
+func TestMixApplication_UsersPickOperationsAccordingToWeights(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+	appA := NewMockApplication(ctrl)
+	appB := NewMockApplication(ctrl)
+	userA := NewMockUser(ctrl)
+	userB := NewMockUser(ctrl)
+
+	txA := types.NewTx(&types.LegacyTx{Nonce: 1})
+	txB := types.NewTx(&types.LegacyTx{Nonce: 2})
+
+	appA.EXPECT().CreateUsers(context, 1).Return([]User{userA}, nil)
+	appB.EXPECT().CreateUsers(context, 1).Return([]User{userB}, nil)
+	userA.EXPECT().GenerateTx().Return(txA, nil).AnyTimes()
+	userB.EXPECT().GenerateTx().Return(txB, nil).AnyTimes()
+
+	mix := &MixApplication{
+		operations: []string{"a", "b"},
+		weights:    []float64{3, 1},
+		apps:       []Application{appA, appB},
+	}
+	users, err := mix.CreateUsers(context, 1)
+	if err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("unexpected number of users, wanted 1, got %d", len(users))
+	}
+
+	const N = 10_000
+	countA := 0
+	for range N {
+		tx, err := users[0].GenerateTx()
+		if err != nil {
+			t.Fatalf("failed to generate transaction: %v", err)
+		}
+		if tx == txA {
+			countA++
+		}
+	}
+	if got, want := float64(countA)/N, 0.75; math.Abs(got-want) > 0.03 {
+		t.Errorf("unexpected share of operation a, wanted %f, got %f", want, got)
+	}
+}
+
+func TestMixApplication_TransactionsAreCountedPerOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	appA := NewMockApplication(ctrl)
+	appB := NewMockApplication(ctrl)
+	userA := NewMockUser(ctrl)
+	userB := NewMockUser(ctrl)
+
+	appA.EXPECT().CreateUsers(context, 1).Return([]User{userA}, nil)
+	appB.EXPECT().CreateUsers(context, 1).Return([]User{userB}, nil)
+	appA.EXPECT().GetReceivedTransactions(client).Return(uint64(5), nil).Times(2)
+	appB.EXPECT().GetReceivedTransactions(client).Return(uint64(7), nil).Times(2)
+	userA.EXPECT().GetSentTransactions().Return(uint64(6)).Times(2)
+	userB.EXPECT().GetSentTransactions().Return(uint64(8)).Times(2)
+
+	mix := &MixApplication{
+		operations: []string{"a", "b"},
+		weights:    []float64{1, 1},
+		apps:       []Application{appA, appB},
+	}
+	if got, want := mix.GetOperations(), []string{"a", "b"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected operations, wanted %v, got %v", want, got)
+	}
+
+	users, err := mix.CreateUsers(context, 1)
+	if err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	user := users[0].(OperationMixUser)
+
+	if got, err := mix.GetReceivedTransactions(client); err != nil || got != 12 {
+		t.Errorf("unexpected number of received transactions, wanted 12, got %d, err %v", got, err)
+	}
+	if got, err := mix.GetReceivedTransactionsOf("a", client); err != nil || got != 5 {
+		t.Errorf("unexpected number of received transactions of a, wanted 5, got %d, err %v", got, err)
+	}
+	if got, err := mix.GetReceivedTransactionsOf("b", client); err != nil || got != 7 {
+		t.Errorf("unexpected number of received transactions of b, wanted 7, got %d, err %v", got, err)
+	}
+	if _, err := mix.GetReceivedTransactionsOf("c", client); err == nil {
+		t.Errorf("expected error for unknown operation")
+	}
+
+	if got := users[0].GetSentTransactions(); got != 14 {
+		t.Errorf("unexpected number of sent transactions, wanted 14, got %d", got)
+	}
+	if got := user.GetSentTransactionsOf("a"); got != 6 {
+		t.Errorf("unexpected number of sent transactions of a, wanted 6, got %d", got)
+	}
+	if got := user.GetSentTransactionsOf("b"); got != 8 {
+		t.Errorf("unexpected number of sent transactions of b, wanted 8, got %d", got)
+	}
+	if got := user.GetSentTransactionsOf("c"); got != 0 {
+		t.Errorf("unexpected number of sent transactions of unknown operation, wanted 0, got %d", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxRetainedTransactions is the maximum number of unconfirmed transactions
+// retained per account for re-sending them in case they got dropped.
+const maxRetainedTransactions = 256
+
+// AccountHolder is an optional interface of users sending transactions from
+// their own accounts. It enables the recovery of nonce gaps of the accounts.
+type AccountHolder interface {
+	// GetAccounts lists the accounts transactions of the user are sent from.
+	GetAccounts() []*Account
+}
+
+// NonceStats summarizes the nonce recovery of one or more accounts.
+type NonceStats struct {
+	Stuck    uint64 // < number of checks finding unconfirmed transactions without progress
+	Resynced uint64 // < number of corrections of the local nonce
+}
+
+// nonceTracker is the part of an Account keeping track of unconfirmed
+// transactions for the detection and recovery of nonce gaps.
+type nonceTracker struct {
+	mutex     sync.Mutex
+	retain    bool                          // < whether sent transactions are retained for re-sending
+	pending   map[uint64]*types.Transaction // < unconfirmed transactions by nonce
+	confirmed uint64                        // < the confirmed nonce at the last check
+	stuck     atomic.Uint64
+	resynced  atomic.Uint64
+}
+
+// trackTransaction registers a transaction sent from this account.
+func (a *Account) trackTransaction(tx *types.Transaction) {
+	a.nonceTracker.mutex.Lock()
+	defer a.nonceTracker.mutex.Unlock()
+	if !a.nonceTracker.retain {
+		return
+	}
+	if a.nonceTracker.pending == nil {
+		a.nonceTracker.pending = map[uint64]*types.Transaction{}
+	}
+	if len(a.nonceTracker.pending) < maxRetainedTransactions {
+		a.nonceTracker.pending[tx.Nonce()] = tx
+	}
+}
+
+// RecoverNonce compares the local nonce of the account with the nonce of the
+// account on the network and resolves discrepancies. It is intended to be
+// called periodically, since gaps are detected by a lack of progress of the
+// confirmed nonce between consecutive calls while transactions are pending.
+//
+// If the local nonce is behind the network, it is moved forward. If a gap is
+// detected and resend is enabled, the retained unconfirmed transactions are
+// returned to be sent again. Otherwise, or if the transactions are no longer
+// available, the local nonce is reset to the pending nonce of the network,
+// such that the gap is filled by future transactions.
+func (a *Account) RecoverNonce(client rpc.Client, resend bool) ([]*types.Transaction, error) {
+	tracker := &a.nonceTracker
+	tracker.mutex.Lock()
+	tracker.retain = resend // < updated by every check, even failing ones
+	tracker.mutex.Unlock()
+
+	confirmed, err := client.NonceAt(context.Background(), a.address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce; %w", err)
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	for nonce := range tracker.pending {
+		if nonce < confirmed {
+			delete(tracker.pending, nonce)
+		}
+	}
+
+	local := atomic.LoadUint64(&a.nonce)
+	progress := confirmed != tracker.confirmed
+	tracker.confirmed = confirmed
+	if confirmed > local {
+		// transactions of this account were confirmed without being sent
+		// from this instance, which would cause "nonce too low" errors
+		if atomic.CompareAndSwapUint64(&a.nonce, local, confirmed) {
+			tracker.resynced.Add(1)
+		}
+		return nil, nil
+	}
+	if confirmed == local || progress {
+		return nil, nil
+	}
+
+	// There are unconfirmed transactions, but none got confirmed since the
+	// last check, so the transaction of the next nonce is likely missing.
+	tracker.stuck.Add(1)
+	if _, found := tracker.pending[confirmed]; resend && found {
+		res := make([]*types.Transaction, 0, len(tracker.pending))
+		for _, tx := range tracker.pending {
+			res = append(res, tx)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			return res[i].Nonce() < res[j].Nonce()
+		})
+		return res, nil
+	}
+
+	pending, err := client.PendingNonceAt(context.Background(), a.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce; %w", err)
+	}
+	if pending < local && atomic.CompareAndSwapUint64(&a.nonce, local, pending) {
+		tracker.resynced.Add(1)
+		for nonce := range tracker.pending {
+			if nonce >= pending {
+				delete(tracker.pending, nonce)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GetNonceStats returns the statistics of the nonce recovery of the account.
+func (a *Account) GetNonceStats() NonceStats {
+	return NonceStats{
+		Stuck:    a.nonceTracker.stuck.Load(),
+		Resynced: a.nonceTracker.resynced.Load(),
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestAccount(t *testing.T, nonce uint64) *Account {
+	t.Helper()
+	account, err := NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	account.nonce = nonce
+	account.nonceTracker.confirmed = nonce
+	return account
+}
+
+func sendTestTransactions(t *testing.T, account *Account, n int) {
+	t.Helper()
+	for range n {
+		if _, err := createTx(account, common.Address{1}, big.NewInt(1), nil, 21_000); err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+	}
+}
+
+func TestRecoverNonce_LocalNonceBehindNetworkIsMovedForward(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	account := newTestAccount(t, 5)
+
+	client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(uint64(8), nil)
+	if txs, err := account.RecoverNonce(client, false); err != nil || len(txs) != 0 {
+		t.Fatalf("unexpected result, got %v, err %v", txs, err)
+	}
+	if got := account.getNextNonce(); got != 8 {
+		t.Errorf("local nonce was not moved forward, got %d", got)
+	}
+	if got, want := account.GetNonceStats(), (NonceStats{Resynced: 1}); got != want {
+		t.Errorf("unexpected stats, wanted %v, got %v", want, got)
+	}
+}
+
+func TestRecoverNonce_ProgressingAccountsAreNotStuck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	account := newTestAccount(t, 0)
+	sendTestTransactions(t, account, 10)
+
+	// confirmed nonces progressing or catching up are fine
+	for _, confirmed := range []uint64{2, 5, 10, 10} {
+		client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(confirmed, nil)
+		if txs, err := account.RecoverNonce(client, true); err != nil || len(txs) != 0 {
+			t.Fatalf("unexpected result, got %v, err %v", txs, err)
+		}
+	}
+	if got, want := account.GetNonceStats(), (NonceStats{}); got != want {
+		t.Errorf("unexpected stats, wanted %v, got %v", want, got)
+	}
+}
+
+func TestRecoverNonce_StuckTransactionsAreResent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	account := newTestAccount(t, 0)
+
+	// transactions are only retained once re-sending is enabled
+	client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(uint64(0), nil)
+	if _, err := account.RecoverNonce(client, true); err != nil {
+		t.Fatalf("failed to recover nonce: %v", err)
+	}
+	sendTestTransactions(t, account, 5)
+
+	client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(uint64(2), nil)
+	if txs, err := account.RecoverNonce(client, true); err != nil || len(txs) != 0 {
+		t.Fatalf("unexpected result, got %v, err %v", txs, err)
+	}
+
+	client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(uint64(2), nil)
+	txs, err := account.RecoverNonce(client, true)
+	if err != nil {
+		t.Fatalf("failed to recover nonce: %v", err)
+	}
+	if len(txs) != 3 {
+		t.Fatalf("unexpected number of transactions to re-send, wanted 3, got %d", len(txs))
+	}
+	for i, tx := range txs {
+		if got, want := tx.Nonce(), uint64(2+i); got != want {
+			t.Errorf("unexpected nonce of re-sent transaction, wanted %d, got %d", want, got)
+		}
+	}
+	if got, want := account.GetNonceStats(), (NonceStats{Stuck: 1}); got != want {
+		t.Errorf("unexpected stats, wanted %v, got %v", want, got)
+	}
+}
+
+func TestRecoverNonce_StuckNonceIsResetToPendingNonce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	account := newTestAccount(t, 0)
+	sendTestTransactions(t, account, 5)
+
+	client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(uint64(2), nil).Times(2)
+	client.EXPECT().PendingNonceAt(gomock.Any(), account.address).Return(uint64(3), nil)
+	for range 2 {
+		if txs, err := account.RecoverNonce(client, false); err != nil || len(txs) != 0 {
+			t.Fatalf("unexpected result, got %v, err %v", txs, err)
+		}
+	}
+	if got := account.getNextNonce(); got != 3 {
+		t.Errorf("local nonce was not reset to pending nonce, got %d", got)
+	}
+	if got, want := account.GetNonceStats(), (NonceStats{Stuck: 1, Resynced: 1}); got != want {
+		t.Errorf("unexpected stats, wanted %v, got %v", want, got)
+	}
+}
+
+func TestRecoverNonce_FailingChecksUpdateRetentionOfTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	account := newTestAccount(t, 0)
+
+	client.EXPECT().NonceAt(gomock.Any(), account.address, nil).Return(uint64(0), fmt.Errorf("injected error")).Times(2)
+	if _, err := account.RecoverNonce(client, true); err == nil {
+		t.Fatalf("failing check should be reported")
+	}
+	sendTestTransactions(t, account, 2)
+	if got := len(account.nonceTracker.pending); got != 2 {
+		t.Errorf("transactions should be retained, got %d", got)
+	}
+
+	if _, err := account.RecoverNonce(client, false); err == nil {
+		t.Fatalf("failing check should be reported")
+	}
+	sendTestTransactions(t, account, 1)
+	if got := len(account.nonceTracker.pending); got != 2 {
+		t.Errorf("transactions should no longer be retained, got %d", got)
+	}
+}
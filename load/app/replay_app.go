@@ -0,0 +1,256 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// replayDefaultGas is the gas limit of replayed transactions carrying
+	// data without a recorded gas limit.
+	replayDefaultGas = 1_000_000
+)
+
+// replayMaxValue is the maximum value transferred by a replayed transaction.
+// Recorded values are capped since the replaying accounts are only funded
+// with a limited amount of tokens.
+var replayMaxValue = big.NewInt(1_000_000_000_000_000_000) // 1 token
+
+// ReplayOptions defines the trace replayed by replay applications.
+type ReplayOptions struct {
+	// File is the path of the replay file, see ReadReplayFile. In configs,
+	// relative paths are relative to the scenario file.
+	File string
+	// Speed is the factor by which the recorded schedule is accelerated. A
+	// speed of 2 replays the trace in half of its recorded time. If zero,
+	// the trace is replayed at its original speed.
+	Speed float64 `yaml:",omitempty"`
+}
+
+// Check tests whether the replay file is accessible and the speed is valid.
+func (o *ReplayOptions) Check() error {
+	errs := []error{}
+	if o.File == "" {
+		errs = append(errs, errors.New("no replay file specified"))
+	} else if info, err := os.Stat(o.File); err != nil {
+		errs = append(errs, fmt.Errorf("replay file %v is not accessible; %w", o.File, err))
+	} else if info.IsDir() {
+		errs = append(errs, fmt.Errorf("replay file %v is a directory", o.File))
+	}
+	if o.Speed < 0 {
+		errs = append(errs, fmt.Errorf("replay speed must be positive, got %f", o.Speed))
+	}
+	return errors.Join(errs...)
+}
+
+// resolve resolves a relative path of the replay file relative to the given
+// directory.
+func (o *ReplayOptions) resolve(dir string) error {
+	if o.File != "" && !filepath.IsAbs(o.File) {
+		o.File = filepath.Join(dir, o.File)
+	}
+	return nil
+}
+
+// ScheduledUser is an optional interface of users sending transactions on
+// their own schedule instead of the schedule of the application's shaper.
+type ScheduledUser interface {
+	// GenerateScheduledTx produces the next transaction of the user and the
+	// time it should be sent at, relative to the start of the user. Once
+	// all transactions are produced, io.EOF is returned.
+	GenerateScheduledTx() (*types.Transaction, time.Duration, error)
+}
+
+// NewReplayApplication creates an application replaying the transactions of
+// a replay file. Original senders are remapped to accounts generated for the
+// users of the application, transactions are re-signed using the chain ID of
+// the network and submitted on the recorded schedule, scaled by the speed of
+// the options. Since the state of the recorded chain is not replicated,
+// calls to contracts absent in the network have no effect apart from
+// consuming gas.
+func NewReplayApplication(ctxt AppContext, options ReplayOptions, feederId, appId uint32) (Application, error) {
+	if options.File == "" {
+		return nil, errors.New("no replay file specified")
+	}
+	speed := options.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	if speed < 0 {
+		return nil, fmt.Errorf("replay speed must be positive, got %f", speed)
+	}
+
+	records, err := ReadReplayFile(options.File)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time < records[j].Time
+	})
+
+	chainId, err := ctxt.GetClient().ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID; %w", err)
+	}
+
+	accountFactory, err := newAccountFactory(ctxt, chainId, feederId, appId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayApplication{
+		records:        records,
+		speed:          speed,
+		accountFactory: accountFactory,
+	}, nil
+}
+
+// ReplayApplication represents a set of users replaying a recorded trace.
+type ReplayApplication struct {
+	records        []ReplayRecord
+	speed          float64
+	accountFactory *AccountFactory
+	users          []*ReplayUser
+}
+
+// CreateUsers creates a list of new users for the app. Original senders are
+// assigned to users round-robin in the order of their first transaction, so
+// transactions of one original sender are always replayed by the same user.
+func (a *ReplayApplication) CreateUsers(appContext AppContext, numUsers int) ([]User, error) {
+	users := make([]*ReplayUser, numUsers)
+	addresses := make([]common.Address, numUsers)
+	for i := 0; i < numUsers; i++ {
+		account, err := a.accountFactory.CreateAccount(appContext.GetClient())
+		if err != nil {
+			return nil, err
+		}
+		users[i] = &ReplayUser{
+			sender:       account,
+			initialNonce: account.nonce,
+			speed:        a.speed,
+		}
+		addresses[i] = account.address
+	}
+
+	if numUsers > 0 {
+		senders := map[common.Address]int{}
+		for _, record := range a.records {
+			index, found := senders[record.From]
+			if !found {
+				index = len(senders) % numUsers
+				senders[record.From] = index
+			}
+			users[index].records = append(users[index].records, record)
+		}
+	}
+	a.users = users
+
+	fundsPerUser := big.NewInt(1_000)
+	fundsPerUser = new(big.Int).Mul(fundsPerUser, big.NewInt(1_000_000_000_000_000_000)) // to wei
+	err := appContext.FundAccounts(addresses, fundsPerUser)
+
+	res := make([]User, len(users))
+	for i, user := range users {
+		res[i] = user
+	}
+	return res, err
+}
+
+// GetReceivedTransactions sums up the transactions processed for the accounts
+// of the users, which is reflected by the nonces of the accounts.
+func (a *ReplayApplication) GetReceivedTransactions(rpcClient rpc.Client) (uint64, error) {
+	total := uint64(0)
+	for _, user := range a.users {
+		nonce, err := rpcClient.NonceAt(context.Background(), user.sender.address, nil)
+		if err != nil {
+			return 0, err
+		}
+		if nonce > user.initialNonce {
+			total += nonce - user.initialNonce
+		}
+	}
+	return total, nil
+}
+
+// ReplayUser represents a user replaying the transactions of a subset of the
+// original senders of a trace. A user is supposed to be used in a single thread.
+type ReplayUser struct {
+	sender       *Account
+	initialNonce uint64
+	speed        float64
+	records      []ReplayRecord
+	next         int
+	sentTxs      atomic.Uint64
+}
+
+// GenerateTx produces the next transaction of the user regardless of its
+// schedule. If all transactions were replayed, io.EOF is returned.
+func (u *ReplayUser) GenerateTx() (*types.Transaction, error) {
+	tx, _, err := u.GenerateScheduledTx()
+	return tx, err
+}
+
+func (u *ReplayUser) GenerateScheduledTx() (*types.Transaction, time.Duration, error) {
+	if u.next >= len(u.records) {
+		return nil, 0, io.EOF
+	}
+	record := u.records[u.next]
+	u.next++
+
+	value := record.Value
+	if value == nil {
+		value = new(big.Int)
+	} else if value.Cmp(replayMaxValue) > 0 {
+		value = replayMaxValue
+	}
+	gas := record.Gas
+	if gas == 0 {
+		gas = 21_000
+		if len(record.Data) > 0 || record.To == nil {
+			gas = replayDefaultGas
+		}
+	}
+
+	tx, err := newSignedTx(u.sender, record.To, value, record.Data, gas)
+	if err != nil {
+		return nil, 0, err
+	}
+	u.sentTxs.Add(1)
+	return tx, time.Duration(float64(record.Time) / u.speed), nil
+}
+
+func (u *ReplayUser) GetSentTransactions() uint64 {
+	return u.sentTxs.Load()
+}
+
+func (u *ReplayUser) GetAccounts() []*Account {
+	return []*Account{u.sender}
+}
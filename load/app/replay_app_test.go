@@ -0,0 +1,268 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReplayFile_RecordsCanBeWrittenAndRead(t *testing.T) {
+	to := common.Address{2}
+	records := []ReplayRecord{
+		{Time: 0, From: common.Address{1}, To: &to, Value: big.NewInt(5), Data: []byte{1, 2}, Gas: 50_000},
+		{Time: 1500 * time.Millisecond, From: common.Address{3}, Value: big.NewInt(0), Data: []byte{3}, Gas: 100_000},
+	}
+	for _, name := range []string{"trace.jsonl", "trace.rlp"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatalf("failed to create file: %v", err)
+			}
+			writer := NewReplayWriter(path, file)
+			for _, record := range records {
+				if err := writer.Write(record); err != nil {
+					t.Fatalf("failed to write record: %v", err)
+				}
+			}
+			if err := file.Close(); err != nil {
+				t.Fatalf("failed to close file: %v", err)
+			}
+
+			got, err := ReadReplayFile(path)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			if len(got) != len(records) {
+				t.Fatalf("unexpected number of records, wanted %d, got %d", len(records), len(got))
+			}
+			for i := range records {
+				want := records[i]
+				if got[i].Value == nil {
+					got[i].Value = new(big.Int)
+				}
+				if got[i].Time != want.Time || got[i].From != want.From ||
+					!reflect.DeepEqual(got[i].To, want.To) || got[i].Value.Cmp(want.Value) != 0 ||
+					!reflect.DeepEqual(got[i].Data, want.Data) || got[i].Gas != want.Gas {
+					t.Errorf("unexpected record %d, wanted %v, got %v", i, want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayFile_SendersOfRawTransactionsAreRecovered(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	to := common.Address{7}
+	tx, err := types.SignNewTx(key, types.NewLondonSigner(big.NewInt(250)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(250),
+		Gas:     21_000,
+		To:      &to,
+		Value:   big.NewInt(12),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	content := fmt.Sprintf("{\"time\":2.5,\"tx\":\"%v\"}\n\n", hexutil.Bytes(raw))
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	records, err := ReadReplayFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("unexpected number of records, got %d", len(records))
+	}
+	record := records[0]
+	if want := crypto.PubkeyToAddress(key.PublicKey); record.From != want {
+		t.Errorf("unexpected sender, wanted %v, got %v", want, record.From)
+	}
+	if record.Time != 2500*time.Millisecond || *record.To != to || record.Value.Int64() != 12 || record.Gas != 21_000 {
+		t.Errorf("unexpected record %v", record)
+	}
+}
+
+func TestReplayFile_InvalidRecordsAreReported(t *testing.T) {
+	tests := map[string]string{
+		"not json":       "{",
+		"missing sender": `{"time":1}`,
+		"negative time":  `{"time":-1,"from":"0x0000000000000000000000000000000000000001"}`,
+		"invalid tx":     `{"time":1,"tx":"0x01"}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "trace.jsonl")
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			if _, err := ReadReplayFile(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+				t.Errorf("invalid record was not reported, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReplayApplication_SendersAreRemappedToUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	context.EXPECT().GetClient().Return(client).AnyTimes()
+	client.EXPECT().NonceAt(gomock.Any(), gomock.Any(), nil).Return(uint64(0), nil).Times(2)
+	context.EXPECT().FundAccounts(gomock.Any(), gomock.Any()).Return(nil)
+
+	to := common.Address{9}
+	huge := new(big.Int).Mul(replayMaxValue, big.NewInt(100))
+	application := &ReplayApplication{
+		records: []ReplayRecord{
+			{Time: 0, From: common.Address{1}, To: &to, Gas: 21_000},
+			{Time: 2 * time.Second, From: common.Address{2}, To: &to, Value: huge},
+			{Time: 4 * time.Second, From: common.Address{3}, Data: []byte{1}},
+			{Time: 6 * time.Second, From: common.Address{1}, To: &to},
+		},
+		speed:          2,
+		accountFactory: &AccountFactory{chainID: big.NewInt(4003)},
+	}
+	users, err := application.CreateUsers(context, 2)
+	if err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+
+	type sent struct {
+		offset time.Duration
+		nonce  uint64
+		value  *big.Int
+		gas    uint64
+	}
+	want := [][]sent{
+		{{0, 0, big.NewInt(0), 21_000}, {2 * time.Second, 1, big.NewInt(0), replayDefaultGas}, {3 * time.Second, 2, big.NewInt(0), 21_000}},
+		{{time.Second, 0, replayMaxValue, 21_000}},
+	}
+	for i, user := range users {
+		scheduled := user.(ScheduledUser)
+		for _, expected := range want[i] {
+			tx, offset, err := scheduled.GenerateScheduledTx()
+			if err != nil {
+				t.Fatalf("failed to generate transaction: %v", err)
+			}
+			if offset != expected.offset || tx.Nonce() != expected.nonce ||
+				tx.Value().Cmp(expected.value) != 0 || tx.Gas() != expected.gas {
+				t.Errorf("unexpected transaction of user %d, wanted %v, got offset %v, nonce %d, value %v, gas %d",
+					i, expected, offset, tx.Nonce(), tx.Value(), tx.Gas())
+			}
+			if tx.ChainId().Int64() != 4003 {
+				t.Errorf("transaction not signed for network, got chain ID %v", tx.ChainId())
+			}
+		}
+		if _, _, err := scheduled.GenerateScheduledTx(); !errors.Is(err, io.EOF) {
+			t.Errorf("end of trace was not reported, got %v", err)
+		}
+		if got := user.GetSentTransactions(); got != uint64(len(want[i])) {
+			t.Errorf("unexpected number of sent transactions, wanted %d, got %d", len(want[i]), got)
+		}
+	}
+
+	client.EXPECT().NonceAt(gomock.Any(), gomock.Any(), nil).Return(uint64(2), nil)
+	client.EXPECT().NonceAt(gomock.Any(), gomock.Any(), nil).Return(uint64(1), nil)
+	if got, err := application.GetReceivedTransactions(client); err != nil || got != 3 {
+		t.Errorf("unexpected number of received transactions, wanted 3, got %d, err %v", got, err)
+	}
+}
+
+func TestRecordReplay_TransactionsAreTimedByBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+
+	blocks := map[uint64]string{
+		5: `{"number":"0x5","timestamp":"0x1","timestampNano":"0x3b9aca00","transactions":[
+			{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":"0x3","input":"0x","gas":"0x5208"}]}`,
+		6: `{"number":"0x6","timestamp":"0x1","timestampNano":"0x59682f00","transactions":[]}`,
+		7: `{"number":"0x7","timestamp":"0x2","timestampNano":"0x77359400","transactions":[
+			{"from":"0x0000000000000000000000000000000000000003","to":null,"value":"0x0","input":"0x6001","gas":"0x186a0"}]}`,
+	}
+	for number, block := range blocks {
+		client.EXPECT().Call(gomock.Any(), "eth_getBlockByNumber", hexutil.Uint64(number), true).DoAndReturn(
+			func(result any, _ string, _ ...any) error {
+				return json.Unmarshal([]byte(block), result)
+			})
+	}
+
+	writer := &replayRecordCollector{}
+	count, err := RecordReplay(client, 5, 7, writer)
+	if err != nil {
+		t.Fatalf("failed to record trace: %v", err)
+	}
+	if count != 2 || len(writer.records) != 2 {
+		t.Fatalf("unexpected number of records, got %d", count)
+	}
+	first, second := writer.records[0], writer.records[1]
+	if first.Time != 0 || first.From != common.HexToAddress("0x1") || *first.To != common.HexToAddress("0x2") || first.Gas != 21_000 {
+		t.Errorf("unexpected first record %v", first)
+	}
+	if second.Time != time.Second || second.To != nil || !reflect.DeepEqual(second.Data, []byte{0x60, 0x01}) {
+		t.Errorf("unexpected second record %v", second)
+	}
+}
+
+func TestRecordReplay_MissingBlocksAreReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	client.EXPECT().Call(gomock.Any(), "eth_getBlockByNumber", hexutil.Uint64(1), true).Return(nil)
+
+	if _, err := RecordReplay(client, 1, 1, &replayRecordCollector{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing block was not reported, got %v", err)
+	}
+	if _, err := RecordReplay(client, 2, 1, &replayRecordCollector{}); err == nil {
+		t.Errorf("invalid range was not reported")
+	}
+}
+
+type replayRecordCollector struct {
+	records []ReplayRecord
+}
+
+func (c *replayRecordCollector) Write(record ReplayRecord) error {
+	c.records = append(c.records, record)
+	return nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReplayRecord is a transaction template of a replay file. Templates do not
+// contain nonces or signatures, which are provided by the replaying accounts.
+type ReplayRecord struct {
+	Time  time.Duration   // < the time of the transaction relative to the start of the trace
+	From  common.Address  // < the original sender, only used to group transactions
+	To    *common.Address // < the recipient, nil for contract creations
+	Value *big.Int        // < the transferred value, nil for none
+	Data  []byte          // < the input of the transaction
+	Gas   uint64          // < the gas limit of the transaction
+}
+
+// jsonReplayRecord is the JSON encoding of a ReplayRecord. Instead of a
+// template, a line may contain a raw signed transaction (field tx), of which
+// the sender is recovered using the transaction's chain ID.
+type jsonReplayRecord struct {
+	Time  float64         `json:"time"` // in seconds
+	From  *common.Address `json:"from,omitempty"`
+	To    *common.Address `json:"to,omitempty"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Data  hexutil.Bytes   `json:"data,omitempty"`
+	Gas   hexutil.Uint64  `json:"gas,omitempty"`
+	Tx    hexutil.Bytes   `json:"tx,omitempty"`
+}
+
+// rlpReplayRecord is the RLP encoding of a ReplayRecord.
+type rlpReplayRecord struct {
+	Time  uint64 // in nanoseconds
+	From  common.Address
+	To    *common.Address `rlp:"nil"`
+	Value *big.Int
+	Data  []byte
+	Gas   uint64
+}
+
+// ReadReplayFile reads the records of a replay file. Files with the extension
+// .rlp are interpreted as a stream of RLP encoded records, all other files as
+// JSON records, one per line. Records are returned in the order of the file.
+func ReadReplayFile(path string) ([]ReplayRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file; %w", err)
+	}
+	defer file.Close()
+	if isRlpReplayFile(path) {
+		return readRlpReplayRecords(file)
+	}
+	return readJsonReplayRecords(file)
+}
+
+// NewReplayWriter creates a writer for replay records in the format implied
+// by the extension of the given path.
+func NewReplayWriter(path string, out io.Writer) ReplayWriter {
+	if isRlpReplayFile(path) {
+		return &rlpReplayWriter{out: out}
+	}
+	return &jsonReplayWriter{encoder: json.NewEncoder(out)}
+}
+
+// ReplayWriter writes records to a replay file.
+type ReplayWriter interface {
+	Write(record ReplayRecord) error
+}
+
+func isRlpReplayFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".rlp"
+}
+
+func readJsonReplayRecords(in io.Reader) ([]ReplayRecord, error) {
+	res := []ReplayRecord{}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 16*1024*1024) // < lines may contain large contract codes
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry jsonReplayRecord
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid record in line %d; %w", line, err)
+		}
+		record, err := entry.toRecord()
+		if err != nil {
+			return nil, fmt.Errorf("invalid record in line %d; %w", line, err)
+		}
+		res = append(res, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay file; %w", err)
+	}
+	return res, nil
+}
+
+func (r *jsonReplayRecord) toRecord() (ReplayRecord, error) {
+	if r.Time < 0 {
+		return ReplayRecord{}, fmt.Errorf("negative time %f", r.Time)
+	}
+	res := ReplayRecord{
+		Time: time.Duration(r.Time * float64(time.Second)),
+	}
+	if len(r.Tx) > 0 {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(r.Tx); err != nil {
+			return ReplayRecord{}, fmt.Errorf("invalid transaction; %w", err)
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return ReplayRecord{}, fmt.Errorf("failed to recover sender; %w", err)
+		}
+		res.From = from
+		res.To = tx.To()
+		res.Value = tx.Value()
+		res.Data = tx.Data()
+		res.Gas = tx.Gas()
+		return res, nil
+	}
+	if r.From == nil {
+		return ReplayRecord{}, errors.New("missing sender")
+	}
+	res.From = *r.From
+	res.To = r.To
+	res.Value = r.Value.ToInt()
+	res.Data = r.Data
+	res.Gas = uint64(r.Gas)
+	return res, nil
+}
+
+func readRlpReplayRecords(in io.Reader) ([]ReplayRecord, error) {
+	res := []ReplayRecord{}
+	stream := rlp.NewStream(bufio.NewReader(in), 0)
+	for {
+		var entry rlpReplayRecord
+		if err := stream.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return res, nil
+			}
+			return nil, fmt.Errorf("invalid record %d; %w", len(res)+1, err)
+		}
+		res = append(res, ReplayRecord{
+			Time:  time.Duration(entry.Time),
+			From:  entry.From,
+			To:    entry.To,
+			Value: entry.Value,
+			Data:  entry.Data,
+			Gas:   entry.Gas,
+		})
+	}
+}
+
+type jsonReplayWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonReplayWriter) Write(record ReplayRecord) error {
+	var value *hexutil.Big
+	if record.Value != nil && record.Value.Sign() != 0 {
+		value = (*hexutil.Big)(record.Value)
+	}
+	return w.encoder.Encode(jsonReplayRecord{
+		Time:  record.Time.Seconds(),
+		From:  &record.From,
+		To:    record.To,
+		Value: value,
+		Data:  record.Data,
+		Gas:   hexutil.Uint64(record.Gas),
+	})
+}
+
+type rlpReplayWriter struct {
+	out io.Writer
+}
+
+func (w *rlpReplayWriter) Write(record ReplayRecord) error {
+	value := record.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	return rlp.Encode(w.out, rlpReplayRecord{
+		Time:  uint64(record.Time),
+		From:  record.From,
+		To:    record.To,
+		Value: value,
+		Data:  record.Data,
+		Gas:   record.Gas,
+	})
+}
+
+// RecordReplay writes the transactions of the blocks [from, to] of a chain
+// to the given writer. Transactions are timed relative to the first block,
+// using the time of the block they are included in.
+func RecordReplay(client rpc.Client, from, to uint64, writer ReplayWriter) (int, error) {
+	if from > to {
+		return 0, fmt.Errorf("invalid block range [%d,%d]", from, to)
+	}
+	count := 0
+	var start time.Time
+	for number := from; number <= to; number++ {
+		var block *struct {
+			blockHeader
+			Transactions []struct {
+				From  common.Address  `json:"from"`
+				To    *common.Address `json:"to"`
+				Value *hexutil.Big    `json:"value"`
+				Input hexutil.Bytes   `json:"input"`
+				Gas   hexutil.Uint64  `json:"gas"`
+			} `json:"transactions"`
+		}
+		if err := client.Call(&block, "eth_getBlockByNumber", hexutil.Uint64(number), true); err != nil {
+			return count, fmt.Errorf("failed to fetch block %d; %w", number, err)
+		}
+		if block == nil {
+			return count, fmt.Errorf("block %d not found", number)
+		}
+		if number == from {
+			start = block.getTime()
+		}
+		offset := block.getTime().Sub(start)
+		for _, tx := range block.Transactions {
+			err := writer.Write(ReplayRecord{
+				Time:  offset,
+				From:  tx.From,
+				To:    tx.To,
+				Value: tx.Value.ToInt(),
+				Data:  tx.Input,
+				Gas:   uint64(tx.Gas),
+			})
+			if err != nil {
+				return count, fmt.Errorf("failed to write record; %w", err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,467 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	contract "github.com/0xsoniclabs/hyperion/load/contracts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// RpcMethods lists the RPC methods supported by rpc applications. Requests of
+// debug_traceTransaction require historic states, which are only retained by
+// archive nodes, so they should be directed at such nodes.
+var RpcMethods = []string{
+	"eth_call",
+	"eth_getBalance",
+	"eth_getLogs",
+	"eth_getBlockByNumber",
+	"debug_traceTransaction",
+}
+
+// IsSupportedRpcMethod returns true if rpc applications can issue requests
+// of the given method.
+func IsSupportedRpcMethod(method string) bool {
+	return slices.Contains(RpcMethods, normalizeRpcMethod(method))
+}
+
+const (
+	// rpcLogsBlockRange is the number of most recent blocks covered by
+	// eth_getLogs requests.
+	rpcLogsBlockRange = 100
+	// rpcMaxRecentTransactions is the maximum number of recent transaction
+	// hashes retained as targets of debug_traceTransaction requests.
+	rpcMaxRecentTransactions = 256
+	// rpcHeadRefreshInterval is the maximum age of the observed head before
+	// it is refreshed ahead of requests depending on it.
+	rpcHeadRefreshInterval = time.Second
+)
+
+// RpcOptions defines the requests issued by rpc applications.
+type RpcOptions struct {
+	// Methods lists the RPC methods to be used and their relative weights.
+	// If empty, all RpcMethods are used with equal weight.
+	Methods []RpcMethod `yaml:",omitempty"`
+	// Nodes lists the names of the nodes requests are sent to. If empty,
+	// requests are sent to all nodes.
+	Nodes []string `yaml:",omitempty"`
+}
+
+// RpcMethod is a single RPC method of an rpc application and its weight.
+type RpcMethod struct {
+	Method string
+	Weight float64
+}
+
+// Check tests the options for unsupported or duplicated methods and invalid
+// weights.
+func (o *RpcOptions) Check() error {
+	errs := []error{}
+	methods := []string{}
+	for _, method := range o.Methods {
+		name := normalizeRpcMethod(method.Method)
+		if !IsSupportedRpcMethod(name) {
+			errs = append(errs, fmt.Errorf("unsupported RPC method '%s'", method.Method))
+		}
+		if slices.Contains(methods, name) {
+			errs = append(errs, fmt.Errorf("RPC method %s listed multiple times", name))
+		}
+		methods = append(methods, name)
+		if method.Weight <= 0 {
+			errs = append(errs, fmt.Errorf("weight of RPC method %s must be positive, got %f", name, method.Weight))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetNodeNames lists the nodes requests are sent to.
+func (o *RpcOptions) GetNodeNames() []string {
+	return o.Nodes
+}
+
+// RequestUser is an optional interface of users producing read-only load.
+// Instead of generating transactions, such users issue RPC requests.
+type RequestUser interface {
+	// SendRequest issues a single request to the network and waits for the
+	// response.
+	SendRequest() error
+}
+
+// RequestStats summarizes the requests of a single RPC method.
+type RequestStats struct {
+	Requests uint64        // number of completed requests
+	Errors   uint64        // number of failed requests
+	Latency  time.Duration // accumulated latency of all completed requests
+}
+
+// RequestLoad is an optional interface of applications issuing RPC requests,
+// providing statistics on a per-method granularity.
+type RequestLoad interface {
+	GetRequestStats(method string) (RequestStats, error)
+}
+
+// NodeDialer provides RPC connections to selected nodes of the network.
+type NodeDialer interface {
+	// DialNodes connects to all active nodes of the given names, or to all
+	// active nodes if no names are given.
+	DialNodes(names []string) ([]rpc.Client, error)
+}
+
+// NewRpcApplication creates an application producing read-only load. Instead
+// of sending transactions, users of this application issue a weighted mix of
+// RPC requests to a configurable set of nodes. The number of sent transactions
+// reported for users is the number of issued requests, the number of received
+// transactions is the number of successfully answered requests.
+func NewRpcApplication(ctxt AppContext, options RpcOptions, feederId, appId uint32) (Application, error) {
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+	methods := options.Methods
+	if len(methods) == 0 {
+		for _, method := range RpcMethods {
+			methods = append(methods, RpcMethod{Method: method, Weight: 1})
+		}
+	}
+
+	res := &RpcApplication{
+		nodes: options.Nodes,
+		stats: make([]rpcMethodStats, len(methods)),
+	}
+	for _, method := range methods {
+		res.methods = append(res.methods, normalizeRpcMethod(method.Method))
+		res.weights = append(res.weights, method.Weight)
+	}
+
+	// Deploy a Counter contract to be targeted by eth_call requests.
+	_, receipt, err := DeployContract(ctxt, contract.DeployCounter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy Counter contract; %w", err)
+	}
+	counterAbi, err := contract.CounterMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	callData, err := counterAbi.Pack("getCount")
+	if err != nil {
+		return nil, err
+	}
+
+	res.counter = receipt.ContractAddress
+	res.callData = callData
+	res.head.Store(receipt.BlockNumber.Uint64())
+	res.recentTxs = []common.Hash{receipt.TxHash}
+	return res, nil
+}
+
+// RpcApplication represents a set of users issuing RPC requests.
+type RpcApplication struct {
+	methods []string
+	weights []float64
+	nodes   []string
+	stats   []rpcMethodStats
+
+	counter  common.Address // < the contract targeted by eth_call requests
+	callData []byte         // < the input of eth_call requests
+
+	head           atomic.Uint64 // < the most recent block number observed
+	headAt         atomic.Int64  // < time of the last head observation in Unix nanoseconds
+	recentTxs      []common.Hash // < targets of debug_traceTransaction requests
+	recentTxsMutex sync.Mutex
+
+	clients      []rpc.Client // < connections to the targeted nodes
+	clientsMutex sync.Mutex
+}
+
+type rpcMethodStats struct {
+	requests atomic.Uint64
+	errors   atomic.Uint64
+	latency  atomic.Int64
+}
+
+// CreateUsers creates a list of new users for the app. All users share the
+// connections to the targeted nodes.
+func (f *RpcApplication) CreateUsers(appContext AppContext, numUsers int) ([]User, error) {
+	dialer, ok := appContext.(NodeDialer)
+	if !ok {
+		return nil, fmt.Errorf("application context does not support connecting to selected nodes")
+	}
+	clients, err := dialer.DialNodes(f.nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nodes %v; %w", f.nodes, err)
+	}
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("no active nodes found matching %v", f.nodes)
+	}
+	f.clientsMutex.Lock()
+	f.clients = append(f.clients, clients...)
+	f.clientsMutex.Unlock()
+
+	users := make([]User, numUsers)
+	for i := range users {
+		users[i] = &RpcUser{
+			app:     f,
+			clients: clients,
+			sent:    make([]atomic.Uint64, len(f.methods)),
+		}
+	}
+	return users, nil
+}
+
+func (f *RpcApplication) GetReceivedTransactions(rpcClient rpc.Client) (uint64, error) {
+	total := uint64(0)
+	for _, method := range f.methods {
+		count, err := f.GetReceivedTransactionsOf(method, rpcClient)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (f *RpcApplication) GetOperations() []string {
+	return f.methods
+}
+
+func (f *RpcApplication) GetReceivedTransactionsOf(method string, _ rpc.Client) (uint64, error) {
+	stats, err := f.GetRequestStats(method)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Requests - stats.Errors, nil
+}
+
+func (f *RpcApplication) GetRequestStats(method string) (RequestStats, error) {
+	i := slices.Index(f.methods, method)
+	if i < 0 {
+		return RequestStats{}, fmt.Errorf("unknown RPC method %s", method)
+	}
+	stats := &f.stats[i]
+	// Errors are loaded first, since they are counted after requests.
+	errs := stats.errors.Load()
+	return RequestStats{
+		Requests: stats.requests.Load(),
+		Errors:   errs,
+		Latency:  time.Duration(stats.latency.Load()),
+	}, nil
+}
+
+// Close releases the connections to the targeted nodes.
+func (f *RpcApplication) Close() error {
+	f.clientsMutex.Lock()
+	defer f.clientsMutex.Unlock()
+	for _, client := range f.clients {
+		client.Close()
+	}
+	f.clients = nil
+	return nil
+}
+
+// pickMethod selects a random method index according to the weights.
+func (f *RpcApplication) pickMethod() int {
+	total := 0.0
+	for _, weight := range f.weights {
+		total += weight
+	}
+	r := rand.Float64() * total
+	for i, weight := range f.weights {
+		if r < weight {
+			return i
+		}
+		r -= weight
+	}
+	return len(f.weights) - 1
+}
+
+// request issues a single request of the given method using the given client.
+func (f *RpcApplication) request(client rpc.Client, method string) error {
+	switch method {
+	case "eth_call":
+		var result hexutil.Bytes
+		return client.Call(&result, method, map[string]any{
+			"to":   f.counter,
+			"data": hexutil.Bytes(f.callData),
+		}, "latest")
+	case "eth_getBalance":
+		var result hexutil.Big
+		address := common.Address{}
+		rand.Read(address[:])
+		return client.Call(&result, method, address, "latest")
+	case "eth_getLogs":
+		var result []json.RawMessage
+		from := uint64(0)
+		if head := f.head.Load(); head > rpcLogsBlockRange {
+			from = head - rpcLogsBlockRange
+		}
+		return client.Call(&result, method, map[string]any{
+			"fromBlock": hexutil.Uint64(from),
+			"toBlock":   "latest",
+		})
+	case "eth_getBlockByNumber":
+		return f.fetchLatestBlock(client)
+	case "debug_traceTransaction":
+		var result json.RawMessage
+		return client.Call(&result, method, f.getRecentTransaction(), map[string]any{
+			"tracer": "callTracer",
+		})
+	}
+	return fmt.Errorf("unsupported RPC method '%s'", method)
+}
+
+// needsHead returns true if requests of the given method target the most
+// recent block or its transactions.
+func needsHead(method string) bool {
+	return method == "eth_getLogs" || method == "debug_traceTransaction"
+}
+
+// refreshHead fetches the latest block if no block has been observed within
+// rpcHeadRefreshInterval. This keeps the targets of eth_getLogs and
+// debug_traceTransaction requests up to date independent of the method mix.
+func (f *RpcApplication) refreshHead(client rpc.Client) error {
+	last := f.headAt.Load()
+	if time.Since(time.Unix(0, last)) < rpcHeadRefreshInterval {
+		return nil
+	}
+	// Only one user refreshes the head at a time, others use the current one.
+	if !f.headAt.CompareAndSwap(last, time.Now().UnixNano()) {
+		return nil
+	}
+	return f.fetchLatestBlock(client)
+}
+
+// fetchLatestBlock requests the latest block using the given client and
+// records its number and transactions.
+func (f *RpcApplication) fetchLatestBlock(client rpc.Client) error {
+	var result *struct {
+		Number       hexutil.Uint64 `json:"number"`
+		Transactions []common.Hash  `json:"transactions"`
+	}
+	if err := client.Call(&result, "eth_getBlockByNumber", "latest", false); err != nil {
+		return err
+	}
+	if result == nil {
+		return errors.New("latest block not found")
+	}
+	f.observeBlock(uint64(result.Number), result.Transactions)
+	return nil
+}
+
+// observeBlock records the number and transactions of a block seen on the
+// network, keeping the targets of subsequent requests up to date.
+func (f *RpcApplication) observeBlock(number uint64, txs []common.Hash) {
+	f.headAt.Store(time.Now().UnixNano())
+	for {
+		head := f.head.Load()
+		if number <= head || f.head.CompareAndSwap(head, number) {
+			break
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+	f.recentTxsMutex.Lock()
+	defer f.recentTxsMutex.Unlock()
+	f.recentTxs = append(f.recentTxs, txs...)
+	if len(f.recentTxs) > rpcMaxRecentTransactions {
+		f.recentTxs = slices.Clone(f.recentTxs[len(f.recentTxs)-rpcMaxRecentTransactions:])
+	}
+}
+
+func (f *RpcApplication) getRecentTransaction() common.Hash {
+	f.recentTxsMutex.Lock()
+	defer f.recentTxsMutex.Unlock()
+	return f.recentTxs[rand.Intn(len(f.recentTxs))]
+}
+
+// RpcUser represents a user issuing RPC requests to randomly selected nodes.
+type RpcUser struct {
+	app     *RpcApplication
+	clients []rpc.Client
+	sent    []atomic.Uint64 // < number of requests per method
+}
+
+func (g *RpcUser) SendRequest() error {
+	i := g.app.pickMethod()
+	method := g.app.methods[i]
+	client := g.clients[rand.Intn(len(g.clients))]
+	g.sent[i].Add(1)
+
+	// The head is refreshed ahead of the timed request to not distort its latency.
+	if needsHead(method) {
+		if err := g.app.refreshHead(client); err != nil {
+			log.Printf("failed to refresh head of rpc application; %v", err)
+		}
+	}
+
+	start := time.Now()
+	err := g.app.request(client, method)
+	latency := time.Since(start)
+
+	// Requests are counted before errors to never report more errors than requests.
+	stats := &g.app.stats[i]
+	stats.latency.Add(int64(latency))
+	stats.requests.Add(1)
+	if err != nil {
+		stats.errors.Add(1)
+		return fmt.Errorf("failed to issue %s request; %w", method, err)
+	}
+	return nil
+}
+
+func (g *RpcUser) GenerateTx() (*types.Transaction, error) {
+	return nil, fmt.Errorf("users of rpc applications do not send transactions")
+}
+
+func (g *RpcUser) GetSentTransactions() uint64 {
+	total := uint64(0)
+	for i := range g.sent {
+		total += g.sent[i].Load()
+	}
+	return total
+}
+
+func (g *RpcUser) GetSentTransactionsOf(method string) uint64 {
+	i := slices.Index(g.app.methods, method)
+	if i < 0 {
+		return 0
+	}
+	return g.sent[i].Load()
+}
+
+// normalizeRpcMethod maps user-provided method names to the names used on
+// the RPC interface, which are case sensitive.
+func normalizeRpcMethod(method string) string {
+	for _, cur := range RpcMethods {
+		if strings.EqualFold(cur, method) {
+			return cur
+		}
+	}
+	return method
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewRpcApplication_RejectsInvalidOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+
+	tests := map[string]struct {
+		methods []RpcMethod
+		issue   string
+	}{
+		"unknown":   {[]RpcMethod{{Method: "eth_sendTransaction", Weight: 1}}, "unsupported RPC method"},
+		"zero":      {[]RpcMethod{{Method: "eth_call", Weight: 0}}, "must be positive"},
+		"duplicate": {[]RpcMethod{{Method: "eth_call", Weight: 1}, {Method: "ETH_CALL", Weight: 1}}, "listed multiple times"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewRpcApplication(context, RpcOptions{Methods: test.methods}, 0, 0)
+			if err == nil || !strings.Contains(err.Error(), test.issue) {
+				t.Errorf("expected error containing %q, got %v", test.issue, err)
+			}
+		})
+	}
+}
+
+func TestIsSupportedRpcMethod_IgnoresCase(t *testing.T) {
+	for _, method := range RpcMethods {
+		if !IsSupportedRpcMethod(method) || !IsSupportedRpcMethod(strings.ToUpper(method)) {
+			t.Errorf("method %s should be supported", method)
+		}
+	}
+	if IsSupportedRpcMethod("eth_sendRawTransaction") {
+		t.Errorf("eth_sendRawTransaction should not be supported")
+	}
+}
+
+// dialingContext is an application context able to connect to selected nodes.
+type dialingContext struct {
+	*MockAppContext
+	clients []rpc.Client
+	names   []string
+}
+
+func (c *dialingContext) DialNodes(names []string) ([]rpc.Client, error) {
+	c.names = names
+	return c.clients, nil
+}
+
+func TestRpcApplication_UsersTargetSelectedNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	context := &dialingContext{
+		MockAppContext: NewMockAppContext(ctrl),
+		clients:        []rpc.Client{client},
+	}
+
+	application := newTestRpcApplication("eth_getBalance")
+	application.nodes = []string{"rpc"}
+	users, err := application.CreateUsers(context, 2)
+	if err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("unexpected number of users, wanted 2, got %d", len(users))
+	}
+	if got, want := strings.Join(context.names, ","), "rpc"; got != want {
+		t.Errorf("unexpected nodes dialed, wanted %v, got %v", want, got)
+	}
+
+	client.EXPECT().Call(gomock.Any(), "eth_getBalance", gomock.Any(), "latest").Return(nil)
+	client.EXPECT().Call(gomock.Any(), "eth_getBalance", gomock.Any(), "latest").Return(fmt.Errorf("injected"))
+	if err := users[0].(RequestUser).SendRequest(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := users[1].(RequestUser).SendRequest(); err == nil {
+		t.Errorf("expected error to be reported")
+	}
+
+	stats, err := application.GetRequestStats("eth_getBalance")
+	if err != nil {
+		t.Fatalf("failed to get request stats: %v", err)
+	}
+	if stats.Requests != 2 || stats.Errors != 1 || stats.Latency <= 0 {
+		t.Errorf("unexpected request stats: %+v", stats)
+	}
+	if got, err := application.GetReceivedTransactions(nil); err != nil || got != 1 {
+		t.Errorf("unexpected number of answered requests, wanted 1, got %d, err %v", got, err)
+	}
+	if got := users[0].GetSentTransactions(); got != 1 {
+		t.Errorf("unexpected number of sent requests, wanted 1, got %d", got)
+	}
+	if got := users[0].(OperationMixUser).GetSentTransactionsOf("eth_getBalance"); got != 1 {
+		t.Errorf("unexpected number of sent eth_getBalance requests, wanted 1, got %d", got)
+	}
+	if _, err := users[0].GenerateTx(); err == nil {
+		t.Errorf("rpc users should not generate transactions")
+	}
+
+	client.EXPECT().Close()
+	if err := application.Close(); err != nil {
+		t.Errorf("failed to close application: %v", err)
+	}
+}
+
+func TestRpcApplication_BlocksProvideTargetsOfSubsequentRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	application := newTestRpcApplication("eth_getBlockByNumber")
+	tx := common.Hash{1, 2, 3}
+
+	client.EXPECT().Call(gomock.Any(), "eth_getBlockByNumber", "latest", false).DoAndReturn(
+		func(result any, _ string, _ ...any) error {
+			return json.Unmarshal([]byte(fmt.Sprintf(`{"number":"0x200","transactions":["%v"]}`, tx)), result)
+		})
+	if err := application.request(client, "eth_getBlockByNumber"); err != nil {
+		t.Fatalf("failed to request block: %v", err)
+	}
+	if got, want := application.head.Load(), uint64(0x200); got != want {
+		t.Errorf("unexpected head, wanted %d, got %d", want, got)
+	}
+
+	client.EXPECT().Call(gomock.Any(), "eth_getLogs", gomock.Any()).DoAndReturn(
+		func(_ any, _ string, args ...any) error {
+			filter := args[0].(map[string]any)
+			if got, want := fmt.Sprint(filter["fromBlock"]), hexutil.Uint64(0x200-rpcLogsBlockRange).String(); got != want {
+				t.Errorf("unexpected start of log range, wanted %s, got %s", want, got)
+			}
+			return nil
+		})
+	if err := application.request(client, "eth_getLogs"); err != nil {
+		t.Fatalf("failed to request logs: %v", err)
+	}
+
+	client.EXPECT().Call(gomock.Any(), "debug_traceTransaction", tx, gomock.Any()).Return(nil)
+	if err := application.request(client, "debug_traceTransaction"); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+}
+
+func TestRpcApplication_HeadIsRefreshedWithoutBlockRequestsInMix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	application := newTestRpcApplication("eth_getLogs", "debug_traceTransaction")
+	user := &RpcUser{
+		app:     application,
+		clients: []rpc.Client{client},
+		sent:    make([]atomic.Uint64, len(application.methods)),
+	}
+	tx := common.Hash{1, 2, 3}
+
+	gomock.InOrder(
+		client.EXPECT().Call(gomock.Any(), "eth_getBlockByNumber", "latest", false).DoAndReturn(
+			func(result any, _ string, _ ...any) error {
+				return json.Unmarshal([]byte(fmt.Sprintf(`{"number":"0x200","transactions":["%v"]}`, tx)), result)
+			}),
+		client.EXPECT().Call(gomock.Any(), "eth_getLogs", gomock.Any()).DoAndReturn(
+			func(_ any, _ string, args ...any) error {
+				filter := args[0].(map[string]any)
+				if got, want := fmt.Sprint(filter["fromBlock"]), hexutil.Uint64(0x200-rpcLogsBlockRange).String(); got != want {
+					t.Errorf("unexpected start of log range, wanted %s, got %s", want, got)
+				}
+				return nil
+			}).Times(2),
+		client.EXPECT().Call(gomock.Any(), "debug_traceTransaction", tx, gomock.Any()).Return(nil),
+	)
+
+	// The head is only refreshed once within the refresh interval.
+	application.weights = []float64{1, 0}
+	for range 2 {
+		if err := user.SendRequest(); err != nil {
+			t.Fatalf("failed to request logs: %v", err)
+		}
+	}
+	application.weights = []float64{0, 1}
+	if err := user.SendRequest(); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+}
+
+// newTestRpcApplication creates an rpc application using the given methods
+// without deploying any contracts.
+func newTestRpcApplication(methods ...string) *RpcApplication {
+	res := &RpcApplication{
+		methods: methods,
+		stats:   make([]rpcMethodStats, len(methods)),
+	}
+	for range methods {
+		res.weights = append(res.weights, 1)
+	}
+	return res
+}
@@ -47,7 +47,7 @@ func NewStoreApplication(ctxt AppContext, feederId, appId uint32) (Application,
 		return nil, fmt.Errorf("failed to deploy Store contract; %w", err)
 	}
 
-	accountFactory, err := NewAccountFactory(chainId, feederId, appId)
+	accountFactory, err := newAccountFactory(ctxt, chainId, feederId, appId)
 	if err != nil {
 		return nil, err
 	}
@@ -146,3 +146,7 @@ func (g *StoreUser) GenerateTx() (*types.Transaction, error) {
 func (g *StoreUser) GetSentTransactions() uint64 {
 	return g.sentTxs.Load()
 }
+
+func (g *StoreUser) GetAccounts() []*Account {
+	return []*Account{g.sender}
+}
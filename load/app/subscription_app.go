@@ -0,0 +1,531 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// SubscriptionKinds lists the eth_subscribe subscriptions supported by
+// subscription applications.
+var SubscriptionKinds = []string{
+	"newHeads",
+	"logs",
+	"newPendingTransactions",
+}
+
+// IsSupportedSubscriptionKind returns true if subscription applications can
+// subscribe to the given kind of events.
+func IsSupportedSubscriptionKind(kind string) bool {
+	return slices.Contains(SubscriptionKinds, normalizeSubscriptionKind(kind))
+}
+
+const (
+	// subscriptionBufferSize is the number of notifications buffered per
+	// subscription before the connection is considered too slow.
+	subscriptionBufferSize = 1024
+	// maxTrackedBlockTimes is the number of most recent block times retained
+	// for computing the latency of log notifications.
+	maxTrackedBlockTimes = 1024
+	// maxTrackedEvents is the minimum number of most recent events per
+	// subscription retained for detecting duplicated notifications.
+	maxTrackedEvents = 1 << 16
+)
+
+// SubscriptionOptions defines the subscriptions of subscription applications.
+type SubscriptionOptions struct {
+	// Kinds lists the kinds of events each user subscribes to. If empty,
+	// users subscribe to newHeads only.
+	Kinds []string `yaml:",omitempty"`
+	// Logs is the filter of logs subscriptions.
+	Logs LogFilter `yaml:",omitempty"`
+	// Nodes lists the names of the nodes subscriptions are opened on. If
+	// empty, subscriptions are spread over all nodes.
+	Nodes []string `yaml:",omitempty"`
+}
+
+// LogFilter restricts the logs reported by logs subscriptions. Empty fields
+// do not restrict the reported logs, an empty list of topics at a position
+// matches any topic. In configs, addresses and topics are hex encoded.
+type LogFilter struct {
+	Addresses []common.Address `yaml:",omitempty"`
+	Topics    [][]common.Hash  `yaml:",omitempty"`
+}
+
+// Check tests the options for unsupported or duplicated kinds of events.
+func (o *SubscriptionOptions) Check() error {
+	errs := []error{}
+	kinds := []string{}
+	for _, kind := range o.Kinds {
+		name := normalizeSubscriptionKind(kind)
+		if !IsSupportedSubscriptionKind(name) {
+			errs = append(errs, fmt.Errorf("unsupported subscription kind '%s'", kind))
+		}
+		if slices.Contains(kinds, name) {
+			errs = append(errs, fmt.Errorf("subscription kind %s listed multiple times", name))
+		}
+		kinds = append(kinds, name)
+	}
+	return errors.Join(errs...)
+}
+
+// GetNodeNames lists the nodes subscriptions are opened on.
+func (o *SubscriptionOptions) GetNodeNames() []string {
+	return o.Nodes
+}
+
+// Subscriber is an optional interface of users consuming notifications
+// instead of sending transactions. Subscriptions are active between the
+// calls of Subscribe and Unsubscribe.
+type Subscriber interface {
+	// Subscribe opens the subscriptions of the user.
+	Subscribe() error
+	// Unsubscribe closes all subscriptions of the user.
+	Unsubscribe()
+}
+
+// SubscriptionStats summarizes the notifications of one kind of subscription.
+type SubscriptionStats struct {
+	Notifications uint64        // number of received notifications
+	Dropped       uint64        // number of events missed by subscriptions
+	Duplicated    uint64        // number of events notified more than once
+	Measured      uint64        // number of notifications with a known latency
+	Latency       time.Duration // accumulated latency of measured notifications
+}
+
+// SubscriptionLoad is an optional interface of applications subscribing to
+// events, providing statistics on a per-kind granularity.
+type SubscriptionLoad interface {
+	GetSubscriptionStats(kind string) (SubscriptionStats, error)
+}
+
+// WebSocketProvider lists the WebSocket endpoints of selected nodes.
+type WebSocketProvider interface {
+	// GetWebSocketUrls lists the WebSocket endpoints of all active nodes of
+	// the given names, or of all active nodes if no names are given.
+	GetWebSocketUrls(names []string) ([]string, error)
+}
+
+// NewSubscriptionApplication creates an application opening eth_subscribe
+// subscriptions. Each user maintains its own WebSocket connection to one of
+// the targeted nodes, subscribing to all configured kinds of events while the
+// application is running. Users do not send transactions, thus the traffic
+// shape of the application is ignored.
+//
+// For each kind of events, the latency of notifications relative to the time
+// of the block they refer to is measured, and events notified more than once
+// are counted. Dropped events are detected for newHeads subscriptions, which
+// are expected to report every block.
+//
+// The number of sent transactions reported for users is the number of opened
+// subscriptions, the number of received transactions is the number of
+// received notifications.
+func NewSubscriptionApplication(ctxt AppContext, options SubscriptionOptions, feederId, appId uint32) (Application, error) {
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+	kinds := []string{}
+	for _, kind := range options.Kinds {
+		kinds = append(kinds, normalizeSubscriptionKind(kind))
+	}
+	if len(kinds) == 0 {
+		kinds = []string{"newHeads"}
+	}
+
+	return &SubscriptionApplication{
+		kinds:      kinds,
+		logs:       options.Logs,
+		nodes:      options.Nodes,
+		stats:      make([]subscriptionKindStats, len(kinds)),
+		client:     ctxt.GetClient(),
+		blockTimes: map[uint64]time.Time{},
+	}, nil
+}
+
+// SubscriptionApplication represents a set of users subscribing to events.
+type SubscriptionApplication struct {
+	kinds []string
+	logs  LogFilter
+	nodes []string
+	stats []subscriptionKindStats
+
+	client          rpc.Client           // < used to fetch unknown block times
+	blockTimes      map[uint64]time.Time // < times of recent blocks
+	blockTimesMutex sync.Mutex
+}
+
+type subscriptionKindStats struct {
+	notifications atomic.Uint64
+	dropped       atomic.Uint64
+	duplicated    atomic.Uint64
+	measured      atomic.Uint64
+	latency       atomic.Int64
+}
+
+// CreateUsers creates a list of new users for the app. Users are distributed
+// evenly among the targeted nodes.
+func (f *SubscriptionApplication) CreateUsers(appContext AppContext, numUsers int) ([]User, error) {
+	provider, ok := appContext.(WebSocketProvider)
+	if !ok {
+		return nil, fmt.Errorf("application context does not provide WebSocket endpoints")
+	}
+	urls, err := provider.GetWebSocketUrls(f.nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WebSocket endpoints of nodes %v; %w", f.nodes, err)
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no active nodes with WebSocket endpoints found matching %v", f.nodes)
+	}
+
+	users := make([]User, numUsers)
+	for i := range users {
+		users[i] = &SubscriptionUser{
+			app: f,
+			url: urls[i%len(urls)],
+		}
+	}
+	return users, nil
+}
+
+func (f *SubscriptionApplication) GetReceivedTransactions(rpcClient rpc.Client) (uint64, error) {
+	total := uint64(0)
+	for _, kind := range f.kinds {
+		count, err := f.GetReceivedTransactionsOf(kind, rpcClient)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (f *SubscriptionApplication) GetOperations() []string {
+	return f.kinds
+}
+
+func (f *SubscriptionApplication) GetReceivedTransactionsOf(kind string, _ rpc.Client) (uint64, error) {
+	stats, err := f.GetSubscriptionStats(kind)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Notifications, nil
+}
+
+func (f *SubscriptionApplication) GetSubscriptionStats(kind string) (SubscriptionStats, error) {
+	i := slices.Index(f.kinds, kind)
+	if i < 0 {
+		return SubscriptionStats{}, fmt.Errorf("unknown subscription kind %s", kind)
+	}
+	stats := &f.stats[i]
+	// The latency is loaded first, since it is accumulated after counting.
+	latency := stats.latency.Load()
+	return SubscriptionStats{
+		Notifications: stats.notifications.Load(),
+		Dropped:       stats.dropped.Load(),
+		Duplicated:    stats.duplicated.Load(),
+		Measured:      stats.measured.Load(),
+		Latency:       time.Duration(latency),
+	}, nil
+}
+
+// getSubscriptionArgs provides the arguments of eth_subscribe for the given kind.
+func (f *SubscriptionApplication) getSubscriptionArgs(kind string) []any {
+	if kind != "logs" {
+		return []any{kind}
+	}
+	filter := map[string]any{}
+	if len(f.logs.Addresses) > 0 {
+		filter["address"] = f.logs.Addresses
+	}
+	if len(f.logs.Topics) > 0 {
+		filter["topics"] = f.logs.Topics
+	}
+	return []any{kind, filter}
+}
+
+// setBlockTime records the time of a block notified by the network.
+func (f *SubscriptionApplication) setBlockTime(number uint64, blockTime time.Time) {
+	f.blockTimesMutex.Lock()
+	defer f.blockTimesMutex.Unlock()
+	f.blockTimes[number] = blockTime
+	if number > maxTrackedBlockTimes {
+		delete(f.blockTimes, number-maxTrackedBlockTimes)
+	}
+}
+
+// getBlockTime provides the time of the given block, fetching it from the
+// network if it has not been notified by a newHeads subscription.
+func (f *SubscriptionApplication) getBlockTime(number uint64) (time.Time, error) {
+	f.blockTimesMutex.Lock()
+	blockTime, found := f.blockTimes[number]
+	f.blockTimesMutex.Unlock()
+	if found {
+		return blockTime, nil
+	}
+
+	var header *blockHeader
+	if err := f.client.Call(&header, "eth_getBlockByNumber", hexutil.Uint64(number), false); err != nil {
+		return time.Time{}, err
+	}
+	if header == nil {
+		return time.Time{}, fmt.Errorf("block %d not found", number)
+	}
+	blockTime = header.getTime()
+	f.setBlockTime(number, blockTime)
+	return blockTime, nil
+}
+
+// blockHeader is the subset of block header fields used by subscriptions.
+type blockHeader struct {
+	Number        hexutil.Uint64  `json:"number"`
+	Hash          common.Hash     `json:"hash"`
+	Timestamp     hexutil.Uint64  `json:"timestamp"`
+	TimestampNano *hexutil.Uint64 `json:"timestampNano"`
+}
+
+// getTime provides the time of the block, which Sonic provides with a
+// nanosecond resolution.
+func (h *blockHeader) getTime() time.Time {
+	if h.TimestampNano != nil {
+		return time.Unix(0, int64(*h.TimestampNano))
+	}
+	return time.Unix(int64(h.Timestamp), 0)
+}
+
+// SubscriptionUser represents a single WebSocket connection subscribed to the
+// events of the application.
+type SubscriptionUser struct {
+	app    *SubscriptionApplication
+	url    string
+	client *gethrpc.Client
+	subs   []*gethrpc.ClientSubscription
+	done   sync.WaitGroup
+	opened atomic.Uint64
+}
+
+func (g *SubscriptionUser) Subscribe() error {
+	client, err := gethrpc.DialContext(context.Background(), g.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s; %w", g.url, err)
+	}
+	g.client = client
+
+	errs := []error{}
+	for i, kind := range g.app.kinds {
+		channel := make(chan json.RawMessage, subscriptionBufferSize)
+		sub, err := client.EthSubscribe(context.Background(), channel, g.app.getSubscriptionArgs(kind)...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to subscribe to %s; %w", kind, err))
+			continue
+		}
+		g.opened.Add(1)
+		g.subs = append(g.subs, sub)
+		g.done.Add(1)
+		go func() {
+			defer g.done.Done()
+			g.consume(kind, &g.app.stats[i], channel, sub)
+		}()
+	}
+	return errors.Join(errs...)
+}
+
+func (g *SubscriptionUser) Unsubscribe() {
+	for _, sub := range g.subs {
+		sub.Unsubscribe()
+	}
+	g.done.Wait()
+	g.subs = nil
+	if g.client != nil {
+		g.client.Close()
+		g.client = nil
+	}
+}
+
+// consume processes the notifications of a single subscription until it ends.
+func (g *SubscriptionUser) consume(
+	kind string,
+	stats *subscriptionKindStats,
+	channel <-chan json.RawMessage,
+	sub *gethrpc.ClientSubscription,
+) {
+	tracker := newEventTracker(kind, g.app)
+	for {
+		select {
+		case msg := <-channel:
+			now := time.Now()
+			event, err := tracker.parse(msg)
+			if err != nil {
+				log.Printf("failed to parse %s notification; %v", kind, err)
+				continue
+			}
+			stats.notifications.Add(1)
+			stats.dropped.Add(event.dropped)
+			if event.duplicated {
+				stats.duplicated.Add(1)
+			}
+			if !event.blockTime.IsZero() {
+				stats.measured.Add(1)
+				stats.latency.Add(int64(now.Sub(event.blockTime)))
+			}
+		case err := <-sub.Err():
+			if err != nil {
+				log.Printf("%s subscription ended with error; %v", kind, err)
+			}
+			return
+		}
+	}
+}
+
+func (g *SubscriptionUser) GenerateTx() (*types.Transaction, error) {
+	return nil, fmt.Errorf("users of subscription applications do not send transactions")
+}
+
+func (g *SubscriptionUser) GetSentTransactions() uint64 {
+	return g.opened.Load()
+}
+
+// subscriptionEvent summarizes a single notification.
+type subscriptionEvent struct {
+	blockTime  time.Time // < zero if the event does not refer to a block
+	duplicated bool      // < true if the event has been notified before
+	dropped    uint64    // < the number of events missed before this event
+}
+
+// eventTracker interprets the notifications of a single subscription,
+// detecting duplicated and missed events.
+type eventTracker struct {
+	kind       string
+	app        *SubscriptionApplication
+	lastHead   uint64
+	seenLogs   recentSet[logId]
+	seenHashes recentSet[common.Hash]
+}
+
+type logId struct {
+	block common.Hash
+	index hexutil.Uint
+}
+
+func newEventTracker(kind string, app *SubscriptionApplication) *eventTracker {
+	return &eventTracker{
+		kind:       kind,
+		app:        app,
+		seenLogs:   newRecentSet[logId](maxTrackedEvents),
+		seenHashes: newRecentSet[common.Hash](maxTrackedEvents),
+	}
+}
+
+func (t *eventTracker) parse(msg json.RawMessage) (subscriptionEvent, error) {
+	res := subscriptionEvent{}
+	switch t.kind {
+	case "newHeads":
+		var header blockHeader
+		if err := json.Unmarshal(msg, &header); err != nil {
+			return res, err
+		}
+		number := uint64(header.Number)
+		res.blockTime = header.getTime()
+		t.app.setBlockTime(number, res.blockTime)
+		if t.lastHead != 0 && number <= t.lastHead {
+			res.duplicated = true
+		} else {
+			if t.lastHead != 0 {
+				res.dropped = number - t.lastHead - 1
+			}
+			t.lastHead = number
+		}
+	case "logs":
+		var entry struct {
+			BlockNumber hexutil.Uint64 `json:"blockNumber"`
+			BlockHash   common.Hash    `json:"blockHash"`
+			Index       hexutil.Uint   `json:"logIndex"`
+		}
+		if err := json.Unmarshal(msg, &entry); err != nil {
+			return res, err
+		}
+		res.duplicated = !t.seenLogs.add(logId{entry.BlockHash, entry.Index})
+		blockTime, err := t.app.getBlockTime(uint64(entry.BlockNumber))
+		if err != nil {
+			log.Printf("failed to get time of block %d; %v", entry.BlockNumber, err)
+		}
+		res.blockTime = blockTime
+	case "newPendingTransactions":
+		var hash common.Hash
+		if err := json.Unmarshal(msg, &hash); err != nil {
+			return res, err
+		}
+		res.duplicated = !t.seenHashes.add(hash)
+	}
+	return res, nil
+}
+
+// recentSet is a set retaining at least the given number of most recently
+// added elements, forgetting older ones to limit memory usage.
+type recentSet[K comparable] struct {
+	limit    int
+	current  map[K]struct{}
+	previous map[K]struct{}
+}
+
+func newRecentSet[K comparable](limit int) recentSet[K] {
+	return recentSet[K]{
+		limit:   limit,
+		current: map[K]struct{}{},
+	}
+}
+
+// add inserts the given key, returning false if it was already present.
+func (s *recentSet[K]) add(key K) bool {
+	if _, found := s.current[key]; found {
+		return false
+	}
+	if _, found := s.previous[key]; found {
+		return false
+	}
+	if len(s.current) >= s.limit {
+		s.previous = s.current
+		s.current = map[K]struct{}{}
+	}
+	s.current[key] = struct{}{}
+	return true
+}
+
+// normalizeSubscriptionKind maps user-provided kinds to the names used by
+// eth_subscribe, which are case sensitive.
+func normalizeSubscriptionKind(kind string) string {
+	for _, cur := range SubscriptionKinds {
+		if strings.EqualFold(cur, kind) {
+			return cur
+		}
+	}
+	return kind
+}
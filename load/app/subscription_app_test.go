@@ -0,0 +1,238 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewSubscriptionApplication_RejectsInvalidKinds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	context := NewMockAppContext(ctrl)
+	context.EXPECT().GetClient().AnyTimes()
+
+	_, err := NewSubscriptionApplication(context, SubscriptionOptions{Kinds: []string{"syncing"}}, 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "unsupported subscription kind") {
+		t.Errorf("unsupported kind was not detected, got %v", err)
+	}
+	_, err = NewSubscriptionApplication(context, SubscriptionOptions{Kinds: []string{"logs", "LOGS"}}, 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "listed multiple times") {
+		t.Errorf("duplicated kind was not detected, got %v", err)
+	}
+
+	application, err := NewSubscriptionApplication(context, SubscriptionOptions{}, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create application: %v", err)
+	}
+	if got := application.(OperationMix).GetOperations(); len(got) != 1 || got[0] != "newHeads" {
+		t.Errorf("unexpected default subscriptions, got %v", got)
+	}
+}
+
+func TestEventTracker_DetectsDroppedAndDuplicatedHeads(t *testing.T) {
+	application := newTestSubscriptionApplication(nil, "newHeads")
+	tracker := newEventTracker("newHeads", application)
+
+	tests := []struct {
+		number     uint64
+		dropped    uint64
+		duplicated bool
+	}{
+		{10, 0, false},
+		{11, 0, false},
+		{11, 0, true},
+		{14, 2, false},
+		{12, 0, true},
+		{15, 0, false},
+	}
+	for _, test := range tests {
+		msg := fmt.Sprintf(`{"number":"%v","timestamp":"0x1","timestampNano":"%v"}`,
+			hexutil.Uint64(test.number), hexutil.Uint64(test.number*1000))
+		event, err := tracker.parse(json.RawMessage(msg))
+		if err != nil {
+			t.Fatalf("failed to parse notification: %v", err)
+		}
+		if event.dropped != test.dropped || event.duplicated != test.duplicated {
+			t.Errorf("unexpected event for block %d, wanted dropped=%d/duplicated=%t, got %d/%t",
+				test.number, test.dropped, test.duplicated, event.dropped, event.duplicated)
+		}
+		if want := time.Unix(0, int64(test.number*1000)); !event.blockTime.Equal(want) {
+			t.Errorf("unexpected block time, wanted %v, got %v", want, event.blockTime)
+		}
+	}
+}
+
+func TestEventTracker_DetectsDuplicatedLogsAndTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := rpc.NewMockClient(ctrl)
+	application := newTestSubscriptionApplication(client, "logs", "newPendingTransactions")
+
+	// The time of the block of the logs is fetched once.
+	client.EXPECT().Call(gomock.Any(), "eth_getBlockByNumber", hexutil.Uint64(5), false).DoAndReturn(
+		func(result any, _ string, _ ...any) error {
+			return json.Unmarshal([]byte(`{"number":"0x5","timestamp":"0x2"}`), result)
+		})
+
+	logs := newEventTracker("logs", application)
+	for i, test := range []struct {
+		index      int
+		duplicated bool
+	}{{0, false}, {1, false}, {0, true}} {
+		msg := fmt.Sprintf(`{"blockNumber":"0x5","blockHash":"%v","logIndex":"%v"}`, common.Hash{5}, hexutil.Uint(test.index))
+		event, err := logs.parse(json.RawMessage(msg))
+		if err != nil {
+			t.Fatalf("failed to parse notification: %v", err)
+		}
+		if event.duplicated != test.duplicated {
+			t.Errorf("unexpected duplication status of log %d, wanted %t, got %t", i, test.duplicated, event.duplicated)
+		}
+		if want := time.Unix(2, 0); !event.blockTime.Equal(want) {
+			t.Errorf("unexpected block time, wanted %v, got %v", want, event.blockTime)
+		}
+	}
+
+	txs := newEventTracker("newPendingTransactions", application)
+	for i, test := range []struct {
+		hash       common.Hash
+		duplicated bool
+	}{{common.Hash{1}, false}, {common.Hash{2}, false}, {common.Hash{1}, true}} {
+		event, err := txs.parse(json.RawMessage(fmt.Sprintf(`"%v"`, test.hash)))
+		if err != nil {
+			t.Fatalf("failed to parse notification: %v", err)
+		}
+		if event.duplicated != test.duplicated || !event.blockTime.IsZero() {
+			t.Errorf("unexpected event %d: %+v", i, event)
+		}
+	}
+}
+
+func TestRecentSet_RetainsRecentElements(t *testing.T) {
+	set := newRecentSet[int](2)
+	for i := range 4 {
+		if !set.add(i) {
+			t.Errorf("element %d should be new", i)
+		}
+	}
+	// The two most recent elements are retained.
+	if set.add(2) || set.add(3) {
+		t.Errorf("recent elements should be retained")
+	}
+}
+
+// headsService is an RPC service notifying a fixed sequence of heads.
+type headsService struct {
+	heads []uint64
+}
+
+func (s *headsService) NewHeads(ctx context.Context) (*gethrpc.Subscription, error) {
+	notifier, supported := gethrpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, gethrpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for _, number := range s.heads {
+			notifier.Notify(sub.ID, map[string]any{
+				"number":        hexutil.Uint64(number),
+				"timestamp":     hexutil.Uint64(time.Now().Unix()),
+				"timestampNano": hexutil.Uint64(time.Now().UnixNano()),
+			})
+		}
+	}()
+	return sub, nil
+}
+
+// webSocketContext is an application context providing WebSocket endpoints.
+type webSocketContext struct {
+	*MockAppContext
+	urls []string
+}
+
+func (c *webSocketContext) GetWebSocketUrls([]string) ([]string, error) {
+	return c.urls, nil
+}
+
+func TestSubscriptionUser_ReceivesNotifications(t *testing.T) {
+	server := gethrpc.NewServer()
+	if err := server.RegisterName("eth", &headsService{heads: []uint64{1, 2, 2, 5}}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	httpServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer httpServer.Close()
+
+	ctrl := gomock.NewController(t)
+	context := &webSocketContext{
+		MockAppContext: NewMockAppContext(ctrl),
+		urls:           []string{"ws" + strings.TrimPrefix(httpServer.URL, "http")},
+	}
+	application := newTestSubscriptionApplication(nil, "newHeads")
+	users, err := application.CreateUsers(context, 1)
+	if err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	user := users[0].(Subscriber)
+	if err := user.Subscribe(); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if count, _ := application.GetReceivedTransactions(nil); count == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("notifications were not received in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	user.Unsubscribe()
+
+	stats, err := application.GetSubscriptionStats("newHeads")
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	if stats.Notifications != 4 || stats.Dropped != 2 || stats.Duplicated != 1 || stats.Measured != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if got := users[0].GetSentTransactions(); got != 1 {
+		t.Errorf("unexpected number of opened subscriptions, wanted 1, got %d", got)
+	}
+}
+
+// newTestSubscriptionApplication creates a subscription application for the
+// given kinds using the given client to fetch block times.
+func newTestSubscriptionApplication(client rpc.Client, kinds ...string) *SubscriptionApplication {
+	return &SubscriptionApplication{
+		kinds:      kinds,
+		stats:      make([]subscriptionKindStats, len(kinds)),
+		client:     client,
+		blockTimes: map[uint64]time.Time{},
+	}
+}
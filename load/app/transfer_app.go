@@ -0,0 +1,125 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NewTransferApplication creates an application sending native tokens.
+// Each transaction transfers 1 wei to one of a set of random recipients.
+// The sum of the balances of the recipients provides the number of applied
+// transfers, which makes a contract for tracking transactions unnecessary.
+func NewTransferApplication(ctxt AppContext, feederId, appId uint32) (Application, error) {
+	client := ctxt.GetClient()
+	chainId, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID; %w", err)
+	}
+
+	recipients, err := generateRecipientsAddresses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recipients addresses; %w", err)
+	}
+
+	accountFactory, err := newAccountFactory(ctxt, chainId, feederId, appId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferApplication{
+		recipients:     recipients,
+		accountFactory: accountFactory,
+	}, nil
+}
+
+// TransferApplication represents a set of users transferring native tokens
+// to a fixed set of recipients.
+type TransferApplication struct {
+	recipients     []common.Address
+	accountFactory *AccountFactory
+}
+
+// CreateUsers creates a list of new users for the app.
+func (f *TransferApplication) CreateUsers(appContext AppContext, numUsers int) ([]User, error) {
+	users := make([]User, numUsers)
+	addresses := make([]common.Address, numUsers)
+	for i := 0; i < numUsers; i++ {
+		// Generate a new account for each worker - avoid account nonces related bottlenecks
+		workerAccount, err := f.accountFactory.CreateAccount(appContext.GetClient())
+		if err != nil {
+			return nil, err
+		}
+		users[i] = &TransferUser{
+			sender:     workerAccount,
+			recipients: f.recipients,
+		}
+		addresses[i] = workerAccount.address
+	}
+
+	fundsPerUser := big.NewInt(1_000)
+	fundsPerUser = new(big.Int).Mul(fundsPerUser, big.NewInt(1_000_000_000_000_000_000)) // to wei
+	err := appContext.FundAccounts(addresses, fundsPerUser)
+	return users, err
+}
+
+func (f *TransferApplication) GetReceivedTransactions(rpcClient rpc.Client) (uint64, error) {
+	totalReceived := uint64(0)
+	for _, recipient := range f.recipients {
+		balance, err := rpcClient.BalanceAt(context.Background(), recipient, nil)
+		if err != nil {
+			return 0, err
+		}
+		totalReceived += balance.Uint64()
+	}
+	return totalReceived, nil
+}
+
+// TransferUser represents a user sending native tokens to random recipients.
+// A generator is supposed to be used in a single thread.
+type TransferUser struct {
+	sender     *Account
+	recipients []common.Address
+	sentTxs    atomic.Uint64
+}
+
+func (g *TransferUser) GenerateTx() (*types.Transaction, error) {
+	recipient := g.recipients[rand.Intn(len(g.recipients))]
+
+	const gasLimit = 21000
+	tx, err := createTx(g.sender, recipient, big.NewInt(1), nil, gasLimit)
+	if err == nil {
+		g.sentTxs.Add(1)
+	}
+	return tx, err
+}
+
+func (g *TransferUser) GetSentTransactions() uint64 {
+	return g.sentTxs.Load()
+}
+
+func (g *TransferUser) GetAccounts() []*Account {
+	return []*Account{g.sender}
+}
@@ -0,0 +1,385 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// gasPriceBaseFeeMultiplier is the factor applied to the base fee when pricing
+// transactions without tip.
+const gasPriceBaseFeeMultiplier = 2
+
+// TxTypes lists the names of the transaction types applications can emit.
+var TxTypes = []string{
+	"legacy",
+	"access_list",
+	"dynamic_fee",
+	"set_code",
+}
+
+// txTypeIds maps the names of transaction types to their type identifiers.
+var txTypeIds = map[string]uint8{
+	"legacy":      types.LegacyTxType,
+	"access_list": types.AccessListTxType,
+	"dynamic_fee": types.DynamicFeeTxType,
+	"set_code":    types.SetCodeTxType,
+}
+
+// IsSupportedTxType checks whether the given transaction type name is known.
+func IsSupportedTxType(txType string) bool {
+	_, found := txTypeIds[strings.ToLower(txType)]
+	return found
+}
+
+// TxTypeWeight is a transaction type emitted by an application and its
+// relative weight among the transactions of the application.
+type TxTypeWeight struct {
+	Type   string
+	Weight float64
+}
+
+// TxTypeMix selects the types of newly created transactions randomly,
+// proportional to configured weights. Transactions are derived from the
+// dynamic-fee transactions created by applications as follows:
+//   - legacy: the gas price is twice the base fee of the latest block plus the
+//     tip, limited by the fee cap of the dynamic-fee transaction; the headroom
+//     covers rising base fees like GetGasPrice does
+//   - access_list: the access list is obtained using eth_createAccessList once
+//     per contract and method, the gas price is chosen like for legacy ones
+//   - dynamic_fee: the transaction is used as is
+//   - set_code: the transaction carries an authorization of a fresh account
+//     delegating to the zero address, contract creations are sent as
+//     dynamic-fee transactions instead
+//
+// The mix is safe to be used concurrently.
+type TxTypeMix struct {
+	types   []uint8
+	weights []float64
+	factory RpcClientFactory
+
+	mutex       sync.Mutex
+	client      rpc.Client                         // < used for creating access lists and getting base fees
+	accessLists map[accessListKey]types.AccessList // < cached per contract and method
+	baseFee     *big.Int                           // < base fee of the latest block, nil if unknown
+	baseFeeAt   time.Time                          // < time of the last query of the base fee
+}
+
+type accessListKey struct {
+	to       common.Address
+	selector [4]byte
+}
+
+// NewTxTypeMix creates a mix of the given transaction types. Set-code
+// transactions are only included if the network enabled the Allegro upgrade,
+// which is checked using a connection obtained from the given factory. The
+// same factory is used for creating access lists. The mix should be closed
+// once no longer needed.
+func NewTxTypeMix(weights []TxTypeWeight, factory RpcClientFactory) (*TxTypeMix, error) {
+	res := &TxTypeMix{
+		factory:     factory,
+		accessLists: map[accessListKey]types.AccessList{},
+	}
+	for _, weight := range weights {
+		name := strings.ToLower(weight.Type)
+		id, found := txTypeIds[name]
+		if !found {
+			return nil, fmt.Errorf("unknown transaction type '%s'", weight.Type)
+		}
+		if slices.Contains(res.types, id) {
+			return nil, fmt.Errorf("transaction type %s listed multiple times", name)
+		}
+		if weight.Weight <= 0 {
+			return nil, fmt.Errorf("weight of transaction type %s must be positive, got %f", name, weight.Weight)
+		}
+		if id == types.SetCodeTxType {
+			supported, err := isSetCodeSupported(factory)
+			if err != nil {
+				return nil, err
+			}
+			if !supported {
+				log.Printf("set-code transactions are not supported by the network, skipping them")
+				continue
+			}
+		}
+		res.types = append(res.types, id)
+		res.weights = append(res.weights, weight.Weight)
+	}
+	if len(res.types) == 0 {
+		return nil, fmt.Errorf("no supported transaction types in mix")
+	}
+	return res, nil
+}
+
+// isSetCodeSupported checks whether the network accepts EIP-7702 set-code
+// transactions, which are enabled by the Allegro upgrade of Sonic. Networks
+// not reporting their rules are assumed not to support them.
+func isSetCodeSupported(factory RpcClientFactory) (bool, error) {
+	client, err := factory.DialRandomRpc()
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to network; %w", err)
+	}
+	defer client.Close()
+	var rules struct {
+		Upgrades struct {
+			Allegro bool
+		}
+	}
+	if err := client.Call(&rules, "eth_getRules", "latest"); err != nil {
+		log.Printf("failed to get network rules; %v", err)
+		return false, nil
+	}
+	return rules.Upgrades.Allegro, nil
+}
+
+// pickType selects a random transaction type according to the weights.
+func (m *TxTypeMix) pickType() uint8 {
+	total := 0.0
+	for _, weight := range m.weights {
+		total += weight
+	}
+	r := rand.Float64() * total
+	for i, weight := range m.weights {
+		if r < weight {
+			return m.types[i]
+		}
+		r -= weight
+	}
+	return m.types[len(m.types)-1]
+}
+
+// newTx creates a transaction of a randomly selected type from the given
+// dynamic-fee transaction sent by the given account. The nonce of the given
+// transaction is ignored; the next nonce of the account is assigned once all
+// fallible steps succeeded. A nil mix creates dynamic-fee transactions only.
+func (m *TxTypeMix) newTx(from *Account, tx *types.DynamicFeeTx) (*types.Transaction, error) {
+	if m == nil {
+		tx.Nonce = from.getNextNonce()
+		return types.NewTx(tx), nil
+	}
+	txType := m.pickType()
+	if txType == types.SetCodeTxType && tx.To == nil {
+		txType = types.DynamicFeeTxType
+	}
+	var authList []types.SetCodeAuthorization
+	var gasPrice *big.Int
+	switch txType {
+	case types.LegacyTxType:
+		gasPrice = m.getGasPrice(tx)
+	case types.AccessListTxType:
+		gasPrice = m.getGasPrice(tx)
+		tx.AccessList = m.getAccessList(from.address, tx)
+		for _, tuple := range tx.AccessList {
+			tx.Gas += params.TxAccessListAddressGas + uint64(len(tuple.StorageKeys))*params.TxAccessListStorageKeyGas
+		}
+	case types.SetCodeTxType:
+		auth, err := newResetAuthorization(from.chainID)
+		if err != nil {
+			return nil, err
+		}
+		authList = []types.SetCodeAuthorization{auth}
+		tx.Gas += params.CallNewAccountGas
+	}
+	tx.Nonce = from.getNextNonce()
+	return types.NewTx(newTxData(txType, from.chainID, tx, gasPrice, authList)), nil
+}
+
+// getGasPrice returns the gas price of a transaction without tip derived from
+// the given dynamic-fee transaction, which is the base fee of the latest block
+// times gasPriceBaseFeeMultiplier plus the tip, limited by the fee cap. The
+// headroom keeps transactions includable while the base fee is rising, since
+// the cached base fee may be outdated and unlike dynamic-fee transactions, the
+// gas price is not adjusted to the base fee of the including block. The base
+// fee is refreshed at most every baseFeeRefreshInterval. If it is unknown, the
+// fee cap is used.
+func (m *TxTypeMix) getGasPrice(tx *types.DynamicFeeTx) *big.Int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.baseFee == nil || time.Since(m.baseFeeAt) >= baseFeeRefreshInterval {
+		if err := m.refreshBaseFee(); err != nil {
+			log.Printf("failed to refresh base fee; %v", err)
+		}
+		m.baseFeeAt = time.Now()
+	}
+	if m.baseFee == nil {
+		return tx.GasFeeCap
+	}
+	price := new(big.Int).Mul(m.baseFee, big.NewInt(gasPriceBaseFeeMultiplier))
+	price.Add(price, tx.GasTipCap)
+	if price.Cmp(tx.GasFeeCap) > 0 {
+		return tx.GasFeeCap
+	}
+	return price
+}
+
+func (m *TxTypeMix) refreshBaseFee() error {
+	if m.client == nil {
+		client, err := m.factory.DialRandomRpc()
+		if err != nil {
+			return err
+		}
+		m.client = client
+	}
+	header, err := m.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		m.client.Close()
+		m.client = nil
+		return err
+	}
+	m.baseFee = header.BaseFee
+	return nil
+}
+
+// newTxData converts a dynamic-fee transaction into the given type. The given
+// gas price is used by types without tips.
+func newTxData(txType uint8, chainID *big.Int, tx *types.DynamicFeeTx, gasPrice *big.Int, authList []types.SetCodeAuthorization) types.TxData {
+	switch txType {
+	case types.LegacyTxType:
+		return &types.LegacyTx{
+			Nonce:    tx.Nonce,
+			GasPrice: gasPrice,
+			Gas:      tx.Gas,
+			To:       tx.To,
+			Value:    tx.Value,
+			Data:     tx.Data,
+		}
+	case types.AccessListTxType:
+		return &types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      tx.Nonce,
+			GasPrice:   gasPrice,
+			Gas:        tx.Gas,
+			To:         tx.To,
+			Value:      tx.Value,
+			Data:       tx.Data,
+			AccessList: tx.AccessList,
+		}
+	case types.SetCodeTxType:
+		return &types.SetCodeTx{
+			ChainID:    uint256.MustFromBig(chainID),
+			Nonce:      tx.Nonce,
+			GasTipCap:  uint256.MustFromBig(tx.GasTipCap),
+			GasFeeCap:  uint256.MustFromBig(tx.GasFeeCap),
+			Gas:        tx.Gas,
+			To:         *tx.To,
+			Value:      uint256.MustFromBig(tx.Value),
+			Data:       tx.Data,
+			AccessList: tx.AccessList,
+			AuthList:   authList,
+		}
+	}
+	return tx
+}
+
+// newResetAuthorization creates an EIP-7702 authorization of a fresh account
+// delegating to the zero address, which clears the code of the account. The
+// authorization is valid independent of the state of the sender and does not
+// alter the code of any account used by applications.
+func newResetAuthorization(chainID *big.Int) (types.SetCodeAuthorization, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return types.SetCodeAuthorization{}, err
+	}
+	return types.SignSetCode(key, types.SetCodeAuthorization{
+		ChainID: *uint256.MustFromBig(chainID),
+		Nonce:   0,
+	})
+}
+
+// getAccessList provides the access list of the given transaction. Lists are
+// created by the network once per contract and method and reused for later
+// transactions. Value transfers and contract creations use empty lists.
+func (m *TxTypeMix) getAccessList(from common.Address, tx *types.DynamicFeeTx) types.AccessList {
+	if tx.To == nil || len(tx.Data) < 4 {
+		return types.AccessList{}
+	}
+	key := accessListKey{to: *tx.To, selector: [4]byte(tx.Data[:4])}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if list, found := m.accessLists[key]; found {
+		return list
+	}
+	list, err := m.createAccessList(from, tx)
+	if err != nil {
+		// failures are cached as well to avoid repeated requests
+		log.Printf("failed to create access list; %v", err)
+		list = types.AccessList{}
+	}
+	m.accessLists[key] = list
+	return list
+}
+
+func (m *TxTypeMix) createAccessList(from common.Address, tx *types.DynamicFeeTx) (types.AccessList, error) {
+	if m.client == nil {
+		client, err := m.factory.DialRandomRpc()
+		if err != nil {
+			return nil, err
+		}
+		m.client = client
+	}
+	var result struct {
+		AccessList types.AccessList `json:"accessList"`
+	}
+	err := m.client.Call(&result, "eth_createAccessList", map[string]any{
+		"from":  from,
+		"to":    tx.To,
+		"gas":   hexutil.Uint64(tx.Gas),
+		"value": (*hexutil.Big)(tx.Value),
+		"data":  hexutil.Bytes(tx.Data),
+	}, "latest")
+	if err != nil {
+		m.client.Close()
+		m.client = nil
+		return nil, err
+	}
+	if result.AccessList == nil {
+		return types.AccessList{}, nil
+	}
+	return result.AccessList, nil
+}
+
+func (m *TxTypeMix) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.client != nil {
+		m.client.Close()
+		m.client = nil
+	}
+	return nil
+}
+
+// SetTxTypes defines the mix of types of transactions created for this
+// account. Without a mix, dynamic-fee transactions are created.
+func (a *Account) SetTxTypes(mix *TxTypeMix) {
+	a.txTypes.Store(mix)
+}
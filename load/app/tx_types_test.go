@@ -0,0 +1,225 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTxTypeMix_InvalidMixesAreRejected(t *testing.T) {
+	tests := map[string][]TxTypeWeight{
+		"empty":        {},
+		"unknown type": {{Type: "blob", Weight: 1}},
+		"duplicate":    {{Type: "legacy", Weight: 1}, {Type: "Legacy", Weight: 1}},
+		"zero weight":  {{Type: "legacy", Weight: 0}},
+	}
+	for name, weights := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewTxTypeMix(weights, nil); err == nil {
+				t.Errorf("invalid mix was not rejected")
+			}
+		})
+	}
+	if IsSupportedTxType("blob") || !IsSupportedTxType("Set_Code") {
+		t.Errorf("unexpected support of transaction types")
+	}
+}
+
+func TestTxTypeMix_SetCodeTransactionsRequireAllegro(t *testing.T) {
+	for _, allegro := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+		factory := NewMockClientFactory(ctrl)
+		client := rpc.NewMockClient(ctrl)
+		factory.EXPECT().DialRandomRpc().Return(client, nil)
+		client.EXPECT().Call(gomock.Any(), "eth_getRules", "latest").DoAndReturn(func(result any, _ string, _ ...any) error {
+			rules := map[string]any{"Upgrades": map[string]bool{"Allegro": allegro}}
+			encoded, _ := json.Marshal(rules)
+			return json.Unmarshal(encoded, result)
+		})
+		client.EXPECT().Close()
+
+		mix, err := NewTxTypeMix([]TxTypeWeight{{Type: "legacy", Weight: 1}, {Type: "set_code", Weight: 1}}, factory)
+		if err != nil {
+			t.Fatalf("failed to create mix: %v", err)
+		}
+		if got := slices.Contains(mix.types, types.SetCodeTxType); got != allegro {
+			t.Errorf("unexpected inclusion of set-code transactions with Allegro=%t, got %t", allegro, got)
+		}
+	}
+}
+
+func TestTxTypeMix_TransactionsOfAllTypesAreCreated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := NewMockClientFactory(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	factory.EXPECT().DialRandomRpc().Return(client, nil).AnyTimes()
+	client.EXPECT().Call(gomock.Any(), "eth_getRules", "latest").DoAndReturn(func(result any, _ string, _ ...any) error {
+		return json.Unmarshal([]byte(`{"Upgrades":{"Allegro":true}}`), result)
+	})
+	slot := common.Hash{2}
+	client.EXPECT().Call(gomock.Any(), "eth_createAccessList", gomock.Any(), "latest").DoAndReturn(func(result any, _ string, _ ...any) error {
+		list := map[string]any{"accessList": types.AccessList{{Address: common.Address{3}, StorageKeys: []common.Hash{slot}}}}
+		encoded, _ := json.Marshal(list)
+		return json.Unmarshal(encoded, result)
+	}) // < only called once, lists are cached
+	baseFee := big.NewInt(1e9)
+	client.EXPECT().HeaderByNumber(gomock.Any(), nil).Return(&types.Header{BaseFee: baseFee}, nil).MinTimes(1)
+	client.EXPECT().Close().Times(2)
+
+	mix, err := NewTxTypeMix([]TxTypeWeight{
+		{Type: "legacy", Weight: 1},
+		{Type: "access_list", Weight: 1},
+		{Type: "dynamic_fee", Weight: 1},
+		{Type: "set_code", Weight: 1},
+	}, factory)
+	if err != nil {
+		t.Fatalf("failed to create mix: %v", err)
+	}
+	account, err := NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.SetTxTypes(mix)
+
+	seen := map[uint8]bool{}
+	for i := 0; i < 100; i++ {
+		tx, err := createTx(account, common.Address{1}, big.NewInt(0), []byte{1, 2, 3, 4}, 50_000)
+		if err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+		seen[tx.Type()] = true
+		if sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err != nil || sender != account.address {
+			t.Errorf("invalid signature of transaction of type %d: %v", tx.Type(), err)
+		}
+		gasPrice := new(big.Int).Mul(baseFee, big.NewInt(2))
+		switch tx.Type() {
+		case types.LegacyTxType:
+			// without tip, the default fees pay twice the base fee
+			if tx.GasPrice().Cmp(gasPrice) != 0 {
+				t.Errorf("unexpected gas price of legacy transaction, wanted %v, got %v", gasPrice, tx.GasPrice())
+			}
+		case types.AccessListTxType:
+			if len(tx.AccessList()) != 1 || tx.Gas() != 50_000+2400+1900 {
+				t.Errorf("unexpected access list %v with gas %d", tx.AccessList(), tx.Gas())
+			}
+			if tx.GasPrice().Cmp(gasPrice) != 0 {
+				t.Errorf("unexpected gas price of access-list transaction, wanted %v, got %v", gasPrice, tx.GasPrice())
+			}
+		case types.SetCodeTxType:
+			if len(tx.SetCodeAuthorizations()) != 1 || tx.Gas() != 50_000+25_000 {
+				t.Errorf("unexpected authorizations %v with gas %d", tx.SetCodeAuthorizations(), tx.Gas())
+			}
+		}
+		if i == 0 || tx.Type() == types.DynamicFeeTxType {
+			continue
+		}
+		replacement, err := account.ReplaceTransaction(tx, 10)
+		if err != nil {
+			t.Fatalf("failed to replace transaction: %v", err)
+		}
+		if replacement.Type() != tx.Type() || replacement.Gas() != tx.Gas() || len(replacement.AccessList()) != len(tx.AccessList()) {
+			t.Errorf("replacement does not match original of type %d", tx.Type())
+		}
+		if want := bumpFee(tx.GasPrice(), 10); tx.Type() != types.SetCodeTxType && replacement.GasPrice().Cmp(want) != 0 {
+			t.Errorf("unexpected gas price of replacement, wanted %v, got %v", want, replacement.GasPrice())
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("not all transaction types were created, got %v", seen)
+	}
+	if err := mix.Close(); err != nil {
+		t.Errorf("failed to close mix: %v", err)
+	}
+}
+
+func TestTxTypeMix_GasPriceIsTwiceBaseFeePlusTipLimitedByFeeCap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := NewMockClientFactory(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	factory.EXPECT().DialRandomRpc().Return(client, nil)
+	client.EXPECT().HeaderByNumber(gomock.Any(), nil).Return(&types.Header{BaseFee: big.NewInt(100)}, nil)
+
+	mix := &TxTypeMix{factory: factory}
+	tests := []struct{ feeCap, tip, want int64 }{
+		{1000, 10, 210},
+		{1000, 0, 200},
+		{205, 10, 205},
+	}
+	for _, test := range tests {
+		got := mix.getGasPrice(&types.DynamicFeeTx{GasFeeCap: big.NewInt(test.feeCap), GasTipCap: big.NewInt(test.tip)})
+		if got.Int64() != test.want {
+			t.Errorf("unexpected gas price for fee cap %d and tip %d, wanted %d, got %v", test.feeCap, test.tip, test.want, got)
+		}
+	}
+}
+
+func TestTxTypeMix_GasPriceCoversRisingBaseFee(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := NewMockClientFactory(ctrl)
+	client := rpc.NewMockClient(ctrl)
+	factory.EXPECT().DialRandomRpc().Return(client, nil)
+	client.EXPECT().HeaderByNumber(gomock.Any(), nil).Return(&types.Header{BaseFee: big.NewInt(100)}, nil)
+
+	// The base fee rises by up to 12.5% per block while the cached one is in use.
+	mix := &TxTypeMix{factory: factory}
+	baseFee := big.NewInt(100)
+	for range 5 {
+		got := mix.getGasPrice(&types.DynamicFeeTx{GasFeeCap: big.NewInt(1000), GasTipCap: big.NewInt(0)})
+		baseFee.Mul(baseFee, big.NewInt(1125))
+		baseFee.Div(baseFee, big.NewInt(1000))
+		if got.Cmp(baseFee) < 0 {
+			t.Errorf("gas price %v is below base fee %v of next block", got, baseFee)
+		}
+	}
+}
+
+func TestTxTypeMix_GasPriceIsFeeCapIfBaseFeeIsUnknown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	factory := NewMockClientFactory(ctrl)
+	factory.EXPECT().DialRandomRpc().Return(nil, fmt.Errorf("injected error"))
+
+	mix := &TxTypeMix{factory: factory}
+	got := mix.getGasPrice(&types.DynamicFeeTx{GasFeeCap: big.NewInt(1000), GasTipCap: big.NewInt(10)})
+	if got.Int64() != 1000 {
+		t.Errorf("unexpected gas price, wanted fee cap, got %v", got)
+	}
+}
+
+func TestTxTypeMix_ContractCreationsAreNotSetCodeTransactions(t *testing.T) {
+	mix := &TxTypeMix{types: []uint8{types.SetCodeTxType}, weights: []float64{1}}
+	account, err := NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.SetTxTypes(mix)
+	tx, err := createDeployTx(account, []byte{0}, 100_000)
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+	if tx.Type() != types.DynamicFeeTxType {
+		t.Errorf("unexpected type of contract creation, got %d", tx.Type())
+	}
+}
@@ -135,7 +135,7 @@ func NewUniswapApplication(context AppContext, feederId, appId uint32) (Applicat
 		configSteps = append(configSteps, tx)
 	}
 
-	accountFactory, err := NewAccountFactory(primaryAccount.chainID, feederId, appId)
+	accountFactory, err := newAccountFactory(context, primaryAccount.chainID, feederId, appId)
 	if err != nil {
 		return nil, err
 	}
@@ -297,3 +297,7 @@ func (g *UniswapUser) GenerateTx() (*types.Transaction, error) {
 func (g *UniswapUser) GetSentTransactions() uint64 {
 	return atomic.LoadUint64(&g.sentTxs)
 }
+
+func (g *UniswapUser) GetAccounts() []*Account {
+	return []*Account{g.sender}
+}
@@ -18,7 +18,9 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"sync"
 	"time"
@@ -27,6 +29,7 @@ import (
 	"github.com/0xsoniclabs/hyperion/driver/rpc"
 	"github.com/0xsoniclabs/hyperion/load/app"
 	"github.com/0xsoniclabs/hyperion/load/shaper"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // AppController emits transactions to the testing network into a blockchain app to generate a load.
@@ -36,14 +39,29 @@ import (
 type AppController struct {
 	shaper      shaper.Shaper
 	application app.Application
-	network     driver.Network
+	network     *trackingNetwork
 	trigger     chan struct{}
 	users       []app.User
-	rpcClient   rpc.Client
+
+	// rpcClient is shared by concurrent queries of metric sources, which hold
+	// a read lock while using it. It is only replaced under the write lock.
+	rpcClientMutex sync.RWMutex
+	rpcClient      rpc.Client
+
+	closedLoop    *driver.ClosedLoopConfig
+	nonceRecovery *driver.NonceRecoveryConfig
+	fees          app.FeeStrategy
+	txTypes       *app.TxTypeMix
+	accounts      *app.AccountPool // < nil if accounts are not swept
 }
 
-func NewAppController(application app.Application, shaper shaper.Shaper, numUsers int, context app.AppContext, network driver.Network) (*AppController, error) {
+// NewAppController creates a controller for the given application, creating
+// the number of users defined by the configuration. Users send transactions
+// whenever triggered by the shaper, unless the configuration defines a closed
+// loop.
+func NewAppController(application app.Application, shaper shaper.Shaper, config *driver.ApplicationConfig, context app.AppContext, network driver.Network) (*AppController, error) {
 	trigger := make(chan struct{}, 100)
+	numUsers := config.Users
 
 	// create users for this application
 	log.Printf("starting initialization of %d users\n", numUsers)
@@ -53,18 +71,112 @@ func NewAppController(application app.Application, shaper shaper.Shaper, numUser
 	}
 	log.Printf("completed initialization of %d users\n", numUsers)
 
+	// pooled accounts are swept at the end of the application if configured
+	var pool *app.AccountPool
+	if config.Accounts != nil && config.Accounts.Sweep {
+		pool, _ = context.(*app.AccountPool)
+	}
+
+	// transactions of the users are sent through a network tracking them
+	tracking := newTrackingNetwork(network)
+
+	accounts := []*app.Account{}
+	for _, user := range users {
+		if holder, ok := user.(app.AccountHolder); ok {
+			accounts = append(accounts, holder.GetAccounts()...)
+		}
+	}
+
+	// fees of transactions of the users follow the configured strategy
+	var fees app.FeeStrategy
+	if config.Fees != nil {
+		fees, err = app.NewFeeStrategy(*config.Fees, network)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fee strategy; %w", err)
+		}
+		for _, account := range accounts {
+			account.SetFeeStrategy(fees)
+		}
+		if escalation, ok := fees.(app.FeeEscalation); ok {
+			tracking.escalateFees(escalation, accounts)
+		}
+	}
+
+	// types of transactions of the users are mixed as configured
+	var txTypes *app.TxTypeMix
+	if len(config.TxTypes) > 0 {
+		txTypes, err = app.NewTxTypeMix(config.TxTypes, network)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transaction type mix; %w", err)
+		}
+		for _, account := range accounts {
+			account.SetTxTypes(txTypes)
+		}
+	}
+
 	return &AppController{
-		shaper:      shaper,
-		application: application,
-		network:     network,
-		trigger:     trigger,
-		users:       users,
-		rpcClient:   context.GetClient(),
+		shaper:        shaper,
+		application:   application,
+		network:       tracking,
+		trigger:       trigger,
+		users:         users,
+		rpcClient:     context.GetClient(),
+		closedLoop:    config.ClosedLoop,
+		nonceRecovery: config.NonceRecovery,
+		fees:          fees,
+		txTypes:       txTypes,
+		accounts:      pool,
 	}, nil
 }
 
 func (ac *AppController) Run(ctx context.Context) error {
-	defer ac.rpcClient.Close()
+	defer func() {
+		ac.rpcClientMutex.Lock()
+		defer ac.rpcClientMutex.Unlock()
+		ac.rpcClient.Close()
+	}()
+	// the tracking network is also closed if the controller fails to start,
+	// stopping the replacement of transactions
+	defer ac.network.close()
+
+	// receipts of closed-loop users are polled using a dedicated connection
+	var receipts rpc.Client
+	if ac.closedLoop != nil {
+		var err error
+		receipts, err = ac.network.DialRandomRpc()
+		if err != nil {
+			return fmt.Errorf("failed to dial random RPC; %v", err)
+		}
+		defer receipts.Close()
+	}
+
+	// shapers controlling their rate rely on measured inclusion latencies,
+	// including those nested in combinations of shapers
+	stopSampling := make(chan struct{})
+	var sampling sync.WaitGroup
+	if len(shaper.GetRateControllers(ac.shaper)) > 0 {
+		client, err := ac.network.DialRandomRpc()
+		if err != nil {
+			return fmt.Errorf("failed to dial random RPC; %v", err)
+		}
+		defer client.Close()
+		sampling.Add(1)
+		go func() {
+			defer sampling.Done()
+			ac.network.latencies.run(client, stopSampling)
+		}()
+	}
+
+	// periodically recover nonce gaps of the accounts of the users
+	stopRecovery := make(chan struct{})
+	var recovery sync.WaitGroup
+	if ac.nonceRecovery != nil && ac.nonceRecovery.Period > 0 {
+		recovery.Add(1)
+		go func() {
+			defer recovery.Done()
+			ac.runNonceRecovery(stopRecovery)
+		}()
+	}
 
 	// start generators for each user
 	var done sync.WaitGroup
@@ -73,7 +185,11 @@ func (ac *AppController) Run(ctx context.Context) error {
 		done.Add(1)
 		go func() {
 			defer done.Done()
-			runGeneratorLoop(user, ac.trigger, ac.network)
+			if ac.closedLoop != nil && sendsTransactions(user) {
+				runClosedLoop(user, ac.trigger, ac.network, receipts, *ac.closedLoop)
+			} else {
+				runGeneratorLoop(user, ac.trigger, ac.network)
+			}
 		}()
 	}
 
@@ -81,23 +197,64 @@ func (ac *AppController) Run(ctx context.Context) error {
 	lastUpdate := time.Now()
 	ac.shaper.Start(lastUpdate, ac)
 
+	// shapers sampling arrivals define the instants of messages themselves
+	arrivals, sampled := ac.shaper.(shaper.ArrivalShaper)
+	var nextArrival time.Time
+	if sampled {
+		nextArrival = arrivals.NextArrival()
+	}
+
 	for {
-		// re-plenish the number of pending messages
-		now := time.Now()
-		pending += ac.shaper.GetNumMessagesInInterval(lastUpdate, now.Sub(lastUpdate))
-		lastUpdate = now
+		wait := time.Millisecond
+		if sampled {
+			// emit all messages whose arrival is due and wait for the next
+			for now := time.Now(); !nextArrival.After(now); nextArrival = arrivals.NextArrival() {
+				ac.trigger <- struct{}{}
+			}
+			wait = time.Until(nextArrival)
+		} else {
+			// re-plenish the number of pending messages
+			now := time.Now()
+			pending += ac.shaper.GetNumMessagesInInterval(lastUpdate, now.Sub(lastUpdate))
+			lastUpdate = now
 
-		for pending > 0 {
-			ac.trigger <- struct{}{}
-			pending -= 1
+			for pending > 0 {
+				ac.trigger <- struct{}{}
+				pending -= 1
+			}
 		}
 
 		select {
-		case <-time.After(time.Millisecond):
+		case <-time.After(wait):
 			// just waiting for next time to send messages.
 		case <-ctx.Done():
 			close(ac.trigger)
 			done.Wait()
+			close(stopRecovery)
+			recovery.Wait()
+			close(stopSampling)
+			sampling.Wait()
+			ac.network.close()
+			if closer, ok := ac.fees.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					log.Printf("failed to close fee strategy; %v", err)
+				}
+			}
+			if ac.txTypes != nil {
+				if err := ac.txTypes.Close(); err != nil {
+					log.Printf("failed to close transaction type mix; %v", err)
+				}
+			}
+			if closer, ok := ac.application.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					log.Printf("failed to close application; %v", err)
+				}
+			}
+			if ac.accounts != nil {
+				if err := ac.sweepAccounts(); err != nil {
+					log.Printf("failed to sweep accounts; %v", err)
+				}
+			}
 			err := ctx.Err()
 			if err == context.DeadlineExceeded || err == context.Canceled {
 				return nil // terminated gracefully
@@ -128,9 +285,234 @@ func (ac *AppController) GetSentTransactions() (uint64, error) {
 }
 
 func (ac *AppController) GetReceivedTransactions() (uint64, error) {
+	return ac.fetchWithRetry(ac.application.GetReceivedTransactions)
+}
+
+// GetOperations lists the operations of the controlled application, which is
+// empty if the application does not mix operations.
+func (ac *AppController) GetOperations() []string {
+	if mix, ok := ac.application.(app.OperationMix); ok {
+		return mix.GetOperations()
+	}
+	return nil
+}
+
+func (ac *AppController) GetTransactionsOfOperationSentBy(operation string, user int) (uint64, error) {
+	if user < 0 || user >= len(ac.users) {
+		return 0, nil
+	}
+	mixUser, ok := ac.users[user].(app.OperationMixUser)
+	if !ok {
+		return 0, fmt.Errorf("application does not support operations")
+	}
+	return mixUser.GetSentTransactionsOf(operation), nil
+}
+
+func (ac *AppController) GetReceivedTransactionsOf(operation string) (uint64, error) {
+	mix, ok := ac.application.(app.OperationMix)
+	if !ok {
+		return 0, fmt.Errorf("application does not support operations")
+	}
+	return ac.fetchWithRetry(func(rpcClient rpc.Client) (uint64, error) {
+		return mix.GetReceivedTransactionsOf(operation, rpcClient)
+	})
+}
+
+// GetRequestMethods lists the RPC methods used by the controlled application,
+// which is empty if the application does not issue RPC requests.
+func (ac *AppController) GetRequestMethods() []string {
+	if _, ok := ac.application.(app.RequestLoad); ok {
+		return ac.GetOperations()
+	}
+	return nil
+}
+
+func (ac *AppController) GetRequestStats(method string) (app.RequestStats, error) {
+	load, ok := ac.application.(app.RequestLoad)
+	if !ok {
+		return app.RequestStats{}, fmt.Errorf("application does not issue requests")
+	}
+	return load.GetRequestStats(method)
+}
+
+// GetSubscriptionKinds lists the kinds of subscriptions of the controlled
+// application, which is empty if the application does not subscribe to events.
+func (ac *AppController) GetSubscriptionKinds() []string {
+	if _, ok := ac.application.(app.SubscriptionLoad); ok {
+		return ac.GetOperations()
+	}
+	return nil
+}
+
+func (ac *AppController) GetSubscriptionStats(kind string) (app.SubscriptionStats, error) {
+	load, ok := ac.application.(app.SubscriptionLoad)
+	if !ok {
+		return app.SubscriptionStats{}, fmt.Errorf("application does not subscribe to events")
+	}
+	return load.GetSubscriptionStats(kind)
+}
+
+// GetNonceStats returns the statistics of the nonce recovery of the accounts
+// of the given user.
+func (ac *AppController) GetNonceStats(user int) (app.NonceStats, error) {
+	res := app.NonceStats{}
+	if user < 0 || user >= len(ac.users) {
+		return res, nil
+	}
+	holder, ok := ac.users[user].(app.AccountHolder)
+	if !ok {
+		return res, fmt.Errorf("user does not hold accounts")
+	}
+	for _, account := range holder.GetAccounts() {
+		stats := account.GetNonceStats()
+		res.Stuck += stats.Stuck
+		res.Resynced += stats.Resynced
+	}
+	return res, nil
+}
+
+// GetSendErrors returns the errors reported so far when sending transactions
+// of the users of the application.
+func (ac *AppController) GetSendErrors() rpc.SendErrors {
+	return ac.network.errors.Get()
+}
+
+// GetEffectiveGasPrice returns the average effective gas price in wei paid by
+// a sample of the transactions processed since the previous call, taken from
+// their receipts. If none were processed, the previous value is returned.
+func (ac *AppController) GetEffectiveGasPrice() (uint64, error) {
+	return ac.fetchWithRetry(ac.network.gasPrices.sample)
+}
+
+// sweepAccounts returns the remaining funds of the pooled accounts of the
+// users to the treasury using a dedicated connection.
+func (ac *AppController) sweepAccounts() error {
+	client, err := ac.network.DialRandomRpc()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return ac.accounts.Sweep(client)
+}
+
+// GetInclusionLatency returns the given percentile, in (0,1], of the time
+// between sending transactions and observing their receipts, over a sample
+// of the recently processed transactions. Transactions are only sampled if
+// the rate of the application is controlled by a feedback loop.
+func (ac *AppController) GetInclusionLatency(percentile float64) (time.Duration, error) {
+	return ac.network.latencies.percentile(percentile)
+}
+
+// GetTxPoolSize returns the number of pending and queued transactions in the
+// transaction pool of a random validator, since only validators are certain
+// to offer the txpool API. If the network can not connect to its validators,
+// the node the controller is connected to is queried.
+func (ac *AppController) GetTxPoolSize() (uint64, error) {
+	dialer, ok := ac.network.Network.(driver.ValidatorDialer)
+	if !ok {
+		return ac.fetchWithRetry(getTxPoolSize)
+	}
+	client, err := dialer.DialRandomValidatorRpc()
+	if err != nil {
+		return 0, fmt.Errorf("failed to dial validator; %w", err)
+	}
+	defer client.Close()
+	return getTxPoolSize(client)
+}
+
+// getTxPoolSize queries the number of pending and queued transactions in the
+// transaction pool of the node of the given client.
+func getTxPoolSize(client rpc.Client) (uint64, error) {
+	var status struct {
+		Pending hexutil.Uint64 `json:"pending"`
+		Queued  hexutil.Uint64 `json:"queued"`
+	}
+	if err := client.Call(&status, "txpool_status"); err != nil {
+		return 0, err
+	}
+	return uint64(status.Pending + status.Queued), nil
+}
+
+// GetSustainableRate returns the rate in Tx/s the feedback loop controlling
+// the load of the application converged to, which may be nested in a
+// combination of shapers, e.g. following a warm-up phase. False is returned
+// if the load is not controlled by exactly one feedback loop, which is the
+// case for all loads accepted by the parser.
+func (ac *AppController) GetSustainableRate() (float64, bool) {
+	controllers := shaper.GetRateControllers(ac.shaper)
+	if len(controllers) != 1 {
+		return 0, false
+	}
+	return controllers[0].GetSustainableRate(), true
+}
+
+// runNonceRecovery periodically checks the accounts of all users for nonce
+// gaps until the stop channel is closed. A dedicated connection is used, which
+// is re-established in case of failures.
+func (ac *AppController) runNonceRecovery(stop <-chan struct{}) {
+	ticker := time.NewTicker(ac.nonceRecovery.Period)
+	defer ticker.Stop()
+	var client rpc.Client
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+	}()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if client == nil {
+			var err error
+			client, err = ac.network.DialRandomRpc()
+			if err != nil {
+				log.Printf("failed to dial random RPC for nonce recovery; %v", err)
+				continue
+			}
+		}
+		if err := ac.recoverNonces(client); err != nil {
+			log.Printf("failed to recover nonces; %v", err)
+			client.Close()
+			client = nil
+		}
+	}
+}
+
+// recoverNonces checks the accounts of all users for nonce gaps once,
+// re-sending dropped transactions if enabled. All accounts are checked, even
+// if the checks of some accounts fail.
+func (ac *AppController) recoverNonces(client rpc.Client) error {
+	errs := []error{}
+	for _, user := range ac.users {
+		holder, ok := user.(app.AccountHolder)
+		if !ok {
+			continue
+		}
+		for _, account := range holder.GetAccounts() {
+			txs, err := account.RecoverNonce(client, ac.nonceRecovery.Resend)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, tx := range txs {
+				ac.network.SendTransaction(tx)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fetchWithRetry runs the given query on the network, re-connecting to a
+// random RPC node in case of failures. Queries may run concurrently.
+func (ac *AppController) fetchWithRetry(query func(rpc.Client) (uint64, error)) (uint64, error) {
 	for retry := 0; ; retry++ {
 		// fetch transaction data from the network
-		res, err := ac.application.GetReceivedTransactions(ac.rpcClient)
+		ac.rpcClientMutex.RLock()
+		client := ac.rpcClient
+		res, err := query(client)
+		ac.rpcClientMutex.RUnlock()
 		if err == nil {
 			return res, nil
 		}
@@ -139,10 +521,26 @@ func (ac *AppController) GetReceivedTransactions() (uint64, error) {
 		}
 
 		// attempt a re-connect
-		ac.rpcClient.Close()
-		ac.rpcClient, err = ac.network.DialRandomRpc()
-		if err != nil {
-			return 0, fmt.Errorf("failed to dial random RPC; %v", err)
+		if err := ac.reconnect(client); err != nil {
+			return 0, err
 		}
 	}
 }
+
+// reconnect replaces the given failed client by a connection to a random RPC
+// node, unless a concurrent query replaced it already. The failed client is
+// only closed once no query is using it anymore.
+func (ac *AppController) reconnect(failed rpc.Client) error {
+	ac.rpcClientMutex.Lock()
+	defer ac.rpcClientMutex.Unlock()
+	if ac.rpcClient != failed {
+		return nil
+	}
+	client, err := ac.network.DialRandomRpc()
+	if err != nil {
+		return fmt.Errorf("failed to dial random RPC; %v", err)
+	}
+	failed.Close()
+	ac.rpcClient = client
+	return nil
+}
@@ -48,7 +48,7 @@ func TestLoadGeneration_CanRealizeConstantTrafficShape(t *testing.T) {
 			rpcClient := rpc.NewMockClient(ctrl)
 			application := app.NewMockApplication(ctrl)
 			user := app.NewMockUser(ctrl)
-			transaction := types.Transaction{}
+			transaction := types.NewTx(&types.LegacyTx{})
 
 			treasure, err := app.NewAccount(0, PrivateKey, nil, FakeNetworkID)
 			if err != nil {
@@ -79,7 +79,7 @@ func TestLoadGeneration_CanRealizeConstantTrafficShape(t *testing.T) {
 			application.EXPECT().CreateUsers(gomock.Any(), 100).AnyTimes().Return(users, nil)
 
 			rpcClient.EXPECT().SuggestGasPrice(gomock.Any()).AnyTimes().Return(big.NewInt(0), nil)
-			user.EXPECT().GenerateTx().AnyTimes().Return(&transaction, nil)
+			user.EXPECT().GenerateTx().AnyTimes().Return(transaction, nil)
 
 			clientFactory := app.NewMockClientFactory(ctrl)
 			clientFactory.EXPECT().DialRandomRpc().AnyTimes().Return(rpcClient, nil)
@@ -89,7 +89,7 @@ func TestLoadGeneration_CanRealizeConstantTrafficShape(t *testing.T) {
 			if err != nil {
 				t.Fatalf("failed to create app context: %v", err)
 			}
-			controller, err := controller.NewAppController(application, shaper, 100, appContext, net)
+			controller, err := controller.NewAppController(application, shaper, &driver.ApplicationConfig{Users: 100}, appContext, net)
 			if err != nil {
 				t.Fatalf("failed to create app controller: %v", err)
 			}
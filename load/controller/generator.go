@@ -17,13 +17,42 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"io"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
 	"github.com/0xsoniclabs/hyperion/load/app"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// closedLoopErrorDelay is the time a closed-loop sender waits after a
+	// failed attempt to generate a transaction.
+	closedLoopErrorDelay = time.Second
+	// closedLoopMaxPollInterval is the maximum interval between two attempts
+	// to fetch the receipt of a transaction in closed-loop mode.
+	closedLoopMaxPollInterval = 500 * time.Millisecond
 )
 
 func runGeneratorLoop(user app.User, trigger <-chan struct{}, network driver.Network) {
+	if requestUser, ok := user.(app.RequestUser); ok {
+		runRequestLoop(requestUser, trigger)
+		return
+	}
+	if subscriber, ok := user.(app.Subscriber); ok {
+		runSubscriptionLoop(subscriber, trigger)
+		return
+	}
+	if scheduled, ok := user.(app.ScheduledUser); ok {
+		runScheduledLoop(scheduled, trigger, network)
+		return
+	}
 	for range trigger {
 		tx, err := user.GenerateTx()
 		if err != nil {
@@ -33,3 +62,163 @@ func runGeneratorLoop(user app.User, trigger <-chan struct{}, network driver.Net
 		}
 	}
 }
+
+// runRequestLoop issues a request for each trigger on behalf of users producing
+// read-only load.
+func runRequestLoop(user app.RequestUser, trigger <-chan struct{}) {
+	for range trigger {
+		if err := user.SendRequest(); err != nil {
+			log.Printf("failed to send request; %v", err)
+		}
+	}
+}
+
+// runSubscriptionLoop keeps the subscriptions of a user open until the trigger
+// channel is closed. Triggers are ignored, since subscribers only consume
+// notifications.
+func runSubscriptionLoop(user app.Subscriber, trigger <-chan struct{}) {
+	if err := user.Subscribe(); err != nil {
+		log.Printf("failed to subscribe; %v", err)
+	}
+	for range trigger {
+	}
+	user.Unsubscribe()
+}
+
+// runScheduledLoop sends the transactions of a user at the times requested by
+// the user, relative to the start of the loop, until all transactions are sent
+// or the trigger channel is closed. Triggers are consumed but otherwise
+// ignored, so the schedule of the user takes precedence over the shaper.
+func runScheduledLoop(user app.ScheduledUser, trigger <-chan struct{}, network driver.Network) {
+	start := time.Now()
+	for {
+		tx, offset, err := user.GenerateScheduledTx()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("failed to generate tx; %v", err)
+			continue
+		}
+		if !waitUntil(start.Add(offset), trigger) {
+			return
+		}
+		network.SendTransaction(tx)
+	}
+	for range trigger {
+	}
+}
+
+// waitUntil blocks until the given time while draining the trigger channel.
+// It returns false if the trigger channel got closed before.
+func waitUntil(deadline time.Time, trigger <-chan struct{}) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case _, open := <-trigger:
+			if !open {
+				return false
+			}
+		}
+	}
+}
+
+// sendsTransactions returns true if the given user produces load by sending
+// transactions on demand, which is the prerequisite for running it in a
+// closed loop. Users following their own send schedule are excluded.
+func sendsTransactions(user app.User) bool {
+	_, requests := user.(app.RequestUser)
+	_, subscriptions := user.(app.Subscriber)
+	_, scheduled := user.(app.ScheduledUser)
+	return !requests && !subscriptions && !scheduled
+}
+
+// runClosedLoop sends transactions of a user in a closed loop until the
+// trigger channel is closed. Up to config.InFlight transactions are pending
+// at any time. Once the receipt of a transaction is available or the timeout
+// is reached, the respective sender waits for the think time before sending
+// the next transaction. Triggers are consumed but otherwise ignored, so the
+// load is determined by the number of users and not by the shaper.
+func runClosedLoop(user app.User, trigger <-chan struct{}, network driver.Network, receipts rpc.Client, config driver.ClosedLoopConfig) {
+	stop := make(chan struct{})
+	var mutex sync.Mutex // < users are not thread-safe
+	var done sync.WaitGroup
+	for range max(config.InFlight, 1) {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			for {
+				mutex.Lock()
+				tx, err := user.GenerateTx()
+				mutex.Unlock()
+				if err != nil {
+					log.Printf("failed to generate tx; %v", err)
+					if !sleep(closedLoopErrorDelay, stop) {
+						return
+					}
+					continue
+				}
+				network.SendTransaction(tx)
+				if !waitForReceipt(receipts, tx.Hash(), config.Timeout, stop) {
+					return
+				}
+				if !sleep(config.ThinkTime, stop) {
+					return
+				}
+			}
+		}()
+	}
+	for range trigger {
+	}
+	close(stop)
+	done.Wait()
+}
+
+// waitForReceipt polls the receipt of the given transaction until it is
+// available or the timeout is reached. It returns false if the stop channel
+// got closed before.
+func waitForReceipt(client rpc.Client, txHash common.Hash, timeout time.Duration, stop <-chan struct{}) bool {
+	deadline := time.Now().Add(timeout)
+	delay := 10 * time.Millisecond
+	for {
+		_, err := client.TransactionReceipt(context.Background(), txHash)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			log.Printf("failed to fetch receipt; %v", err)
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return true // < timed out, the next transaction may be sent
+		}
+		if !sleep(min(delay, remaining), stop) {
+			return false
+		}
+		delay = min(2*delay, closedLoopMaxPollInterval)
+	}
+}
+
+// sleep waits for the given duration. It returns false if the stop channel
+// got closed before.
+func sleep(duration time.Duration, stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+	if duration <= 0 {
+		return true
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -18,6 +18,11 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +30,7 @@ import (
 	"github.com/0xsoniclabs/hyperion/driver/rpc"
 	"github.com/0xsoniclabs/hyperion/load/app"
 	"github.com/0xsoniclabs/hyperion/load/shaper"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
 	"go.uber.org/mock/gomock"
 )
@@ -33,7 +39,7 @@ func TestMockedTrafficGenerating(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
-	var demoTx types.Transaction
+	demoTx := types.NewTx(&types.LegacyTx{})
 
 	numUsers := 2
 	mockUser := app.NewMockUser(mockCtrl)
@@ -50,14 +56,14 @@ func TestMockedTrafficGenerating(t *testing.T) {
 	mockedApp.EXPECT().CreateUsers(appContext, numUsers).Return([]app.User{mockUser, mockUser}, nil)
 
 	// app should be called 10-times to generate 10 txs
-	mockUser.EXPECT().GenerateTx().Return(&demoTx, nil).MinTimes(5).MaxTimes(11)
+	mockUser.EXPECT().GenerateTx().Return(demoTx, nil).MinTimes(5).MaxTimes(11)
 	// network should be called 10-times to send 10 txs
-	mockedNetwork.EXPECT().SendTransaction(&demoTx).MinTimes(5).MaxTimes(11)
+	mockedNetwork.EXPECT().SendTransaction(demoTx).MinTimes(5).MaxTimes(11)
 
 	// use constant shaper
 	constantShaper := shaper.NewConstantShaper(100) // 100 txs/sec
 
-	appController, err := NewAppController(mockedApp, constantShaper, numUsers, appContext, mockedNetwork)
+	appController, err := NewAppController(mockedApp, constantShaper, &driver.ApplicationConfig{Users: numUsers}, appContext, mockedNetwork)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -72,3 +78,568 @@ func TestMockedTrafficGenerating(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// requestUser is a user issuing requests instead of sending transactions.
+type requestUser struct {
+	*app.MockUser
+	requests int
+}
+
+func (u *requestUser) SendRequest() error {
+	u.requests++
+	return nil
+}
+
+func TestGeneratorLoop_RequestUsersIssueRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	// neither transactions should be generated nor sent
+	user := &requestUser{MockUser: app.NewMockUser(mockCtrl)}
+	network := driver.NewMockNetwork(mockCtrl)
+
+	trigger := make(chan struct{}, 3)
+	for range 3 {
+		trigger <- struct{}{}
+	}
+	close(trigger)
+
+	runGeneratorLoop(user, trigger, network)
+	if got, want := user.requests, 3; got != want {
+		t.Errorf("unexpected number of requests, wanted %d, got %d", want, got)
+	}
+}
+
+// subscriber is a user consuming notifications instead of sending transactions.
+type subscriber struct {
+	*app.MockUser
+	subscribed   bool
+	unsubscribed bool
+}
+
+func (u *subscriber) Subscribe() error {
+	u.subscribed = true
+	return nil
+}
+
+func (u *subscriber) Unsubscribe() {
+	u.unsubscribed = true
+}
+
+func TestGeneratorLoop_SubscribersStayActiveUntilStopped(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := &subscriber{MockUser: app.NewMockUser(mockCtrl)}
+	network := driver.NewMockNetwork(mockCtrl)
+
+	trigger := make(chan struct{}, 3)
+	for range 3 {
+		trigger <- struct{}{}
+	}
+	close(trigger)
+
+	runGeneratorLoop(user, trigger, network)
+	if !user.subscribed || !user.unsubscribed {
+		t.Errorf("subscriptions were not opened and closed, subscribed %t, unsubscribed %t", user.subscribed, user.unsubscribed)
+	}
+}
+
+// scheduledUser is a user sending transactions at fixed offsets.
+type scheduledUser struct {
+	*app.MockUser
+	offsets []time.Duration
+}
+
+func (u *scheduledUser) GenerateScheduledTx() (*types.Transaction, time.Duration, error) {
+	if len(u.offsets) == 0 {
+		return nil, 0, io.EOF
+	}
+	offset := u.offsets[0]
+	u.offsets = u.offsets[1:]
+	return types.NewTx(&types.LegacyTx{}), offset, nil
+}
+
+func TestGeneratorLoop_ScheduledUsersSendOnTheirSchedule(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := &scheduledUser{
+		MockUser: app.NewMockUser(mockCtrl),
+		offsets:  []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond},
+	}
+	network := driver.NewMockNetwork(mockCtrl)
+	network.EXPECT().SendTransaction(gomock.Any()).Times(3)
+
+	// triggers are drained until the channel is closed
+	trigger := make(chan struct{}, 3)
+	for range 3 {
+		trigger <- struct{}{}
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		close(trigger)
+	}()
+
+	start := time.Now()
+	runGeneratorLoop(user, trigger, network)
+	if got := time.Since(start); got < 100*time.Millisecond {
+		t.Errorf("transactions were sent ahead of schedule, loop ended after %v", got)
+	}
+}
+
+func TestGeneratorLoop_ScheduledUsersStopWhenTriggerIsClosed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := &scheduledUser{
+		MockUser: app.NewMockUser(mockCtrl),
+		offsets:  []time.Duration{0, time.Hour},
+	}
+	network := driver.NewMockNetwork(mockCtrl)
+	network.EXPECT().SendTransaction(gomock.Any()).Times(1)
+
+	trigger := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(trigger)
+	}()
+	runGeneratorLoop(user, trigger, network)
+}
+
+func TestSendsTransactions_ScheduledUsersAreExcludedFromClosedLoops(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	if !sendsTransactions(app.NewMockUser(mockCtrl)) {
+		t.Errorf("plain users should be run in closed loops")
+	}
+	if sendsTransactions(&scheduledUser{MockUser: app.NewMockUser(mockCtrl)}) {
+		t.Errorf("scheduled users should not be run in closed loops")
+	}
+}
+
+func TestClosedLoop_NumberOfPendingTransactionsIsLimited(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	receipts := rpc.NewMockClient(mockCtrl)
+
+	// receipts never arrive, so only the permitted transactions are sent
+	user.EXPECT().GenerateTx().DoAndReturn(func() (*types.Transaction, error) {
+		return types.NewTx(&types.LegacyTx{}), nil
+	}).Times(3)
+	network.EXPECT().SendTransaction(gomock.Any()).Times(3)
+	receipts.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, ethereum.NotFound).MinTimes(3)
+
+	trigger := make(chan struct{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(trigger)
+	}()
+	runClosedLoop(user, trigger, network, receipts, driver.ClosedLoopConfig{
+		InFlight: 3,
+		Timeout:  time.Hour,
+	})
+}
+
+func TestClosedLoop_NextTransactionIsSentAfterReceiptAndThinkTime(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	receipts := rpc.NewMockClient(mockCtrl)
+
+	// 200ms of think time after each receipt admit 2-3 transactions in 500ms
+	user.EXPECT().GenerateTx().Return(types.NewTx(&types.LegacyTx{}), nil).MinTimes(2).MaxTimes(3)
+	network.EXPECT().SendTransaction(gomock.Any()).MinTimes(2).MaxTimes(3)
+	receipts.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(&types.Receipt{}, nil).MinTimes(2).MaxTimes(3)
+
+	trigger := make(chan struct{})
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		close(trigger)
+	}()
+	runClosedLoop(user, trigger, network, receipts, driver.ClosedLoopConfig{
+		InFlight:  1,
+		Timeout:   time.Hour,
+		ThinkTime: 200 * time.Millisecond,
+	})
+}
+
+func TestClosedLoop_TimedOutTransactionsAreReplaced(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	receipts := rpc.NewMockClient(mockCtrl)
+
+	user.EXPECT().GenerateTx().Return(types.NewTx(&types.LegacyTx{}), nil).MinTimes(3)
+	network.EXPECT().SendTransaction(gomock.Any()).MinTimes(3)
+	receipts.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, ethereum.NotFound).AnyTimes()
+
+	trigger := make(chan struct{})
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		close(trigger)
+	}()
+	runClosedLoop(user, trigger, network, receipts, driver.ClosedLoopConfig{
+		InFlight: 1,
+		Timeout:  20 * time.Millisecond,
+	})
+}
+
+func TestAppController_ClosedLoopUsesDedicatedConnectionForReceipts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	client := rpc.NewMockClient(mockCtrl)
+	receipts := rpc.NewMockClient(mockCtrl)
+	appContext := app.NewMockAppContext(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	application := app.NewMockApplication(mockCtrl)
+
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+	application.EXPECT().CreateUsers(appContext, 1).Return([]app.User{user}, nil)
+	network.EXPECT().DialRandomRpc().Return(receipts, nil)
+	client.EXPECT().Close()
+	receipts.EXPECT().Close()
+
+	user.EXPECT().GenerateTx().Return(types.NewTx(&types.LegacyTx{}), nil)
+	network.EXPECT().SendTransaction(gomock.Any())
+	receipts.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, ethereum.NotFound).MinTimes(1)
+
+	controller, err := NewAppController(application, shaper.NewConstantShaper(100), &driver.ApplicationConfig{
+		Users: 1,
+		ClosedLoop: &driver.ClosedLoopConfig{
+			InFlight: 1,
+			Timeout:  time.Hour,
+		},
+	}, appContext, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := controller.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppController_FeeEscalationIsStoppedIfRunFailsToStart(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	client := rpc.NewMockClient(mockCtrl)
+	appContext := app.NewMockAppContext(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	application := app.NewMockApplication(mockCtrl)
+
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+	application.EXPECT().CreateUsers(appContext, 1).Return([]app.User{user}, nil)
+	network.EXPECT().DialRandomRpc().Return(nil, fmt.Errorf("injected error"))
+	client.EXPECT().Close()
+
+	controller, err := NewAppController(application, shaper.NewConstantShaper(100), &driver.ApplicationConfig{
+		Users: 1,
+		ClosedLoop: &driver.ClosedLoopConfig{
+			InFlight: 1,
+			Timeout:  time.Hour,
+		},
+		Fees: &app.FeeOptions{Strategy: "escalating", ReplaceAfter: time.Hour},
+	}, appContext, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := controller.Run(context.Background()); err == nil {
+		t.Fatal("expected run to fail")
+	}
+	select {
+	case <-controller.network.stop:
+	default:
+		t.Errorf("replacement of transactions was not stopped")
+	}
+}
+
+// fixedArrivals is a shaper producing messages at fixed offsets after its start.
+type fixedArrivals struct {
+	shaper.Shaper
+	start   time.Time
+	offsets []time.Duration
+}
+
+func (s *fixedArrivals) Start(start time.Time, _ shaper.LoadInfoSource) {
+	s.start = start
+}
+
+func (s *fixedArrivals) NextArrival() time.Time {
+	if len(s.offsets) == 0 {
+		return s.start.Add(time.Hour)
+	}
+	next := s.offsets[0]
+	s.offsets = s.offsets[1:]
+	return s.start.Add(next)
+}
+
+func TestAppController_MessagesAreTriggeredAtSampledArrivals(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	client := rpc.NewMockClient(mockCtrl)
+	appContext := app.NewMockAppContext(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	application := app.NewMockApplication(mockCtrl)
+
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+	application.EXPECT().CreateUsers(appContext, 1).Return([]app.User{user}, nil)
+	client.EXPECT().Close()
+
+	// a burst of two messages followed by a single message, but no more
+	var sent []time.Time
+	user.EXPECT().GenerateTx().DoAndReturn(func() (*types.Transaction, error) {
+		sent = append(sent, time.Now())
+		return types.NewTx(&types.LegacyTx{}), nil
+	}).Times(3)
+	network.EXPECT().SendTransaction(gomock.Any()).Times(3)
+
+	arrivals := &fixedArrivals{offsets: []time.Duration{20 * time.Millisecond, 20 * time.Millisecond, 60 * time.Millisecond}}
+	controller, err := NewAppController(application, arrivals, &driver.ApplicationConfig{Users: 1}, appContext, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := controller.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 3 {
+		t.Fatalf("unexpected number of messages, got %d", len(sent))
+	}
+	if sent[0].Sub(start) < 20*time.Millisecond || sent[2].Sub(start) < 60*time.Millisecond {
+		t.Errorf("messages were sent before their arrival, at %v, %v", sent[0].Sub(start), sent[2].Sub(start))
+	}
+}
+
+func TestAppController_FeedbackControlledLoadSamplesInclusionLatencies(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	client := rpc.NewMockClient(mockCtrl)
+	receipts := rpc.NewMockClient(mockCtrl)
+	appContext := app.NewMockAppContext(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	application := app.NewMockApplication(mockCtrl)
+
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+	application.EXPECT().CreateUsers(appContext, 1).Return([]app.User{user}, nil)
+	network.EXPECT().DialRandomRpc().Return(receipts, nil)
+	client.EXPECT().Close()
+	receipts.EXPECT().Close()
+
+	user.EXPECT().GenerateTx().Return(types.NewTx(&types.LegacyTx{}), nil).MinTimes(1)
+	network.EXPECT().SendTransaction(gomock.Any()).MinTimes(1)
+	receipts.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(&types.Receipt{}, nil).MinTimes(1)
+
+	pid := shaper.NewLatencyTargetShaper(time.Second, 0.95, shaper.PidOptions{InitialRate: 100, Period: time.Hour})
+	controller, err := NewAppController(application, pid, &driver.ApplicationConfig{Users: 1}, appContext, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := controller.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := controller.GetInclusionLatency(0.95); err != nil {
+		t.Errorf("no inclusion latencies were sampled: %v", err)
+	}
+	if rate, ok := controller.GetSustainableRate(); !ok || math.Abs(rate-100) > 1e-9 {
+		t.Errorf("unexpected sustainable rate, wanted 100, got %f, %t", rate, ok)
+	}
+}
+
+func TestAppController_NestedFeedbackControlledLoadSamplesInclusionLatencies(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	user := app.NewMockUser(mockCtrl)
+	client := rpc.NewMockClient(mockCtrl)
+	receipts := rpc.NewMockClient(mockCtrl)
+	appContext := app.NewMockAppContext(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	application := app.NewMockApplication(mockCtrl)
+
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+	application.EXPECT().CreateUsers(appContext, 1).Return([]app.User{user}, nil)
+	network.EXPECT().DialRandomRpc().Return(receipts, nil)
+	client.EXPECT().Close()
+	receipts.EXPECT().Close()
+
+	user.EXPECT().GenerateTx().Return(types.NewTx(&types.LegacyTx{}), nil).MinTimes(1)
+	network.EXPECT().SendTransaction(gomock.Any()).MinTimes(1)
+	receipts.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(&types.Receipt{}, nil).MinTimes(1)
+
+	pid := shaper.NewLatencyTargetShaper(time.Second, 0.95, shaper.PidOptions{InitialRate: 100, Period: time.Hour})
+	sum := shaper.NewSumShaper(shaper.NewConstantShaper(0), pid)
+	controller, err := NewAppController(application, sum, &driver.ApplicationConfig{Users: 1}, appContext, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := controller.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := controller.GetInclusionLatency(0.95); err != nil {
+		t.Errorf("no inclusion latencies were sampled: %v", err)
+	}
+}
+
+// accountHolder is a user sending transactions from a single account.
+type accountHolder struct {
+	*app.MockUser
+	account *app.Account
+}
+
+func (u *accountHolder) GetAccounts() []*app.Account {
+	return []*app.Account{u.account}
+}
+
+func TestAppController_NoncesOfUsersAreRecoveredPeriodically(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	account, err := app.NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &accountHolder{MockUser: app.NewMockUser(mockCtrl), account: account}
+	client := rpc.NewMockClient(mockCtrl)
+	recoveryClient := rpc.NewMockClient(mockCtrl)
+	appContext := app.NewMockAppContext(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+	application := app.NewMockApplication(mockCtrl)
+
+	appContext.EXPECT().GetClient().Return(client).AnyTimes()
+	application.EXPECT().CreateUsers(appContext, 1).Return([]app.User{user}, nil)
+	client.EXPECT().Close()
+
+	// the network is ahead of the local nonce of the account
+	network.EXPECT().DialRandomRpc().Return(recoveryClient, nil)
+	recoveryClient.EXPECT().NonceAt(gomock.Any(), gomock.Any(), nil).Return(uint64(3), nil).MinTimes(1)
+	recoveryClient.EXPECT().Close()
+
+	controller, err := NewAppController(application, shaper.NewConstantShaper(0), &driver.ApplicationConfig{
+		Users:         1,
+		NonceRecovery: &driver.NonceRecoveryConfig{Period: 10 * time.Millisecond},
+	}, appContext, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := controller.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := controller.GetNonceStats(0)
+	if err != nil {
+		t.Fatalf("failed to get nonce stats: %v", err)
+	}
+	if stats.Resynced != 1 || stats.Stuck != 0 {
+		t.Errorf("unexpected nonce stats, got %v", stats)
+	}
+}
+
+func TestAppController_ConcurrentQueriesShareSingleReconnect(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	failing := rpc.NewMockClient(mockCtrl)
+	working := rpc.NewMockClient(mockCtrl)
+	network := driver.NewMockNetwork(mockCtrl)
+
+	// all queries fail on the first client, which is replaced only once
+	network.EXPECT().DialRandomRpc().Return(working, nil)
+	failing.EXPECT().Close()
+
+	controller := &AppController{
+		network:   newTrackingNetwork(network),
+		rpcClient: failing,
+	}
+
+	const numQueries = 10
+	var wg sync.WaitGroup
+	for i := 0; i < numQueries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := controller.fetchWithRetry(func(client rpc.Client) (uint64, error) {
+				if client == failing {
+					return 0, fmt.Errorf("injected error")
+				}
+				return 42, nil
+			})
+			if err != nil || res != 42 {
+				t.Errorf("unexpected result of query, wanted 42, got %d, %v", res, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// validatorNetwork is a network connecting to its validators.
+type validatorNetwork struct {
+	*driver.MockNetwork
+	validator rpc.Client
+}
+
+func (n *validatorNetwork) DialRandomValidatorRpc() (rpc.Client, error) {
+	return n.validator, nil
+}
+
+func expectTxPoolStatus(client *rpc.MockClient) {
+	client.EXPECT().Call(gomock.Any(), "txpool_status").DoAndReturn(func(result any, _ string, _ ...any) error {
+		return json.Unmarshal([]byte(`{"pending":"0x2","queued":"0x3"}`), result)
+	})
+}
+
+func TestAppController_TxPoolSizeIsQueriedFromValidators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	shared := rpc.NewMockClient(ctrl)
+	validator := rpc.NewMockClient(ctrl)
+	expectTxPoolStatus(validator)
+	validator.EXPECT().Close()
+
+	controller := &AppController{
+		network: newTrackingNetwork(&validatorNetwork{
+			MockNetwork: driver.NewMockNetwork(ctrl),
+			validator:   validator,
+		}),
+		rpcClient: shared,
+	}
+	if got, err := controller.GetTxPoolSize(); err != nil || got != 5 {
+		t.Errorf("unexpected txpool size, wanted 5, got %d, err %v", got, err)
+	}
+}
+
+func TestAppController_TxPoolSizeIsQueriedFromSharedClientWithoutValidators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	shared := rpc.NewMockClient(ctrl)
+	expectTxPoolStatus(shared)
+
+	controller := &AppController{
+		network:   newTrackingNetwork(driver.NewMockNetwork(ctrl)),
+		rpcClient: shared,
+	}
+	if got, err := controller.GetTxPoolSize(); err != nil || got != 5 {
+		t.Errorf("unexpected txpool size, wanted 5, got %d, err %v", got, err)
+	}
+}
+
+func TestAppController_SustainableRateOfNestedFeedbackLoopIsReported(t *testing.T) {
+	target := shaper.NewLatencyTargetShaper(time.Second, 0.95, shaper.PidOptions{InitialRate: 20})
+	warmUp := shaper.Phase{Shaper: shaper.NewConstantShaper(10), Duration: time.Minute}
+
+	tests := map[string]struct {
+		shaper shaper.Shaper
+		found  bool
+	}{
+		"no target":          {shaper.NewConstantShaper(10), false},
+		"target":             {target, true},
+		"target in sequence": {shaper.NewSequenceShaper(warmUp, shaper.Phase{Shaper: target}), true},
+		"target in sum":      {shaper.NewSumShaper(shaper.NewConstantShaper(10), target), true},
+		"multiple targets":   {shaper.NewSumShaper(target, target), false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			controller := &AppController{shaper: test.shaper}
+			rate, found := controller.GetSustainableRate()
+			if found != test.found {
+				t.Fatalf("unexpected availability of sustainable rate, wanted %t, got %t", test.found, found)
+			}
+			if found && math.Abs(rate-20) > 1e-9 {
+				t.Errorf("unexpected sustainable rate, wanted 20, got %f", rate)
+			}
+		})
+	}
+}
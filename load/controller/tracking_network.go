@@ -0,0 +1,503 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/big"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/0xsoniclabs/hyperion/load/app"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// maxSampledTransactions is the maximum number of transactions sampled
+	// for their effective gas price between two samples, and the maximum
+	// number of earlier sampled transactions still waiting for their receipts.
+	maxSampledTransactions = 64
+	// maxSampleAge is the time after which a sampled transaction without a
+	// receipt is no longer considered.
+	maxSampleAge = 10 * time.Second
+
+	// maxLatencySamples is the maximum number of sent transactions waiting
+	// for their receipts to measure their inclusion latency. Free slots are
+	// filled at each poll from a sample of the transactions sent since the
+	// previous poll.
+	maxLatencySamples = 32
+	// latencyPollPeriod is the time between two polls of the receipts of
+	// transactions sampled for their inclusion latency.
+	latencyPollPeriod = 100 * time.Millisecond
+	// latencyWindow is the time span of recent observations considered for
+	// the percentiles of the inclusion latency. Transactions pending for
+	// longer are considered with their current age.
+	latencyWindow = 5 * time.Second
+)
+
+// trackingNetwork is a decorator of a network keeping track of the
+// transactions sent through it. It counts send errors, samples transactions
+// for their effective gas price, and replaces transactions of accounts with
+// escalating fees. Errors can only be observed if the decorated network
+// implements driver.SendReporter, otherwise none are counted.
+type trackingNetwork struct {
+	driver.Network
+	errors    *rpc.SendErrorCounter
+	gasPrices *gasPriceSampler
+	latencies *latencySampler
+
+	escalation app.FeeEscalation               // < nil if transactions are not replaced
+	accounts   map[common.Address]*app.Account // < accounts of replaced transactions
+	stop       chan struct{}                   // < closed to cancel pending replacements
+	stopOnce   sync.Once                       // < closes stop only once
+	pending    sync.WaitGroup                  // < the goroutine sending replacements
+
+	replacementsMutex sync.Mutex
+	replacements      []*replacement // < scheduled replacements, ordered by due time
+	scheduled         chan struct{}  // < signals newly scheduled replacements
+}
+
+// replacement is a transaction to be replaced by a copy with raised fees
+// once it is due, unless it was included in the meantime.
+type replacement struct {
+	tx      *types.Transaction
+	sender  common.Address
+	account *app.Account
+	due     time.Time
+	left    int // < number of remaining replacements, including this one
+}
+
+func newTrackingNetwork(network driver.Network) *trackingNetwork {
+	return &trackingNetwork{
+		Network:   network,
+		errors:    &rpc.SendErrorCounter{},
+		gasPrices: &gasPriceSampler{},
+		latencies: &latencySampler{},
+		stop:      make(chan struct{}),
+		scheduled: make(chan struct{}, 1),
+	}
+}
+
+// escalateFees enables the replacement of transactions sent from the given
+// accounts according to the schedule of the given escalation. It must be
+// called before any transaction is sent.
+func (n *trackingNetwork) escalateFees(escalation app.FeeEscalation, accounts []*app.Account) {
+	n.escalation = escalation
+	n.accounts = make(map[common.Address]*app.Account, len(accounts))
+	for _, account := range accounts {
+		n.accounts[account.GetAddress()] = account
+	}
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		n.runReplacements()
+	}()
+}
+
+func (n *trackingNetwork) SendTransaction(tx *types.Transaction) {
+	n.send(tx)
+	n.gasPrices.add(tx)
+	n.latencies.add(tx)
+	if n.escalation != nil {
+		n.scheduleReplacements(tx)
+	}
+}
+
+func (n *trackingNetwork) send(tx *types.Transaction) {
+	reporter, ok := n.Network.(driver.SendReporter)
+	if !ok {
+		n.Network.SendTransaction(tx)
+		return
+	}
+	reporter.SendTransactionAndReport(tx, func(err error) {
+		if err != nil {
+			n.errors.Count(err)
+		}
+	})
+}
+
+// scheduleReplacements schedules the replacement of the given transaction by
+// copies with raised fees, which are sent by runReplacements.
+func (n *trackingNetwork) scheduleReplacements(tx *types.Transaction) {
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return
+	}
+	account, found := n.accounts[sender]
+	if !found {
+		return
+	}
+	count, delay, _ := n.escalation.GetReplacementSchedule()
+	if count <= 0 {
+		return
+	}
+	n.addReplacement(&replacement{
+		tx:      tx,
+		sender:  sender,
+		account: account,
+		due:     time.Now().Add(delay),
+		left:    count,
+	})
+}
+
+// addReplacement schedules the given replacement. Since all replacements are
+// delayed equally, appending them keeps the schedule ordered by due time.
+func (n *trackingNetwork) addReplacement(r *replacement) {
+	n.replacementsMutex.Lock()
+	n.replacements = append(n.replacements, r)
+	n.replacementsMutex.Unlock()
+	select {
+	case n.scheduled <- struct{}{}:
+	default:
+	}
+}
+
+// nextReplacement returns the replacement due next, nil if there is none.
+func (n *trackingNetwork) nextReplacement() *replacement {
+	n.replacementsMutex.Lock()
+	defer n.replacementsMutex.Unlock()
+	if len(n.replacements) == 0 {
+		return nil
+	}
+	return n.replacements[0]
+}
+
+func (n *trackingNetwork) removeNextReplacement() {
+	n.replacementsMutex.Lock()
+	defer n.replacementsMutex.Unlock()
+	n.replacements[0] = nil
+	n.replacements = n.replacements[1:]
+}
+
+// runReplacements sends the scheduled replacements when they are due, until
+// the network is closed. Before each replacement, the nonce of the sender on
+// the network is checked, and transactions that got included are no longer
+// replaced.
+func (n *trackingNetwork) runReplacements() {
+	_, delay, bump := n.escalation.GetReplacementSchedule()
+	confirmed := map[common.Address]uint64{} // < confirmed nonces observed so far
+	var client rpc.Client
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+	}()
+	for {
+		next := n.nextReplacement()
+		if next == nil {
+			select {
+			case <-n.stop:
+				return
+			case <-n.scheduled:
+				continue
+			}
+		}
+		select {
+		case <-n.stop:
+			return
+		case <-time.After(time.Until(next.due)):
+		}
+		n.removeNextReplacement()
+
+		// nonces of accounts only grow, such that the network is only asked if
+		// the transaction was not known to be included before
+		if next.tx.Nonce() >= confirmed[next.sender] {
+			if client == nil {
+				var err error
+				client, err = n.Network.DialRandomRpc()
+				if err != nil {
+					log.Printf("failed to dial random RPC for replacing transactions; %v", err)
+				}
+			}
+			if client != nil {
+				nonce, err := client.NonceAt(context.Background(), next.sender, nil)
+				if err != nil {
+					log.Printf("failed to get nonce of account; %v", err)
+					client.Close()
+					client = nil
+				} else if nonce > confirmed[next.sender] {
+					confirmed[next.sender] = nonce
+				}
+			}
+		}
+		if next.tx.Nonce() < confirmed[next.sender] {
+			continue // < the transaction or one of its replacements got included
+		}
+
+		tx, err := next.account.ReplaceTransaction(next.tx, bump)
+		if err != nil {
+			log.Printf("failed to replace transaction; %v", err)
+			continue
+		}
+		n.sendReplacement(tx)
+		n.gasPrices.add(tx)
+		if next.left > 1 {
+			n.addReplacement(&replacement{
+				tx:      tx,
+				sender:  next.sender,
+				account: next.account,
+				due:     time.Now().Add(delay),
+				left:    next.left - 1,
+			})
+		}
+	}
+}
+
+// sendReplacement sends the given replacement like send, except that nonce
+// errors are not counted. They are expected for replacements of transactions
+// included after the last check of the nonce of their sender.
+func (n *trackingNetwork) sendReplacement(tx *types.Transaction) {
+	reporter, ok := n.Network.(driver.SendReporter)
+	if !ok {
+		n.Network.SendTransaction(tx)
+		return
+	}
+	reporter.SendTransactionAndReport(tx, func(err error) {
+		if err != nil && rpc.ClassifySendError(err) != rpc.NonceError {
+			n.errors.Count(err)
+		}
+	})
+}
+
+// close cancels all pending replacements. After closing, no more
+// transactions may be sent through the network. It may be called more than
+// once.
+func (n *trackingNetwork) close() {
+	n.stopOnce.Do(func() { close(n.stop) })
+	n.pending.Wait()
+}
+
+// gasPriceSampler keeps track of a sample of sent transactions to determine
+// the effective gas price paid by them from their receipts.
+type gasPriceSampler struct {
+	mutex   sync.Mutex
+	sent    reservoir            // < transactions sent since the last sample
+	pending []sampledTransaction // < sampled earlier, waiting for their receipts
+	last    uint64
+}
+
+type sampledTransaction struct {
+	hash common.Hash
+	sent time.Time
+}
+
+// reservoir is a uniform random sample of bounded size of the transactions
+// added since it was last drained, such that transactions sent at any time
+// between two drains are equally likely to be sampled.
+type reservoir struct {
+	samples []sampledTransaction
+	seen    int
+}
+
+func (r *reservoir) add(tx sampledTransaction, size int) {
+	r.seen++
+	if len(r.samples) < size {
+		r.samples = append(r.samples, tx)
+	} else if i := rand.Intn(r.seen); i < size {
+		r.samples[i] = tx
+	}
+}
+
+// drain returns the sampled transactions in random order and resets the
+// reservoir.
+func (r *reservoir) drain() []sampledTransaction {
+	res := r.samples
+	rand.Shuffle(len(res), func(i, j int) { res[i], res[j] = res[j], res[i] })
+	r.samples, r.seen = nil, 0
+	return res
+}
+
+func (s *gasPriceSampler) add(tx *types.Transaction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sent.add(sampledTransaction{hash: tx.Hash(), sent: time.Now()}, maxSampledTransactions)
+}
+
+// sample fetches the receipts of the sampled transactions and returns the
+// average effective gas price paid by those processed since the last call.
+// If none were processed, the result of the previous call is returned.
+// Transactions without a receipt are retained until they expire.
+func (s *gasPriceSampler) sample(client rpc.Client) (uint64, error) {
+	s.mutex.Lock()
+	pending := append(s.pending, s.sent.drain()...)
+	s.pending = nil
+	s.mutex.Unlock()
+
+	sum, count := new(big.Int), int64(0)
+	retained := []sampledTransaction{}
+	for i, tx := range pending {
+		receipt, err := client.TransactionReceipt(context.Background(), tx.hash)
+		if errors.Is(err, ethereum.NotFound) {
+			if time.Since(tx.sent) < maxSampleAge {
+				retained = append(retained, tx)
+			}
+			continue
+		}
+		if err != nil {
+			s.retain(append(retained, pending[i:]...))
+			return 0, err
+		}
+		if receipt.EffectiveGasPrice != nil {
+			sum.Add(sum, receipt.EffectiveGasPrice)
+			count++
+		}
+	}
+	s.retain(retained)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if count > 0 {
+		s.last = sum.Div(sum, big.NewInt(count)).Uint64()
+	}
+	return s.last, nil
+}
+
+// retain re-adds transactions still waiting for their receipts, keeping at
+// most maxSampledTransactions of them.
+func (s *gasPriceSampler) retain(txs []sampledTransaction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending = append(txs, s.pending...)
+	if len(s.pending) > maxSampledTransactions {
+		s.pending = s.pending[:maxSampledTransactions]
+	}
+}
+
+// latencySampler measures the inclusion latency of a sample of the sent
+// transactions, which is the time between sending a transaction and the first
+// observation of its receipt. Transactions are only sampled while enabled.
+type latencySampler struct {
+	mutex    sync.Mutex
+	enabled  bool
+	sent     reservoir            // < transactions sent since the last poll
+	pending  []sampledTransaction // < transactions polled for their receipts
+	observed []latencyObservation
+}
+
+type latencyObservation struct {
+	time    time.Time
+	latency time.Duration
+}
+
+func (s *latencySampler) add(tx *types.Transaction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.enabled {
+		s.sent.add(sampledTransaction{hash: tx.Hash(), sent: time.Now()}, maxLatencySamples)
+	}
+}
+
+// run enables the sampling and polls the receipts of the sampled
+// transactions using the given client until the stop channel is closed.
+func (s *latencySampler) run(client rpc.Client, stop <-chan struct{}) {
+	s.mutex.Lock()
+	s.enabled = true
+	s.mutex.Unlock()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(latencyPollPeriod):
+		}
+		if err := s.poll(client); err != nil {
+			log.Printf("failed to poll receipts for inclusion latency; %v", err)
+		}
+	}
+}
+
+// poll fetches the receipts of the sampled transactions once, recording the
+// latency of those processed. Before, free slots of the polled transactions
+// are filled with transactions sampled since the previous poll.
+func (s *latencySampler) poll(client rpc.Client) error {
+	s.mutex.Lock()
+	sent := s.sent.drain()
+	free := max(0, maxLatencySamples-len(s.pending))
+	s.pending = append(s.pending, sent[:min(free, len(sent))]...)
+	pending := s.pending
+	s.mutex.Unlock()
+
+	included := map[common.Hash]time.Time{}
+	var err error
+	for _, tx := range pending {
+		_, err = client.TransactionReceipt(context.Background(), tx.hash)
+		if errors.Is(err, ethereum.NotFound) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			break
+		}
+		included[tx.hash] = time.Now()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	retained := s.pending[:0]
+	for _, tx := range s.pending {
+		if at, found := included[tx.hash]; found {
+			s.observed = append(s.observed, latencyObservation{time: at, latency: at.Sub(tx.sent)})
+		} else if now.Sub(tx.sent) < maxSampleAge {
+			retained = append(retained, tx)
+		} else {
+			// transactions not included in time are recorded with their age
+			s.observed = append(s.observed, latencyObservation{time: now, latency: now.Sub(tx.sent)})
+		}
+	}
+	s.pending = retained
+	for len(s.observed) > 0 && now.Sub(s.observed[0].time) > latencyWindow {
+		s.observed = s.observed[1:]
+	}
+	return err
+}
+
+// percentile returns the given percentile, in (0,1], of the latencies
+// observed recently, including the ages of transactions pending for longer
+// than the observation window.
+func (s *latencySampler) percentile(p float64) (time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	latencies := make([]time.Duration, 0, len(s.observed)+len(s.pending))
+	for _, observation := range s.observed {
+		if now.Sub(observation.time) <= latencyWindow {
+			latencies = append(latencies, observation.latency)
+		}
+	}
+	for _, tx := range s.pending {
+		if age := now.Sub(tx.sent); age > latencyWindow {
+			latencies = append(latencies, age)
+		}
+	}
+	if len(latencies) == 0 {
+		return 0, errNoLatencySamples
+	}
+	slices.Sort(latencies)
+	index := int(math.Ceil(p*float64(len(latencies)))) - 1
+	return latencies[max(0, min(index, len(latencies)-1))], nil
+}
+
+// errNoLatencySamples is reported if no inclusion latencies were observed recently.
+var errNoLatencySamples = errors.New("no inclusion latencies observed recently")
@@ -0,0 +1,374 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+package controller
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver"
+	"github.com/0xsoniclabs/hyperion/driver/rpc"
+	"github.com/0xsoniclabs/hyperion/load/app"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTrackingNetwork_SendErrorsAreCountedForReportingNetworks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	network := &reportingNetwork{
+		MockNetwork:      driver.NewMockNetwork(mockCtrl),
+		MockSendReporter: driver.NewMockSendReporter(mockCtrl),
+	}
+	network.MockSendReporter.EXPECT().SendTransactionAndReport(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *types.Transaction, report func(error)) {
+			report(errors.New("nonce too low"))
+		}).Times(2)
+	network.MockSendReporter.EXPECT().SendTransactionAndReport(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *types.Transaction, report func(error)) {
+			report(nil)
+		})
+
+	controller := &AppController{network: newTrackingNetwork(network)}
+	for i := 0; i < 3; i++ {
+		controller.network.SendTransaction(types.NewTx(&types.LegacyTx{Nonce: uint64(i)}))
+	}
+
+	want := rpc.SendErrors{}
+	want[rpc.NonceError] = 2
+	if got := controller.GetSendErrors(); got != want {
+		t.Errorf("unexpected send errors, wanted %v, got %v", want, got)
+	}
+}
+
+func TestTrackingNetwork_SendErrorsAreNotCountedForOtherNetworks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	network := driver.NewMockNetwork(mockCtrl)
+	network.EXPECT().SendTransaction(gomock.Any())
+
+	tracking := newTrackingNetwork(network)
+	tracking.SendTransaction(types.NewTx(&types.LegacyTx{}))
+	if got := tracking.errors.Get(); got.Total() != 0 {
+		t.Errorf("unexpected send errors %v", got)
+	}
+}
+
+func TestTrackingNetwork_TransactionsOfEscalatingAccountsAreReplaced(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	network := driver.NewMockNetwork(mockCtrl)
+	account, err := app.NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees, err := app.NewFeeStrategy(app.FeeOptions{
+		Strategy:     "escalating",
+		FeeCap:       big.NewInt(100),
+		Tip:          big.NewInt(10),
+		Replacements: 2,
+		ReplaceAfter: 10 * time.Millisecond,
+		Bump:         10,
+	}, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.SetFeeStrategy(fees)
+
+	sent := make(chan *types.Transaction, 10)
+	network.EXPECT().SendTransaction(gomock.Any()).Do(func(tx *types.Transaction) {
+		sent <- tx
+	}).Times(3)
+
+	client := rpc.NewMockClient(mockCtrl)
+	network.EXPECT().DialRandomRpc().Return(client, nil)
+	client.EXPECT().NonceAt(gomock.Any(), account.GetAddress(), nil).Return(uint64(7), nil).Times(2)
+	client.EXPECT().Close()
+
+	tracking := newTrackingNetwork(network)
+	tracking.escalateFees(fees.(app.FeeEscalation), []*app.Account{account})
+	original, err := account.ReplaceTransaction(types.NewTx(&types.DynamicFeeTx{
+		Nonce: 7, GasFeeCap: big.NewInt(100), GasTipCap: big.NewInt(10), Gas: 21_000, To: &common.Address{1},
+	}), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracking.SendTransaction(original)
+
+	wantFees := [][2]int64{{100, 10}, {110, 11}, {121, 13}}
+	for i, want := range wantFees {
+		select {
+		case tx := <-sent:
+			if tx.Nonce() != 7 || tx.GasFeeCap().Int64() != want[0] || tx.GasTipCap().Int64() != want[1] {
+				t.Errorf("unexpected transaction %d, wanted nonce 7 and fees %v, got nonce %d, fees %v/%v",
+					i, want, tx.Nonce(), tx.GasFeeCap(), tx.GasTipCap())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("transaction %d was not sent", i)
+		}
+	}
+	tracking.close()
+}
+
+func TestTrackingNetwork_PendingReplacementsAreCanceledOnClose(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	network := driver.NewMockNetwork(mockCtrl)
+	network.EXPECT().SendTransaction(gomock.Any()).Times(1)
+	account, err := app.NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees, err := app.NewFeeStrategy(app.FeeOptions{Strategy: "escalating", ReplaceAfter: time.Hour}, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracking := newTrackingNetwork(network)
+	tracking.escalateFees(fees.(app.FeeEscalation), []*app.Account{account})
+	tx, err := account.ReplaceTransaction(types.NewTx(&types.DynamicFeeTx{GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracking.SendTransaction(tx)
+	tracking.close()
+}
+
+func TestTrackingNetwork_IncludedTransactionsAreNoLongerReplaced(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	network := driver.NewMockNetwork(mockCtrl)
+	account, err := app.NewAccount(0, "163f5f0f9a621d72fedd85ffca3d08d131ab4e812181e0d30ffd1c885d20aac7", nil, 0xfa3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees, err := app.NewFeeStrategy(app.FeeOptions{
+		Strategy:     "escalating",
+		Replacements: 3,
+		ReplaceAfter: 10 * time.Millisecond,
+	}, network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.SetFeeStrategy(fees)
+
+	// the first transaction gets replaced once before it is included, while
+	// the second one is known to be included without asking the network
+	sent := make(chan *types.Transaction, 10)
+	network.EXPECT().SendTransaction(gomock.Any()).Do(func(tx *types.Transaction) {
+		sent <- tx
+	}).Times(3)
+	client := rpc.NewMockClient(mockCtrl)
+	network.EXPECT().DialRandomRpc().Return(client, nil)
+	gomock.InOrder(
+		client.EXPECT().NonceAt(gomock.Any(), account.GetAddress(), nil).Return(uint64(0), nil),
+		client.EXPECT().NonceAt(gomock.Any(), account.GetAddress(), nil).Return(uint64(2), nil),
+	)
+	client.EXPECT().Close()
+
+	tracking := newTrackingNetwork(network)
+	tracking.escalateFees(fees.(app.FeeEscalation), []*app.Account{account})
+	for nonce := range uint64(2) {
+		tx, err := account.ReplaceTransaction(types.NewTx(&types.DynamicFeeTx{
+			Nonce: nonce, GasFeeCap: big.NewInt(100), GasTipCap: big.NewInt(10), Gas: 21_000, To: &common.Address{1},
+		}), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tracking.SendTransaction(tx)
+	}
+
+	for i := range 3 {
+		select {
+		case <-sent:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("transaction %d was not sent", i)
+		}
+	}
+	time.Sleep(100 * time.Millisecond) // < time for further, unexpected replacements
+	tracking.close()
+}
+
+func TestTrackingNetwork_NonceErrorsOfReplacementsAreNotCounted(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	network := &reportingNetwork{
+		MockNetwork:      driver.NewMockNetwork(mockCtrl),
+		MockSendReporter: driver.NewMockSendReporter(mockCtrl),
+	}
+	network.MockSendReporter.EXPECT().SendTransactionAndReport(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *types.Transaction, report func(error)) {
+			report(errors.New("nonce too low"))
+		}).Times(2)
+	network.MockSendReporter.EXPECT().SendTransactionAndReport(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *types.Transaction, report func(error)) {
+			report(errors.New("txpool is full"))
+		})
+
+	tracking := newTrackingNetwork(network)
+	tracking.sendReplacement(types.NewTx(&types.LegacyTx{}))
+	tracking.send(types.NewTx(&types.LegacyTx{}))
+	tracking.sendReplacement(types.NewTx(&types.LegacyTx{}))
+
+	want := rpc.SendErrors{}
+	want[rpc.NonceError] = 1
+	want[rpc.PoolFullError] = 1
+	if got := tracking.errors.Get(); got != want {
+		t.Errorf("unexpected send errors, wanted %v, got %v", want, got)
+	}
+}
+
+func TestGasPriceSampler_AveragesEffectiveGasPricesOfReceipts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	client := rpc.NewMockClient(mockCtrl)
+
+	sampler := &gasPriceSampler{}
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1}),
+		types.NewTx(&types.LegacyTx{Nonce: 2}),
+		types.NewTx(&types.LegacyTx{Nonce: 3}),
+	}
+	for _, tx := range txs {
+		sampler.add(tx)
+	}
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[0].Hash()).Return(&types.Receipt{EffectiveGasPrice: big.NewInt(100)}, nil)
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[1].Hash()).Return(&types.Receipt{EffectiveGasPrice: big.NewInt(200)}, nil)
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[2].Hash()).Return(nil, ethereum.NotFound)
+
+	if got, err := sampler.sample(client); err != nil || got != 150 {
+		t.Errorf("unexpected gas price, wanted 150, got %d, err %v", got, err)
+	}
+
+	// the pending transaction is retained, without new receipts the previous
+	// value is reported
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[2].Hash()).Return(nil, ethereum.NotFound)
+	if got, err := sampler.sample(client); err != nil || got != 150 {
+		t.Errorf("unexpected gas price, wanted 150, got %d, err %v", got, err)
+	}
+
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[2].Hash()).Return(&types.Receipt{EffectiveGasPrice: big.NewInt(400)}, nil)
+	if got, err := sampler.sample(client); err != nil || got != 400 {
+		t.Errorf("unexpected gas price, wanted 400, got %d, err %v", got, err)
+	}
+}
+
+func TestLatencySampler_PercentilesOfInclusionLatenciesAreReported(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	client := rpc.NewMockClient(mockCtrl)
+
+	sampler := &latencySampler{}
+	if _, err := sampler.percentile(0.95); err == nil {
+		t.Errorf("missing latencies were not reported")
+	}
+	sampler.add(types.NewTx(&types.LegacyTx{Nonce: 0}))
+	if len(sampler.sent.samples) != 0 {
+		t.Errorf("transactions were sampled while disabled")
+	}
+
+	sampler.enabled = true
+	now := time.Now()
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1}),
+		types.NewTx(&types.LegacyTx{Nonce: 2}),
+		types.NewTx(&types.LegacyTx{Nonce: 3}),
+	}
+	for i, tx := range txs {
+		sampler.pending = append(sampler.pending, sampledTransaction{hash: tx.Hash(), sent: now.Add(-time.Duration(i+1) * time.Second)})
+	}
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[0].Hash()).Return(&types.Receipt{}, nil)
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[1].Hash()).Return(&types.Receipt{}, nil)
+	client.EXPECT().TransactionReceipt(gomock.Any(), txs[2].Hash()).Return(nil, ethereum.NotFound)
+	if err := sampler.poll(client); err != nil {
+		t.Fatalf("failed to poll receipts: %v", err)
+	}
+	if len(sampler.pending) != 1 {
+		t.Errorf("pending transaction was not retained")
+	}
+
+	median, err := sampler.percentile(0.5)
+	if err != nil || median < time.Second || median >= 2*time.Second {
+		t.Errorf("unexpected median latency, wanted ~1s, got %v, err %v", median, err)
+	}
+	maximum, err := sampler.percentile(1)
+	if err != nil || maximum < 2*time.Second || maximum >= 3*time.Second {
+		t.Errorf("unexpected maximum latency, wanted ~2s, got %v, err %v", maximum, err)
+	}
+}
+
+func TestReservoir_TransactionsOfWholeIntervalAreSampledUniformly(t *testing.T) {
+	const size, numTransactions, numRounds = 10, 100, 1000
+	counts := make([]int, numTransactions)
+	for round := 0; round < numRounds; round++ {
+		r := reservoir{}
+		for i := 0; i < numTransactions; i++ {
+			r.add(sampledTransaction{hash: common.Hash{byte(i)}}, size)
+		}
+		samples := r.drain()
+		if len(samples) != size {
+			t.Fatalf("unexpected number of samples, wanted %d, got %d", size, len(samples))
+		}
+		for _, sample := range samples {
+			counts[sample.hash[0]]++
+		}
+		if len(r.samples) != 0 || r.seen != 0 {
+			t.Fatalf("reservoir was not reset by draining")
+		}
+	}
+
+	// each transaction is expected to be sampled in 10% of the rounds
+	want := numRounds * size / numTransactions
+	for i, count := range counts {
+		if count < want/2 || count > 2*want {
+			t.Errorf("transaction %d sampled %d times, wanted ~%d", i, count, want)
+		}
+	}
+}
+
+func TestLatencySampler_TransactionsSentLateInPollIntervalAreSampled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	client := rpc.NewMockClient(mockCtrl)
+
+	sampler := &latencySampler{enabled: true}
+	txs := []*types.Transaction{}
+	for i := 0; i < 10*maxLatencySamples; i++ {
+		tx := types.NewTx(&types.LegacyTx{Nonce: uint64(i)})
+		txs = append(txs, tx)
+		sampler.add(tx)
+	}
+	client.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, ethereum.NotFound).Times(maxLatencySamples)
+	if err := sampler.poll(client); err != nil {
+		t.Fatalf("failed to poll receipts: %v", err)
+	}
+	if got := len(sampler.pending); got != maxLatencySamples {
+		t.Fatalf("unexpected number of polled transactions, wanted %d, got %d", maxLatencySamples, got)
+	}
+	late := map[common.Hash]bool{}
+	for _, tx := range txs[maxLatencySamples:] {
+		late[tx.Hash()] = true
+	}
+	numLate := 0
+	for _, tx := range sampler.pending {
+		if late[tx.hash] {
+			numLate++
+		}
+	}
+	if numLate == 0 {
+		t.Errorf("only the first transactions of the poll interval were sampled")
+	}
+}
+
+type reportingNetwork struct {
+	*driver.MockNetwork
+	*driver.MockSendReporter
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ArrivalShaper is an optional extension of Shapers producing messages at
+// randomly sampled instants instead of following a smooth rate. Messages are
+// expected to be produced at the instants returned by NextArrival. Counting
+// messages using GetNumMessagesInInterval consumes the same sequence of
+// arrivals, such that both methods should not be mixed.
+type ArrivalShaper interface {
+	Shaper
+	// NextArrival consumes and returns the instant of the next message. It
+	// may only be called after the shaper got started.
+	NextArrival() time.Time
+}
+
+// never is the offset of arrivals of processes not producing any messages.
+const never = 100 * 365 * 24 * time.Hour
+
+// arrivalSampler produces the offsets of arrivals, relative to the start of
+// an arrival process, in increasing order.
+type arrivalSampler interface {
+	nextOffset() time.Duration
+}
+
+// arrivalShaper produces messages at the instants sampled by an arrivalSampler.
+type arrivalShaper struct {
+	sampler arrivalSampler
+	start   time.Time
+	next    time.Time
+}
+
+func (s *arrivalShaper) Start(start time.Time, _ LoadInfoSource) {
+	s.start = start
+	s.next = start.Add(s.sampler.nextOffset())
+}
+
+func (s *arrivalShaper) NextArrival() time.Time {
+	res := s.next
+	s.next = s.start.Add(s.sampler.nextOffset())
+	return res
+}
+
+// GetNumMessagesInInterval provides the number of messages to be produced
+// in the given time interval, which is the number of arrivals not yet
+// consumed before the end of the interval.
+func (s *arrivalShaper) GetNumMessagesInInterval(start time.Time, duration time.Duration) float64 {
+	end := start.Add(duration)
+	count := 0
+	for s.next.Before(end) {
+		s.NextArrival()
+		count++
+	}
+	return float64(count)
+}
+
+// newRandom creates a source of random numbers using the given seed, or a
+// random seed if nil.
+func newRandom(seed *int64) *rand.Rand {
+	if seed == nil {
+		return rand.New(rand.NewSource(rand.Int63()))
+	}
+	return rand.New(rand.NewSource(*seed))
+}
+
+// toDuration converts seconds into a duration, saturating at never.
+func toDuration(seconds float64) time.Duration {
+	if seconds >= never.Seconds() {
+		return never
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// NewPoissonShaper creates a shaper producing messages following a Poisson
+// process of the given average rate, such that the gaps between messages are
+// exponentially distributed. A nil seed is interpreted as a random seed.
+func NewPoissonShaper(rate float64, seed *int64) ArrivalShaper {
+	return &arrivalShaper{sampler: &poissonSampler{
+		rate:   rate,
+		random: newRandom(seed),
+	}}
+}
+
+type poissonSampler struct {
+	rate   float64
+	random *rand.Rand
+	offset float64 // < seconds since the start of the process
+}
+
+func (s *poissonSampler) nextOffset() time.Duration {
+	if s.rate <= 0 {
+		return never
+	}
+	s.offset += s.random.ExpFloat64() / s.rate
+	return toDuration(s.offset)
+}
+
+// Distribution of the durations of the on and off periods of bursty shapers.
+type Distribution int
+
+const (
+	Exponential Distribution = iota
+	Pareto
+)
+
+// DefaultParetoShape is the shape of Pareto distributions if not specified.
+const DefaultParetoShape = 1.5
+
+// NewBurstyShaper creates a shaper alternating between bursts, in which
+// messages are produced following a Poisson process of the given rate, and
+// pauses without any messages. The durations of bursts and pauses are drawn
+// from the given distribution with the given mean durations. Pareto
+// distributions use the given shape and produce heavy-tailed durations.
+// Shapes <= 1, which have no finite mean, are replaced by DefaultParetoShape.
+// A nil seed is interpreted as a random seed.
+func NewBurstyShaper(rate float64, on, off time.Duration, distribution Distribution, shape float64, seed *int64) ArrivalShaper {
+	if shape <= 1 {
+		shape = DefaultParetoShape
+	}
+	return &arrivalShaper{sampler: &burstySampler{
+		rate:         rate,
+		on:           on.Seconds(),
+		off:          off.Seconds(),
+		distribution: distribution,
+		shape:        shape,
+		random:       newRandom(seed),
+		burstEnd:     -1,
+	}}
+}
+
+type burstySampler struct {
+	rate         float64
+	on, off      float64 // < mean durations in seconds
+	distribution Distribution
+	shape        float64
+	random       *rand.Rand
+
+	offset   float64 // < seconds since the start of the process
+	burstEnd float64 // < end of the current burst, negative before the first burst
+}
+
+func (s *burstySampler) nextOffset() time.Duration {
+	if s.rate <= 0 || s.on <= 0 {
+		return never
+	}
+	if s.burstEnd < 0 {
+		s.burstEnd = s.sampleDuration(s.on)
+	}
+	for {
+		// Arrivals within a burst are memoryless, so the sampling of the
+		// next arrival can be restarted at the beginning of each burst.
+		candidate := s.offset + s.random.ExpFloat64()/s.rate
+		if candidate < s.burstEnd {
+			s.offset = candidate
+			return toDuration(s.offset)
+		}
+		s.offset = s.burstEnd + s.sampleDuration(s.off)
+		s.burstEnd = s.offset + s.sampleDuration(s.on)
+		if s.offset >= never.Seconds() {
+			return never
+		}
+	}
+}
+
+// sampleDuration draws a duration of the given mean in seconds.
+func (s *burstySampler) sampleDuration(mean float64) float64 {
+	if mean <= 0 {
+		return 0
+	}
+	if s.distribution == Pareto {
+		// The mean of a Pareto distribution is scale * shape / (shape - 1).
+		scale := mean * (s.shape - 1) / s.shape
+		return scale / math.Pow(1-s.random.Float64(), 1/s.shape)
+	}
+	return s.random.ExpFloat64() * mean
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/parser"
+)
+
+// countArrivals counts the arrivals of a shaper within the given duration
+// after its start and reports the longest gap between arrivals.
+func countArrivals(shaper ArrivalShaper, duration time.Duration) (int, time.Duration) {
+	start := time.Now()
+	shaper.Start(start, nil)
+	count := 0
+	maxGap := time.Duration(0)
+	last := start
+	for next := shaper.NextArrival(); next.Before(start.Add(duration)); next = shaper.NextArrival() {
+		if next.Before(last) {
+			panic("arrivals are not ordered")
+		}
+		maxGap = max(maxGap, next.Sub(last))
+		last = next
+		count++
+	}
+	return count, maxGap
+}
+
+func TestPoissonShaper_AverageRateIsMatched(t *testing.T) {
+	seed := int64(42)
+	count, maxGap := countArrivals(NewPoissonShaper(100, &seed), 1000*time.Second)
+	if math.Abs(float64(count)-100_000) > 1_000 {
+		t.Errorf("unexpected number of arrivals, wanted about 100000, got %d", count)
+	}
+	// with exponential gaps, some gaps are far longer than the mean of 10ms
+	if maxGap < 50*time.Millisecond {
+		t.Errorf("arrivals are too regular, longest gap is %v", maxGap)
+	}
+}
+
+func TestPoissonShaper_ArrivalsAreReproducibleWithSeed(t *testing.T) {
+	seed := int64(1)
+	a := NewPoissonShaper(10, &seed)
+	b := NewPoissonShaper(10, &seed)
+	start := time.Now()
+	a.Start(start, nil)
+	b.Start(start, nil)
+	for i := 0; i < 100; i++ {
+		if x, y := a.NextArrival(), b.NextArrival(); !x.Equal(y) {
+			t.Fatalf("arrival %d differs, %v vs %v", i, x, y)
+		}
+	}
+}
+
+func TestPoissonShaper_ZeroRateProducesNoArrivals(t *testing.T) {
+	if count, _ := countArrivals(NewPoissonShaper(0, nil), time.Hour); count != 0 {
+		t.Errorf("unexpected number of arrivals, got %d", count)
+	}
+}
+
+func TestBurstyShaper_AverageRateIsMatched(t *testing.T) {
+	for _, distribution := range []Distribution{Exponential, Pareto} {
+		seed := int64(7)
+		// bursts of 1s at 1000 Tx/s, pauses of 3s, giving 250 Tx/s on average
+		shaper := NewBurstyShaper(1000, time.Second, 3*time.Second, distribution, 2.5, &seed)
+		count, maxGap := countArrivals(shaper, 4000*time.Second)
+		if math.Abs(float64(count)-1_000_000) > 100_000 {
+			t.Errorf("unexpected number of arrivals for distribution %d, wanted about 1000000, got %d", distribution, count)
+		}
+		if maxGap < 3*time.Second {
+			t.Errorf("no pauses between bursts for distribution %d, longest gap is %v", distribution, maxGap)
+		}
+	}
+}
+
+func TestArrivalShaper_MessagesInIntervalAreCountedArrivals(t *testing.T) {
+	seed := int64(3)
+	shaper := NewPoissonShaper(100, &seed)
+	start := time.Now()
+	shaper.Start(start, nil)
+	total := 0.0
+	for i := 0; i < 100; i++ {
+		got := shaper.GetNumMessagesInInterval(start.Add(time.Duration(i)*100*time.Millisecond), 100*time.Millisecond)
+		if got != math.Trunc(got) {
+			t.Fatalf("number of messages must be integral, got %f", got)
+		}
+		total += got
+	}
+	if math.Abs(total-1000) > 150 {
+		t.Errorf("unexpected number of messages, wanted about 1000, got %f", total)
+	}
+}
+
+func TestParseRate_ArrivalProcessesAreParsed(t *testing.T) {
+	seed := int64(1)
+	rates := map[string]parser.Rate{
+		"poisson": {Poisson: &parser.Poisson{Rate: 10, Seed: &seed}},
+		"bursty":  {Bursty: &parser.Bursty{Rate: 10, On: 1, Off: 1, Distribution: "pareto", Seed: &seed}},
+	}
+	for name, rate := range rates {
+		t.Run(name, func(t *testing.T) {
+			shaper, err := ParseRate(&rate)
+			if err != nil {
+				t.Fatalf("failed to parse rate: %v", err)
+			}
+			if _, ok := shaper.(ArrivalShaper); !ok {
+				t.Errorf("shaper does not sample arrivals")
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"time"
+)
+
+// SumShaper produces the sum of the traffic of a list of shapers.
+type SumShaper struct {
+	shapers []Shaper
+}
+
+func NewSumShaper(shapers ...Shaper) *SumShaper {
+	return &SumShaper{shapers: shapers}
+}
+
+func (s *SumShaper) Start(start time.Time, info LoadInfoSource) {
+	for _, shaper := range s.shapers {
+		shaper.Start(start, info)
+	}
+}
+
+// GetNumMessagesInInterval provides the number of messages to be produced
+// in the given time interval.
+func (s *SumShaper) GetNumMessagesInInterval(start time.Time, duration time.Duration) float64 {
+	res := 0.0
+	for _, shaper := range s.shapers {
+		res += shaper.GetNumMessagesInInterval(start, duration)
+	}
+	return res
+}
+
+func (s *SumShaper) getParts() []Shaper {
+	return s.shapers
+}
+
+// Phase is a shaper active for a limited duration in a sequence.
+type Phase struct {
+	Shaper   Shaper
+	Duration time.Duration // 0 is interpreted as unlimited
+}
+
+// SequenceShaper runs a list of shapers one after the other, each for the
+// duration of its phase. Each shaper is started at the beginning of its
+// phase. After the last phase, no traffic is produced.
+type SequenceShaper struct {
+	phases []Phase
+	// startTimeStamp is the time when the first phase got started.
+	startTimeStamp time.Time
+}
+
+func NewSequenceShaper(phases ...Phase) *SequenceShaper {
+	return &SequenceShaper{phases: phases}
+}
+
+func (s *SequenceShaper) Start(start time.Time, info LoadInfoSource) {
+	s.startTimeStamp = start
+	for _, phase := range s.phases {
+		phase.Shaper.Start(start, info)
+		if phase.Duration == 0 {
+			break
+		}
+		start = start.Add(phase.Duration)
+	}
+}
+
+// GetNumMessagesInInterval provides the number of messages to be produced
+// in the given time interval. Intervals spanning multiple phases are split
+// at the boundaries of the phases.
+func (s *SequenceShaper) GetNumMessagesInInterval(start time.Time, duration time.Duration) float64 {
+	end := start.Add(duration)
+	res := 0.0
+	phaseStart := s.startTimeStamp
+	for _, phase := range s.phases {
+		from := start
+		if from.Before(phaseStart) {
+			from = phaseStart
+		}
+		to := end
+		if phase.Duration != 0 {
+			if phaseEnd := phaseStart.Add(phase.Duration); to.After(phaseEnd) {
+				to = phaseEnd
+			}
+		}
+		if from.Before(to) {
+			res += phase.Shaper.GetNumMessagesInInterval(from, to.Sub(from))
+		}
+		if phase.Duration == 0 {
+			break
+		}
+		phaseStart = phaseStart.Add(phase.Duration)
+	}
+	return res
+}
+
+func (s *SequenceShaper) getParts() []Shaper {
+	res := make([]Shaper, 0, len(s.phases))
+	for _, phase := range s.phases {
+		res = append(res, phase.Shaper)
+	}
+	return res
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/parser"
+)
+
+func TestSumShaper(t *testing.T) {
+	shaper := NewSumShaper(NewConstantShaper(10), NewSlopeShaper(0, 2))
+	startTime := time.Now()
+	shaper.Start(startTime, nil)
+
+	// 10 * 2 + 2/2 * 2^2
+	got := shaper.GetNumMessagesInInterval(startTime, 2*time.Second)
+	if math.Abs(got-24) > 1e-6 {
+		t.Errorf("expected number of messages 24, got %f", got)
+	}
+}
+
+func TestSequenceShaper(t *testing.T) {
+	tests := []struct {
+		from     time.Duration
+		to       time.Duration
+		expected float64
+	}{
+		// Within the first phase.
+		{0 * time.Second, 1 * time.Second, 10},
+		// Spanning the first two phases, where the slope starts at 0.
+		{1 * time.Second, 3 * time.Second, 10 + 0.5},
+		// Within the second phase.
+		{3 * time.Second, 4 * time.Second, 1.5},
+		// Spanning into the last, unlimited phase.
+		{3 * time.Second, 6 * time.Second, 1.5 + 2.5 + 5},
+		{10 * time.Second, 20 * time.Second, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("from=%v,to=%v", test.from, test.to), func(t *testing.T) {
+			shaper := NewSequenceShaper(
+				Phase{Shaper: NewConstantShaper(10), Duration: 2 * time.Second},
+				Phase{Shaper: NewSlopeShaper(0, 1), Duration: 3 * time.Second},
+				Phase{Shaper: NewConstantShaper(5)},
+			)
+			startTime := time.Now()
+			shaper.Start(startTime, nil)
+
+			got := shaper.GetNumMessagesInInterval(startTime.Add(test.from), test.to-test.from)
+			if math.Abs(got-test.expected) > 1e-6 {
+				t.Errorf("expected number of messages %f, got %f", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestSequenceShaper_NoMessagesAfterLastLimitedPhase(t *testing.T) {
+	shaper := NewSequenceShaper(Phase{Shaper: NewConstantShaper(10), Duration: time.Second})
+	startTime := time.Now()
+	shaper.Start(startTime, nil)
+	if got := shaper.GetNumMessagesInInterval(startTime.Add(time.Second), time.Second); got != 0 {
+		t.Errorf("unexpected number of messages after last phase, got %f", got)
+	}
+}
+
+func TestParseRate_CombinatorsAreParsedRecursively(t *testing.T) {
+	ten := float32(10)
+	one := float32(1)
+	rate := parser.Rate{
+		Sequence: []parser.Phase{
+			{Duration: &one, Rate: parser.Rate{Constant: &ten}},
+			{Rate: parser.Rate{Sum: []parser.Rate{{Constant: &ten}, {Constant: &one}}}},
+		},
+	}
+	shaper, err := ParseRate(&rate)
+	if err != nil {
+		t.Fatalf("failed to parse rate: %v", err)
+	}
+	startTime := time.Now()
+	shaper.Start(startTime, nil)
+	if got := shaper.GetNumMessagesInInterval(startTime, 2*time.Second); math.Abs(got-21) > 1e-6 {
+		t.Errorf("unexpected number of messages, wanted 21, got %f", got)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// FeedbackSource is an optional extension of LoadInfoSources providing live
+// measurements of the network for shapers controlling their rate.
+type FeedbackSource interface {
+	LoadInfoSource
+	// GetInclusionLatency returns the given percentile, in (0,1], of the time
+	// between sending transactions and observing their receipts, over the
+	// recently processed transactions.
+	GetInclusionLatency(percentile float64) (time.Duration, error)
+	// GetTxPoolSize returns the number of transactions in the pool of a node.
+	GetTxPoolSize() (uint64, error)
+}
+
+// RateController is an optional extension of Shapers adjusting their rate by
+// a feedback loop to hold a measurement of the network at a target value.
+type RateController interface {
+	Shaper
+	// GetSustainableRate returns the rate in messages per second the feedback
+	// loop converged to, which is the average rate of recent control periods.
+	GetSustainableRate() float64
+}
+
+// composedShaper is an optional extension of Shapers combining the traffic
+// of other shapers.
+type composedShaper interface {
+	Shaper
+	// getParts returns the shapers the traffic is combined from.
+	getParts() []Shaper
+}
+
+// GetRateControllers lists the rate controllers among the given shaper and
+// the shapers it is composed of.
+func GetRateControllers(shaper Shaper) []RateController {
+	res := []RateController{}
+	if controller, ok := shaper.(RateController); ok {
+		res = append(res, controller)
+	}
+	if composed, ok := shaper.(composedShaper); ok {
+		for _, part := range composed.getParts() {
+			res = append(res, GetRateControllers(part)...)
+		}
+	}
+	return res
+}
+
+// PidOptions configures the feedback loop of a PID shaper. Zero values are
+// interpreted as the respective defaults.
+type PidOptions struct {
+	// Kp, Ki, and Kd are the proportional, integral, and derivative gains,
+	// defaulting to 0.5, 0.3, and 0.
+	Kp, Ki, Kd float64
+	// InitialRate is the rate in messages per second at the start, defaults
+	// to 10.
+	InitialRate float64
+	// MinRate and MaxRate bound the rate, defaulting to 1 and unbounded.
+	MinRate, MaxRate float64
+	// Period is the time between two updates of the rate, defaults to 1s.
+	Period time.Duration
+}
+
+const (
+	// sustainableRateWindow is the number of control periods averaged for
+	// the sustainable rate.
+	sustainableRateWindow = 30
+	// maxControlError bounds the relative control error to limit the change
+	// of the rate within a single period.
+	maxControlError = 1.0
+)
+
+// measurement obtains the value controlled by a PID shaper.
+type measurement func(FeedbackSource) (float64, error)
+
+// NewLatencyTargetShaper creates a shaper adjusting its rate to hold the
+// given percentile of the inclusion latency of transactions at the target.
+func NewLatencyTargetShaper(target time.Duration, percentile float64, options PidOptions) *PidShaper {
+	return newPidShaper(target.Seconds(), func(source FeedbackSource) (float64, error) {
+		latency, err := source.GetInclusionLatency(percentile)
+		return latency.Seconds(), err
+	}, options)
+}
+
+// NewTxPoolTargetShaper creates a shaper adjusting its rate to hold the size
+// of the transaction pool at the target.
+func NewTxPoolTargetShaper(target uint64, options PidOptions) *PidShaper {
+	return newPidShaper(float64(target), func(source FeedbackSource) (float64, error) {
+		size, err := source.GetTxPoolSize()
+		return float64(size), err
+	}, options)
+}
+
+// PidShaper controls its rate by a PID loop holding a measurement of the
+// network at a target value. The loop operates on the logarithm of the rate
+// using the relative deviation from the target as error, such that the gains
+// are independent of the throughput of the network. Measurements are taken
+// from the FeedbackSource the shaper is started with. If the source does not
+// provide feedback, the rate is kept constant.
+type PidShaper struct {
+	target  float64
+	measure measurement
+	options PidOptions
+
+	source     FeedbackSource
+	lastUpdate time.Time
+
+	mutex   sync.Mutex // < protects the rate against concurrent reads
+	logRate float64    // < the logarithm of the current rate
+	errors  [2]float64 // < the errors of the previous two updates
+	updates int        // < the number of updates so far
+	history []float64  // < the rates of recent control periods
+}
+
+func newPidShaper(target float64, measure measurement, options PidOptions) *PidShaper {
+	if options.Kp == 0 && options.Ki == 0 && options.Kd == 0 {
+		options.Kp, options.Ki = 0.5, 0.3
+	}
+	if options.InitialRate <= 0 {
+		options.InitialRate = 10
+	}
+	if options.MinRate <= 0 {
+		options.MinRate = 1
+	}
+	if options.MaxRate <= 0 {
+		options.MaxRate = math.Inf(1)
+	}
+	if options.Period <= 0 {
+		options.Period = time.Second
+	}
+	return &PidShaper{
+		target:  target,
+		measure: measure,
+		options: options,
+		logRate: math.Log(math.Min(math.Max(options.InitialRate, options.MinRate), options.MaxRate)),
+	}
+}
+
+func (s *PidShaper) Start(start time.Time, info LoadInfoSource) {
+	s.lastUpdate = start
+	s.source, _ = info.(FeedbackSource)
+	if s.source == nil {
+		log.Printf("pidShaper: no feedback available, keeping the initial rate")
+	}
+}
+
+// GetNumMessagesInInterval provides the number of messages to be produced
+// in the given time interval.
+func (s *PidShaper) GetNumMessagesInInterval(start time.Time, duration time.Duration) float64 {
+	if elapsed := start.Sub(s.lastUpdate); elapsed >= s.options.Period {
+		s.lastUpdate = start
+		if err := s.update(elapsed.Seconds()); err != nil {
+			log.Printf("pidShaper: failed to update rate; %v", err)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.getRate() * duration.Seconds()
+}
+
+// update adjusts the rate based on a new measurement, using the velocity
+// form of the PID algorithm:
+//
+//	log r_k = log r_{k-1} + Kp*(e_k-e_{k-1}) + Ki*e_k*dt + Kd*(e_k-2e_{k-1}+e_{k-2})/dt
+//
+// where e_k is the relative deviation of the k-th measurement from the target.
+func (s *PidShaper) update(dt float64) error {
+	if s.source == nil {
+		return nil
+	}
+	measured, err := s.measure(s.source)
+	if err != nil {
+		return err
+	}
+	e := 1.0
+	if s.target > 0 {
+		e = (s.target - measured) / s.target
+	} else if measured > 0 {
+		e = -1.0
+	}
+	e = math.Max(-maxControlError, math.Min(maxControlError, e))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	last, beforeLast := s.errors[0], s.errors[1]
+	if s.updates == 0 {
+		last, beforeLast = e, e // < no proportional or derivative kick at the start
+	} else if s.updates == 1 {
+		beforeLast = last
+	}
+	o := s.options
+	s.logRate += o.Kp*(e-last) + o.Ki*e*dt + o.Kd*(e-2*last+beforeLast)/dt
+	s.logRate = math.Max(math.Log(o.MinRate), math.Min(math.Log(o.MaxRate), s.logRate))
+	s.errors = [2]float64{e, last}
+	s.updates++
+
+	s.history = append(s.history, s.getRate())
+	if len(s.history) > sustainableRateWindow {
+		s.history = s.history[1:]
+	}
+	return nil
+}
+
+// getRate returns the current rate. The mutex must be held by the caller.
+func (s *PidShaper) getRate() float64 {
+	return math.Exp(s.logRate)
+}
+
+func (s *PidShaper) GetSustainableRate() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.history) == 0 {
+		return s.getRate()
+	}
+	sum := 0.0
+	for _, rate := range s.history {
+		sum += rate
+	}
+	return sum / float64(len(s.history))
+}
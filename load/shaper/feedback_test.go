@@ -0,0 +1,140 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/parser"
+)
+
+// simulatedNetwork is a FeedbackSource whose latency and pool size grow
+// linearly with the rate of the shaper it observes.
+type simulatedNetwork struct {
+	shaper   *PidShaper
+	capacity float64 // < Tx/s at a latency of 1s and a pool size of 1000
+}
+
+func (n *simulatedNetwork) GetSentTransactions() (uint64, error)     { return 0, nil }
+func (n *simulatedNetwork) GetReceivedTransactions() (uint64, error) { return 0, nil }
+
+func (n *simulatedNetwork) GetInclusionLatency(float64) (time.Duration, error) {
+	return toDuration(n.load()), nil
+}
+
+func (n *simulatedNetwork) GetTxPoolSize() (uint64, error) {
+	return uint64(1000 * n.load()), nil
+}
+
+func (n *simulatedNetwork) load() float64 {
+	n.shaper.mutex.Lock()
+	defer n.shaper.mutex.Unlock()
+	return n.shaper.getRate() / n.capacity
+}
+
+// runPidShaper runs the given shaper against a simulated network for the
+// given number of control periods and returns the final rate.
+func runPidShaper(shaper *PidShaper, capacity float64, periods int) float64 {
+	start := time.Now()
+	shaper.Start(start, &simulatedNetwork{shaper: shaper, capacity: capacity})
+	rate := 0.0
+	for i := 1; i <= periods; i++ {
+		rate = shaper.GetNumMessagesInInterval(start.Add(time.Duration(i)*time.Second), time.Second)
+	}
+	return rate
+}
+
+func TestPidShaper_ConvergesToRateHoldingTargetLatency(t *testing.T) {
+	shaper := NewLatencyTargetShaper(time.Second, 0.95, PidOptions{})
+	rate := runPidShaper(shaper, 200, 100)
+	if math.Abs(rate-200) > 2 {
+		t.Errorf("rate did not converge to capacity, wanted 200, got %f", rate)
+	}
+	if got := shaper.GetSustainableRate(); math.Abs(got-200) > 2 {
+		t.Errorf("unexpected sustainable rate, wanted 200, got %f", got)
+	}
+}
+
+func TestPidShaper_RateIsReducedAboveTarget(t *testing.T) {
+	shaper := NewTxPoolTargetShaper(1000, PidOptions{InitialRate: 500})
+	if rate := runPidShaper(shaper, 50, 100); math.Abs(rate-50) > 1 {
+		t.Errorf("rate did not converge to capacity, wanted 50, got %f", rate)
+	}
+}
+
+func TestPidShaper_RateIsBounded(t *testing.T) {
+	shaper := NewLatencyTargetShaper(time.Second, 0.95, PidOptions{MaxRate: 20})
+	if rate := runPidShaper(shaper, 1000, 50); math.Abs(rate-20) > 1e-9 {
+		t.Errorf("rate exceeds maximum, got %f", rate)
+	}
+	shaper = NewLatencyTargetShaper(time.Second, 0.95, PidOptions{MinRate: 5})
+	if rate := runPidShaper(shaper, 0.001, 50); math.Abs(rate-5) > 1e-9 {
+		t.Errorf("rate is below minimum, got %f", rate)
+	}
+}
+
+func TestPidShaper_RateIsConstantWithoutFeedback(t *testing.T) {
+	shaper := NewLatencyTargetShaper(time.Second, 0.95, PidOptions{InitialRate: 7})
+	start := time.Now()
+	shaper.Start(start, nil)
+	for i := 1; i <= 10; i++ {
+		if got := shaper.GetNumMessagesInInterval(start.Add(time.Duration(i)*time.Second), time.Second); math.Abs(got-7) > 1e-9 {
+			t.Fatalf("unexpected rate without feedback, got %f", got)
+		}
+	}
+	if got := shaper.GetSustainableRate(); math.Abs(got-7) > 1e-9 {
+		t.Errorf("unexpected sustainable rate, got %f", got)
+	}
+}
+
+func TestParseRate_TargetsAreParsed(t *testing.T) {
+	latency := float32(1)
+	pool := 1000
+	rates := map[string]parser.Rate{
+		"latency": {Target: &parser.Target{Latency: &latency}},
+		"txpool":  {Target: &parser.Target{TxPool: &pool}},
+	}
+	for name, rate := range rates {
+		t.Run(name, func(t *testing.T) {
+			shaper, err := ParseRate(&rate)
+			if err != nil {
+				t.Fatalf("failed to parse rate: %v", err)
+			}
+			if _, ok := shaper.(RateController); !ok {
+				t.Errorf("shaper does not control its rate")
+			}
+		})
+	}
+}
+
+func TestGetRateControllers_FindsNestedRateControllers(t *testing.T) {
+	first := NewTxPoolTargetShaper(1000, PidOptions{})
+	second := NewLatencyTargetShaper(time.Second, 0.95, PidOptions{})
+	shaper := NewSumShaper(
+		NewConstantShaper(10),
+		NewSequenceShaper(Phase{Shaper: first, Duration: time.Minute}, Phase{Shaper: second}),
+	)
+	got := GetRateControllers(shaper)
+	if len(got) != 2 || got[0] != first || got[1] != second {
+		t.Errorf("unexpected rate controllers, wanted %v, got %v", []RateController{first, second}, got)
+	}
+	if got := GetRateControllers(NewConstantShaper(10)); len(got) != 0 {
+		t.Errorf("unexpected rate controllers of constant shaper: %v", got)
+	}
+}
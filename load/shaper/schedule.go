@@ -0,0 +1,156 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RatePoint is the traffic rate of a schedule at a point in time.
+type RatePoint struct {
+	Time float64 // seconds since the start of the shaper
+	Rate float64 // messages per second
+}
+
+// ScheduleShaper is used to send txs with a frequency defined by a list of
+// rates at given points in time. Between two points, the frequency is either
+// kept at the rate of the earlier point (step interpolation) or linearly
+// interpolated. Before the first point and after the last point, the rates
+// of the first and last point are used, respectively.
+type ScheduleShaper struct {
+	points []RatePoint
+	linear bool
+	// integrals holds the number of messages sent between the first point
+	// and each of the points, for faster queries on long schedules.
+	integrals []float64
+	// startTimeStamp is the time when the schedule got started.
+	startTimeStamp time.Time
+}
+
+// NewScheduleShaper creates a shaper following the given points, which need
+// to be sorted by time. Negative rates are interpreted as zero.
+func NewScheduleShaper(points []RatePoint, linear bool) *ScheduleShaper {
+	res := &ScheduleShaper{
+		points:    make([]RatePoint, len(points)),
+		linear:    linear,
+		integrals: make([]float64, len(points)),
+	}
+	for i, point := range points {
+		res.points[i] = RatePoint{Time: point.Time, Rate: math.Max(point.Rate, 0)}
+	}
+	for i := 1; i < len(res.points); i++ {
+		res.integrals[i] = res.integrals[i-1] + res.segment(i-1, res.points[i].Time)
+	}
+	return res
+}
+
+func (s *ScheduleShaper) Start(start time.Time, _ LoadInfoSource) {
+	s.startTimeStamp = start
+}
+
+// GetNumMessagesInInterval provides the number of messages to be produced
+// in the given time interval.
+func (s *ScheduleShaper) GetNumMessagesInInterval(start time.Time, duration time.Duration) float64 {
+	a := start.Sub(s.startTimeStamp).Seconds()
+	b := a + duration.Seconds()
+	return s.integral(b) - s.integral(a)
+}
+
+// integral computes the number of messages sent between the first point of
+// the schedule and the given time, which is negative for earlier times.
+func (s *ScheduleShaper) integral(t float64) float64 {
+	if len(s.points) == 0 {
+		return 0
+	}
+	first := s.points[0]
+	if t <= first.Time {
+		return first.Rate * (t - first.Time)
+	}
+	// find the last point not after t
+	i := sort.Search(len(s.points), func(i int) bool { return s.points[i].Time > t }) - 1
+	return s.integrals[i] + s.segment(i, t)
+}
+
+// segment computes the number of messages sent between point i and time t,
+// where t is not after the next point, if there is any.
+func (s *ScheduleShaper) segment(i int, t float64) float64 {
+	from := s.points[i]
+	if !s.linear || i+1 == len(s.points) {
+		return from.Rate * (t - from.Time)
+	}
+	to := s.points[i+1]
+	if to.Time == from.Time {
+		return 0
+	}
+	rate := from.Rate + (to.Rate-from.Rate)*(t-from.Time)/(to.Time-from.Time)
+	return (from.Rate + rate) / 2 * (t - from.Time)
+}
+
+// ReadRateTrace reads a rate-over-time trace from a CSV file. Each row of the
+// file consists of a time in seconds and a rate in messages per second. A
+// leading header row is skipped. Times must not decrease. Rates are scaled
+// by the given factor.
+func ReadRateTrace(path string, scale float64) ([]RatePoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	res := []RatePoint{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rate trace %s; %w", path, err)
+		}
+		at, timeErr := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
+		rate, rateErr := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if timeErr != nil || rateErr != nil {
+			if line == 1 {
+				continue // < header row
+			}
+			return nil, fmt.Errorf("invalid row %d in rate trace %s; %w", line, path, errors.Join(timeErr, rateErr))
+		}
+		if at < 0 || rate < 0 {
+			return nil, fmt.Errorf("invalid row %d in rate trace %s, time and rate must be >= 0", line, path)
+		}
+		if len(res) > 0 && at < res[len(res)-1].Time {
+			return nil, fmt.Errorf("invalid row %d in rate trace %s, time must not decrease", line, path)
+		}
+		res = append(res, RatePoint{Time: at, Rate: rate * scale})
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("rate trace %s is empty", path)
+	}
+	return res, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Hyperion System Testing Infrastructure for Sonic.
+//
+// Hyperion is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Hyperion is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Hyperion. If not, see <http://www.gnu.org/licenses/>.
+
+package shaper
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xsoniclabs/hyperion/driver/parser"
+)
+
+func TestScheduleShaper(t *testing.T) {
+	points := []RatePoint{{Time: 1, Rate: 10}, {Time: 3, Rate: 20}, {Time: 4, Rate: 0}}
+	tests := []struct {
+		linear   bool
+		from     time.Duration
+		to       time.Duration
+		expected float64
+	}{
+		// Before the first point, the first rate is used.
+		{false, 0 * time.Second, 1 * time.Second, 10},
+		{true, 0 * time.Second, 1 * time.Second, 10},
+
+		// Step interpolation keeps the rate of the earlier point.
+		{false, 1 * time.Second, 2 * time.Second, 10},
+		{false, 1 * time.Second, 3 * time.Second, 20},
+		{false, 2 * time.Second, 4 * time.Second, 30},
+		{false, 0 * time.Second, 4 * time.Second, 50},
+
+		// Linear interpolation between points.
+		{true, 1 * time.Second, 2 * time.Second, 12.5},
+		{true, 1 * time.Second, 3 * time.Second, 30},
+		{true, 3 * time.Second, 4 * time.Second, 10},
+		{true, 2500 * time.Millisecond, 3500 * time.Millisecond, 9.375 + 7.5},
+
+		// After the last point, the last rate is used.
+		{false, 4 * time.Second, 10 * time.Second, 0},
+		{true, 4 * time.Second, 10 * time.Second, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("linear=%t,from=%v,to=%v", test.linear, test.from, test.to), func(t *testing.T) {
+			shaper := NewScheduleShaper(points, test.linear)
+
+			startTime := time.Now()
+			shaper.Start(startTime, nil)
+
+			got := shaper.GetNumMessagesInInterval(startTime.Add(test.from), test.to-test.from)
+			if math.Abs(got-test.expected) > 1e-6 {
+				t.Errorf("expected number of messages %f, got %f", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestScheduleShaper_EmptyScheduleProducesNoMessages(t *testing.T) {
+	shaper := NewScheduleShaper(nil, false)
+	shaper.Start(time.Now(), nil)
+	if got := shaper.GetNumMessagesInInterval(time.Now(), time.Second); got != 0 {
+		t.Errorf("unexpected number of messages, got %f", got)
+	}
+}
+
+func TestReadRateTrace_PointsAreReadAndScaled(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.csv")
+	content := "time,rate\n# comment\n0, 10\n1.5,20\n1.5,5\n"
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	points, err := ReadRateTrace(file, 2)
+	if err != nil {
+		t.Fatalf("failed to read trace: %v", err)
+	}
+	want := []RatePoint{{Time: 0, Rate: 20}, {Time: 1.5, Rate: 40}, {Time: 1.5, Rate: 10}}
+	if fmt.Sprint(points) != fmt.Sprint(want) {
+		t.Errorf("unexpected points, wanted %v, got %v", want, points)
+	}
+}
+
+func TestReadRateTrace_InvalidTracesAreRejected(t *testing.T) {
+	tests := map[string]string{
+		"empty":            "",
+		"header only":      "time,rate\n",
+		"invalid value":    "0,10\n1,fast\n",
+		"negative rate":    "0,-1\n",
+		"decreasing time":  "2,10\n1,10\n",
+		"too many columns": "0,1,2\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "trace.csv")
+			if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadRateTrace(file, 1); err == nil {
+				t.Errorf("invalid trace was not rejected")
+			}
+		})
+	}
+	if _, err := ReadRateTrace(filepath.Join(t.TempDir(), "missing.csv"), 1); err == nil {
+		t.Errorf("missing trace was not rejected")
+	}
+}
+
+func TestParseRate_ScheduleAndTraceAreParsed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.csv")
+	if err := os.WriteFile(file, []byte("0,10\n10,10\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scale := float32(0.5)
+	rates := map[string]parser.Rate{
+		"schedule": {Schedule: &parser.Schedule{Points: []parser.RatePoint{{Time: 0, Rate: 5}}, Interpolation: "linear"}},
+		"trace":    {Trace: &parser.Trace{File: file, Scale: &scale}},
+	}
+	for name, rate := range rates {
+		t.Run(name, func(t *testing.T) {
+			shaper, err := ParseRate(&rate)
+			if err != nil {
+				t.Fatalf("failed to parse rate: %v", err)
+			}
+			start := time.Now()
+			shaper.Start(start, nil)
+			if got := shaper.GetNumMessagesInInterval(start, 2*time.Second); math.Abs(got-10) > 1e-6 {
+				t.Errorf("unexpected number of messages, wanted 10, got %f", got)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package shaper
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/0xsoniclabs/hyperion/driver/parser"
@@ -74,6 +75,102 @@ func ParseRate(rate *parser.Rate) (Shaper, error) {
 		}
 		return NewWaveShaper(min, rate.Wave.Max, rate.Wave.Period), nil
 	}
+	if rate.Schedule != nil {
+		points := make([]RatePoint, 0, len(rate.Schedule.Points))
+		for _, point := range rate.Schedule.Points {
+			points = append(points, RatePoint{Time: float64(point.Time), Rate: float64(point.Rate)})
+		}
+		return NewScheduleShaper(points, isLinear(rate.Schedule.Interpolation)), nil
+	}
+	if rate.Trace != nil {
+		scale := 1.0
+		if rate.Trace.Scale != nil {
+			scale = float64(*rate.Trace.Scale)
+		}
+		points, err := ReadRateTrace(rate.Trace.File, scale)
+		if err != nil {
+			return nil, err
+		}
+		return NewScheduleShaper(points, isLinear(rate.Trace.Interpolation)), nil
+	}
+	if rate.Sum != nil {
+		shapers := make([]Shaper, 0, len(rate.Sum))
+		for i := range rate.Sum {
+			shaper, err := ParseRate(&rate.Sum[i])
+			if err != nil {
+				return nil, err
+			}
+			shapers = append(shapers, shaper)
+		}
+		return NewSumShaper(shapers...), nil
+	}
+	if rate.Sequence != nil {
+		phases := make([]Phase, 0, len(rate.Sequence))
+		for i := range rate.Sequence {
+			shaper, err := ParseRate(&rate.Sequence[i].Rate)
+			if err != nil {
+				return nil, err
+			}
+			phase := Phase{Shaper: shaper}
+			if duration := rate.Sequence[i].Duration; duration != nil {
+				phase.Duration = time.Duration(float64(*duration) * float64(time.Second))
+			}
+			phases = append(phases, phase)
+		}
+		return NewSequenceShaper(phases...), nil
+	}
+	if rate.Poisson != nil {
+		return NewPoissonShaper(float64(rate.Poisson.Rate), rate.Poisson.Seed), nil
+	}
+	if bursty := rate.Bursty; bursty != nil {
+		distribution := Exponential
+		if strings.ToLower(bursty.Distribution) == "pareto" {
+			distribution = Pareto
+		}
+		shape := DefaultParetoShape
+		if bursty.Shape != nil {
+			shape = float64(*bursty.Shape)
+		}
+		on := toDuration(float64(bursty.On))
+		off := toDuration(float64(bursty.Off))
+		return NewBurstyShaper(float64(bursty.Rate), on, off, distribution, shape, bursty.Seed), nil
+	}
+
+	if target := rate.Target; target != nil {
+		options := PidOptions{Kp: 0.5, Ki: 0.3}
+		if target.Kp != nil {
+			options.Kp = float64(*target.Kp)
+		}
+		if target.Ki != nil {
+			options.Ki = float64(*target.Ki)
+		}
+		if target.Kd != nil {
+			options.Kd = float64(*target.Kd)
+		}
+		if target.Initial != nil {
+			options.InitialRate = float64(*target.Initial)
+		}
+		if target.Max != nil {
+			options.MaxRate = float64(*target.Max)
+		}
+		if target.TxPool != nil {
+			return NewTxPoolTargetShaper(uint64(*target.TxPool), options), nil
+		}
+		percentile := 0.95
+		if target.Percentile != nil {
+			percentile = float64(*target.Percentile)
+		}
+		latency := time.Duration(0)
+		if target.Latency != nil {
+			latency = toDuration(float64(*target.Latency))
+		}
+		return NewLatencyTargetShaper(latency, percentile, options), nil
+	}
 
 	return nil, fmt.Errorf("unknown rate type")
 }
+
+// isLinear determines whether the given interpolation mode is linear.
+func isLinear(interpolation string) bool {
+	return strings.ToLower(interpolation) == "linear"
+}
@@ -5,16 +5,46 @@ array=($list)
 external_ip=${array[0]}
 echo "Sonic is going to export its services on ${external_ip}"
 
-# Initialize datadir
-mkdir /datadir
-./sonictool --datadir=/datadir genesis fake ${VALIDATORS_COUNT}
+# Initialize datadir, unless it was restored from a snapshot or the container
+# is being restarted.
+if [[ -z "$(ls -A /datadir 2>/dev/null)" ]]; then
+  mkdir -p /datadir
+  ./sonictool --datadir=/datadir genesis fake ${VALIDATORS_COUNT}
+else
+  echo "Using existing datadir"
+fi
 
-# Start sonic as part of a fake net with RPC service.
+# Configure the client according to the type of the node. If not specified,
+# all APIs are enabled and the full history is retained.
+http_api=${HTTP_API:-admin,eth,ftm,debug}
+ws_api=${WS_API-admin,eth,ftm}
+ws_flags=""
+if [[ -n "${ws_api}" ]]; then
+  ws_flags="--ws --ws.addr 0.0.0.0 --ws.port 18546 --ws.api ${ws_api}"
+fi
+mode_flag="--mode rpc"
+if [[ "${ARCHIVE}" == "false" ]]; then
+  mode_flag="--mode validator"
+fi
+txpool_flags=""
+if [[ -n "${TXPOOL_GLOBAL_SLOTS}" ]]; then
+  txpool_flags="${txpool_flags} --txpool.globalslots ${TXPOOL_GLOBAL_SLOTS}"
+fi
+if [[ -n "${TXPOOL_GLOBAL_QUEUE}" ]]; then
+  txpool_flags="${txpool_flags} --txpool.globalqueue ${TXPOOL_GLOBAL_QUEUE}"
+fi
+echo "http.api=${http_api} ws.api=${ws_api} mode=${mode_flag}${txpool_flags}"
+
+# Start sonic as part of a fake net with RPC service. Additional flags of the
+# scenario are passed in CLIENT_ARGS and appended to the ones above.
 ./sonicd --fakenet ${VALIDATOR_NUMBER}/${VALIDATORS_COUNT} \
     --datadir=/datadir \
-    --http --http.addr 0.0.0.0 --http.port 18545 --http.api admin,eth,ftm \
-    --ws --ws.addr 0.0.0.0 --ws.port 18546 --ws.api admin,eth,ftm \
+    ${mode_flag} \
+    --http --http.addr 0.0.0.0 --http.port 18545 --http.api ${http_api} \
+    ${ws_flags} \
+    ${txpool_flags} \
     --pprof --pprof.addr 0.0.0.0 \
     --nat=extip:${external_ip} \
     --metrics \
-    --metrics.expensive
+    --metrics.expensive \
+    ${CLIENT_ARGS}